@@ -0,0 +1,125 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var (
+	typeType  = reflect.TypeOf((*Type)(nil)).Elem()
+	scopeType = reflect.TypeOf((*Scope)(nil))
+	nodeType  = reflect.TypeOf((*Node)(nil)).Elem()
+)
+
+// Difference describes the first place at which two syntax trees diverge.
+type Difference struct {
+	// A and B are the innermost nodes of each tree that contain the
+	// divergence. Either may be nil if one tree has a node where the other
+	// has none.
+	A, B Node
+
+	// Path is the field path from the roots to the diverging value, such as
+	// "Branches[0].Condition.Operator".
+	Path string
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: (%T)%v != (%T)%v", d.Path, d.A, d.A, d.B, d.B)
+}
+
+// Equal reports whether a and b describe the same syntax. Pointers and
+// values of the same node type are interchangeable, nil and empty slices are
+// equivalent, and information the parser derives rather than reads from the
+// source, such as scopes and inferred types, is ignored.
+func Equal(a, b Node) bool {
+	return Diff(a, b) == nil
+}
+
+// Diff returns the first difference found between a and b in a depth-first
+// walk, or nil if they are Equal.
+func Diff(a, b Node) *Difference {
+	d := &differ{}
+	if d.compare(reflect.ValueOf(a), reflect.ValueOf(b), "", a, b) {
+		return nil
+	}
+	return d.diff
+}
+
+type differ struct {
+	diff *Difference
+}
+
+func (d *differ) fail(path string, a, b Node) bool {
+	if path == "" {
+		path = "."
+	}
+	d.diff = &Difference{A: a, B: b, Path: path}
+	return false
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func (d *differ) compare(x, y reflect.Value, path string, a, b Node) bool {
+	x, y = indirect(x), indirect(y)
+	if !x.IsValid() || !y.IsValid() {
+		if x.IsValid() == y.IsValid() {
+			return true
+		}
+		if x.IsValid() && x.Type().Implements(nodeType) {
+			a = x.Interface().(Node)
+		} else if y.IsValid() && y.Type().Implements(nodeType) {
+			b = y.Interface().(Node)
+		}
+		return d.fail(path, a, b)
+	}
+
+	if x.Type().Implements(nodeType) {
+		a = x.Interface().(Node)
+	}
+	if y.Type().Implements(nodeType) {
+		b = y.Interface().(Node)
+	}
+	if x.Type() != y.Type() {
+		return d.fail(path, a, b)
+	}
+
+	switch x.Kind() {
+	case reflect.Struct:
+		for i := 0; i < x.NumField(); i++ {
+			field := x.Type().Field(i)
+			if field.PkgPath != "" || field.Type == typeType || field.Type == scopeType {
+				continue
+			}
+			if !d.compare(x.Field(i), y.Field(i), path+"."+field.Name, a, b) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if x.Len() != y.Len() {
+			return d.fail(path, a, b)
+		}
+		for i := 0; i < x.Len(); i++ {
+			if !d.compare(x.Index(i), y.Index(i), fmt.Sprintf("%s[%d]", path, i), a, b) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		// maps only appear in derived information such as compound types
+		return true
+	default:
+		if x.Interface() != y.Interface() {
+			return d.fail(path, a, b)
+		}
+		return true
+	}
+}
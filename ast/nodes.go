@@ -241,14 +241,16 @@ func (e ExprStmt) Children() []Node {
 func (e ExprStmt) Declares() DeclarationType { return NoDeclaration }
 
 // Echo returns a new echo statement.
-func Echo(exprs ...Expr) EchoStmt {
-	return EchoStmt{Expressions: exprs}
+func Echo(exprs ...Expr) *EchoStmt {
+	return &EchoStmt{Expressions: exprs}
 }
 
-// EchoStmt represents an echo statement. It may be either a literal statement
-// or it may be from data outside PHP-mode, such as "here" in: <? not here ?> here <? not here ?>
+// EchoStmt represents an echo statement.
 type EchoStmt struct {
 	Expressions []Expr
+
+	// ShortTag is set if the statement was written as <?= expr ?>.
+	ShortTag bool
 }
 
 func (e EchoStmt) String() string {
@@ -265,6 +267,21 @@ func (e EchoStmt) Children() []Node {
 
 func (e EchoStmt) Declares() DeclarationType { return NoDeclaration }
 
+// InlineHTML is data outside PHP-mode, such as "here" in: <? not here ?> here <? not here ?>
+type InlineHTML struct {
+	Value string
+}
+
+func (h InlineHTML) String() string {
+	return "HTML"
+}
+
+func (h InlineHTML) Children() []Node {
+	return nil
+}
+
+func (h InlineHTML) Declares() DeclarationType { return NoDeclaration }
+
 // ReturnStmt represents a function return.
 type ReturnStmt struct {
 	Expr
@@ -290,7 +307,7 @@ type BreakStmt struct {
 
 func (b BreakStmt) Children() []Node {
 	if b.Expr != nil {
-		return b.Expr.Children()
+		return []Node{b.Expr}
 	}
 	return nil
 }
@@ -310,7 +327,7 @@ func (c ContinueStmt) String() string {
 
 func (c ContinueStmt) Children() []Node {
 	if c.Expr != nil {
-		return c.Expr.Children()
+		return []Node{c.Expr}
 	}
 	return nil
 }
@@ -320,6 +337,17 @@ type ThrowStmt struct {
 	Expr
 }
 
+func (t ThrowStmt) String() string {
+	return "throw"
+}
+
+func (t ThrowStmt) Children() []Node {
+	if t.Expr != nil {
+		return []Node{t.Expr}
+	}
+	return nil
+}
+
 func (t ThrowStmt) Declares() DeclarationType { return NoDeclaration }
 
 // IncludeStmt is a include statment
@@ -350,12 +378,48 @@ func (i Include) EvaluatesTo() Type {
 
 func (i Include) Declares() DeclarationType { return NoDeclaration }
 
+// YieldExpr is a yield expression, which makes the function containing it
+// a generator. Value is the value yielded, or nil if there is none, and Key
+// its key, or nil if it has none. From is set for yield from, which yields
+// the values of Value in turn.
+type YieldExpr struct {
+	Key, Value Expr
+	From       bool
+}
+
+func (y YieldExpr) Children() []Node {
+	var n []Node
+	if y.Key != nil {
+		n = append(n, y.Key)
+	}
+	if y.Value != nil {
+		n = append(n, y.Value)
+	}
+	return n
+}
+
+func (y YieldExpr) String() string {
+	if y.From {
+		return "yield from"
+	}
+	return "yield"
+}
+
+func (y YieldExpr) EvaluatesTo() Type {
+	return Unknown
+}
+
+func (y YieldExpr) Declares() DeclarationType { return NoDeclaration }
+
 // ExitStmt is an exit statment
 type ExitStmt struct {
 	Expr Expr
 }
 
 func (e ExitStmt) Children() []Node {
+	if e.Expr != nil {
+		return []Node{e.Expr}
+	}
 	return nil
 }
 
@@ -443,9 +507,10 @@ func (f FunctionCallExpr) String() string {
 }
 
 func (f FunctionCallExpr) Children() []Node {
-	n := make([]Node, len(f.Arguments))
-	for i, a := range f.Arguments {
-		n[i] = a
+	n := make([]Node, 0, len(f.Arguments)+1)
+	n = append(n, f.FunctionName)
+	for _, a := range f.Arguments {
+		n = append(n, a)
 	}
 	return n
 }
@@ -547,6 +612,9 @@ type FunctionArgument struct {
 	TypeHint string
 	Default  Expr
 	Variable *Variable
+
+	// Reference is set if the argument is passed by reference, as in &$x.
+	Reference bool
 }
 
 func (fa FunctionArgument) String() string {
@@ -563,42 +631,103 @@ func (fa FunctionArgument) Children() []Node {
 	return n
 }
 
-// Class is a class
+// Class is a class, or a trait if Trait is set. Traits holds the names of
+// the traits that it uses, and TraitUses the use statements naming them,
+// with their rules.
 type Class struct {
 	Name       string
 	Extends    string
 	Implements []string
+	Traits     []string
+	TraitUses  []*TraitUse
 	Methods    []*Method
 	Properties []*Property
 	Constants  []*Constant
+	Trait      bool
+
+	// Abstract and Final are set if the class is declared abstract or
+	// final.
+	Abstract, Final bool
 }
 
 func (c Class) String() string {
+	if c.Trait {
+		return fmt.Sprintf("trait %s", c.Name)
+	}
 	return fmt.Sprintf("class %s", c.Name)
 }
 
 func (c Class) Children() []Node {
-	n := make([]Node, len(c.Methods)+len(c.Properties))
-	for i, p := range c.Properties {
-		n[i] = p
+	n := make([]Node, 0, len(c.Constants)+len(c.Properties)+len(c.Methods))
+	for _, c := range c.Constants {
+		n = append(n, c)
+	}
+	for _, p := range c.Properties {
+		n = append(n, p)
 	}
-	offset := len(c.Properties)
-	for i, m := range c.Methods {
-		n[i+offset] = m
+	for _, m := range c.Methods {
+		n = append(n, m)
 	}
 	return n
 }
 
 func (c Class) Declares() DeclarationType { return ClassDeclaration }
 
+// TraitUse is a use statement in a class or trait, naming the traits it
+// uses and the rules for their methods.
+type TraitUse struct {
+	Traits []string
+	Rules  []*TraitRule
+}
+
+func (u TraitUse) String() string {
+	return fmt.Sprintf("use %s", strings.Join(u.Traits, ", "))
+}
+
+func (u TraitUse) Children() []Node { return nil }
+
+// TraitRule is a rule of a trait use. Method, of the trait Trait, is used
+// instead of the methods of that name of the traits in Insteadof, as in
+// A::hello insteadof B, or is also available as Alias, with the
+// visibility Visibility if HasVisibility is set, as in
+// B::hello as protected helloB. Trait may be empty in an alias, and Alias
+// if the rule only changes the visibility of the method.
+type TraitRule struct {
+	Trait, Method string
+	Insteadof     []string
+
+	Alias         string
+	Visibility    Visibility
+	HasVisibility bool
+}
+
+func (r TraitRule) String() string {
+	method := r.Method
+	if r.Trait != "" {
+		method = r.Trait + "::" + method
+	}
+	if len(r.Insteadof) > 0 {
+		return fmt.Sprintf("%s insteadof %s", method, strings.Join(r.Insteadof, ", "))
+	}
+	return fmt.Sprintf("%s as %s", method, r.Alias)
+}
+
+func (r TraitRule) Children() []Node { return nil }
+
 // Constant is a constant
 type Constant struct {
 	Name  string
 	Value interface{}
 }
 
-func (c Constant) Children() []Node { return nil }
-func (c Constant) String() string   { return c.Name }
+func (c Constant) Children() []Node {
+	if value, ok := c.Value.(Node); ok && value != nil {
+		return []Node{value}
+	}
+	return nil
+}
+
+func (c Constant) String() string { return c.Name }
 
 // ConstantExpr is a constant expression
 type ConstantExpr struct {
@@ -622,9 +751,12 @@ func (i Interface) String() string {
 }
 
 func (i Interface) Children() []Node {
-	n := make([]Node, len(i.Methods))
-	for ii, method := range i.Methods {
-		n[ii] = method
+	n := make([]Node, 0, len(i.Constants)+len(i.Methods))
+	for _, c := range i.Constants {
+		n = append(n, c)
+	}
+	for _, method := range i.Methods {
+		n = append(n, method)
 	}
 	return n
 }
@@ -635,6 +767,7 @@ func (i Interface) Declares() DeclarationType { return InterfaceDeclaration }
 type Property struct {
 	Name           string
 	Visibility     Visibility
+	Static         bool
 	Type           Type
 	Initialization Expr
 }
@@ -648,6 +781,9 @@ func (p Property) AssignableType() Type {
 }
 
 func (p Property) Children() []Node {
+	if p.Initialization == nil {
+		return nil
+	}
 	return []Node{p.Initialization}
 }
 
@@ -716,6 +852,10 @@ func (c ClassExpr) Declares() DeclarationType { return NoDeclaration }
 type Method struct {
 	*FunctionStmt
 	Visibility Visibility
+
+	// Static, Abstract and Final are set if the method is declared with
+	// those modifiers. The methods of interfaces are abstract without it.
+	Static, Abstract, Final bool
 }
 
 func (m Method) String() string {
@@ -768,6 +908,10 @@ func (v Visibility) Token() token.Token {
 type IfStmt struct {
 	Branches  []IfBranch
 	ElseBlock Statement
+
+	// AltSyntax is set if the statement was written in the alternative
+	// syntax, with colons and endif instead of braces.
+	AltSyntax bool
 }
 
 // IfBranch is an if branch
@@ -781,7 +925,7 @@ func (i IfBranch) String() string {
 }
 
 func (i IfBranch) Children() []Node {
-	return []Node{i.Block}
+	return []Node{i.Condition, i.Block}
 }
 
 func (i IfStmt) String() string {
@@ -806,6 +950,9 @@ type SwitchStmt struct {
 	Expr        Expr
 	Cases       []*SwitchCase
 	DefaultCase *Block
+
+	// AltSyntax is set if the cases are enclosed by : and endswitch.
+	AltSyntax bool
 }
 
 func (s SwitchStmt) String() string {
@@ -850,6 +997,9 @@ type ForStmt struct {
 	Termination    []Expr
 	Iteration      []Expr
 	LoopBlock      Statement
+
+	// AltSyntax is set if the loop is enclosed by : and endfor.
+	AltSyntax bool
 }
 
 func (f ForStmt) String() string {
@@ -867,7 +1017,7 @@ func (f ForStmt) Children() []Node {
 	for _, stmt := range f.Iteration {
 		nodes = append(nodes, stmt)
 	}
-	return nodes
+	return append(nodes, f.LoopBlock)
 }
 
 func (ForStmt) Declares() DeclarationType { return NoDeclaration }
@@ -876,6 +1026,9 @@ func (ForStmt) Declares() DeclarationType { return NoDeclaration }
 type WhileStmt struct {
 	Termination Expr
 	LoopBlock   Statement
+
+	// AltSyntax is set if the loop is enclosed by : and endwhile.
+	AltSyntax bool
 }
 
 func (w WhileStmt) String() string {
@@ -946,7 +1099,7 @@ func (c CatchStmt) String() string {
 }
 
 func (c CatchStmt) Children() []Node {
-	return []Node{c.CatchBlock}
+	return []Node{c.CatchVar, c.CatchBlock}
 }
 
 // Literal is a literal
@@ -975,6 +1128,12 @@ type ForeachStmt struct {
 	Key       *Variable
 	Value     *Variable
 	LoopBlock Statement
+
+	// Reference is set if the value is bound by reference, as in &$v.
+	Reference bool
+
+	// AltSyntax is set if the loop is enclosed by : and endforeach.
+	AltSyntax bool
 }
 
 func (f ForeachStmt) String() string {
@@ -1050,7 +1209,10 @@ func (a ArrayLookupExpr) String() string {
 }
 
 func (a ArrayLookupExpr) Children() []Node {
-	return []Node{a.Index}
+	if a.Index == nil {
+		return []Node{a.Array}
+	}
+	return []Node{a.Array, a.Index}
 }
 
 func (a ArrayLookupExpr) EvaluatesTo() Type {
@@ -1075,7 +1237,7 @@ func (a ArrayAppendExpr) AssignableType() Type {
 }
 
 func (a ArrayAppendExpr) Children() []Node {
-	return nil
+	return []Node{a.Array}
 }
 
 func (a ArrayAppendExpr) String() string {
@@ -1118,7 +1280,13 @@ func (l ListStatement) String() string {
 }
 
 func (l ListStatement) Children() []Node {
-	return []Node{l.Value}
+	n := make([]Node, 0, len(l.Assignees)+1)
+	for _, a := range l.Assignees {
+		if a != nil {
+			n = append(n, a)
+		}
+	}
+	return append(n, l.Value)
 }
 
 func (ListStatement) Declares() DeclarationType { return NoDeclaration }
@@ -1129,7 +1297,11 @@ type StaticVariableDeclaration struct {
 }
 
 func (s StaticVariableDeclaration) Children() []Node {
-	return nil
+	n := make([]Node, len(s.Declarations))
+	for i, d := range s.Declarations {
+		n[i] = d
+	}
+	return n
 }
 
 func (s StaticVariableDeclaration) String() string {
@@ -1152,6 +1324,9 @@ type DeclareBlock struct {
 }
 
 func (d DeclareBlock) Children() []Node {
+	if d.Statements == nil {
+		return nil
+	}
 	return d.Statements.Children()
 }
 
@@ -1160,3 +1335,23 @@ func (d DeclareBlock) String() string {
 }
 
 func (DeclareBlock) Declares() DeclarationType { return NoDeclaration }
+
+// UseStmt imports the class or namespace Name, which may be referred to by
+// Alias, or if Alias is empty, by the last part of Name.
+type UseStmt struct {
+	Name  string
+	Alias string
+}
+
+func (u UseStmt) Children() []Node {
+	return nil
+}
+
+func (u UseStmt) String() string {
+	if u.Alias != "" {
+		return fmt.Sprintf("use %s as %s", u.Name, u.Alias)
+	}
+	return "use " + u.Name
+}
+
+func (UseStmt) Declares() DeclarationType { return NoDeclaration }
@@ -0,0 +1,68 @@
+package ast
+
+import (
+	"reflect"
+
+	"github.com/stephens2424/php/token"
+)
+
+// Span is the range of source a node was parsed from. End is the position
+// just after the last byte of the node.
+type Span struct {
+	Begin, End token.Position
+}
+
+// Positions records the spans of the nodes of a file. Only nodes
+// represented by pointers are recorded, and not every one is.
+type Positions map[Node]Span
+
+// Span returns the span of n. If n was not recorded, it is the smallest
+// span enclosing its children, which may omit keywords and punctuation
+// belonging to n itself. ok is false if neither n nor any of its
+// descendants were recorded.
+func (p Positions) Span(n Node) (span Span, ok bool) {
+	if IsNil(n) {
+		return Span{}, false
+	}
+	if reflect.ValueOf(n).Kind() == reflect.Ptr {
+		if span, ok := p[n]; ok {
+			return span, true
+		}
+	}
+	for _, child := range n.Children() {
+		s, found := p.Span(child)
+		if !found {
+			continue
+		}
+		if !ok || s.Begin.Position < span.Begin.Position {
+			span.Begin = s.Begin
+		}
+		if !ok || s.End.Position > span.End.Position {
+			span.End = s.End
+		}
+		ok = true
+	}
+	return span, ok
+}
+
+// IsNil reports whether n is nil or a nil pointer, as nodes return for
+// missing parts, such as an absent default value, among their children.
+func IsNil(n Node) bool {
+	v := reflect.ValueOf(n)
+	return !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// Comment is a comment, as written with its delimiters, such as
+// "// note" or "/* note */", and the span of source it was lexed from.
+type Comment struct {
+	Text string
+	Span Span
+}
+
+// ClassName is a class name written in a declaration, such as a type hint
+// or the class a class extends, which the AST holds as a string rather
+// than a node.
+type ClassName struct {
+	Name string
+	Span Span
+}
@@ -0,0 +1,256 @@
+package printer
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// NewMinifier returns a Printer that prints as little whitespace as the
+// syntax allows. If renameLocals is set, variables local to a function are
+// also printed with short names, except in functions that may refer to
+// their variables by name at run time, such as through compact, extract or
+// $$name.
+func NewMinifier(w io.Writer, renameLocals bool) *Printer {
+	p := NewPrinter(&compactWriter{w: w})
+	p.renameLocals = renameLocals
+	return p
+}
+
+// compactWriter removes whitespace written to it, except where it separates
+// tokens that would otherwise run together.
+type compactWriter struct {
+	w       io.Writer
+	last    byte
+	pending bool
+	buf     []byte
+}
+
+func (c *compactWriter) Write(b []byte) (int, error) {
+	c.buf = c.buf[:0]
+	for _, ch := range b {
+		if isSpace(ch) {
+			c.pending = c.last != 0
+			continue
+		}
+		c.separate(ch)
+		c.buf = append(c.buf, ch)
+		c.last = ch
+	}
+	if _, err := c.w.Write(c.buf); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *compactWriter) writeRaw(s string) {
+	if s == "" {
+		return
+	}
+	c.buf = c.buf[:0]
+	c.separate(s[0])
+	c.buf = append(c.buf, s...)
+	c.last = s[len(s)-1]
+	if strings.HasPrefix(s, "<<<") {
+		// before PHP 7.3, the closing identifier of a heredoc must end its line
+		c.buf = append(c.buf, '\n')
+		c.last = '\n'
+	}
+	c.w.Write(c.buf)
+}
+
+// separate appends a space to the buffer if whitespace was removed between
+// the last byte written and next, and is needed to keep them apart.
+func (c *compactWriter) separate(next byte) {
+	if c.pending && !isSpace(c.last) && needsSpace(c.last, next) {
+		c.buf = append(c.buf, ' ')
+	}
+	c.pending = false
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+func isWordByte(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' ||
+		ch == '_' || ch == '$' || ch == '\\' || ch >= 0x80
+}
+
+func isOperatorByte(ch byte) bool {
+	return strings.IndexByte("+-*/%.=<>!&|^~?:@#", ch) >= 0
+}
+
+// needsSpace reports whether a and b would be read as part of the same
+// token, or as a different token, if they were adjacent.
+func needsSpace(a, b byte) bool {
+	switch {
+	case isWordByte(a) && isWordByte(b):
+		return true
+	case isOperatorByte(a) && isOperatorByte(b):
+		return true
+	case a == '.' && b >= '0' && b <= '9', b == '.' && a >= '0' && a <= '9':
+		// a number would absorb the dot
+		return true
+	}
+	return false
+}
+
+// enterFunction sets the names of the local variables of the function with
+// the given arguments, closure variables and body, returning a function
+// that restores the names of the enclosing function.
+func (p *Printer) enterFunction(args, closureVars []*ast.FunctionArgument, body *ast.Block) func() {
+	enclosing := p.locals
+	p.locals = localNames(args, closureVars, body)
+	return func() { p.locals = enclosing }
+}
+
+// staticProperty returns the variable naming the static property accessed
+// by c, or nil if c is not a property access, as in Foo::$method().
+func staticProperty(c *ast.ClassExpr) *ast.Variable {
+	expr := ast.Node(c.Expr)
+	for {
+		switch n := addressable(expr).(type) {
+		case *ast.Variable:
+			return n
+		case *ast.ArrayLookupExpr:
+			expr = n.Array
+		case *ast.ArrayAppendExpr:
+			expr = n.Array
+		default:
+			return nil
+		}
+	}
+}
+
+// dynamicScopeFuncs are functions that access the local variables of their
+// caller by name.
+var dynamicScopeFuncs = map[string]bool{
+	"compact":          true,
+	"extract":          true,
+	"get_defined_vars": true,
+	"parse_str":        true,
+	"eval":             true,
+}
+
+// magicLocals are local variables that PHP defines implicitly.
+var magicLocals = map[string]bool{
+	"this":                 true,
+	"php_errormsg":         true,
+	"http_response_header": true,
+}
+
+var interpolated = regexp.MustCompile(`\$\{?([a-zA-Z_\x80-\xff][a-zA-Z0-9_\x80-\xff]*)`)
+
+// localNames returns short names for the local variables of a function, or
+// nil if they cannot safely be renamed.
+func localNames(args, closureVars []*ast.FunctionArgument, body *ast.Block) map[string]string {
+	if body == nil || body.Scope != nil && len(body.Scope.DynamicVariables) > 0 {
+		return nil
+	}
+	c := &localCollector{seen: map[string]bool{}, keep: map[string]bool{}}
+	for _, arg := range args {
+		c.collect(arg.Variable)
+	}
+	for _, v := range closureVars {
+		c.keepVariable(v.Variable)
+	}
+	c.collect(body)
+	if c.dynamic {
+		return nil
+	}
+
+	names := map[string]string{}
+	next := 0
+	for _, name := range c.order {
+		if c.keep[name] {
+			continue
+		}
+		short := shortName(next)
+		for next++; c.keep[short]; next++ {
+			short = shortName(next)
+		}
+		names[name] = short
+	}
+	return names
+}
+
+// shortName returns the nth of the names a, b, ..., z, A, ..., Z, aa, ab, ...
+func shortName(n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	name := ""
+	for ; n >= len(letters); n = n/len(letters) - 1 {
+		name = string(letters[n%len(letters)]) + name
+	}
+	return string(letters[n]) + name
+}
+
+// localCollector finds the variables used in a function body.
+type localCollector struct {
+	order    []string
+	seen     map[string]bool
+	keep     map[string]bool
+	property *ast.Variable
+	dynamic  bool
+}
+
+func (c *localCollector) keepVariable(v *ast.Variable) {
+	if id, ok := v.Name.(*ast.Identifier); ok {
+		c.keep[id.Value] = true
+	}
+}
+
+func (c *localCollector) collect(node ast.Node) {
+	switch n := addressable(node).(type) {
+	case *ast.Variable:
+		id, ok := n.Name.(*ast.Identifier)
+		switch {
+		case !ok:
+			c.dynamic = true
+		case n == c.property:
+		case ast.SuperGlobals[id.Value] || magicLocals[id.Value]:
+			c.keep[id.Value] = true
+		case !c.seen[id.Value]:
+			c.seen[id.Value] = true
+			c.order = append(c.order, id.Value)
+		}
+		return
+	case *ast.Literal:
+		if n.Type == ast.String && !strings.HasPrefix(n.Value, "'") {
+			for _, m := range interpolated.FindAllStringSubmatch(n.Value, -1) {
+				c.keep[m[1]] = true
+			}
+		}
+		return
+	case *ast.FunctionCallExpr:
+		if id, ok := n.FunctionName.(*ast.Identifier); ok && dynamicScopeFuncs[strings.ToLower(id.Value)] {
+			c.dynamic = true
+		}
+	case *ast.Include:
+		// included files share the local scope
+		c.dynamic = true
+	case *ast.GlobalDeclaration:
+		for _, v := range n.Identifiers {
+			c.keepVariable(v)
+		}
+		return
+	case *ast.AnonymousFunction:
+		for _, v := range n.ClosureVariables {
+			c.keepVariable(v.Variable)
+		}
+		return
+	case *ast.FunctionStmt, *ast.Class, *ast.Interface:
+		return
+	case *ast.ClassExpr:
+		defer func(property *ast.Variable) { c.property = property }(c.property)
+		c.property = staticProperty(n)
+	}
+	for _, child := range node.Children() {
+		if v := reflect.ValueOf(child); v.IsValid() && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+			c.collect(child)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package printer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/parser"
+)
+
+func TestMinifyPreservesTree(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/*.php")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		original, err := parser.NewParser().Parse(file, string(src))
+		if err != nil {
+			continue
+		}
+
+		minified := minify(original, false)
+		reparsed, err := parser.NewParser().Parse(file, minified)
+		if err != nil {
+			t.Errorf("%s: minified output does not parse: %s\n%s", file, err, minified)
+			continue
+		}
+		if d := DiffFiles(original, reparsed); d != nil {
+			t.Errorf("%s: minified output parses to a different tree at %s\n%s", file, d, minified)
+			continue
+		}
+
+		renamed, err := parser.NewParser().Parse(file, minify(original, true))
+		if err != nil {
+			t.Errorf("%s: output with renamed locals does not parse: %s", file, err)
+			continue
+		}
+		unnameVariables(reparsed)
+		unnameVariables(renamed)
+		if d := DiffFiles(reparsed, renamed); d != nil {
+			t.Errorf("%s: renaming locals changed the tree at %s", file, d)
+		}
+	}
+}
+
+func minify(f *ast.File, renameLocals bool) string {
+	buf := &bytes.Buffer{}
+	NewMinifier(buf, renameLocals).PrintFile(f)
+	return buf.String()
+}
+
+// unnameVariables removes the names of the variables in f, so that trees
+// differing only in variable names are equal.
+func unnameVariables(f *ast.File) {
+	var unname func(n ast.Node)
+	unname = func(n ast.Node) {
+		if n == nil {
+			return
+		}
+		if v, ok := n.(*ast.Variable); ok && v != nil {
+			if id, ok := v.Name.(*ast.Identifier); ok {
+				id.Value = ""
+			}
+		}
+		for _, child := range n.Children() {
+			unname(child)
+		}
+	}
+	for _, n := range f.Nodes {
+		unname(n)
+	}
+}
+
+func TestMinify(t *testing.T) {
+	src := `<?php
+// comment
+function total($items, $tax = 0) {
+	global $config;
+	$sum = 0;
+	foreach ($items as $item) {
+		$sum = $sum + $item - -$tax;
+		echo "$item\n";
+	}
+	Foo::$cache[$sum] = $this;
+	return function ($x) use ($sum) {
+		return $x . .5;
+	};
+}
+function dynamic($a) {
+	return compact('a');
+}
+?>
+<b> html </b>`
+	expected := `<?php function total($a,$b=0){global $config;$sum=0;foreach($a as $item){$sum=($sum+($item- -$b));echo"$item\n";}Foo::$cache[$sum]=$this;return function($a)use($sum){return $a. .5;};}function dynamic($a){return compact('a');}?>
+<b> html </b>`
+
+	f, err := parser.NewParser().Parse("test.php", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := minify(f, true); out != expected {
+		t.Errorf("minified output did not match\nFound\n\n%s\n\nExpected\n\n%s\n", out, expected)
+	}
+}
@@ -1,10 +1,11 @@
 package printer
 
 import (
-	"bytes"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
+	"unicode"
 
 	"github.com/stephens2424/php/ast"
 )
@@ -14,6 +15,26 @@ type Printer struct {
 	w         io.Writer
 	tabLevel  int
 	tabString string
+
+	// inPHP tracks whether the output is currently inside a PHP section, as
+	// opposed to inline HTML.
+	inPHP bool
+
+	// renameLocals is set if local variables are printed with the short
+	// names in locals, which holds the names for the function being printed.
+	renameLocals bool
+	locals       map[string]string
+
+	// property is the variable naming the static property being printed,
+	// which is never renamed.
+	property *ast.Variable
+
+	// comments holds the comments of the file being printed that are yet
+	// to be printed, positions the spans of its nodes, and line the line
+	// of the source where the last statement or comment printed ends.
+	comments  []ast.Comment
+	positions ast.Positions
+	line      int
 }
 
 // NewPrinter returns a Printer
@@ -22,6 +43,26 @@ func NewPrinter(w io.Writer) *Printer {
 		w:         w,
 		tabLevel:  0,
 		tabString: "\t",
+		inPHP:     true,
+	}
+}
+
+// raw writes s as it is, even when the printer is minifying. It is used for
+// source text whose whitespace is significant, such as string literals.
+func (p *Printer) raw(s string) {
+	if c, ok := p.w.(*compactWriter); ok {
+		c.writeRaw(s)
+		return
+	}
+	io.WriteString(p.w, s)
+}
+
+// openTag writes the tag beginning a PHP section.
+func (p *Printer) openTag() {
+	p.raw("<?php")
+	if _, ok := p.w.(*compactWriter); ok {
+		// PHP requires whitespace after the tag, which would be removed
+		p.raw(" ")
 	}
 }
 
@@ -37,8 +78,145 @@ func (p *Printer) detab() {
 	p.tabLevel--
 }
 
+// PrintFile prints every node in f as a complete source file, beginning in
+// HTML mode as PHP does. The comments of f are kept, each before the
+// statement or member following it, or at the end of the line of the
+// statement it follows on that line, unless the printer is minifying.
+func (p *Printer) PrintFile(f *ast.File) {
+	if _, ok := p.w.(*compactWriter); !ok {
+		p.comments, p.positions = f.Comments, f.Positions
+		defer func() { p.comments, p.positions = nil, nil }()
+	}
+	p.inPHP = false
+	namespaced := f.Namespace != nil && f.Namespace.Name != "/"
+	if namespaced || len(f.Nodes) > 0 && !isHTML(f.Nodes[0]) {
+		p.openTag()
+		if namespaced {
+			fmt.Fprintf(p.w, "\nnamespace %s;", f.Namespace.Name)
+		}
+		p.inPHP = true
+	}
+	for _, node := range f.Nodes {
+		p.printStmt(node)
+	}
+	p.printComments(-1)
+	if p.inPHP {
+		io.WriteString(p.w, "\n")
+	}
+}
+
+// isHTML reports whether n is printed in HTML mode, which is true of inline
+// HTML and of echo statements written as <?= expr ?>.
+func isHTML(n ast.Node) bool {
+	switch n := addressable(n).(type) {
+	case *ast.InlineHTML:
+		return true
+	case *ast.EchoStmt:
+		return n.ShortTag
+	}
+	return false
+}
+
+// beginStmt prints what comes before the statement s, or before the token
+// closing a list of statements if s is nil. That is a new line in PHP mode,
+// but when s is HTML it instead closes the PHP section, and when s follows
+// HTML it opens one.
+func (p *Printer) beginStmt(s ast.Node) {
+	switch {
+	case s != nil && isHTML(s):
+		if p.inPHP {
+			io.WriteString(p.w, " ?>")
+			p.inPHP = false
+		}
+	case !p.inPHP:
+		p.openTag()
+		io.WriteString(p.w, " ")
+		p.inPHP = true
+	default:
+		io.WriteString(p.w, "\n")
+		p.tab()
+	}
+}
+
+// printStmt prints the statement s on a line of its own, after the
+// comments before it.
+func (p *Printer) printStmt(s ast.Node) {
+	span, ok := p.span(s)
+	if ok {
+		p.printComments(span.Begin.Position)
+	}
+	p.beginStmt(s)
+	p.PrintNode(s)
+	if ok {
+		p.line = span.End.Line
+	}
+}
+
+// printStmts prints a list of statements one level deeper than the
+// enclosing statement, leaving the output ready for its closing token. The
+// comments after the statements and before the end of the enclosing node
+// n are printed with them.
+func (p *Printer) printStmts(stmts []ast.Statement, n ast.Node) {
+	p.entab()
+	for _, s := range stmts {
+		p.printStmt(s)
+	}
+	p.printCommentsIn(n)
+	p.detab()
+	p.beginStmt(nil)
+}
+
+// span returns the span of n in the file being printed, if its comments
+// are printed.
+func (p *Printer) span(n ast.Node) (ast.Span, bool) {
+	if len(p.comments) == 0 || ast.IsNil(n) {
+		return ast.Span{}, false
+	}
+	return p.positions.Span(n)
+}
+
+// printCommentsIn prints the comments before the end of n.
+func (p *Printer) printCommentsIn(n ast.Node) {
+	if span, ok := p.span(n); ok {
+		p.printComments(span.End.Position)
+	}
+}
+
+// printComments prints the comments that begin before the offset before,
+// or every one left if it is negative. A comment beginning on the line
+// where the last statement or comment printed ends follows it on that
+// line, and the others are on lines of their own.
+func (p *Printer) printComments(before int) {
+	for len(p.comments) > 0 && (before < 0 || p.comments[0].Span.Begin.Position < before) {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		if c.Span.Begin.Line == p.line && p.inPHP {
+			io.WriteString(p.w, " ")
+		} else {
+			p.beginStmt(nil)
+		}
+		p.raw(c.Text)
+		p.line = c.Span.Begin.Line + strings.Count(c.Text, "\n")
+	}
+}
+
+// addressable returns a pointer to n if n is a node stored by value, so
+// that each node type needs to be handled only once.
+func addressable(n ast.Node) ast.Node {
+	if n == nil {
+		return nil
+	}
+	v := reflect.ValueOf(n)
+	if v.Kind() != reflect.Struct {
+		return n
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr.Interface().(ast.Node)
+}
+
 func (p *Printer) PrintNode(node ast.Node) {
-	switch n := node.(type) {
+	switch n := addressable(node).(type) {
 	case *ast.AnonymousFunction:
 		p.PrintAnonymousFunction(n)
 	case *ast.ArrayAppendExpr:
@@ -49,8 +227,6 @@ func (p *Printer) PrintNode(node ast.Node) {
 		p.PrintArrayLookupExpression(n)
 	case *ast.ArrayPair:
 		p.PrintArrayPair(n)
-	case ast.AssignmentExpr:
-		p.PrintAssignmentExpression(&n)
 	case *ast.AssignmentExpr:
 		p.PrintAssignmentExpression(n)
 	case *ast.BinaryExpr:
@@ -81,8 +257,6 @@ func (p *Printer) PrintNode(node ast.Node) {
 		p.PrintEmptyStatement(n)
 	case *ast.ExitStmt:
 		p.PrintExitStmt(n)
-	case ast.ExprStmt:
-		p.PrintExpressionStmt(&n)
 	case *ast.ExprStmt:
 		p.PrintExpressionStmt(n)
 	case *ast.ForStmt:
@@ -101,6 +275,8 @@ func (p *Printer) PrintNode(node ast.Node) {
 		p.PrintFunctionStmt(n)
 	case *ast.GlobalDeclaration:
 		p.PrintGlobalDeclaration(n)
+	case *ast.InlineHTML:
+		p.PrintInlineHTML(n)
 	case *ast.Identifier:
 		p.PrintIdentifier(n)
 	case *ast.IfStmt:
@@ -127,8 +303,6 @@ func (p *Printer) PrintNode(node ast.Node) {
 		p.PrintPropertyExpression(n)
 	case *ast.ReturnStmt:
 		p.PrintReturnStmt(n)
-	case ast.ShellCommand:
-		p.PrintShellCommand(&n)
 	case *ast.ShellCommand:
 		p.PrintShellCommand(n)
 	case *ast.StaticVariableDeclaration:
@@ -139,28 +313,105 @@ func (p *Printer) PrintNode(node ast.Node) {
 		p.PrintSwitchStmt(n)
 	case *ast.TernaryCallExpr:
 		p.PrintTernaryExpression(n)
+	case *ast.TraitRule:
+		p.PrintTraitRule(n)
+	case *ast.TraitUse:
+		p.PrintTraitUse(n)
 	case *ast.ThrowStmt:
 		p.PrintThrowStmt(n)
 	case *ast.TryStmt:
 		p.PrintTryStmt(n)
 	case *ast.UnaryCallExpr:
 		p.PrintUnaryExpression(n)
+	case *ast.UseStmt:
+		p.PrintUseStmt(n)
 	case *ast.Variable:
 		p.PrintVariable(n)
 	case *ast.WhileStmt:
 		p.PrintWhileStmt(n)
+	case *ast.YieldExpr:
+		p.PrintYieldExpression(n)
 	default:
 		fmt.Fprintf(p.w, `/* Unsupported node type: %T */`, n)
 	}
 }
 
+// printOperand prints an operand of an operator. The AST has no node for
+// parentheses, so any operand that is itself an operation is parenthesized
+// to keep the shape of the tree when the output is parsed again.
+func (p *Printer) printOperand(e ast.Node) {
+	switch addressable(e).(type) {
+	case *ast.BinaryExpr, *ast.TernaryCallExpr, *ast.AssignmentExpr, *ast.ListStatement, *ast.YieldExpr:
+		io.WriteString(p.w, "(")
+		p.PrintNode(e)
+		io.WriteString(p.w, ")")
+	default:
+		p.PrintNode(e)
+	}
+}
+
+// printList prints nodes separated by sep.
+func (p *Printer) printList(sep string, nodes ...ast.Node) {
+	for i, n := range nodes {
+		if i > 0 {
+			io.WriteString(p.w, sep)
+		}
+		p.PrintNode(n)
+	}
+}
+
+func exprNodes(exprs []ast.Expr) []ast.Node {
+	nodes := make([]ast.Node, len(exprs))
+	for i, e := range exprs {
+		nodes[i] = e
+	}
+	return nodes
+}
+
+// printBody prints the body of a control structure, which is either a
+// block or a single statement.
+func (p *Printer) printBody(s ast.Statement) {
+	if _, ok := addressable(s).(*ast.Block); ok {
+		io.WriteString(p.w, " ")
+		p.PrintNode(s)
+		return
+	}
+	p.entab()
+	p.printStmt(s)
+	p.detab()
+}
+
+// printAltBody prints the body of a control structure written in the
+// alternative syntax, up to its closing keyword.
+func (p *Printer) printAltBody(s ast.Statement) {
+	io.WriteString(p.w, ":")
+	if b, ok := addressable(s).(*ast.Block); ok {
+		p.printStmts(b.Statements, s)
+		return
+	}
+	p.printStmts([]ast.Statement{s}, s)
+}
+
 func (p *Printer) PrintIdentifier(i *ast.Identifier) {
 	io.WriteString(p.w, i.Value)
 }
 
 func (p *Printer) PrintVariable(v *ast.Variable) {
 	io.WriteString(p.w, "$")
-	p.PrintNode(v.Name)
+	switch name := addressable(v.Name).(type) {
+	case *ast.Identifier:
+		if short, ok := p.locals[name.Value]; ok && v != p.property {
+			io.WriteString(p.w, short)
+			return
+		}
+		p.PrintNode(name)
+	case *ast.Variable:
+		p.PrintNode(name)
+	default:
+		io.WriteString(p.w, "{")
+		p.PrintNode(v.Name)
+		io.WriteString(p.w, "}")
+	}
 }
 
 func (p *Printer) PrintGlobalDeclaration(g *ast.GlobalDeclaration) {
@@ -171,155 +422,222 @@ func (p *Printer) PrintGlobalDeclaration(g *ast.GlobalDeclaration) {
 			io.WriteString(p.w, ", ")
 		}
 	}
+	io.WriteString(p.w, ";")
 }
 
-func (p *Printer) PrintEmptyStatement(e *ast.EmptyStatement) {}
+func (p *Printer) PrintEmptyStatement(e *ast.EmptyStatement) {
+	io.WriteString(p.w, ";")
+}
 
 func (p *Printer) PrintBinaryExpression(b *ast.BinaryExpr) {
-	fmt.Fprintf(p.w, "%s %s %s", b.Antecedent, b.Operator, b.Subsequent)
+	p.printOperand(b.Antecedent)
+	fmt.Fprintf(p.w, " %s ", b.Operator)
+	p.printOperand(b.Subsequent)
+}
+
+// sameNode reports whether a and b are the same pointer, as they are for the
+// condition and true branch of a ternary written without its middle part.
+func sameNode(a, b ast.Node) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	return va.IsValid() && vb.IsValid() && va.Kind() == reflect.Ptr && vb.Kind() == reflect.Ptr && va.Pointer() == vb.Pointer()
 }
 
 func (p *Printer) PrintTernaryExpression(t *ast.TernaryCallExpr) {
-	fmt.Fprintf(p.w, "%s ? %s : %s", t.Condition, t.True, t.False)
+	p.printOperand(t.Condition)
+	if sameNode(t.Condition, t.True) {
+		io.WriteString(p.w, " ?: ")
+	} else {
+		io.WriteString(p.w, " ? ")
+		p.printOperand(t.True)
+		io.WriteString(p.w, " : ")
+	}
+	p.printOperand(t.False)
 }
+
+// PrintUnaryExpression prints a unary operation. Increment and decrement
+// operators record whether they precede their operand; all other unary
+// operators are prefix operators.
 func (p *Printer) PrintUnaryExpression(u *ast.UnaryCallExpr) {
-	if u.Preceding {
-		fmt.Fprintf(p.w, "%s%s", u.Operator, u.Operand)
+	if (u.Operator == "++" || u.Operator == "--") && !u.Preceding {
+		p.printOperand(u.Operand)
+		io.WriteString(p.w, u.Operator)
+		return
+	}
+	io.WriteString(p.w, u.Operator)
+	if last := rune(u.Operator[len(u.Operator)-1]); unicode.IsLetter(last) || mergesWith(last, u.Operand) {
+		io.WriteString(p.w, " ")
 	}
-	fmt.Fprintf(p.w, "%s%s", u.Operand, u.Operator)
+	p.printOperand(u.Operand)
+}
+
+// mergesWith reports whether the operator ending in last would run together
+// with the prefix operator of operand, as - and -$x would make --$x.
+func mergesWith(last rune, operand ast.Node) bool {
+	u, ok := addressable(operand).(*ast.UnaryCallExpr)
+	if !ok || (u.Operator == "++" || u.Operator == "--") && !u.Preceding {
+		return false
+	}
+	return (last == '-' || last == '+') && rune(u.Operator[0]) == last
 }
 
 func (p *Printer) PrintEchoStmt(e *ast.EchoStmt) {
-	io.WriteString(p.w, "echo ")
-	for i, expr := range e.Expressions {
-		if i > 0 {
-			io.WriteString(p.w, ", ")
-		}
-		p.PrintNode(expr)
+	if e.ShortTag {
+		io.WriteString(p.w, "<?= ")
+		p.printList(", ", exprNodes(e.Expressions)...)
+		io.WriteString(p.w, " ?>")
+		return
 	}
+	io.WriteString(p.w, "echo ")
+	p.printList(", ", exprNodes(e.Expressions)...)
 	io.WriteString(p.w, ";")
 }
 
+func (p *Printer) PrintInlineHTML(h *ast.InlineHTML) {
+	p.raw(h.Value)
+}
+
 func (p *Printer) PrintReturnStmt(r *ast.ReturnStmt) {
-	io.WriteString(p.w, "return ")
+	io.WriteString(p.w, "return")
 	if r.Expr != nil {
+		io.WriteString(p.w, " ")
 		p.PrintNode(r.Expr)
 	}
 	io.WriteString(p.w, ";")
 }
+
 func (p *Printer) PrintBreakStmt(b *ast.BreakStmt) {
 	io.WriteString(p.w, "break")
 	if b.Expr != nil {
+		io.WriteString(p.w, " ")
 		p.PrintNode(b.Expr)
 	}
 	io.WriteString(p.w, ";")
-
 }
+
 func (p *Printer) PrintContinueStmt(b *ast.ContinueStmt) {
 	io.WriteString(p.w, "continue")
 	if b.Expr != nil {
+		io.WriteString(p.w, " ")
 		p.PrintNode(b.Expr)
 	}
 	io.WriteString(p.w, ";")
-
 }
+
 func (p *Printer) PrintThrowStmt(b *ast.ThrowStmt) {
 	io.WriteString(p.w, "throw")
 	if b.Expr != nil {
+		io.WriteString(p.w, " ")
 		p.PrintNode(b.Expr)
 	}
 	io.WriteString(p.w, ";")
-
 }
+
 func (p *Printer) PrintInclude(e *ast.Include) {
 	io.WriteString(p.w, "include ")
-	for i, expr := range e.Expressions {
-		if i > 0 {
-			io.WriteString(p.w, ", ")
-		}
-		p.PrintNode(expr)
-	}
-	io.WriteString(p.w, ";")
+	p.printList(", ", exprNodes(e.Expressions)...)
+}
 
+func (p *Printer) PrintYieldExpression(y *ast.YieldExpr) {
+	io.WriteString(p.w, "yield")
+	if y.From {
+		io.WriteString(p.w, " from")
+	}
+	if y.Key != nil {
+		io.WriteString(p.w, " ")
+		p.PrintNode(y.Key)
+		io.WriteString(p.w, " =>")
+	}
+	if y.Value != nil {
+		io.WriteString(p.w, " ")
+		p.PrintNode(y.Value)
+	}
 }
+
 func (p *Printer) PrintExitStmt(b *ast.ExitStmt) {
 	io.WriteString(p.w, "exit")
 	if b.Expr != nil {
+		io.WriteString(p.w, "(")
 		p.PrintNode(b.Expr)
+		io.WriteString(p.w, ")")
 	}
 	io.WriteString(p.w, ";")
-
 }
+
 func (p *Printer) PrintNewExpression(b *ast.NewCallExpr) {
 	io.WriteString(p.w, "new ")
 	p.PrintNode(b.Class)
 	io.WriteString(p.w, "(")
-	for i, arg := range b.Arguments {
-		if i > 0 {
-			io.WriteString(p.w, ",")
-		}
-		p.PrintNode(arg)
-	}
+	p.printList(", ", exprNodes(b.Arguments)...)
 	io.WriteString(p.w, ")")
-
 }
+
 func (p *Printer) PrintAssignmentExpression(a *ast.AssignmentExpr) {
 	p.PrintNode(a.Assignee)
 	io.WriteString(p.w, " ")
 	io.WriteString(p.w, a.Operator)
 	io.WriteString(p.w, " ")
-	p.PrintNode(a.Value)
+	if _, ok := addressable(a.Value).(*ast.AssignmentExpr); ok {
+		p.PrintNode(a.Value)
+		return
+	}
+	p.printOperand(a.Value)
 }
 
 func (p *Printer) PrintFunctionCallStmt(f *ast.FunctionCallStmt) {
-	p.PrintNode(f.FunctionCallExpr)
+	p.PrintNode(&f.FunctionCallExpr)
 	io.WriteString(p.w, ";")
-
 }
+
 func (p *Printer) PrintFunctionCallExpression(f *ast.FunctionCallExpr) {
-	p.PrintNode(f.FunctionName)
-	io.WriteString(p.w, "(")
-	for i, arg := range f.Arguments {
-		if i > 0 {
-			io.WriteString(p.w, ",")
-		}
-		p.PrintNode(arg)
+	switch addressable(f.FunctionName).(type) {
+	case *ast.Identifier, *ast.Variable, *ast.ClassExpr, *ast.ArrayLookupExpr, *ast.PropertyCallExpr:
+		p.PrintNode(f.FunctionName)
+	default:
+		io.WriteString(p.w, "(")
+		p.PrintNode(f.FunctionName)
+		io.WriteString(p.w, ")")
 	}
+	io.WriteString(p.w, "(")
+	p.printList(", ", exprNodes(f.Arguments)...)
 	io.WriteString(p.w, ")")
-
 }
 
 func (p *Printer) PrintBlock(b *ast.Block) {
-	io.WriteString(p.w, "{\n")
-	p.entab()
-	for _, s := range b.Statements {
-		p.tab()
-		p.PrintNode(s)
-		io.WriteString(p.w, "\n")
-	}
-	p.detab()
-	p.tab()
+	io.WriteString(p.w, "{")
+	p.printStmts(b.Statements, b)
 	io.WriteString(p.w, "}")
 }
 
 func (p *Printer) PrintFunctionStmt(f *ast.FunctionStmt) {
+	if p.renameLocals {
+		defer p.enterFunction(f.Arguments, nil, f.Body)()
+	}
 	p.PrintNode(f.FunctionDefinition)
+	if f.Body == nil {
+		io.WriteString(p.w, ";")
+		return
+	}
+	io.WriteString(p.w, " ")
 	p.PrintNode(f.Body)
 }
+
 func (p *Printer) PrintAnonymousFunction(a *ast.AnonymousFunction) {
+	if p.renameLocals {
+		defer p.enterFunction(a.Arguments, a.ClosureVariables, a.Body)()
+	}
 	io.WriteString(p.w, "function (")
 	for i, arg := range a.Arguments {
 		if i > 0 {
-			io.WriteString(p.w, ",")
+			io.WriteString(p.w, ", ")
 		}
 		p.PrintNode(arg)
 	}
 	io.WriteString(p.w, ") ")
 	if len(a.ClosureVariables) > 0 {
-		fmt.Fprint(p.w, "use (")
+		io.WriteString(p.w, "use (")
 		for i, arg := range a.ClosureVariables {
 			if i > 0 {
-				io.WriteString(p.w, ",")
+				io.WriteString(p.w, ", ")
 			}
 			p.PrintNode(arg)
 		}
@@ -335,59 +653,111 @@ func (p *Printer) PrintFunctionDefinition(fd *ast.FunctionDefinition) {
 	for i, arg := range fd.Arguments {
 		p.PrintNode(arg)
 		if i+1 < len(fd.Arguments) {
-			io.WriteString(p.w, ",")
+			io.WriteString(p.w, ", ")
 		}
 	}
-	io.WriteString(p.w, ") ")
-
+	io.WriteString(p.w, ")")
+	if fd.Type != "" {
+		io.WriteString(p.w, ": ")
+		io.WriteString(p.w, fd.Type)
+	}
 }
+
 func (p *Printer) PrintFunctionArgument(fa *ast.FunctionArgument) {
-	buf := &bytes.Buffer{}
 	if fa.TypeHint != "" {
-		fmt.Fprint(buf, fa.TypeHint, "")
+		io.WriteString(p.w, fa.TypeHint)
+		io.WriteString(p.w, " ")
+	}
+	if fa.Reference {
+		io.WriteString(p.w, "&")
 	}
 	p.PrintNode(fa.Variable)
 	if fa.Default != nil {
-		io.WriteString(p.w, " =")
+		io.WriteString(p.w, " = ")
 		p.PrintNode(fa.Default)
 	}
-
 }
+
 func (p *Printer) PrintClass(c *ast.Class) {
-	io.WriteString(p.w, "class ")
+	switch {
+	case c.Abstract:
+		io.WriteString(p.w, "abstract ")
+	case c.Final:
+		io.WriteString(p.w, "final ")
+	}
+	if c.Trait {
+		io.WriteString(p.w, "trait ")
+	} else {
+		io.WriteString(p.w, "class ")
+	}
 	io.WriteString(p.w, c.Name)
 	if c.Extends != "" {
 		fmt.Fprintf(p.w, " extends %s", c.Extends)
 	}
 	for i, imp := range c.Implements {
 		if i > 0 {
-			io.WriteString(p.w, ",")
+			io.WriteString(p.w, ", ")
 		} else {
-			io.WriteString(p.w, "implements ")
+			io.WriteString(p.w, " implements ")
 		}
 		io.WriteString(p.w, imp)
 	}
-	io.WriteString(p.w, " {\n")
+	io.WriteString(p.w, " {")
 	p.entab()
+	for _, u := range c.TraitUses {
+		p.printStmt(u)
+	}
 	for _, c := range c.Constants {
-		p.tab()
-		p.PrintNode(c)
-		io.WriteString(p.w, "\n")
+		p.printStmt(c)
 	}
 	for _, pr := range c.Properties {
-		p.tab()
-		p.PrintNode(pr)
-		io.WriteString(p.w, "\n")
+		p.printStmt(pr)
 	}
 	for _, m := range c.Methods {
+		p.printStmt(m)
+	}
+	p.printCommentsIn(c)
+	p.detab()
+	p.beginStmt(nil)
+	io.WriteString(p.w, "}")
+}
+
+func (p *Printer) PrintTraitUse(u *ast.TraitUse) {
+	fmt.Fprintf(p.w, "use %s", strings.Join(u.Traits, ", "))
+	if len(u.Rules) == 0 {
+		io.WriteString(p.w, ";")
+		return
+	}
+	io.WriteString(p.w, " {\n")
+	p.entab()
+	for _, r := range u.Rules {
 		p.tab()
-		p.PrintNode(m)
+		p.PrintNode(r)
 		io.WriteString(p.w, "\n")
 	}
 	p.detab()
 	p.tab()
 	io.WriteString(p.w, "}")
+}
 
+func (p *Printer) PrintTraitRule(r *ast.TraitRule) {
+	if r.Trait != "" {
+		fmt.Fprintf(p.w, "%s::", r.Trait)
+	}
+	io.WriteString(p.w, r.Method)
+	if len(r.Insteadof) > 0 {
+		fmt.Fprintf(p.w, " insteadof %s;", strings.Join(r.Insteadof, ", "))
+		return
+	}
+	io.WriteString(p.w, " as")
+	if r.HasVisibility {
+		io.WriteString(p.w, " ")
+		p.PrintVisibility(r.Visibility)
+	}
+	if r.Alias != "" {
+		fmt.Fprintf(p.w, " %s", r.Alias)
+	}
+	io.WriteString(p.w, ";")
 }
 
 func (p *Printer) PrintInterface(i *ast.Interface) {
@@ -398,131 +768,243 @@ func (p *Printer) PrintInterface(i *ast.Interface) {
 		if i > 0 {
 			io.WriteString(p.w, ", ")
 		} else {
-			io.WriteString(p.w, "implements ")
+			io.WriteString(p.w, " extends ")
 		}
 		io.WriteString(p.w, imp)
 	}
 
 	io.WriteString(p.w, " {")
-	for _, c := range i.Constants {
-		p.PrintNode(c)
+	p.entab()
+	for n := range i.Constants {
+		p.printStmt(&i.Constants[n])
 	}
 
 	for _, m := range i.Methods {
-		p.PrintNode(m)
+		// interface methods are implicitly abstract
+		span, ok := p.span(m.FunctionStmt)
+		if ok {
+			p.printComments(span.Begin.Position)
+		}
+		p.beginStmt(nil)
+		p.PrintVisibility(m.Visibility)
+		if m.Static {
+			io.WriteString(p.w, " static")
+		}
+		io.WriteString(p.w, " ")
+		p.PrintNode(m.FunctionStmt)
+		if ok {
+			p.line = span.End.Line
+		}
 	}
-
+	p.printCommentsIn(i)
+	p.detab()
+	p.beginStmt(nil)
 	io.WriteString(p.w, "}")
-
 }
 
 func (p *Printer) PrintProperty(pr *ast.Property) {
-	buf := &bytes.Buffer{}
 	p.PrintVisibility(pr.Visibility)
-	fmt.Fprintf(buf, " %s", pr.Name)
+	if pr.Static {
+		io.WriteString(p.w, " static")
+	}
+	fmt.Fprintf(p.w, " %s", pr.Name)
 	if pr.Initialization != nil {
+		io.WriteString(p.w, " = ")
 		p.PrintNode(pr.Initialization)
 	}
 	io.WriteString(p.w, ";")
+}
 
+// printMemberName prints the name following -> or ::. Names that are neither
+// identifiers nor variables must be wrapped in braces.
+func (p *Printer) printMemberName(name ast.Node) {
+	switch addressable(name).(type) {
+	case *ast.Identifier, *ast.Variable:
+		p.PrintNode(name)
+	default:
+		io.WriteString(p.w, "{")
+		p.PrintNode(name)
+		io.WriteString(p.w, "}")
+	}
 }
+
 func (p *Printer) PrintPropertyExpression(pr *ast.PropertyCallExpr) {
 	p.PrintNode(pr.Receiver)
 	io.WriteString(p.w, "->")
-	p.PrintNode(pr.Name)
+	p.printMemberName(pr.Name)
 }
 
 func (p *Printer) PrintClassExpression(c *ast.ClassExpr) {
 	p.PrintNode(c.Receiver)
 	io.WriteString(p.w, "::")
+	defer func(property *ast.Variable) { p.property = property }(p.property)
+	p.property = staticProperty(c)
 	p.PrintNode(c.Expr)
 }
+
 func (p *Printer) PrintMethod(m *ast.Method) {
+	switch {
+	case m.Abstract:
+		io.WriteString(p.w, "abstract ")
+	case m.Final:
+		io.WriteString(p.w, "final ")
+	}
 	p.PrintVisibility(m.Visibility)
+	if m.Static {
+		io.WriteString(p.w, " static")
+	}
 	io.WriteString(p.w, " ")
 	p.PrintNode(m.FunctionStmt)
 }
+
 func (p *Printer) PrintMethodCallExpression(m *ast.MethodCallExpr) {
 	p.PrintNode(m.Receiver)
 	io.WriteString(p.w, "->")
-	p.PrintNode(m.FunctionCallExpr)
+	p.printMemberName(m.FunctionName)
+	io.WriteString(p.w, "(")
+	p.printList(", ", exprNodes(m.Arguments)...)
+	io.WriteString(p.w, ")")
 }
+
 func (p *Printer) PrintIfStmt(i *ast.IfStmt) {
-	if len(i.Branches) == 0 {
+	if i.AltSyntax {
+		p.printAltIfStmt(i)
 		return
 	}
+	for n, branch := range i.Branches {
+		if n > 0 {
+			p.printBranchSeparator(i.Branches[n-1].Block)
+			io.WriteString(p.w, "else")
+		}
+		io.WriteString(p.w, "if (")
+		p.PrintNode(branch.Condition)
+		io.WriteString(p.w, ")")
+		p.printBody(branch.Block)
+	}
+	if i.ElseBlock != nil {
+		p.printBranchSeparator(i.Branches[len(i.Branches)-1].Block)
+		io.WriteString(p.w, "else")
+		p.printBody(i.ElseBlock)
+	}
+}
 
-	fmt.Fprintf(p.w, "if (%s) {\n%s\n}", i.Branches[0].Condition, i.Branches[0].Block)
-	for _, branch := range i.Branches[1:] {
-		fmt.Fprintf(p.w, " else if (%s) {\n%s\n}", branch.Condition, branch.Block)
+func (p *Printer) printAltIfStmt(i *ast.IfStmt) {
+	for n, branch := range i.Branches {
+		if n > 0 {
+			io.WriteString(p.w, "else")
+		}
+		io.WriteString(p.w, "if (")
+		p.PrintNode(branch.Condition)
+		io.WriteString(p.w, ")")
+		p.printAltBody(branch.Block)
 	}
 	if i.ElseBlock != nil {
-		fmt.Fprintf(p.w, " else {\n%s\n}", i.ElseBlock)
+		io.WriteString(p.w, "else")
+		p.printAltBody(i.ElseBlock)
 	}
+	io.WriteString(p.w, "endif;")
+}
+
+// printBranchSeparator prints what comes between the body of one if branch
+// and the next "else" keyword.
+func (p *Printer) printBranchSeparator(previous ast.Statement) {
+	if _, ok := addressable(previous).(*ast.Block); ok {
+		io.WriteString(p.w, " ")
+		return
+	}
+	p.beginStmt(nil)
 }
 
 func (p *Printer) PrintSwitchStmt(s *ast.SwitchStmt) {
-	fmt.Fprintf(p.w, "switch (%s) {\n", s.Expr)
+	io.WriteString(p.w, "switch (")
+	p.PrintNode(s.Expr)
+	if s.AltSyntax {
+		io.WriteString(p.w, "):")
+	} else {
+		io.WriteString(p.w, ") {")
+	}
 	for _, c := range s.Cases {
-		p.PrintNode(c)
-		io.WriteString(p.w, "\n")
+		p.printStmt(c)
 	}
 	if s.DefaultCase != nil {
-		fmt.Fprintf(p.w, "default:\n")
-		p.PrintNode(s.DefaultCase)
+		p.beginStmt(nil)
+		io.WriteString(p.w, "default:")
+		p.printCaseBlock(s.DefaultCase)
+	}
+	p.beginStmt(nil)
+	if s.AltSyntax {
+		io.WriteString(p.w, "endswitch;")
+	} else {
+		io.WriteString(p.w, "}")
 	}
-	io.WriteString(p.w, "}")
 }
 
 func (p *Printer) PrintSwitchCase(s *ast.SwitchCase) {
 	io.WriteString(p.w, "case ")
 	p.PrintNode(s.Expr)
-	io.WriteString(p.w, ":\n")
-	p.PrintNode(s.Block)
-	io.WriteString(p.w, "\n")
+	io.WriteString(p.w, ":")
+	p.printCaseBlock(&s.Block)
 }
-func (p *Printer) PrintForStmt(f *ast.ForStmt) {
-	fmt.Fprintf(p.w, "for (")
-	for i, e := range f.Initialization {
-		if i > 0 {
-			io.WriteString(p.w, ", ")
-		}
-		p.PrintNode(e)
-	}
-	for i, e := range f.Termination {
-		if i > 0 {
-			io.WriteString(p.w, ", ")
-		}
-		p.PrintNode(e)
-	}
-	for i, e := range f.Iteration {
-		if i > 0 {
-			io.WriteString(p.w, ", ")
-		}
-		p.PrintNode(e)
+
+// printCaseBlock prints the statements of a switch case without the braces
+// of its block.
+func (p *Printer) printCaseBlock(b *ast.Block) {
+	p.entab()
+	for _, s := range b.Statements {
+		p.printStmt(s)
 	}
-	io.WriteString(p.w, ") ")
-	p.PrintNode(f.LoopBlock)
+	p.detab()
+}
 
+func (p *Printer) PrintForStmt(f *ast.ForStmt) {
+	io.WriteString(p.w, "for (")
+	p.printList(", ", exprNodes(f.Initialization)...)
+	io.WriteString(p.w, "; ")
+	p.printList(", ", exprNodes(f.Termination)...)
+	io.WriteString(p.w, "; ")
+	p.printList(", ", exprNodes(f.Iteration)...)
+	io.WriteString(p.w, ")")
+	if f.AltSyntax {
+		p.printAltBody(f.LoopBlock)
+		io.WriteString(p.w, "endfor;")
+		return
+	}
+	p.printBody(f.LoopBlock)
 }
+
 func (p *Printer) PrintWhileStmt(wh *ast.WhileStmt) {
-	fmt.Fprintf(p.w, "while (%s) %s", wh.Termination, wh.LoopBlock)
+	io.WriteString(p.w, "while (")
+	p.PrintNode(wh.Termination)
+	io.WriteString(p.w, ")")
+	if wh.AltSyntax {
+		p.printAltBody(wh.LoopBlock)
+		io.WriteString(p.w, "endwhile;")
+		return
+	}
+	p.printBody(wh.LoopBlock)
 }
+
 func (p *Printer) PrintDoWhileStmt(wh *ast.DoWhileStmt) {
-	fmt.Fprintf(p.w, "do %s while (%s);", wh.LoopBlock, wh.Termination)
+	io.WriteString(p.w, "do ")
+	p.PrintNode(wh.LoopBlock)
+	io.WriteString(p.w, " while (")
+	p.PrintNode(wh.Termination)
+	io.WriteString(p.w, ");")
 }
+
 func (p *Printer) PrintTryStmt(t *ast.TryStmt) {
-	fmt.Fprintf(p.w, "try ")
+	io.WriteString(p.w, "try ")
 	p.PrintNode(t.TryBlock)
 	for _, c := range t.CatchStmts {
+		io.WriteString(p.w, " ")
 		p.PrintNode(c)
 	}
 	if t.FinallyBlock != nil {
-		fmt.Fprintf(p.w, "finally ")
+		io.WriteString(p.w, " finally ")
 		p.PrintNode(t.FinallyBlock)
 	}
-
 }
+
 func (p *Printer) PrintCatchStmt(c *ast.CatchStmt) {
 	fmt.Fprintf(p.w, "catch (%s ", c.CatchType)
 	p.PrintNode(c.CatchVar)
@@ -532,31 +1014,40 @@ func (p *Printer) PrintCatchStmt(c *ast.CatchStmt) {
 
 func (p *Printer) PrintLiteral(l *ast.Literal) {
 	switch l.Type {
-	case ast.String:
-		io.WriteString(p.w, l.Value)
-	case ast.Integer, ast.Float:
-		io.WriteString(p.w, l.Value)
-	case ast.Boolean:
-		io.WriteString(p.w, l.Value)
 	case ast.Null:
-		io.WriteString(p.w, "null")
-	default:
+		if l.Value == "" {
+			io.WriteString(p.w, "null")
+			return
+		}
 		io.WriteString(p.w, l.Value)
+	default:
+		p.raw(l.Value)
 	}
 }
 
 func (p *Printer) PrintForeachStmt(f *ast.ForeachStmt) {
-	fmt.Fprintf(p.w, "foreach (%s as ", f.Source)
+	io.WriteString(p.w, "foreach (")
+	p.PrintNode(f.Source)
+	io.WriteString(p.w, " as ")
 	if f.Key != nil {
-		fmt.Fprintf(p.w, "%s => ", f.Key)
+		p.PrintNode(f.Key)
+		io.WriteString(p.w, " => ")
+	}
+	if f.Reference {
+		io.WriteString(p.w, "&")
 	}
 	p.PrintNode(f.Value)
-	io.WriteString(p.w, ") ")
-	p.PrintNode(f.LoopBlock)
+	io.WriteString(p.w, ")")
+	if f.AltSyntax {
+		p.printAltBody(f.LoopBlock)
+		io.WriteString(p.w, "endforeach;")
+		return
+	}
+	p.printBody(f.LoopBlock)
 }
 
 func (p *Printer) PrintArrayExpression(a *ast.ArrayExpr) {
-	fmt.Fprintf(p.w, "array(")
+	io.WriteString(p.w, "array(")
 	for i, pair := range a.Pairs {
 		if i > 0 {
 			io.WriteString(p.w, ", ")
@@ -569,8 +1060,7 @@ func (p *Printer) PrintArrayExpression(a *ast.ArrayExpr) {
 func (p *Printer) PrintArrayPair(pr *ast.ArrayPair) {
 	if pr.Key != nil {
 		p.PrintNode(pr.Key)
-		fmt.Fprintf(p.w, " => ")
-		p.PrintNode(pr.Value)
+		io.WriteString(p.w, " => ")
 	}
 	p.PrintNode(pr.Value)
 }
@@ -583,27 +1073,28 @@ func (p *Printer) PrintArrayLookupExpression(a *ast.ArrayLookupExpr) {
 }
 
 func (p *Printer) PrintArrayAppendExpression(a *ast.ArrayAppendExpr) {
-	fmt.Fprintf(p.w, "%s[]", a.Array)
+	p.PrintNode(a.Array)
+	io.WriteString(p.w, "[]")
 }
 
 func (p *Printer) PrintShellCommand(s *ast.ShellCommand) {
-	io.WriteString(p.w, s.Command)
+	p.raw(s.Command)
 }
 
 func (p *Printer) PrintListStatement(l *ast.ListStatement) {
-	fmt.Fprintf(p.w, "list(")
+	io.WriteString(p.w, "list(")
 	for i, a := range l.Assignees {
 		if i > 0 {
 			io.WriteString(p.w, ", ")
 		}
 		p.PrintNode(a)
 	}
-	io.WriteString(p.w, ") =")
+	fmt.Fprintf(p.w, ") %s ", l.Operator)
 	p.PrintNode(l.Value)
 }
 
 func (p *Printer) PrintStaticVariableDeclaration(s *ast.StaticVariableDeclaration) {
-	fmt.Fprintf(p.w, "static ")
+	io.WriteString(p.w, "static ")
 	for i, d := range s.Declarations {
 		if i > 0 {
 			io.WriteString(p.w, ", ")
@@ -612,21 +1103,40 @@ func (p *Printer) PrintStaticVariableDeclaration(s *ast.StaticVariableDeclaratio
 	}
 	io.WriteString(p.w, ";")
 }
+
+func (p *Printer) PrintUseStmt(u *ast.UseStmt) {
+	io.WriteString(p.w, "use "+u.Name)
+	if u.Alias != "" {
+		io.WriteString(p.w, " as "+u.Alias)
+	}
+	io.WriteString(p.w, ";")
+}
+
 func (p *Printer) PrintDeclareBlock(d *ast.DeclareBlock) {
-	io.WriteString(p.w, "declare (")
+	io.WriteString(p.w, "declare(")
 	for i, decl := range d.Declarations {
 		if i > 0 {
-			io.WriteString(p.w, ",")
+			io.WriteString(p.w, ", ")
 		}
 		io.WriteString(p.w, decl)
 	}
-	io.WriteString(p.w, ") {")
+	io.WriteString(p.w, ")")
+	if d.Statements == nil {
+		io.WriteString(p.w, ";")
+		return
+	}
+	io.WriteString(p.w, " ")
 	p.PrintNode(d.Statements)
-	io.WriteString(p.w, "}")
 }
 
 func (p *Printer) PrintConstant(c *ast.Constant) {
+	io.WriteString(p.w, "const ")
 	io.WriteString(p.w, c.Name)
+	if value, ok := c.Value.(ast.Node); ok && value != nil {
+		io.WriteString(p.w, " = ")
+		p.PrintNode(value)
+	}
+	io.WriteString(p.w, ";")
 }
 
 func (p *Printer) PrintConstantExpression(c *ast.ConstantExpr) {
@@ -12,8 +12,6 @@ type Test struct {
 }
 
 func TestPrinter(t *testing.T) {
-	t.SkipNow() // disabling this test before some ast restructuring
-
 	for _, test := range tests {
 		p := parser.NewParser()
 		file, err := p.Parse("test.php", test.Before)
@@ -22,13 +20,9 @@ func TestPrinter(t *testing.T) {
 			continue
 		}
 
-		if len(file.Nodes) < 1 {
-			continue
-		}
-
 		buf := &bytes.Buffer{}
 		pr := NewPrinter(buf)
-		pr.PrintNode(file.Nodes[0])
+		pr.PrintFile(file)
 
 		if buf.String() != test.After {
 			t.Fatalf("formatted text did not match\nFormatted\n\n%s\n\nExpected\n\n%s\n", buf.String(), test.After)
@@ -41,10 +35,149 @@ var tests = []Test{
 		Before: ``,
 		After:  ``,
 	},
+	{
+		Before: `<?php
+// the answer
+$a = 42; # trailing
+
+/**
+ * Greeter greets.
+ */
+class Greeter {
+	// the greeting
+	const HELLO = "hello";
+
+	/** @var string */
+	public $name;
+
+	function greet() {
+		echo self::HELLO; /* inline */ /* twice */
+		// nothing follows
+	}
+	// the end of the class
+}
+interface Named {
+	// the name
+	function name();
+}
+if ($a) {
+	foo();
+} // after the if
+/* the end */`,
+		After: `<?php
+// the answer
+$a = 42; # trailing
+/**
+ * Greeter greets.
+ */
+class Greeter {
+	// the greeting
+	const HELLO = "hello";
+	/** @var string */
+	public $name;
+	public function greet() {
+		echo self::HELLO; /* inline */ /* twice */
+		// nothing follows
+	}
+	// the end of the class
+}
+interface Named {
+	// the name
+	public function name();
+}
+if ($a) {
+	foo();
+} // after the if
+/* the end */
+`,
+	},
 	{
 		Before: `<?php $var = "x"; `,
 		After: `<?php
 $var = "x";
+`,
+	},
+	{
+		Before: `<ul>
+<?php foreach ($items as $item): ?>
+  <li><?= $item ?></li>
+<?php endforeach; ?>
+</ul>
+<?php if ($a): ?>a<?php elseif ($b): ?>b<?php else: ?>c<?php endif; ?>
+`,
+		After: `<ul>
+<?php foreach ($items as $item): ?>
+  <li><?= $item ?></li>
+<?php endforeach; ?>
+</ul>
+<?php if ($a): ?>a<?php elseif ($b): ?>b<?php else: ?>c<?php endif; ?>
+`,
+	},
+	{
+		Before: `<?php if ($a) { ?><b><?php echo $c; } ?>`,
+		After: `<?php
+if ($a) { ?><b><?php echo $c;
+}
+`,
+	},
+	{
+		Before: `<?php
+abstract class A {
+  public static $count = 0;
+  abstract protected function make();
+  final public static function create() {}
+}
+final class B {}
+interface I { public static function make(); }`,
+		After: `<?php
+abstract class A {
+	public static $count = 0;
+	abstract protected function make();
+	final public static function create() {
+	}
+}
+final class B {
+}
+interface I {
+	public static function make();
+}
+`,
+	},
+	{
+		Before: `<?php $a = - -$x; $b = -(-$x); $c = + +$x; $d = -(--$x); $e = -$x--; $f = $x + +$x; $g = - +$x;`,
+		After: `<?php
+$a = - -$x;
+$b = - -$x;
+$c = + +$x;
+$d = - --$x;
+$e = -$x--;
+$f = ($x + +$x);
+$g = -+$x;
+`,
+	},
+	{
+		Before: `<?php class C { use T, U { T::hello insteadof U; U::hello as protected helloU; hello as private; } use V; }`,
+		After: `<?php
+class C {
+	use T, U {
+		T::hello insteadof U;
+		U::hello as protected helloU;
+		hello as private;
+	}
+	use V;
+}
+`,
+	},
+	{
+		Before: `<?php function g() { yield; $a = yield $b + 1; yield $k => $v; yield from h(); $c = 1 + (yield); }`,
+		After: `<?php
+function g() {
+	yield;
+	$a = (yield $b + 1);
+	yield $k => $v;
+	yield from h();
+	$c = (1 + (yield));
+}
 `,
 	},
 }
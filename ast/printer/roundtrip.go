@@ -0,0 +1,112 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/parser"
+)
+
+// RoundTripError describes a file for which printing either changes the
+// syntax tree or is not stable.
+type RoundTripError struct {
+	Filename string
+
+	// Output is the first printing of the file.
+	Output string
+
+	// ParseErr is set if Output could not be parsed.
+	ParseErr error
+
+	// Diff is set if Output parsed to a different tree than the original.
+	Diff *ast.Difference
+
+	// Line is set to the first line, counting from 1, at which printing the
+	// reparsed tree differs from Output.
+	Line int
+}
+
+func (e *RoundTripError) Error() string {
+	switch {
+	case e.ParseErr != nil:
+		return fmt.Sprintf("%s: printed output does not parse: %s", e.Filename, e.ParseErr)
+	case e.Diff != nil:
+		return fmt.Sprintf("%s: printed output parses to a different tree at %s", e.Filename, e.Diff)
+	default:
+		return fmt.Sprintf("%s: printing is not stable at output line %d", e.Filename, e.Line)
+	}
+}
+
+// CheckRoundTrip parses src, prints it and parses the output again. It
+// returns a *RoundTripError if the output does not parse, if it parses to a
+// tree that is not ast.Equal to the original, or if printing it again does
+// not reproduce the same output. If src itself does not parse, that error
+// is returned.
+func CheckRoundTrip(filename, src string) error {
+	original, err := parser.NewParser().Parse(filename, src)
+	if err != nil {
+		return err
+	}
+
+	first := sprintFile(original)
+	rtErr := &RoundTripError{Filename: filename, Output: first}
+
+	reparsed, err := parser.NewParser().Parse(filename, first)
+	if err != nil {
+		rtErr.ParseErr = err
+		return rtErr
+	}
+
+	if rtErr.Diff = DiffFiles(original, reparsed); rtErr.Diff != nil {
+		return rtErr
+	}
+
+	if second := sprintFile(reparsed); second != first {
+		rtErr.Line = firstDifferentLine(first, second)
+		return rtErr
+	}
+	return nil
+}
+
+// DiffFiles returns the first difference between the nodes of a and b, or
+// nil if every node is ast.Equal.
+func DiffFiles(a, b *ast.File) *ast.Difference {
+	for i := 0; i < len(a.Nodes) || i < len(b.Nodes); i++ {
+		path := fmt.Sprintf("Nodes[%d]", i)
+		if i >= len(a.Nodes) {
+			return &ast.Difference{B: b.Nodes[i], Path: path}
+		}
+		if i >= len(b.Nodes) {
+			return &ast.Difference{A: a.Nodes[i], Path: path}
+		}
+		if d := ast.Diff(a.Nodes[i], b.Nodes[i]); d != nil {
+			if d.Path == "." {
+				d.Path = ""
+			}
+			d.Path = path + d.Path
+			return d
+		}
+	}
+	return nil
+}
+
+func sprintFile(f *ast.File) string {
+	buf := &bytes.Buffer{}
+	NewPrinter(buf).PrintFile(f)
+	return buf.String()
+}
+
+func firstDifferentLine(a, b string) int {
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := 0; i < len(al) && i < len(bl); i++ {
+		if al[i] != bl[i] {
+			return i + 1
+		}
+	}
+	if len(al) < len(bl) {
+		return len(al) + 1
+	}
+	return len(bl) + 1
+}
@@ -0,0 +1,131 @@
+package printer
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+var corpus = flag.String("corpus", "", "comma-separated list of additional directories of PHP files to round trip")
+
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/*.php")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		checkRoundTrip(t, file)
+	}
+
+	if *corpus == "" {
+		return
+	}
+	for _, dir := range strings.Split(*corpus, ",") {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && strings.HasSuffix(path, ".php") {
+				checkRoundTrip(t, path)
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkRoundTrip(t *testing.T, path string) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := parser.NewParser().Parse(path, string(src)); err != nil {
+		t.Logf("skipping %s, which does not parse: %s", path, err)
+		return
+	}
+	if err := CheckRoundTrip(path, string(src)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRoundTripReportsDifference(t *testing.T) {
+	a, err := parser.NewParser().Parse("a.php", `<?php if ($a) { echo $b + 1; }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := parser.NewParser().Parse("b.php", `<?php if ($a) { echo $b - 1; }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := DiffFiles(a, b)
+	if d == nil {
+		t.Fatal("expected a difference")
+	}
+	if expected := "Nodes[0].Branches[0].Block.Statements[0].Expressions[0].Operator"; d.Path != expected {
+		t.Errorf("difference found at %s, expected %s", d.Path, expected)
+	}
+	if DiffFiles(a, a) != nil {
+		t.Error("found a difference between a file and itself")
+	}
+}
+
+func TestRoundTripModifiers(t *testing.T) {
+	for _, test := range []struct{ src, without string }{
+		{`<?php class A { public static function make() {} }`, `<?php class A { public function make() {} }`},
+		{`<?php class A { public static $count; }`, `<?php class A { public $count; }`},
+		{`<?php class A { final public function make() {} }`, `<?php class A { public function make() {} }`},
+		{`<?php abstract class A { abstract public function make(); }`, `<?php abstract class A { public function make() {} }`},
+		{`<?php abstract class A {}`, `<?php class A {}`},
+		{`<?php final class A {}`, `<?php class A {}`},
+		{`<?php interface I { public static function make(); }`, `<?php interface I { public function make(); }`},
+		{`<?php class C { use T, U { T::hello insteadof U; U::hello as helloU; } }`, `<?php class C { use T, U; }`},
+		{`<?php class C { use T { hello as protected; } }`, `<?php class C { use T { hello as public; } }`},
+	} {
+		if err := CheckRoundTrip("test.php", test.src); err != nil {
+			t.Error(err)
+		}
+		a, err := parser.NewParser().Parse("a.php", test.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := parser.NewParser().Parse("b.php", test.without)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if DiffFiles(a, b) == nil {
+			t.Errorf("found no difference between %s and %s", test.src, test.without)
+		}
+	}
+}
+
+func TestRoundTripPrefixOperators(t *testing.T) {
+	for _, src := range []string{
+		`<?php $a = - -$x;`,
+		`<?php $a = -(-$x);`,
+		`<?php $a = + +$x;`,
+		`<?php $a = -(--$x);`,
+		`<?php $a = +(++$x);`,
+		`<?php $a = $x + +$x;`,
+		`<?php $a = $x - -$x;`,
+	} {
+		if err := CheckRoundTrip("test.php", src); err != nil {
+			t.Error(err)
+		}
+		buf := &bytes.Buffer{}
+		f, err := parser.NewParser().Parse("test.php", src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		NewMinifier(buf, false).PrintFile(f)
+		if err := CheckRoundTrip("test.php", buf.String()); err != nil {
+			t.Errorf("minified %s: %s", src, err)
+		}
+	}
+}
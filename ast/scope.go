@@ -5,6 +5,20 @@ type SuperGlobalScope struct {
 	Identifiers map[string]*Variable
 }
 
+// SuperGlobals contains the names of the variables that are available in
+// every scope without being declared global.
+var SuperGlobals = map[string]bool{
+	"GLOBALS":  true,
+	"_SERVER":  true,
+	"_GET":     true,
+	"_POST":    true,
+	"_FILES":   true,
+	"_COOKIE":  true,
+	"_SESSION": true,
+	"_REQUEST": true,
+	"_ENV":     true,
+}
+
 // NewSuperGlobalScope returns a new SuperGlobalScope
 func NewSuperGlobalScope() *SuperGlobalScope {
 	return &SuperGlobalScope{map[string]*Variable{}}
@@ -61,6 +75,35 @@ type File struct {
 	Name      string
 	Namespace *Namespace
 	Nodes     []Node
+	Positions Positions
+	// Names records the spans of the names of declarations, such as
+	// classes, functions and properties, whose names are not nodes. The
+	// name of a function or method is recorded for its FunctionDefinition.
+	Names Positions
+	// ClassNames records the class names written in declarations, in the
+	// order they appear.
+	ClassNames []ClassName
+	// Scope is the scope of the code outside of functions, which is shared
+	// by the files of a FileSet.
+	Scope *Scope
+	// Docs records the doc comments, which begin with /**, by the offset of
+	// the beginning of the declaration or statement that follows each. The
+	// beginning of a property includes its modifiers, so the doc comment of
+	// a property is also recorded by the beginning of its span.
+	Docs map[int]string
+	// Comments holds the comments of the file, doc comments included, in
+	// the order they appear.
+	Comments []Comment
+}
+
+// Doc returns the doc comment preceding the declaration or statement n, or
+// "" if there is none.
+func (f *File) Doc(n Node) string {
+	span, ok := f.Positions.Span(n)
+	if !ok {
+		return ""
+	}
+	return f.Docs[span.Begin.Position]
 }
 
 // FileSet is a file set
@@ -1,5 +1,10 @@
 package ast
 
+import (
+	"sort"
+	"strings"
+)
+
 type keyType BasicType
 
 const (
@@ -31,7 +36,7 @@ const (
 )
 
 // Numeric represents either a float or an integer
-var Numeric = compoundType{Integer: struct{}{}, Float: struct{}{}}
+var Numeric = compoundType{Integer.String(): Integer, Float.String(): Float}
 
 var typeMap = map[BasicType]string{
 	String:   "string",
@@ -77,25 +82,26 @@ func (t BasicType) Single() bool {
 }
 
 func (t BasicType) Union(o Type) Type {
-	return compoundType{t: struct{}{}, o: struct{}{}}
+	return Union(t, o)
 }
 
-type compoundType map[Type]struct{}
+// compoundType is a union of types, which holds each by its String.
+type compoundType map[string]Type
 
 func (c compoundType) Equals(t Type) bool {
 	if ct, ok := t.(compoundType); ok {
 		if len(ct) != len(c) {
 			return false
 		}
-		for it := range c {
-			if _, ok := ct[it]; !ok {
+		for name := range c {
+			if _, ok := ct[name]; !ok {
 				return false
 			}
 		}
 		return true
 	}
 	if len(c) == 1 {
-		for it := range c {
+		for _, it := range c {
 			return it.Equals(t)
 		}
 	}
@@ -107,15 +113,15 @@ func (c compoundType) Contains(t Type) bool {
 		if len(ct) > len(c) {
 			return false
 		}
-		for it := range ct {
-			if _, ok := c[it]; !ok {
+		for name := range ct {
+			if _, ok := c[name]; !ok {
 				return false
 			}
 		}
 		return true
 	}
 
-	for it := range c {
+	for _, it := range c {
 		if it.Contains(t) {
 			return true
 		}
@@ -126,8 +132,7 @@ func (c compoundType) Contains(t Type) bool {
 
 // Union returns a new type that includes both the receiver and the argument.
 func (c compoundType) Union(t Type) Type {
-	c[t] = struct{}{}
-	return c
+	return Union(c, t)
 }
 
 // Single returns true if the receiver expresses one type and only one type.
@@ -135,15 +140,21 @@ func (c compoundType) Single() bool {
 	if len(c) != 1 {
 		return false
 	}
-	for t := range c {
+	for _, t := range c {
 		return t.Single()
 	}
 	return false
 }
 
-// String returns the receiver expressed as a string.
+// String returns the receiver expressed as a string, listing its types
+// separated by |.
 func (c compoundType) String() string {
-	return ""
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
 }
 
 // Basic returns the basic type a type expresses.
@@ -151,6 +162,51 @@ func (c compoundType) Basic() []BasicType {
 	return nil
 }
 
+// Union returns the type that includes each of types. Unions among types are
+// flattened, and unknown types are ignored, so the union of no known types
+// is Unknown and that of a single type is the type itself.
+func Union(types ...Type) Type {
+	c := compoundType{}
+	for _, t := range types {
+		for _, member := range Members(t) {
+			if member != Unknown {
+				c[member.String()] = member
+			}
+		}
+	}
+	switch len(c) {
+	case 0:
+		return Unknown
+	case 1:
+		for _, t := range c {
+			return t
+		}
+	}
+	return c
+}
+
+// Members returns the types that t is a union of, or t itself if it is not
+// a union.
+func Members(t Type) []Type {
+	c, ok := t.(compoundType)
+	if !ok {
+		if t == nil {
+			return nil
+		}
+		return []Type{t}
+	}
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	members := make([]Type, len(names))
+	for i, name := range names {
+		members[i] = c[name]
+	}
+	return members
+}
+
 // Type is a type
 type Type interface {
 	// Equals returns true if the receiver is of the same type as the argument.
@@ -218,10 +274,7 @@ func (o ObjectType) Contains(t Type) bool {
 }
 
 func (o ObjectType) Union(t Type) Type {
-	if o.Equals(t) {
-		return o
-	}
-	return compoundType{o: struct{}{}, t: struct{}{}}
+	return Union(o, t)
 }
 
 func (ObjectType) Single() bool {
@@ -235,3 +288,93 @@ func (o ObjectType) String() string {
 func (ObjectType) Basic() []BasicType {
 	return []BasicType{Object}
 }
+
+// ArrayOf is the type of an array whose keys and values are of the types
+// Key and Value, either of which may be Unknown. A list is an array whose
+// keys are the integers counting from 0.
+type ArrayOf struct {
+	Key, Value Type
+	List       bool
+}
+
+func (a ArrayOf) Equals(t Type) bool {
+	o, ok := t.(ArrayOf)
+	return ok && o.List == a.List && sameType(o.Key, a.Key) && sameType(o.Value, a.Value)
+}
+
+func (a ArrayOf) Contains(t Type) bool {
+	return a.Equals(t)
+}
+
+func (a ArrayOf) Union(t Type) Type {
+	return Union(a, t)
+}
+
+func (ArrayOf) Single() bool {
+	return true
+}
+
+func (a ArrayOf) String() string {
+	value := Type(Unknown)
+	if a.Value != nil {
+		value = a.Value
+	}
+	switch {
+	case a.List:
+		return "list<" + value.String() + ">"
+	case a.Key == nil || a.Key == Unknown:
+		return "array<" + value.String() + ">"
+	}
+	return "array<" + a.Key.String() + ", " + value.String() + ">"
+}
+
+func (ArrayOf) Basic() []BasicType {
+	return []BasicType{Array}
+}
+
+// TypeParameter is a type that is a parameter of a generic function or
+// class, which may be bound to be a subtype of Bound.
+type TypeParameter struct {
+	Name  string
+	Bound Type
+}
+
+func (p TypeParameter) Equals(t Type) bool {
+	o, ok := t.(TypeParameter)
+	return ok && o.Name == p.Name
+}
+
+func (p TypeParameter) Contains(t Type) bool {
+	return p.Equals(t)
+}
+
+func (p TypeParameter) Union(t Type) Type {
+	return Union(p, t)
+}
+
+func (TypeParameter) Single() bool {
+	return true
+}
+
+func (p TypeParameter) String() string {
+	return p.Name
+}
+
+func (p TypeParameter) Basic() []BasicType {
+	if p.Bound == nil {
+		return nil
+	}
+	return p.Bound.Basic()
+}
+
+// sameType reports whether a and b are the same type, treating nil as
+// Unknown.
+func sameType(a, b Type) bool {
+	if a == nil {
+		a = Unknown
+	}
+	if b == nil {
+		b = Unknown
+	}
+	return a.Equals(b)
+}
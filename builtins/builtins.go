@@ -0,0 +1,447 @@
+// Package builtins describes the functions, classes and constants built into
+// PHP and its common extensions.
+//
+// They are declared by stubs, PHP files embedded in the package that
+// declare each function and class with an empty body, and each constant
+// with define. Their doc comments give the types that the declarations
+// cannot, which parameters are passed by reference or are variadic, and
+// the versions of PHP that added, deprecated and removed each with the
+// @since, @deprecated and @removed tags.
+package builtins
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/parser"
+	"github.com/stephens2424/php/phpdoc"
+)
+
+//go:embed stubs/*.php
+var stubs embed.FS
+
+// Availability describes the versions of PHP that have a built-in.
+type Availability struct {
+	// Since is the version adding it, and Removed the version removing it,
+	// either of which is "" if it is not known.
+	Since, Removed string
+
+	// Deprecated reports whether it is deprecated, which it has been since
+	// the version DeprecatedSince, if that is known.
+	Deprecated      bool
+	DeprecatedSince string
+}
+
+// Available reports whether version, such as "7.4", has the built-in.
+func (a Availability) Available(version string) bool {
+	return (a.Since == "" || CompareVersions(version, a.Since) >= 0) &&
+		(a.Removed == "" || CompareVersions(version, a.Removed) < 0)
+}
+
+// DeprecatedIn reports whether the built-in is deprecated in version.
+func (a Availability) DeprecatedIn(version string) bool {
+	return a.Deprecated && (a.DeprecatedSince == "" || CompareVersions(version, a.DeprecatedSince) >= 0)
+}
+
+// CompareVersions compares the versions a and b, such as "5.6" and
+// "7.0.1", returning -1, 0 or 1 as a is before, the same as or after b. A
+// version missing a part is the same as one with a 0 there.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// Function is a built-in function or method.
+type Function struct {
+	Name   string
+	Params []Param
+	Return ast.Type
+	Availability
+
+	Def *ast.FunctionDefinition
+	Doc *phpdoc.Doc
+}
+
+// Param is a parameter of a built-in function or method.
+type Param struct {
+	Name     string // without the $
+	Type     ast.Type
+	ByRef    bool
+	Variadic bool
+	Optional bool
+}
+
+// Class is a built-in class or interface.
+type Class struct {
+	Name       string
+	Interface  bool
+	Parent     string
+	Interfaces []string
+
+	// Methods holds the methods of the class by their lower case names, and
+	// Constants and Properties the types of its constants and properties
+	// by their names, without the $.
+	Methods    map[string]*Function
+	Constants  map[string]ast.Type
+	Properties map[string]ast.Type
+	Availability
+
+	Node ast.Statement
+	Doc  *phpdoc.Doc
+}
+
+// Constant is a built-in constant.
+type Constant struct {
+	Name string
+	Type ast.Type
+	Availability
+}
+
+// DB is the database of built-ins.
+type DB struct {
+	// Files holds the parsed stubs, with a file for each extension.
+	Files *ast.FileSet
+
+	functions map[string]*Function
+	classes   map[string]*Class
+	constants map[string]*Constant
+}
+
+var (
+	loadOnce sync.Once
+	db       *DB
+)
+
+// Load returns the database of built-ins, which is parsed from the stubs
+// the first time it is called.
+func Load() *DB {
+	loadOnce.Do(func() {
+		db = load()
+	})
+	return db
+}
+
+func load() *DB {
+	p := parser.NewParser()
+	db := &DB{
+		Files:     p.FileSet,
+		functions: map[string]*Function{},
+		classes:   map[string]*Class{},
+		constants: map[string]*Constant{},
+	}
+	names, err := stubs.ReadDir("stubs")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range names {
+		src, err := stubs.ReadFile(path.Join("stubs", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		f, err := p.Parse(entry.Name(), string(src))
+		if err != nil {
+			panic("builtins: " + err.Error())
+		}
+		db.declare(f)
+	}
+	return db
+}
+
+// declare adds the declarations of the stub f to db.
+func (db *DB) declare(f *ast.File) {
+	for _, n := range f.Nodes {
+		switch n := n.(type) {
+		case *ast.FunctionStmt:
+			fn := function(n.FunctionDefinition, f.Doc(n), nil)
+			db.functions[strings.ToLower(fn.Name)] = fn
+		case *ast.Class:
+			c := &Class{
+				Name:       n.Name,
+				Parent:     n.Extends,
+				Interfaces: n.Implements,
+				Node:       n,
+			}
+			db.class(c, f, n)
+			for _, m := range n.Methods {
+				c.Methods[strings.ToLower(m.Name)] = function(m.FunctionDefinition, f.Doc(m), c)
+			}
+			for _, k := range n.Constants {
+				c.Constants[k.Name] = valueType(k.Value)
+			}
+			for _, p := range n.Properties {
+				name := strings.TrimPrefix(p.Name, "$")
+				c.Properties[name] = ast.Unknown
+				if v := phpdoc.Parse(f.Doc(p), c.resolve).Var(name); v != nil {
+					c.Properties[name] = v.Type
+				}
+			}
+		case *ast.Interface:
+			c := &Class{
+				Name:       n.Name,
+				Interface:  true,
+				Interfaces: n.Inherits,
+				Node:       n,
+			}
+			db.class(c, f, n)
+			for _, m := range n.Methods {
+				c.Methods[strings.ToLower(m.Name)] = function(m.FunctionDefinition, f.Doc(m.FunctionStmt), c)
+			}
+			for _, k := range n.Constants {
+				c.Constants[k.Name] = valueType(k.Value)
+			}
+		case *ast.ExprStmt:
+			call, ok := n.Expr.(*ast.FunctionCallExpr)
+			if !ok || len(call.Arguments) != 2 {
+				continue
+			}
+			if id := ast.Static(call.FunctionName); id == nil || id.Value != "define" {
+				continue
+			}
+			name, ok := call.Arguments[0].(*ast.Literal)
+			if !ok {
+				continue
+			}
+			k := &Constant{Name: strings.Trim(name.Value, `'"`), Type: valueType(call.Arguments[1])}
+			d := phpdoc.Parse(f.Doc(n), nil)
+			if v := d.Var(""); v != nil {
+				k.Type = v.Type
+			}
+			k.Availability = availability(d)
+			db.constants[k.Name] = k
+		}
+	}
+}
+
+// class initializes and adds c, declared by n in the stub f.
+func (db *DB) class(c *Class, f *ast.File, n ast.Node) {
+	c.Methods = map[string]*Function{}
+	c.Constants = map[string]ast.Type{}
+	c.Properties = map[string]ast.Type{}
+	c.Doc = phpdoc.Parse(f.Doc(n), c.resolve)
+	c.Availability = availability(c.Doc)
+	for _, p := range c.Doc.Properties {
+		c.Properties[p.Name] = p.Type
+	}
+	db.classes[strings.ToLower(c.Name)] = c
+}
+
+// resolve resolves the names of classes in the doc comments of c.
+func (c *Class) resolve(name string) ast.Type {
+	switch strings.ToLower(name) {
+	case "self", "static", "$this":
+		return ast.ObjectType{Class: c.Name}
+	case "parent":
+		if c.Parent != "" {
+			return ast.ObjectType{Class: c.Parent}
+		}
+		return ast.Object
+	}
+	return ast.ObjectType{Class: strings.TrimPrefix(name, `\`)}
+}
+
+// function returns the function or method def, whose doc comment is doc,
+// of the class c, which is nil for a function.
+func function(def *ast.FunctionDefinition, doc string, c *Class) *Function {
+	var resolve phpdoc.Resolver
+	if c != nil {
+		resolve = c.resolve
+	}
+	fn := &Function{
+		Name:   def.Name,
+		Return: ast.Unknown,
+		Def:    def,
+		Doc:    phpdoc.Parse(doc, resolve),
+	}
+	fn.Availability = availability(fn.Doc)
+	if t, err := phpdoc.ParseType(def.Type, resolve); def.Type != "" && err == nil {
+		fn.Return = t
+	} else if fn.Doc.Return != nil {
+		fn.Return = fn.Doc.Return
+	}
+	for _, arg := range def.Arguments {
+		p := Param{Name: strings.TrimPrefix(arg.Variable.String(), "$"), Type: ast.Unknown, Optional: arg.Default != nil}
+		if t, err := phpdoc.ParseType(arg.TypeHint, resolve); arg.TypeHint != "" && err == nil {
+			p.Type = t
+			if valueType(arg.Default) == ast.Null {
+				p.Type = ast.Union(t, ast.Null)
+			}
+		}
+		if d := fn.Doc.Param(p.Name); d != nil {
+			if arg.TypeHint == "" {
+				p.Type = d.Type
+			}
+			p.ByRef = d.ByRef
+			p.Variadic = d.Variadic
+			p.Optional = p.Optional || d.Variadic
+		}
+		fn.Params = append(fn.Params, p)
+	}
+	return fn
+}
+
+// availability returns the availability given by the tags of d.
+func availability(d *phpdoc.Doc) Availability {
+	a := Availability{Since: d.Since, Removed: d.Removed, Deprecated: d.Deprecated}
+	if version, _ := cut(d.Deprecation); version != "" && version[0] >= '0' && version[0] <= '9' {
+		a.DeprecatedSince = version
+	}
+	return a
+}
+
+func cut(s string) (word, rest string) {
+	if i := strings.IndexAny(s, " \t\n"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// valueType returns the type of the literal value n, or ast.Unknown if n is
+// not a literal.
+func valueType(n interface{}) ast.Type {
+	switch n := n.(type) {
+	case *ast.Literal:
+		switch n.Type {
+		case ast.Float:
+			if strings.ContainsAny(n.Value, ".eE") && !strings.HasPrefix(n.Value, "0x") {
+				return ast.Float
+			}
+			return ast.Integer
+		case nil:
+			return ast.Unknown
+		}
+		return n.Type
+	case ast.ConstantExpr:
+		if id := ast.Static(n.Name); id != nil {
+			switch strings.ToLower(id.Value) {
+			case "true", "false":
+				return ast.Boolean
+			case "null":
+				return ast.Null
+			}
+		}
+	case ast.UnaryCallExpr:
+		if n.Operator == "-" {
+			return valueType(n.Operand)
+		}
+	}
+	return ast.Unknown
+}
+
+// Function returns the built-in function name, or nil if there is none.
+func (db *DB) Function(name string) *Function {
+	return db.functions[strings.ToLower(strings.TrimPrefix(name, `\`))]
+}
+
+// Class returns the built-in class or interface name, or nil if there is
+// none.
+func (db *DB) Class(name string) *Class {
+	return db.classes[strings.ToLower(strings.TrimPrefix(name, `\`))]
+}
+
+// Constant returns the built-in constant name, or nil if there is none.
+func (db *DB) Constant(name string) *Constant {
+	return db.constants[strings.TrimPrefix(name, `\`)]
+}
+
+// Functions returns the names of the built-in functions, sorted.
+func (db *DB) Functions() []string {
+	names := make([]string, 0, len(db.functions))
+	for _, fn := range db.functions {
+		names = append(names, fn.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Supertypes returns the built-in classes and interfaces that the built-in
+// class directly extends or implements.
+func (db *DB) Supertypes(class string) []string {
+	c := db.Class(class)
+	if c == nil {
+		return nil
+	}
+	var supertypes []string
+	if c.Parent != "" {
+		supertypes = append(supertypes, c.Parent)
+	}
+	return append(supertypes, c.Interfaces...)
+}
+
+// Method returns the method name of the built-in class, or of the classes
+// and interfaces it extends or implements, or nil if there is none.
+func (db *DB) Method(class, name string) *Function {
+	var m *Function
+	db.find(class, func(c *Class) bool {
+		m = c.Methods[strings.ToLower(name)]
+		return m != nil
+	})
+	return m
+}
+
+// ClassConstant returns the type of the constant name of the built-in
+// class, or of the classes and interfaces it extends or implements, and
+// whether there is one.
+func (db *DB) ClassConstant(class, name string) (ast.Type, bool) {
+	var t ast.Type
+	found := db.find(class, func(c *Class) bool {
+		t = c.Constants[name]
+		return t != nil
+	})
+	return t, found
+}
+
+// Property returns the type of the property name, without the $, of the
+// built-in class or of the classes it extends, and whether there is one.
+func (db *DB) Property(class, name string) (ast.Type, bool) {
+	var t ast.Type
+	found := db.find(class, func(c *Class) bool {
+		t = c.Properties[name]
+		return t != nil
+	})
+	return t, found
+}
+
+// find calls f with the built-in class and the classes and interfaces it
+// extends or implements, each once, until f returns true, and reports
+// whether it did.
+func (db *DB) find(class string, f func(*Class) bool) bool {
+	seen := map[string]bool{}
+	var walk func(class string) bool
+	walk = func(class string) bool {
+		c := db.Class(class)
+		if c == nil || seen[strings.ToLower(c.Name)] {
+			return false
+		}
+		seen[strings.ToLower(c.Name)] = true
+		if f(c) {
+			return true
+		}
+		for _, s := range db.Supertypes(class) {
+			if walk(s) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(class)
+}
@@ -0,0 +1,201 @@
+package builtins
+
+import (
+	"testing"
+
+	"github.com/stephens2424/php/ast"
+)
+
+func TestLoad(t *testing.T) {
+	db := Load()
+	if len(db.Files.Files) == 0 {
+		t.Fatal("no stubs were parsed")
+	}
+	if Load() != db {
+		t.Error("Load parsed the stubs again")
+	}
+	for _, name := range []string{"strlen", "preg_match", "json_encode", "mb_strlen", "ctype_digit", "date", "spl_object_hash", "mysqli_query", "mysql_query", "curl_init", "session_start", "filter_var"} {
+		if db.Function(name) == nil {
+			t.Errorf("missing function %s", name)
+		}
+	}
+	for _, name := range []string{"Exception", "Traversable", "ArrayAccess", "DateTime", "JsonSerializable", "ArrayObject", "PDO", "mysqli", "mysqli_result", "mysqli_stmt", "CurlHandle"} {
+		if db.Class(name) == nil {
+			t.Errorf("missing class %s", name)
+		}
+	}
+}
+
+func TestFunction(t *testing.T) {
+	db := Load()
+	fn := db.Function(`\StrLen`)
+	if fn == nil {
+		t.Fatal("no strlen")
+	}
+	if fn.Name != "strlen" || fn.Return != ast.Integer {
+		t.Errorf("strlen: %s returns %v", fn.Name, fn.Return)
+	}
+	if len(fn.Params) != 1 || fn.Params[0].Name != "string" || fn.Params[0].Type != ast.String {
+		t.Errorf("strlen params: %+v", fn.Params)
+	}
+
+	fn = db.Function("preg_match")
+	if len(fn.Params) < 3 {
+		t.Fatalf("preg_match params: %+v", fn.Params)
+	}
+	if p := fn.Params[2]; p.Name != "matches" || !p.ByRef || !p.Optional {
+		t.Errorf("preg_match matches: %+v", p)
+	}
+	if fn.Params[0].ByRef || fn.Params[0].Optional {
+		t.Errorf("preg_match pattern: %+v", fn.Params[0])
+	}
+
+	fn = db.Function("sprintf")
+	if p := fn.Params[len(fn.Params)-1]; !p.Variadic || !p.Optional {
+		t.Errorf("sprintf values: %+v", p)
+	}
+
+	fn = db.Function("strpos")
+	if got := fn.Return.String(); got != "boolean|integer" {
+		t.Errorf("strpos returns %s", got)
+	}
+
+	fn = db.Function("mysqli_stmt_bind_param")
+	if p := fn.Params[len(fn.Params)-1]; !p.ByRef || !p.Variadic {
+		t.Errorf("mysqli_stmt_bind_param vars: %+v", p)
+	}
+}
+
+func TestClass(t *testing.T) {
+	db := Load()
+	m := db.Method("InvalidArgumentException", "getMessage")
+	if m == nil {
+		t.Fatal("no InvalidArgumentException::getMessage")
+	}
+	if m.Return != ast.String {
+		t.Errorf("getMessage returns %v", m.Return)
+	}
+
+	m = db.Method("DateTime", "modify")
+	if m == nil {
+		t.Fatal("no DateTime::modify")
+	}
+	if got := m.Return.String(); got != "DateTime|boolean" {
+		t.Errorf("DateTime::modify returns %s", got)
+	}
+
+	m = db.Method("DateTime", "getTimestamp")
+	if m == nil || m.Return != ast.Integer {
+		t.Errorf("DateTime::getTimestamp = %+v", m)
+	}
+
+	m = db.Method("DateTimeInterface", "format")
+	if m == nil || m.Return != ast.String {
+		t.Errorf("DateTimeInterface::format = %+v", m)
+	}
+
+	if got := db.Supertypes("RuntimeException"); len(got) != 1 || got[0] != "Exception" {
+		t.Errorf("RuntimeException supertypes: %v", got)
+	}
+
+	if k, ok := db.ClassConstant("DateTime", "ATOM"); !ok || k != ast.String {
+		t.Errorf("DateTime::ATOM is %v", k)
+	}
+	if p, ok := db.Property("PDOException", "message"); !ok || p != ast.String {
+		t.Errorf("PDOException::$message is %v", p)
+	}
+
+	c := db.Class("DateInterval")
+	if c.Properties["days"].String() != "boolean|integer" {
+		t.Errorf("DateInterval::$days is %v", c.Properties["days"])
+	}
+	if c := db.Class("DateTimeZone"); c.Constants["UTC"] != ast.Integer {
+		t.Errorf("DateTimeZone::UTC is %v", c.Constants["UTC"])
+	}
+	if c := db.Class("DateTimeImmutable"); c.Since != "5.5" {
+		t.Errorf("DateTimeImmutable since %q", c.Since)
+	}
+	for _, class := range []string{"DateTime", "DateTimeImmutable"} {
+		if m := db.Method(class, "setISODate"); m == nil || len(m.Params) != 3 || !m.Params[2].Optional {
+			t.Errorf("%s::setISODate = %+v", class, m)
+		}
+	}
+	if m := db.Method("mysqli", "query"); m == nil || m.Return.String() != "boolean|mysqli_result" {
+		t.Errorf("mysqli::query = %+v", m)
+	}
+}
+
+func TestConstant(t *testing.T) {
+	db := Load()
+	tests := []struct {
+		name string
+		want ast.Type
+	}{
+		{"PHP_EOL", ast.String},
+		{"PHP_INT_MAX", ast.Integer},
+		{`\E_ALL`, ast.Integer},
+		{"JSON_PRETTY_PRINT", ast.Integer},
+		{"ENT_SUBSTITUTE", ast.Integer},
+		{"MYSQLI_ASSOC", ast.Integer},
+		{"CURLOPT_RETURNTRANSFER", ast.Integer},
+		{"PHP_SESSION_ACTIVE", ast.Integer},
+		{"FILTER_VALIDATE_EMAIL", ast.Integer},
+	}
+	for _, test := range tests {
+		k := db.Constant(test.name)
+		if k == nil {
+			t.Errorf("missing constant %s", test.name)
+			continue
+		}
+		if k.Type != test.want {
+			t.Errorf("%s is %v, want %v", test.name, k.Type, test.want)
+		}
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	db := Load()
+	each := db.Function("each")
+	if each == nil {
+		t.Fatal("no each")
+	}
+	tests := []struct {
+		version               string
+		available, deprecated bool
+	}{
+		{"5.6", true, false},
+		{"7.2", true, true},
+		{"7.4.3", true, true},
+		{"8", false, true},
+	}
+	for _, test := range tests {
+		if got := each.Available(test.version); got != test.available {
+			t.Errorf("each available in %s = %v", test.version, got)
+		}
+		if got := each.DeprecatedIn(test.version); got != test.deprecated {
+			t.Errorf("each deprecated in %s = %v", test.version, got)
+		}
+	}
+
+	if fn := db.Function("mysql_query"); fn.Available("7.0") || !fn.DeprecatedIn("5.5") {
+		t.Errorf("mysql_query availability: %+v", fn.Availability)
+	}
+
+	if !db.Function("strlen").Available("4.0") {
+		t.Error("strlen is unavailable")
+	}
+
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{"7.0", "7", 0},
+		{"7.0.1", "7", 1},
+		{"5.6", "7.0", -1},
+		{"7.10", "7.9", 1},
+	} {
+		if got := CompareVersions(test.a, test.b); got != test.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
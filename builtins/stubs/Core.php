@@ -0,0 +1,585 @@
+<?php
+// The classes, interfaces, functions and constants of the Zend engine.
+
+define('PHP_VERSION', '');
+define('PHP_MAJOR_VERSION', 0);
+define('PHP_MINOR_VERSION', 0);
+define('PHP_RELEASE_VERSION', 0);
+define('PHP_VERSION_ID', 0);
+define('PHP_OS', '');
+/** @since 7.2 */
+define('PHP_OS_FAMILY', '');
+define('PHP_EOL', "\n");
+define('PHP_INT_MAX', 9223372036854775807);
+/** @since 7.0 */
+define('PHP_INT_MIN', -9223372036854775808);
+define('PHP_INT_SIZE', 8);
+/** @since 7.2 */
+define('PHP_FLOAT_EPSILON', 2.220446049250313E-16);
+/** @since 7.2 */
+define('PHP_FLOAT_MAX', 1.7976931348623157E+308);
+/** @since 7.2 */
+define('PHP_FLOAT_MIN', 2.2250738585072014E-308);
+define('DIRECTORY_SEPARATOR', '/');
+define('PATH_SEPARATOR', ':');
+define('PHP_SAPI', '');
+define('PHP_BINARY', '');
+define('DEFAULT_INCLUDE_PATH', '');
+define('E_ERROR', 1);
+define('E_WARNING', 2);
+define('E_PARSE', 4);
+define('E_NOTICE', 8);
+define('E_CORE_ERROR', 16);
+define('E_CORE_WARNING', 32);
+define('E_COMPILE_ERROR', 64);
+define('E_COMPILE_WARNING', 128);
+define('E_USER_ERROR', 256);
+define('E_USER_WARNING', 512);
+define('E_USER_NOTICE', 1024);
+define('E_STRICT', 2048);
+define('E_RECOVERABLE_ERROR', 4096);
+define('E_DEPRECATED', 8192);
+define('E_USER_DEPRECATED', 16384);
+define('E_ALL', 32767);
+
+/**
+ * @param string $name
+ * @param mixed $value
+ * @return bool
+ */
+function define($name, $value, $case_insensitive = false) {}
+
+/**
+ * @param string $name
+ * @return bool
+ */
+function defined($name) {}
+
+/**
+ * @param string $name
+ * @return mixed
+ */
+function constant($name) {}
+
+/** @return int */
+function func_num_args() {}
+
+/**
+ * @param int $position
+ * @return mixed
+ */
+function func_get_arg($position) {}
+
+/** @return array */
+function func_get_args() {}
+
+/**
+ * @param string $string
+ * @return int
+ */
+function strlen($string) {}
+
+/**
+ * @param string $string1
+ * @param string $string2
+ * @return int
+ */
+function strcmp($string1, $string2) {}
+
+/**
+ * @param string $string1
+ * @param string $string2
+ * @param int $length
+ * @return int
+ */
+function strncmp($string1, $string2, $length) {}
+
+/**
+ * @param string $string1
+ * @param string $string2
+ * @return int
+ */
+function strcasecmp($string1, $string2) {}
+
+/**
+ * @param string $string1
+ * @param string $string2
+ * @param int $length
+ * @return int
+ */
+function strncasecmp($string1, $string2, $length) {}
+
+/**
+ * @param int $error_level
+ * @return int
+ */
+function error_reporting($error_level = null) {}
+
+/**
+ * @param object|string $object_or_class
+ * @param string $class
+ * @return bool
+ */
+function is_subclass_of($object_or_class, $class, $allow_string = true) {}
+
+/**
+ * @param object|string $object_or_class
+ * @param string $class
+ * @return bool
+ */
+function is_a($object_or_class, $class, $allow_string = false) {}
+
+/**
+ * @param object $object
+ * @return string
+ */
+function get_class($object = null) {}
+
+/** @return string|false */
+function get_called_class() {}
+
+/**
+ * @param object|string $object_or_class
+ * @return string|false
+ */
+function get_parent_class($object_or_class = null) {}
+
+/**
+ * @param object|string $object_or_class
+ * @param string $method
+ * @return bool
+ */
+function method_exists($object_or_class, $method) {}
+
+/**
+ * @param object|string $object_or_class
+ * @param string $property
+ * @return bool
+ */
+function property_exists($object_or_class, $property) {}
+
+/**
+ * @param string $class
+ * @return bool
+ */
+function class_exists($class, $autoload = true) {}
+
+/**
+ * @param string $interface
+ * @return bool
+ */
+function interface_exists($interface, $autoload = true) {}
+
+/**
+ * @param string $function
+ * @return bool
+ */
+function function_exists($function) {}
+
+/**
+ * @param object|string $object_or_class
+ * @return string[]
+ */
+function get_class_methods($object_or_class) {}
+
+/**
+ * @param object $object
+ * @return array<string, mixed>
+ */
+function get_object_vars($object) {}
+
+/**
+ * @param string $class
+ * @return array<string, mixed>
+ */
+function get_class_vars($class) {}
+
+/** @return string[] */
+function get_declared_classes() {}
+
+/**
+ * @param string $message
+ * @param int $error_level
+ * @return bool
+ */
+function trigger_error($message, $error_level = E_USER_NOTICE) {}
+
+/**
+ * @param string $message
+ * @param int $error_level
+ * @return bool
+ */
+function user_error($message, $error_level = E_USER_NOTICE) {}
+
+/**
+ * @param callable|null $callback
+ * @param int $error_levels
+ * @return callable|null
+ */
+function set_error_handler($callback, $error_levels = E_ALL) {}
+
+/** @return bool */
+function restore_error_handler() {}
+
+/**
+ * @param callable|null $callback
+ * @return callable|null
+ */
+function set_exception_handler($callback) {}
+
+/** @return bool */
+function restore_exception_handler() {}
+
+/** @return array|null */
+function error_get_last() {}
+
+/**
+ * @param callable $callback
+ * @param mixed ...$args
+ * @return mixed
+ */
+function call_user_func($callback, $args = null) {}
+
+/**
+ * @param callable $callback
+ * @param array $args
+ * @return mixed
+ */
+function call_user_func_array($callback, $args) {}
+
+/**
+ * @param callable $callback
+ * @param mixed ...$args
+ * @return void
+ */
+function register_shutdown_function($callback, $args = null) {}
+
+/**
+ * @param callable|null $callback
+ * @return bool
+ */
+function spl_autoload_register($callback = null, $throw = true, $prepend = false) {}
+
+/**
+ * @param int $options
+ * @param int $limit
+ * @return array
+ */
+function debug_backtrace($options = DEBUG_BACKTRACE_PROVIDE_OBJECT, $limit = 0) {}
+
+/**
+ * @param string $extension
+ * @return bool
+ */
+function extension_loaded($extension) {}
+
+/**
+ * @param string $code
+ * @return string
+ * @deprecated 7.2
+ * @removed 8.0
+ */
+function create_function($args, $code) {}
+
+/**
+ * @param array &$array
+ * @return array|false
+ * @deprecated 7.2
+ * @removed 8.0
+ */
+function each(&$array) {}
+
+define('DEBUG_BACKTRACE_PROVIDE_OBJECT', 1);
+define('DEBUG_BACKTRACE_IGNORE_ARGS', 2);
+
+class stdClass {}
+
+interface Traversable {}
+
+interface IteratorAggregate extends Traversable {
+	/** @return Traversable */
+	public function getIterator();
+}
+
+interface Iterator extends Traversable {
+	/** @return mixed */
+	public function current();
+
+	/** @return void */
+	public function next();
+
+	/** @return mixed */
+	public function key();
+
+	/** @return bool */
+	public function valid();
+
+	/** @return void */
+	public function rewind();
+}
+
+interface ArrayAccess {
+	/** @return bool */
+	public function offsetExists($offset);
+
+	/** @return mixed */
+	public function offsetGet($offset);
+
+	/** @return void */
+	public function offsetSet($offset, $value);
+
+	/** @return void */
+	public function offsetUnset($offset);
+}
+
+interface Countable {
+	/** @return int */
+	public function count();
+}
+
+interface Serializable {
+	/** @return string|null */
+	public function serialize();
+
+	/** @return void */
+	public function unserialize($data);
+}
+
+/** @since 7.0 */
+interface Throwable {
+	/** @return string */
+	public function getMessage();
+
+	/** @return int */
+	public function getCode();
+
+	/** @return string */
+	public function getFile();
+
+	/** @return int */
+	public function getLine();
+
+	/** @return array */
+	public function getTrace();
+
+	/** @return string */
+	public function getTraceAsString();
+
+	/** @return Throwable|null */
+	public function getPrevious();
+
+	/** @return string */
+	public function __toString();
+}
+
+class Exception implements Throwable {
+	/** @var string */
+	protected $message;
+
+	/** @var int */
+	protected $code;
+
+	/** @var string */
+	protected $file;
+
+	/** @var int */
+	protected $line;
+
+	/**
+	 * @param string $message
+	 * @param int $code
+	 * @param Throwable|null $previous
+	 */
+	public function __construct($message = "", $code = 0, $previous = null) {}
+
+	/** @return string */
+	final public function getMessage() {}
+
+	/** @return int */
+	final public function getCode() {}
+
+	/** @return string */
+	final public function getFile() {}
+
+	/** @return int */
+	final public function getLine() {}
+
+	/** @return array */
+	final public function getTrace() {}
+
+	/** @return string */
+	final public function getTraceAsString() {}
+
+	/** @return Throwable|null */
+	final public function getPrevious() {}
+
+	/** @return string */
+	public function __toString() {}
+}
+
+class ErrorException extends Exception {
+	/** @var int */
+	protected $severity;
+
+	/**
+	 * @param string $message
+	 * @param int $code
+	 * @param int $severity
+	 * @param string|null $filename
+	 * @param int|null $line
+	 * @param Throwable|null $previous
+	 */
+	public function __construct($message = "", $code = 0, $severity = E_ERROR, $filename = null, $line = null, $previous = null) {}
+
+	/** @return int */
+	final public function getSeverity() {}
+}
+
+/** @since 7.0 */
+class Error implements Throwable {
+	/** @var string */
+	protected $message;
+
+	/** @var int */
+	protected $code;
+
+	/** @var string */
+	protected $file;
+
+	/** @var int */
+	protected $line;
+
+	/**
+	 * @param string $message
+	 * @param int $code
+	 * @param Throwable|null $previous
+	 */
+	public function __construct($message = "", $code = 0, $previous = null) {}
+
+	/** @return string */
+	final public function getMessage() {}
+
+	/** @return int */
+	final public function getCode() {}
+
+	/** @return string */
+	final public function getFile() {}
+
+	/** @return int */
+	final public function getLine() {}
+
+	/** @return array */
+	final public function getTrace() {}
+
+	/** @return string */
+	final public function getTraceAsString() {}
+
+	/** @return Throwable|null */
+	final public function getPrevious() {}
+
+	/** @return string */
+	public function __toString() {}
+}
+
+/** @since 7.0 */
+class TypeError extends Error {}
+
+/** @since 7.0 */
+class ParseError extends Error {}
+
+/** @since 7.0 */
+class ArithmeticError extends Error {}
+
+/** @since 7.0 */
+class DivisionByZeroError extends ArithmeticError {}
+
+/** @since 7.3 */
+class CompileError extends Error {}
+
+/** @since 7.1 */
+class ArgumentCountError extends TypeError {}
+
+/** @since 8.0 */
+class ValueError extends Error {}
+
+/** @since 8.0 */
+class UnhandledMatchError extends Error {}
+
+final class Closure {
+	/**
+	 * @param Closure $closure
+	 * @param object|null $newThis
+	 * @param object|string|null $newScope
+	 * @return Closure|null
+	 */
+	public static function bind($closure, $newThis, $newScope = "static") {}
+
+	/**
+	 * @param object|null $newThis
+	 * @param object|string|null $newScope
+	 * @return Closure|null
+	 */
+	public function bindTo($newThis, $newScope = "static") {}
+
+	/**
+	 * @param object $newThis
+	 * @param mixed ...$args
+	 * @return mixed
+	 * @since 7.0
+	 */
+	public function call($newThis, $args = null) {}
+
+	/**
+	 * @param callable $callback
+	 * @return Closure
+	 * @since 7.1
+	 */
+	public static function fromCallable($callback) {}
+}
+
+final class Generator implements Iterator {
+	/** @return mixed */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return mixed */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+
+	/** @return mixed */
+	public function send($value) {}
+
+	/** @return mixed */
+	public function throw($exception) {}
+
+	/**
+	 * @return mixed
+	 * @since 7.0
+	 */
+	public function getReturn() {}
+}
+
+/** @since 8.0 */
+interface Stringable {
+	/** @return string */
+	public function __toString();
+}
+
+/** @since 7.4 */
+final class WeakReference {
+	/**
+	 * @param object $object
+	 * @return WeakReference
+	 */
+	public static function create($object) {}
+
+	/** @return object|null */
+	public function get() {}
+}
@@ -0,0 +1,50 @@
+<?php
+// The functions of the ctype extension.
+
+/**
+ * @param mixed $text
+ * @return bool
+ */
+function ctype_alnum($text) {}
+
+/**
+ * @param mixed $text
+ * @return bool
+ */
+function ctype_alpha($text) {}
+
+/**
+ * @param mixed $text
+ * @return bool
+ */
+function ctype_digit($text) {}
+
+/**
+ * @param mixed $text
+ * @return bool
+ */
+function ctype_lower($text) {}
+
+/**
+ * @param mixed $text
+ * @return bool
+ */
+function ctype_upper($text) {}
+
+/**
+ * @param mixed $text
+ * @return bool
+ */
+function ctype_space($text) {}
+
+/**
+ * @param mixed $text
+ * @return bool
+ */
+function ctype_punct($text) {}
+
+/**
+ * @param mixed $text
+ * @return bool
+ */
+function ctype_xdigit($text) {}
@@ -0,0 +1,90 @@
+<?php
+// The functions, classes and constants of the curl extension.
+
+define('CURLOPT_URL', 10002);
+define('CURLOPT_RETURNTRANSFER', 19913);
+define('CURLOPT_HEADER', 42);
+define('CURLOPT_HTTPHEADER', 10023);
+define('CURLOPT_POST', 47);
+define('CURLOPT_POSTFIELDS', 10015);
+define('CURLOPT_CUSTOMREQUEST', 10036);
+define('CURLOPT_FOLLOWLOCATION', 52);
+define('CURLOPT_MAXREDIRS', 68);
+define('CURLOPT_TIMEOUT', 13);
+define('CURLOPT_CONNECTTIMEOUT', 78);
+define('CURLOPT_USERAGENT', 10018);
+define('CURLOPT_USERPWD', 10005);
+define('CURLOPT_SSL_VERIFYPEER', 64);
+define('CURLOPT_SSL_VERIFYHOST', 81);
+define('CURLINFO_HTTP_CODE', 2097154);
+define('CURLINFO_CONTENT_TYPE', 1048594);
+define('CURLINFO_TOTAL_TIME', 3145731);
+define('CURLE_OK', 0);
+
+/** @since 8.0 */
+final class CurlHandle {}
+
+/**
+ * @param string|null $url
+ * @return CurlHandle|false
+ */
+function curl_init($url = null) {}
+
+/**
+ * @param CurlHandle $handle
+ * @param int $option
+ * @param mixed $value
+ * @return bool
+ */
+function curl_setopt($handle, $option, $value) {}
+
+/**
+ * @param CurlHandle $handle
+ * @param array $options
+ * @return bool
+ */
+function curl_setopt_array($handle, $options) {}
+
+/**
+ * @param CurlHandle $handle
+ * @return string|bool
+ */
+function curl_exec($handle) {}
+
+/**
+ * @param CurlHandle $handle
+ * @param int|null $option
+ * @return mixed
+ */
+function curl_getinfo($handle, $option = null) {}
+
+/**
+ * @param CurlHandle $handle
+ * @return string
+ */
+function curl_error($handle) {}
+
+/**
+ * @param CurlHandle $handle
+ * @return int
+ */
+function curl_errno($handle) {}
+
+/**
+ * @param CurlHandle $handle
+ * @return void
+ */
+function curl_close($handle) {}
+
+/**
+ * @param CurlHandle $handle
+ * @return void
+ */
+function curl_reset($handle) {}
+
+/**
+ * @param CurlHandle $handle
+ * @param string $string
+ * @return string|false
+ */
+function curl_escape($handle, $string) {}
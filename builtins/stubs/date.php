@@ -0,0 +1,404 @@
+<?php
+// The functions, classes and constants of the date extension.
+
+/**
+ * @param string $format
+ * @param int|null $timestamp
+ * @return string
+ */
+function date($format, $timestamp = null) {}
+
+/**
+ * @param string $format
+ * @param int|null $timestamp
+ * @return string
+ */
+function gmdate($format, $timestamp = null) {}
+
+/** @return int */
+function time() {}
+
+/**
+ * @param int $hour
+ * @param int|null $minute
+ * @param int|null $second
+ * @param int|null $month
+ * @param int|null $day
+ * @param int|null $year
+ * @return int|false
+ */
+function mktime($hour, $minute = null, $second = null, $month = null, $day = null, $year = null) {}
+
+/**
+ * @param string $datetime
+ * @param int|null $baseTimestamp
+ * @return int|false
+ */
+function strtotime($datetime, $baseTimestamp = null) {}
+
+/**
+ * @param int $month
+ * @param int $day
+ * @param int $year
+ * @return bool
+ */
+function checkdate($month, $day, $year) {}
+
+/**
+ * @param string $timezoneId
+ * @return bool
+ */
+function date_default_timezone_set($timezoneId) {}
+
+/** @return string */
+function date_default_timezone_get() {}
+
+/**
+ * @param string $format
+ * @param int|null $timestamp
+ * @return string|false
+ * @deprecated 8.1
+ */
+function strftime($format, $timestamp = null) {}
+
+/**
+ * @param string $datetime
+ * @param DateTimeZone|null $timezone
+ * @return DateTime|false
+ */
+function date_create($datetime = "now", $timezone = null) {}
+
+interface DateTimeInterface {
+	const ATOM = "Y-m-d\TH:i:sP";
+	const COOKIE = "l, d-M-Y H:i:s T";
+	const ISO8601 = "Y-m-d\TH:i:sO";
+	const RFC2822 = "D, d M Y H:i:s O";
+	const RFC3339 = "Y-m-d\TH:i:sP";
+	const RSS = "D, d M Y H:i:s O";
+	const W3C = "Y-m-d\TH:i:sP";
+
+	/** @return string */
+	public function format($format);
+
+	/** @return int */
+	public function getTimestamp();
+
+	/** @return DateTimeZone|false */
+	public function getTimezone();
+
+	/** @return int */
+	public function getOffset();
+
+	/** @return DateInterval */
+	public function diff($targetObject, $absolute = false);
+}
+
+class DateTime implements DateTimeInterface {
+	/**
+	 * @param string $datetime
+	 * @param DateTimeZone|null $timezone
+	 */
+	public function __construct($datetime = "now", $timezone = null) {}
+
+	/**
+	 * @param string $format
+	 * @param string $datetime
+	 * @param DateTimeZone|null $timezone
+	 * @return DateTime|false
+	 */
+	public static function createFromFormat($format, $datetime, $timezone = null) {}
+
+	/** @return array|false */
+	public static function getLastErrors() {}
+
+	/**
+	 * @param DateTimeImmutable $object
+	 * @return DateTime
+	 * @since 7.3
+	 */
+	public static function createFromImmutable($object) {}
+
+	/**
+	 * @param string $format
+	 * @return string
+	 */
+	public function format($format) {}
+
+	/**
+	 * @param string $modifier
+	 * @return static|false
+	 */
+	public function modify($modifier) {}
+
+	/**
+	 * @param DateInterval $interval
+	 * @return static
+	 */
+	public function add($interval) {}
+
+	/**
+	 * @param DateInterval $interval
+	 * @return static
+	 */
+	public function sub($interval) {}
+
+	/**
+	 * @param int $year
+	 * @param int $month
+	 * @param int $day
+	 * @return static
+	 */
+	public function setDate($year, $month, $day) {}
+
+	/**
+	 * @param int $year
+	 * @param int $week
+	 * @param int $dayOfWeek
+	 * @return static
+	 */
+	public function setISODate($year, $week, $dayOfWeek = 1) {}
+
+	/**
+	 * @param int $hour
+	 * @param int $minute
+	 * @param int $second
+	 * @param int $microsecond
+	 * @return static
+	 */
+	public function setTime($hour, $minute, $second = 0, $microsecond = 0) {}
+
+	/**
+	 * @param int $timestamp
+	 * @return static
+	 */
+	public function setTimestamp($timestamp) {}
+
+	/**
+	 * @param DateTimeZone $timezone
+	 * @return static
+	 */
+	public function setTimezone($timezone) {}
+
+	/** @return int */
+	public function getTimestamp() {}
+
+	/** @return DateTimeZone|false */
+	public function getTimezone() {}
+
+	/** @return int */
+	public function getOffset() {}
+
+	/**
+	 * @param DateTimeInterface $targetObject
+	 * @param bool $absolute
+	 * @return DateInterval
+	 */
+	public function diff($targetObject, $absolute = false) {}
+}
+
+/** @since 5.5 */
+class DateTimeImmutable implements DateTimeInterface {
+	/**
+	 * @param string $datetime
+	 * @param DateTimeZone|null $timezone
+	 */
+	public function __construct($datetime = "now", $timezone = null) {}
+
+	/**
+	 * @param string $format
+	 * @param string $datetime
+	 * @param DateTimeZone|null $timezone
+	 * @return DateTimeImmutable|false
+	 */
+	public static function createFromFormat($format, $datetime, $timezone = null) {}
+
+	/** @return array|false */
+	public static function getLastErrors() {}
+
+	/**
+	 * @param DateTime $object
+	 * @return DateTimeImmutable
+	 */
+	public static function createFromMutable($object) {}
+
+	/**
+	 * @param string $format
+	 * @return string
+	 */
+	public function format($format) {}
+
+	/**
+	 * @param string $modifier
+	 * @return static|false
+	 */
+	public function modify($modifier) {}
+
+	/**
+	 * @param DateInterval $interval
+	 * @return static
+	 */
+	public function add($interval) {}
+
+	/**
+	 * @param DateInterval $interval
+	 * @return static
+	 */
+	public function sub($interval) {}
+
+	/**
+	 * @param int $year
+	 * @param int $month
+	 * @param int $day
+	 * @return static
+	 */
+	public function setDate($year, $month, $day) {}
+
+	/**
+	 * @param int $year
+	 * @param int $week
+	 * @param int $dayOfWeek
+	 * @return static
+	 */
+	public function setISODate($year, $week, $dayOfWeek = 1) {}
+
+	/**
+	 * @param int $hour
+	 * @param int $minute
+	 * @param int $second
+	 * @param int $microsecond
+	 * @return static
+	 */
+	public function setTime($hour, $minute, $second = 0, $microsecond = 0) {}
+
+	/**
+	 * @param int $timestamp
+	 * @return static
+	 */
+	public function setTimestamp($timestamp) {}
+
+	/**
+	 * @param DateTimeZone $timezone
+	 * @return static
+	 */
+	public function setTimezone($timezone) {}
+
+	/** @return int */
+	public function getTimestamp() {}
+
+	/** @return DateTimeZone|false */
+	public function getTimezone() {}
+
+	/** @return int */
+	public function getOffset() {}
+
+	/**
+	 * @param DateTimeInterface $targetObject
+	 * @param bool $absolute
+	 * @return DateInterval
+	 */
+	public function diff($targetObject, $absolute = false) {}
+}
+
+class DateTimeZone {
+	const UTC = 1024;
+	const ALL = 2047;
+
+	/** @param string $timezone */
+	public function __construct($timezone) {}
+
+	/** @return string */
+	public function getName() {}
+
+	/**
+	 * @param DateTimeInterface $datetime
+	 * @return int
+	 */
+	public function getOffset($datetime) {}
+
+	/** @return string[] */
+	public static function listIdentifiers($timezoneGroup = 2047, $countryCode = null) {}
+
+	/** @return array */
+	public static function listAbbreviations() {}
+
+	/**
+	 * @param int $timestampBegin
+	 * @param int $timestampEnd
+	 * @return array|false
+	 */
+	public function getTransitions($timestampBegin = PHP_INT_MIN, $timestampEnd = PHP_INT_MAX) {}
+
+	/** @return array|false */
+	public function getLocation() {}
+}
+
+class DateInterval {
+	/** @var int */
+	public $y;
+
+	/** @var int */
+	public $m;
+
+	/** @var int */
+	public $d;
+
+	/** @var int */
+	public $h;
+
+	/** @var int */
+	public $i;
+
+	/** @var int */
+	public $s;
+
+	/** @var float */
+	public $f;
+
+	/** @var int */
+	public $invert;
+
+	/** @var int|false */
+	public $days;
+
+	/** @param string $duration */
+	public function __construct($duration) {}
+
+	/**
+	 * @param string $format
+	 * @return string
+	 */
+	public function format($format) {}
+
+	/**
+	 * @param string $datetime
+	 * @return DateInterval|false
+	 */
+	public static function createFromDateString($datetime) {}
+}
+
+class DatePeriod implements IteratorAggregate {
+	const EXCLUDE_START_DATE = 1;
+
+	/**
+	 * @param DateTimeInterface $start
+	 * @param DateInterval $interval
+	 * @param DateTimeInterface|int $end
+	 * @param int $options
+	 */
+	public function __construct($start, $interval, $end, $options = 0) {}
+
+	/** @return DateTimeInterface */
+	public function getStartDate() {}
+
+	/** @return DateTimeInterface|null */
+	public function getEndDate() {}
+
+	/** @return DateInterval */
+	public function getDateInterval() {}
+
+	/**
+	 * @return Iterator
+	 * @since 8.0
+	 */
+	public function getIterator() {}
+}
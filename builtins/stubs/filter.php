@@ -0,0 +1,82 @@
+<?php
+// The functions and constants of the filter extension.
+
+define('INPUT_POST', 0);
+define('INPUT_GET', 1);
+define('INPUT_COOKIE', 2);
+define('INPUT_ENV', 4);
+define('INPUT_SERVER', 5);
+define('FILTER_FLAG_NONE', 0);
+define('FILTER_REQUIRE_SCALAR', 33554432);
+define('FILTER_REQUIRE_ARRAY', 16777216);
+define('FILTER_FORCE_ARRAY', 67108864);
+define('FILTER_NULL_ON_FAILURE', 134217728);
+define('FILTER_VALIDATE_INT', 257);
+define('FILTER_VALIDATE_BOOLEAN', 258);
+/** @since 8.0 */
+define('FILTER_VALIDATE_BOOL', 258);
+define('FILTER_VALIDATE_FLOAT', 259);
+define('FILTER_VALIDATE_REGEXP', 272);
+define('FILTER_VALIDATE_URL', 273);
+define('FILTER_VALIDATE_EMAIL', 274);
+define('FILTER_VALIDATE_IP', 275);
+define('FILTER_DEFAULT', 516);
+define('FILTER_UNSAFE_RAW', 516);
+define('FILTER_SANITIZE_STRING', 513);
+define('FILTER_SANITIZE_ENCODED', 514);
+define('FILTER_SANITIZE_SPECIAL_CHARS', 515);
+define('FILTER_SANITIZE_FULL_SPECIAL_CHARS', 522);
+define('FILTER_SANITIZE_EMAIL', 517);
+define('FILTER_SANITIZE_URL', 518);
+define('FILTER_SANITIZE_NUMBER_INT', 519);
+define('FILTER_SANITIZE_NUMBER_FLOAT', 520);
+define('FILTER_CALLBACK', 1024);
+
+/**
+ * @param mixed $value
+ * @param int $filter
+ * @param array|int $options
+ * @return mixed
+ */
+function filter_var($value, $filter = FILTER_DEFAULT, $options = 0) {}
+
+/**
+ * @param int $type
+ * @param string $var_name
+ * @param int $filter
+ * @param array|int $options
+ * @return mixed
+ */
+function filter_input($type, $var_name, $filter = FILTER_DEFAULT, $options = 0) {}
+
+/**
+ * @param array $array
+ * @param array|int $options
+ * @param bool $add_empty
+ * @return array|false|null
+ */
+function filter_var_array($array, $options = FILTER_DEFAULT, $add_empty = true) {}
+
+/**
+ * @param int $type
+ * @param array|int $options
+ * @param bool $add_empty
+ * @return array|false|null
+ */
+function filter_input_array($type, $options = FILTER_DEFAULT, $add_empty = true) {}
+
+/**
+ * @param int $input_type
+ * @param string $var_name
+ * @return bool
+ */
+function filter_has_var($input_type, $var_name) {}
+
+/**
+ * @param string $name
+ * @return int|false
+ */
+function filter_id($name) {}
+
+/** @return array */
+function filter_list() {}
@@ -0,0 +1,52 @@
+<?php
+// The functions, classes and constants of the json extension.
+
+define('JSON_HEX_TAG', 1);
+define('JSON_HEX_AMP', 2);
+define('JSON_HEX_APOS', 4);
+define('JSON_HEX_QUOT', 8);
+define('JSON_FORCE_OBJECT', 16);
+define('JSON_NUMERIC_CHECK', 32);
+define('JSON_UNESCAPED_SLASHES', 64);
+define('JSON_PRETTY_PRINT', 128);
+define('JSON_UNESCAPED_UNICODE', 256);
+define('JSON_PARTIAL_OUTPUT_ON_ERROR', 512);
+define('JSON_PRESERVE_ZERO_FRACTION', 1024);
+define('JSON_OBJECT_AS_ARRAY', 1);
+define('JSON_BIGINT_AS_STRING', 2);
+define('JSON_INVALID_UTF8_IGNORE', 1048576);
+define('JSON_INVALID_UTF8_SUBSTITUTE', 2097152);
+/** @since 7.3 */
+define('JSON_THROW_ON_ERROR', 4194304);
+define('JSON_ERROR_NONE', 0);
+
+/**
+ * @param mixed $value
+ * @param int $flags
+ * @param int $depth
+ * @return string|false
+ */
+function json_encode($value, $flags = 0, $depth = 512) {}
+
+/**
+ * @param string $json
+ * @param bool|null $associative
+ * @param int $depth
+ * @param int $flags
+ * @return mixed
+ */
+function json_decode($json, $associative = null, $depth = 512, $flags = 0) {}
+
+/** @return int */
+function json_last_error() {}
+
+/** @return string */
+function json_last_error_msg() {}
+
+interface JsonSerializable {
+	/** @return mixed */
+	public function jsonSerialize();
+}
+
+/** @since 7.3 */
+class JsonException extends Exception {}
@@ -0,0 +1,134 @@
+<?php
+// The functions and constants of the mbstring extension.
+
+define('MB_CASE_UPPER', 0);
+define('MB_CASE_LOWER', 1);
+define('MB_CASE_TITLE', 2);
+
+/**
+ * @param string $string
+ * @param string|null $encoding
+ * @return int
+ */
+function mb_strlen($string, $encoding = null) {}
+
+/**
+ * @param string $string
+ * @param int $start
+ * @param int|null $length
+ * @param string|null $encoding
+ * @return string
+ */
+function mb_substr($string, $start, $length = null, $encoding = null) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param int $offset
+ * @param string|null $encoding
+ * @return int|false
+ */
+function mb_strpos($haystack, $needle, $offset = 0, $encoding = null) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param int $offset
+ * @param string|null $encoding
+ * @return int|false
+ */
+function mb_stripos($haystack, $needle, $offset = 0, $encoding = null) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param int $offset
+ * @param string|null $encoding
+ * @return int|false
+ */
+function mb_strrpos($haystack, $needle, $offset = 0, $encoding = null) {}
+
+/**
+ * @param string $string
+ * @param string|null $encoding
+ * @return string
+ */
+function mb_strtolower($string, $encoding = null) {}
+
+/**
+ * @param string $string
+ * @param string|null $encoding
+ * @return string
+ */
+function mb_strtoupper($string, $encoding = null) {}
+
+/**
+ * @param string $string
+ * @param int $mode
+ * @param string|null $encoding
+ * @return string
+ */
+function mb_convert_case($string, $mode, $encoding = null) {}
+
+/**
+ * @param array|string $string
+ * @param string $to_encoding
+ * @param array|string|null $from_encoding
+ * @return array|string|false
+ */
+function mb_convert_encoding($string, $to_encoding, $from_encoding = null) {}
+
+/**
+ * @param string $string
+ * @param array|string|null $encodings
+ * @param bool $strict
+ * @return string|false
+ */
+function mb_detect_encoding($string, $encodings = null, $strict = false) {}
+
+/**
+ * @param array|string|null $value
+ * @param string|null $encoding
+ * @return bool
+ */
+function mb_check_encoding($value = null, $encoding = null) {}
+
+/**
+ * @param string|null $encoding
+ * @return string|bool
+ */
+function mb_internal_encoding($encoding = null) {}
+
+/**
+ * @param string $string
+ * @param string|null $encoding
+ * @return int
+ */
+function mb_strwidth($string, $encoding = null) {}
+
+/**
+ * @param string $string
+ * @param int $start
+ * @param int $width
+ * @param string $trim_marker
+ * @param string|null $encoding
+ * @return string
+ */
+function mb_strimwidth($string, $start, $width, $trim_marker = "", $encoding = null) {}
+
+/**
+ * @param string $string
+ * @param int $length
+ * @param string|null $encoding
+ * @return string[]
+ * @since 7.4
+ */
+function mb_str_split($string, $length = 1, $encoding = null) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param string|null $encoding
+ * @return int
+ */
+function mb_substr_count($haystack, $needle, $encoding = null) {}
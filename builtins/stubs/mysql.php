@@ -0,0 +1,146 @@
+<?php
+// The functions and constants of the mysql extension, which was removed in
+// PHP 7.0 in favour of mysqli and pdo.
+
+define('MYSQL_ASSOC', 1);
+define('MYSQL_NUM', 2);
+define('MYSQL_BOTH', 3);
+
+/**
+ * @param string|null $server
+ * @param string|null $username
+ * @param string|null $password
+ * @param bool $new_link
+ * @param int $client_flags
+ * @return resource|false
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_connect($server = null, $username = null, $password = null, $new_link = false, $client_flags = 0) {}
+
+/**
+ * @param string|null $server
+ * @param string|null $username
+ * @param string|null $password
+ * @param int $client_flags
+ * @return resource|false
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_pconnect($server = null, $username = null, $password = null, $client_flags = 0) {}
+
+/**
+ * @param string $database_name
+ * @param resource|null $link_identifier
+ * @return bool
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_select_db($database_name, $link_identifier = null) {}
+
+/**
+ * @param string $query
+ * @param resource|null $link_identifier
+ * @return resource|bool
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_query($query, $link_identifier = null) {}
+
+/**
+ * @param string $unescaped_string
+ * @param resource|null $link_identifier
+ * @return string|false
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_real_escape_string($unescaped_string, $link_identifier = null) {}
+
+/**
+ * @param string $unescaped_string
+ * @return string
+ * @deprecated 5.3
+ * @removed 7.0
+ */
+function mysql_escape_string($unescaped_string) {}
+
+/**
+ * @param resource $result
+ * @return array|false
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_fetch_assoc($result) {}
+
+/**
+ * @param resource $result
+ * @return array|false
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_fetch_row($result) {}
+
+/**
+ * @param resource $result
+ * @param int $result_type
+ * @return array|false
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_fetch_array($result, $result_type = MYSQL_BOTH) {}
+
+/**
+ * @param resource $result
+ * @return int|false
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_num_rows($result) {}
+
+/**
+ * @param resource|null $link_identifier
+ * @return int
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_affected_rows($link_identifier = null) {}
+
+/**
+ * @param resource|null $link_identifier
+ * @return int|false
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_insert_id($link_identifier = null) {}
+
+/**
+ * @param resource|null $link_identifier
+ * @return string
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_error($link_identifier = null) {}
+
+/**
+ * @param resource|null $link_identifier
+ * @return int
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_errno($link_identifier = null) {}
+
+/**
+ * @param resource $result
+ * @return bool
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_free_result($result) {}
+
+/**
+ * @param resource|null $link_identifier
+ * @return bool
+ * @deprecated 5.5
+ * @removed 7.0
+ */
+function mysql_close($link_identifier = null) {}
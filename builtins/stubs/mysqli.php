@@ -0,0 +1,350 @@
+<?php
+// The functions, classes and constants of the mysqli extension.
+
+define('MYSQLI_STORE_RESULT', 0);
+define('MYSQLI_USE_RESULT', 1);
+define('MYSQLI_ASSOC', 1);
+define('MYSQLI_NUM', 2);
+define('MYSQLI_BOTH', 3);
+define('MYSQLI_REPORT_OFF', 0);
+define('MYSQLI_REPORT_ERROR', 1);
+define('MYSQLI_REPORT_STRICT', 2);
+define('MYSQLI_REPORT_ALL', 255);
+
+final class mysqli_sql_exception extends RuntimeException {
+	/** @var string */
+	protected $sqlstate;
+}
+
+class mysqli {
+	/** @var int|string */
+	public $affected_rows;
+	/** @var int */
+	public $connect_errno;
+	/** @var string|null */
+	public $connect_error;
+	/** @var int */
+	public $errno;
+	/** @var string */
+	public $error;
+	/** @var int|string */
+	public $insert_id;
+	/** @var int */
+	public $field_count;
+
+	/**
+	 * @param string|null $hostname
+	 * @param string|null $username
+	 * @param string|null $password
+	 * @param string|null $database
+	 * @param int|null $port
+	 * @param string|null $socket
+	 */
+	public function __construct($hostname = null, $username = null, $password = null, $database = null, $port = null, $socket = null) {}
+
+	/**
+	 * @param string $query
+	 * @param int $result_mode
+	 * @return mysqli_result|bool
+	 */
+	public function query($query, $result_mode = MYSQLI_STORE_RESULT) {}
+
+	/**
+	 * @param string $query
+	 * @return mysqli_stmt|false
+	 */
+	public function prepare($query) {}
+
+	/**
+	 * @param string $string
+	 * @return string
+	 */
+	public function real_escape_string($string) {}
+
+	/**
+	 * @param string $string
+	 * @return string
+	 */
+	public function escape_string($string) {}
+
+	/**
+	 * @param string $query
+	 * @return bool
+	 */
+	public function multi_query($query) {}
+
+	/**
+	 * @param string $database
+	 * @return bool
+	 */
+	public function select_db($database) {}
+
+	/**
+	 * @param string $charset
+	 * @return bool
+	 */
+	public function set_charset($charset) {}
+
+	/**
+	 * @param int $flags
+	 * @param string|null $name
+	 * @return bool
+	 */
+	public function begin_transaction($flags = 0, $name = null) {}
+
+	/**
+	 * @param int $flags
+	 * @param string|null $name
+	 * @return bool
+	 */
+	public function commit($flags = 0, $name = null) {}
+
+	/**
+	 * @param int $flags
+	 * @param string|null $name
+	 * @return bool
+	 */
+	public function rollback($flags = 0, $name = null) {}
+
+	/** @return bool */
+	public function close() {}
+}
+
+class mysqli_result implements IteratorAggregate {
+	/** @var int */
+	public $field_count;
+	/** @var int|string */
+	public $num_rows;
+
+	/** @return array|null|false */
+	public function fetch_assoc() {}
+
+	/** @return array|null|false */
+	public function fetch_row() {}
+
+	/**
+	 * @param int $mode
+	 * @return array|null|false
+	 */
+	public function fetch_array($mode = MYSQLI_BOTH) {}
+
+	/**
+	 * @param int $mode
+	 * @return array
+	 */
+	public function fetch_all($mode = MYSQLI_NUM) {}
+
+	/**
+	 * @param string $class
+	 * @param array $constructor_args
+	 * @return object|null|false
+	 */
+	public function fetch_object($class = 'stdClass', $constructor_args = array()) {}
+
+	/** @return void */
+	public function free() {}
+
+	/** @return Iterator */
+	public function getIterator() {}
+}
+
+class mysqli_stmt {
+	/** @var int|string */
+	public $affected_rows;
+	/** @var int|string */
+	public $insert_id;
+	/** @var int */
+	public $errno;
+	/** @var string */
+	public $error;
+
+	/**
+	 * @param string $types
+	 * @param mixed &...$vars
+	 * @return bool
+	 */
+	public function bind_param($types, &$vars = null) {}
+
+	/**
+	 * @param mixed &...$vars
+	 * @return bool
+	 */
+	public function bind_result(&$vars = null) {}
+
+	/**
+	 * @param array|null $params
+	 * @return bool
+	 */
+	public function execute($params = null) {}
+
+	/** @return bool|null */
+	public function fetch() {}
+
+	/** @return mysqli_result|false */
+	public function get_result() {}
+
+	/** @return bool */
+	public function close() {}
+}
+
+/**
+ * @param string|null $hostname
+ * @param string|null $username
+ * @param string|null $password
+ * @param string|null $database
+ * @param int|null $port
+ * @param string|null $socket
+ * @return mysqli|false
+ */
+function mysqli_connect($hostname = null, $username = null, $password = null, $database = null, $port = null, $socket = null) {}
+
+/** @return int */
+function mysqli_connect_errno() {}
+
+/** @return string|null */
+function mysqli_connect_error() {}
+
+/**
+ * @param mysqli $mysql
+ * @param string $query
+ * @param int $result_mode
+ * @return mysqli_result|bool
+ */
+function mysqli_query($mysql, $query, $result_mode = MYSQLI_STORE_RESULT) {}
+
+/**
+ * @param mysqli $mysql
+ * @param string $query
+ * @return bool
+ */
+function mysqli_multi_query($mysql, $query) {}
+
+/**
+ * @param mysqli $mysql
+ * @param string $query
+ * @return mysqli_stmt|false
+ */
+function mysqli_prepare($mysql, $query) {}
+
+/**
+ * @param mysqli_stmt $statement
+ * @param string $types
+ * @param mixed &...$vars
+ * @return bool
+ */
+function mysqli_stmt_bind_param($statement, $types, &$vars = null) {}
+
+/**
+ * @param mysqli_stmt $statement
+ * @param array|null $params
+ * @return bool
+ */
+function mysqli_stmt_execute($statement, $params = null) {}
+
+/**
+ * @param mysqli_stmt $statement
+ * @return mysqli_result|false
+ */
+function mysqli_stmt_get_result($statement) {}
+
+/**
+ * @param mysqli $mysql
+ * @param string $string
+ * @return string
+ */
+function mysqli_real_escape_string($mysql, $string) {}
+
+/**
+ * @param mysqli $mysql
+ * @param string $string
+ * @return string
+ */
+function mysqli_escape_string($mysql, $string) {}
+
+/**
+ * @param mysqli_result $result
+ * @return array|null|false
+ */
+function mysqli_fetch_assoc($result) {}
+
+/**
+ * @param mysqli_result $result
+ * @return array|null|false
+ */
+function mysqli_fetch_row($result) {}
+
+/**
+ * @param mysqli_result $result
+ * @param int $mode
+ * @return array|null|false
+ */
+function mysqli_fetch_array($result, $mode = MYSQLI_BOTH) {}
+
+/**
+ * @param mysqli_result $result
+ * @param int $mode
+ * @return array
+ */
+function mysqli_fetch_all($result, $mode = MYSQLI_NUM) {}
+
+/**
+ * @param mysqli_result $result
+ * @return int|string
+ */
+function mysqli_num_rows($result) {}
+
+/**
+ * @param mysqli $mysql
+ * @return int|string
+ */
+function mysqli_affected_rows($mysql) {}
+
+/**
+ * @param mysqli $mysql
+ * @return int|string
+ */
+function mysqli_insert_id($mysql) {}
+
+/**
+ * @param mysqli $mysql
+ * @return string
+ */
+function mysqli_error($mysql) {}
+
+/**
+ * @param mysqli $mysql
+ * @return int
+ */
+function mysqli_errno($mysql) {}
+
+/**
+ * @param mysqli $mysql
+ * @param string $database
+ * @return bool
+ */
+function mysqli_select_db($mysql, $database) {}
+
+/**
+ * @param mysqli $mysql
+ * @param string $charset
+ * @return bool
+ */
+function mysqli_set_charset($mysql, $charset) {}
+
+/**
+ * @param mysqli_result $result
+ * @return void
+ */
+function mysqli_free_result($result) {}
+
+/**
+ * @param mysqli $mysql
+ * @return bool
+ */
+function mysqli_close($mysql) {}
+
+/**
+ * @param int $flags
+ * @return bool
+ */
+function mysqli_report($flags) {}
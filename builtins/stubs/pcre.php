@@ -0,0 +1,87 @@
+<?php
+// The functions and constants of the pcre extension.
+
+define('PREG_PATTERN_ORDER', 1);
+define('PREG_SET_ORDER', 2);
+define('PREG_OFFSET_CAPTURE', 256);
+define('PREG_UNMATCHED_AS_NULL', 512);
+define('PREG_SPLIT_NO_EMPTY', 1);
+define('PREG_SPLIT_DELIM_CAPTURE', 2);
+define('PREG_SPLIT_OFFSET_CAPTURE', 4);
+define('PREG_GREP_INVERT', 1);
+
+/**
+ * @param string $pattern
+ * @param string $subject
+ * @param array &$matches
+ * @param int $flags
+ * @param int $offset
+ * @return int|false
+ */
+function preg_match($pattern, $subject, &$matches = null, $flags = 0, $offset = 0) {}
+
+/**
+ * @param string $pattern
+ * @param string $subject
+ * @param array &$matches
+ * @param int $flags
+ * @param int $offset
+ * @return int|false
+ */
+function preg_match_all($pattern, $subject, &$matches = null, $flags = 0, $offset = 0) {}
+
+/**
+ * @param string|string[] $pattern
+ * @param string|string[] $replacement
+ * @param string|string[] $subject
+ * @param int $limit
+ * @param int &$count
+ * @return string|string[]|null
+ */
+function preg_replace($pattern, $replacement, $subject, $limit = -1, &$count = null) {}
+
+/**
+ * @param string|string[] $pattern
+ * @param callable $callback
+ * @param string|string[] $subject
+ * @param int $limit
+ * @param int &$count
+ * @param int $flags
+ * @return string|string[]|null
+ */
+function preg_replace_callback($pattern, $callback, $subject, $limit = -1, &$count = null, $flags = 0) {}
+
+/**
+ * @param string $pattern
+ * @param string $subject
+ * @param int $limit
+ * @param int $flags
+ * @return string[]|false
+ */
+function preg_split($pattern, $subject, $limit = -1, $flags = 0) {}
+
+/**
+ * @param string $str
+ * @param string|null $delimiter
+ * @return string
+ */
+function preg_quote($str, $delimiter = null) {}
+
+/**
+ * @template K
+ * @template V
+ * @param string $pattern
+ * @param array<K, V> $array
+ * @param int $flags
+ * @return array<K, V>|false
+ */
+function preg_grep($pattern, $array, $flags = 0) {}
+
+/** @return int */
+function preg_last_error() {}
+
+/**
+ * @return string
+ * @since 8.0
+ */
+function preg_last_error_msg() {}
@@ -0,0 +1,175 @@
+<?php
+// The classes of the pdo extension.
+
+class PDOException extends RuntimeException {
+	/** @var array|null */
+	public $errorInfo;
+}
+
+class PDO {
+	const PARAM_NULL = 0;
+	const PARAM_INT = 1;
+	const PARAM_STR = 2;
+	const PARAM_LOB = 3;
+	const PARAM_BOOL = 5;
+	const FETCH_ASSOC = 2;
+	const FETCH_NUM = 3;
+	const FETCH_BOTH = 4;
+	const FETCH_OBJ = 5;
+	const FETCH_CLASS = 8;
+	const FETCH_COLUMN = 7;
+	const ATTR_ERRMODE = 3;
+	const ATTR_DEFAULT_FETCH_MODE = 19;
+	const ATTR_EMULATE_PREPARES = 20;
+	const ERRMODE_SILENT = 0;
+	const ERRMODE_WARNING = 1;
+	const ERRMODE_EXCEPTION = 2;
+
+	/**
+	 * @param string $dsn
+	 * @param string|null $username
+	 * @param string|null $password
+	 * @param array|null $options
+	 */
+	public function __construct($dsn, $username = null, $password = null, $options = null) {}
+
+	/**
+	 * @param string $query
+	 * @param array $options
+	 * @return PDOStatement|false
+	 */
+	public function prepare($query, $options = array()) {}
+
+	/**
+	 * @param string $query
+	 * @param int|null $fetchMode
+	 * @return PDOStatement|false
+	 */
+	public function query($query, $fetchMode = null) {}
+
+	/**
+	 * @param string $statement
+	 * @return int|false
+	 */
+	public function exec($statement) {}
+
+	/**
+	 * @param string $string
+	 * @param int $type
+	 * @return string|false
+	 */
+	public function quote($string, $type = PDO::PARAM_STR) {}
+
+	/**
+	 * @param string|null $name
+	 * @return string|false
+	 */
+	public function lastInsertId($name = null) {}
+
+	/** @return bool */
+	public function beginTransaction() {}
+
+	/** @return bool */
+	public function commit() {}
+
+	/** @return bool */
+	public function rollBack() {}
+
+	/** @return bool */
+	public function inTransaction() {}
+
+	/**
+	 * @param int $attribute
+	 * @param mixed $value
+	 * @return bool
+	 */
+	public function setAttribute($attribute, $value) {}
+
+	/**
+	 * @param int $attribute
+	 * @return mixed
+	 */
+	public function getAttribute($attribute) {}
+
+	/** @return string|null */
+	public function errorCode() {}
+
+	/** @return array */
+	public function errorInfo() {}
+}
+
+class PDOStatement implements IteratorAggregate {
+	/** @var string */
+	public $queryString;
+
+	/**
+	 * @param array|null $params
+	 * @return bool
+	 */
+	public function execute($params = null) {}
+
+	/**
+	 * @param int $mode
+	 * @return mixed
+	 */
+	public function fetch($mode = PDO::FETCH_BOTH, $cursorOrientation = 0, $cursorOffset = 0) {}
+
+	/**
+	 * @param int $mode
+	 * @return array
+	 */
+	public function fetchAll($mode = PDO::FETCH_BOTH, $args = null) {}
+
+	/**
+	 * @param int $column
+	 * @return mixed
+	 */
+	public function fetchColumn($column = 0) {}
+
+	/**
+	 * @param string|null $class
+	 * @param array $constructorArgs
+	 * @return object|false
+	 */
+	public function fetchObject($class = "stdClass", $constructorArgs = array()) {}
+
+	/**
+	 * @param int|string $param
+	 * @param mixed $value
+	 * @param int $type
+	 * @return bool
+	 */
+	public function bindValue($param, $value, $type = PDO::PARAM_STR) {}
+
+	/**
+	 * @param int|string $param
+	 * @param mixed &$var
+	 * @param int $type
+	 * @return bool
+	 */
+	public function bindParam($param, &$var, $type = PDO::PARAM_STR, $maxLength = 0, $driverOptions = null) {}
+
+	/** @return int */
+	public function rowCount() {}
+
+	/** @return int */
+	public function columnCount() {}
+
+	/** @return bool */
+	public function closeCursor() {}
+
+	/** @return bool */
+	public function setFetchMode($mode, $args = null) {}
+
+	/** @return string|null */
+	public function errorCode() {}
+
+	/** @return array */
+	public function errorInfo() {}
+
+	/**
+	 * @return Iterator
+	 * @since 8.0
+	 */
+	public function getIterator() {}
+}
@@ -0,0 +1,73 @@
+<?php
+// The functions and constants of the session extension.
+
+define('PHP_SESSION_DISABLED', 0);
+define('PHP_SESSION_NONE', 1);
+define('PHP_SESSION_ACTIVE', 2);
+
+/**
+ * @param array $options
+ * @return bool
+ */
+function session_start($options = array()) {}
+
+/** @return bool */
+function session_destroy() {}
+
+/** @return bool */
+function session_unset() {}
+
+/** @return bool */
+function session_write_close() {}
+
+/** @return bool */
+function session_abort() {}
+
+/** @return int */
+function session_status() {}
+
+/**
+ * @param string|null $id
+ * @return string|false
+ */
+function session_id($id = null) {}
+
+/**
+ * @param string|null $name
+ * @return string|false
+ */
+function session_name($name = null) {}
+
+/**
+ * @param bool $delete_old_session
+ * @return bool
+ */
+function session_regenerate_id($delete_old_session = false) {}
+
+/**
+ * @param string|null $path
+ * @return string|false
+ */
+function session_save_path($path = null) {}
+
+/** @return array */
+function session_get_cookie_params() {}
+
+/**
+ * @param array|int $lifetime_or_options
+ * @param string|null $path
+ * @param string|null $domain
+ * @param bool|null $secure
+ * @param bool|null $httponly
+ * @return bool
+ */
+function session_set_cookie_params($lifetime_or_options, $path = null, $domain = null, $secure = null, $httponly = null) {}
+
+/** @return string|false */
+function session_encode() {}
+
+/**
+ * @param string $data
+ * @return bool
+ */
+function session_decode($data) {}
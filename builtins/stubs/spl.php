@@ -0,0 +1,697 @@
+<?php
+// The classes and functions of the spl extension.
+
+/**
+ * @param object|string $object_or_class
+ * @param bool $autoload
+ * @return array<string, string>|false
+ */
+function class_implements($object_or_class, $autoload = true) {}
+
+/**
+ * @param object|string $object_or_class
+ * @param bool $autoload
+ * @return array<string, string>|false
+ */
+function class_parents($object_or_class, $autoload = true) {}
+
+/**
+ * @param object $object
+ * @return string
+ */
+function spl_object_hash($object) {}
+
+/**
+ * @param object $object
+ * @return int
+ * @since 7.2
+ */
+function spl_object_id($object) {}
+
+/**
+ * @param Traversable|array $iterator
+ * @param bool $preserve_keys
+ * @return array
+ */
+function iterator_to_array($iterator, $preserve_keys = true) {}
+
+/**
+ * @param Traversable|array $iterator
+ * @return int
+ */
+function iterator_count($iterator) {}
+
+class LogicException extends Exception {}
+
+class BadFunctionCallException extends LogicException {}
+
+class BadMethodCallException extends BadFunctionCallException {}
+
+class DomainException extends LogicException {}
+
+class InvalidArgumentException extends LogicException {}
+
+class LengthException extends LogicException {}
+
+class OutOfRangeException extends LogicException {}
+
+class RuntimeException extends Exception {}
+
+class OutOfBoundsException extends RuntimeException {}
+
+class OverflowException extends RuntimeException {}
+
+class RangeException extends RuntimeException {}
+
+class UnderflowException extends RuntimeException {}
+
+class UnexpectedValueException extends RuntimeException {}
+
+interface OuterIterator extends Iterator {
+	/** @return Iterator|null */
+	public function getInnerIterator();
+}
+
+interface SeekableIterator extends Iterator {
+	/**
+	 * @param int $offset
+	 * @return void
+	 */
+	public function seek($offset);
+}
+
+interface SplObserver {
+	/** @return void */
+	public function update($subject);
+}
+
+interface SplSubject {
+	/** @return void */
+	public function attach($observer);
+
+	/** @return void */
+	public function detach($observer);
+
+	/** @return void */
+	public function notify();
+}
+
+class ArrayIterator implements SeekableIterator, ArrayAccess, Countable, Serializable {
+	/**
+	 * @param array|object $array
+	 * @param int $flags
+	 */
+	public function __construct($array = array(), $flags = 0) {}
+
+	/** @return mixed */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return string|int|null */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+
+	/** @return void */
+	public function seek($offset) {}
+
+	/** @return bool */
+	public function offsetExists($key) {}
+
+	/** @return mixed */
+	public function offsetGet($key) {}
+
+	/** @return void */
+	public function offsetSet($key, $value) {}
+
+	/** @return void */
+	public function offsetUnset($key) {}
+
+	/** @return int */
+	public function count() {}
+
+	/** @return array */
+	public function getArrayCopy() {}
+
+	/** @return string */
+	public function serialize() {}
+
+	/** @return void */
+	public function unserialize($data) {}
+}
+
+class ArrayObject implements IteratorAggregate, ArrayAccess, Countable, Serializable {
+	const STD_PROP_LIST = 1;
+	const ARRAY_AS_PROPS = 2;
+
+	/**
+	 * @param array|object $array
+	 * @param int $flags
+	 * @param string $iteratorClass
+	 */
+	public function __construct($array = array(), $flags = 0, $iteratorClass = "ArrayIterator") {}
+
+	/** @return Iterator */
+	public function getIterator() {}
+
+	/** @return bool */
+	public function offsetExists($key) {}
+
+	/** @return mixed */
+	public function offsetGet($key) {}
+
+	/** @return void */
+	public function offsetSet($key, $value) {}
+
+	/** @return void */
+	public function offsetUnset($key) {}
+
+	/** @return void */
+	public function append($value) {}
+
+	/** @return int */
+	public function count() {}
+
+	/** @return array */
+	public function getArrayCopy() {}
+
+	/**
+	 * @param array|object $array
+	 * @return array
+	 */
+	public function exchangeArray($array) {}
+
+	/** @return string */
+	public function serialize() {}
+
+	/** @return void */
+	public function unserialize($data) {}
+}
+
+class SplDoublyLinkedList implements Iterator, Countable, ArrayAccess, Serializable {
+	/** @return void */
+	public function push($value) {}
+
+	/** @return mixed */
+	public function pop() {}
+
+	/** @return mixed */
+	public function shift() {}
+
+	/** @return void */
+	public function unshift($value) {}
+
+	/** @return mixed */
+	public function top() {}
+
+	/** @return mixed */
+	public function bottom() {}
+
+	/** @return bool */
+	public function isEmpty() {}
+
+	/** @return int */
+	public function count() {}
+
+	/** @return array */
+	public function toArray() {}
+
+	/** @return mixed */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return int */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+
+	/** @return bool */
+	public function offsetExists($index) {}
+
+	/** @return mixed */
+	public function offsetGet($index) {}
+
+	/** @return void */
+	public function offsetSet($index, $value) {}
+
+	/** @return void */
+	public function offsetUnset($index) {}
+
+	/** @return string */
+	public function serialize() {}
+
+	/** @return void */
+	public function unserialize($data) {}
+}
+
+class SplQueue extends SplDoublyLinkedList {
+	/** @return void */
+	public function enqueue($value) {}
+
+	/** @return mixed */
+	public function dequeue() {}
+}
+
+class SplStack extends SplDoublyLinkedList {}
+
+abstract class SplHeap implements Iterator, Countable {
+	/** @return mixed */
+	public function extract() {}
+
+	/** @return bool */
+	public function insert($value) {}
+
+	/** @return mixed */
+	public function top() {}
+
+	/** @return int */
+	public function count() {}
+
+	/** @return bool */
+	public function isEmpty() {}
+
+	/** @return mixed */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return int */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+
+	/** @return int */
+	abstract protected function compare($value1, $value2);
+}
+
+class SplMinHeap extends SplHeap {
+	/** @return int */
+	protected function compare($value1, $value2) {}
+}
+
+class SplMaxHeap extends SplHeap {
+	/** @return int */
+	protected function compare($value1, $value2) {}
+}
+
+class SplPriorityQueue implements Iterator, Countable {
+	/** @return bool */
+	public function insert($value, $priority) {}
+
+	/** @return mixed */
+	public function extract() {}
+
+	/** @return mixed */
+	public function top() {}
+
+	/** @return int */
+	public function count() {}
+
+	/** @return bool */
+	public function isEmpty() {}
+
+	/** @return mixed */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return int */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+}
+
+class SplFixedArray implements IteratorAggregate, ArrayAccess, Countable {
+	/** @param int $size */
+	public function __construct($size = 0) {}
+
+	/**
+	 * @param array $array
+	 * @param bool $preserveKeys
+	 * @return SplFixedArray
+	 */
+	public static function fromArray($array, $preserveKeys = true) {}
+
+	/** @return array */
+	public function toArray() {}
+
+	/** @return int */
+	public function getSize() {}
+
+	/** @return bool */
+	public function setSize($size) {}
+
+	/** @return int */
+	public function count() {}
+
+	/** @return Iterator */
+	public function getIterator() {}
+
+	/** @return bool */
+	public function offsetExists($index) {}
+
+	/** @return mixed */
+	public function offsetGet($index) {}
+
+	/** @return void */
+	public function offsetSet($index, $value) {}
+
+	/** @return void */
+	public function offsetUnset($index) {}
+}
+
+class SplObjectStorage implements Countable, Iterator, Serializable, ArrayAccess {
+	/** @return void */
+	public function attach($object, $info = null) {}
+
+	/** @return void */
+	public function detach($object) {}
+
+	/** @return bool */
+	public function contains($object) {}
+
+	/** @return int */
+	public function count($mode = COUNT_NORMAL) {}
+
+	/** @return mixed */
+	public function getInfo() {}
+
+	/** @return void */
+	public function setInfo($info) {}
+
+	/** @return object */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return int */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+
+	/** @return bool */
+	public function offsetExists($object) {}
+
+	/** @return mixed */
+	public function offsetGet($object) {}
+
+	/** @return void */
+	public function offsetSet($object, $info = null) {}
+
+	/** @return void */
+	public function offsetUnset($object) {}
+
+	/** @return string */
+	public function serialize() {}
+
+	/** @return void */
+	public function unserialize($data) {}
+}
+
+class SplFileInfo {
+	/** @param string $filename */
+	public function __construct($filename) {}
+
+	/** @return string */
+	public function getFilename() {}
+
+	/** @return string */
+	public function getPath() {}
+
+	/** @return string */
+	public function getPathname() {}
+
+	/** @return string */
+	public function getExtension() {}
+
+	/** @return string */
+	public function getBasename($suffix = "") {}
+
+	/** @return string|false */
+	public function getRealPath() {}
+
+	/** @return int|false */
+	public function getSize() {}
+
+	/** @return int|false */
+	public function getMTime() {}
+
+	/** @return bool */
+	public function isDir() {}
+
+	/** @return bool */
+	public function isFile() {}
+
+	/** @return bool */
+	public function isReadable() {}
+
+	/** @return bool */
+	public function isWritable() {}
+
+	/**
+	 * @param string $mode
+	 * @return SplFileObject
+	 */
+	public function openFile($mode = "r", $useIncludePath = false, $context = null) {}
+
+	/** @return string */
+	public function __toString() {}
+}
+
+class SplFileObject extends SplFileInfo implements RecursiveIterator, SeekableIterator {
+	const DROP_NEW_LINE = 1;
+	const READ_AHEAD = 2;
+	const SKIP_EMPTY = 4;
+	const READ_CSV = 8;
+
+	/**
+	 * @param string $filename
+	 * @param string $mode
+	 */
+	public function __construct($filename, $mode = "r", $useIncludePath = false, $context = null) {}
+
+	/** @return bool */
+	public function eof() {}
+
+	/** @return string */
+	public function fgets() {}
+
+	/** @return array|false */
+	public function fgetcsv($separator = ",", $enclosure = "\"", $escape = "\\") {}
+
+	/** @return int|false */
+	public function fwrite($data, $length = 0) {}
+
+	/** @return void */
+	public function setFlags($flags) {}
+
+	/** @return string|array|false */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return int */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+
+	/** @return void */
+	public function seek($line) {}
+
+	/** @return bool */
+	public function hasChildren() {}
+
+	/** @return RecursiveIterator|null */
+	public function getChildren() {}
+}
+
+interface RecursiveIterator extends Iterator {
+	/** @return bool */
+	public function hasChildren();
+
+	/** @return RecursiveIterator|null */
+	public function getChildren();
+}
+
+class DirectoryIterator extends SplFileInfo implements SeekableIterator {
+	/** @param string $directory */
+	public function __construct($directory) {}
+
+	/** @return bool */
+	public function isDot() {}
+
+	/** @return DirectoryIterator */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return int|string */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+
+	/** @return void */
+	public function seek($offset) {}
+}
+
+class FilesystemIterator extends DirectoryIterator {
+	const SKIP_DOTS = 4096;
+	const KEY_AS_PATHNAME = 0;
+	const CURRENT_AS_FILEINFO = 0;
+
+	/**
+	 * @param string $directory
+	 * @param int $flags
+	 */
+	public function __construct($directory, $flags = 0) {}
+}
+
+class RecursiveDirectoryIterator extends FilesystemIterator implements RecursiveIterator {
+	/**
+	 * @param string $directory
+	 * @param int $flags
+	 */
+	public function __construct($directory, $flags = 0) {}
+
+	/** @return bool */
+	public function hasChildren($allowLinks = false) {}
+
+	/** @return RecursiveDirectoryIterator */
+	public function getChildren() {}
+}
+
+class IteratorIterator implements OuterIterator {
+	/** @param Traversable $iterator */
+	public function __construct($iterator, $class = null) {}
+
+	/** @return Iterator|null */
+	public function getInnerIterator() {}
+
+	/** @return mixed */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return mixed */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+}
+
+class RecursiveIteratorIterator implements OuterIterator {
+	const LEAVES_ONLY = 0;
+	const SELF_FIRST = 1;
+	const CHILD_FIRST = 2;
+
+	/**
+	 * @param Traversable $iterator
+	 * @param int $mode
+	 * @param int $flags
+	 */
+	public function __construct($iterator, $mode = 0, $flags = 0) {}
+
+	/** @return int */
+	public function getDepth() {}
+
+	/** @return Iterator|null */
+	public function getInnerIterator() {}
+
+	/** @return mixed */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return mixed */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+}
+
+abstract class FilterIterator extends IteratorIterator {
+	/** @return bool */
+	abstract public function accept();
+}
+
+class CallbackFilterIterator extends FilterIterator {
+	/**
+	 * @param Iterator $iterator
+	 * @param callable $callback
+	 */
+	public function __construct($iterator, $callback) {}
+
+	/** @return bool */
+	public function accept() {}
+}
+
+class LimitIterator extends IteratorIterator {
+	/**
+	 * @param Iterator $iterator
+	 * @param int $offset
+	 * @param int $limit
+	 */
+	public function __construct($iterator, $offset = 0, $limit = -1) {}
+}
+
+class EmptyIterator implements Iterator {
+	/** @return mixed */
+	public function current() {}
+
+	/** @return void */
+	public function next() {}
+
+	/** @return mixed */
+	public function key() {}
+
+	/** @return bool */
+	public function valid() {}
+
+	/** @return void */
+	public function rewind() {}
+}
@@ -0,0 +1,2108 @@
+<?php
+// The functions and constants of the standard extension: strings, arrays,
+// math, variables, files and output.
+
+define('COUNT_NORMAL', 0);
+define('COUNT_RECURSIVE', 1);
+define('SORT_REGULAR', 0);
+define('SORT_NUMERIC', 1);
+define('SORT_STRING', 2);
+define('SORT_FLAG_CASE', 8);
+define('ARRAY_FILTER_USE_KEY', 2);
+define('ARRAY_FILTER_USE_BOTH', 1);
+define('STR_PAD_RIGHT', 1);
+define('STR_PAD_LEFT', 0);
+define('STR_PAD_BOTH', 2);
+define('ENT_QUOTES', 3);
+define('ENT_COMPAT', 2);
+define('ENT_SUBSTITUTE', 8);
+define('ENT_HTML401', 0);
+define('ENT_HTML5', 48);
+define('M_PI', 3.14159265358979323846);
+define('M_E', 2.7182818284590452354);
+define('PHP_ROUND_HALF_UP', 1);
+define('FILE_APPEND', 8);
+define('FILE_IGNORE_NEW_LINES', 2);
+define('FILE_SKIP_EMPTY_LINES', 4);
+define('LOCK_SH', 1);
+define('LOCK_EX', 2);
+define('LOCK_UN', 3);
+define('SEEK_SET', 0);
+define('SEEK_CUR', 1);
+define('SEEK_END', 2);
+/** @var resource */
+define('STDIN', null);
+/** @var resource */
+define('STDOUT', null);
+/** @var resource */
+define('STDERR', null);
+
+// Strings
+
+/**
+ * @param string $string
+ * @param int $offset
+ * @param int|null $length
+ * @return string
+ */
+function substr($string, $offset, $length = null) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param int $offset
+ * @return int|false
+ */
+function strpos($haystack, $needle, $offset = 0) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param int $offset
+ * @return int|false
+ */
+function stripos($haystack, $needle, $offset = 0) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param int $offset
+ * @return int|false
+ */
+function strrpos($haystack, $needle, $offset = 0) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param bool $before_needle
+ * @return string|false
+ */
+function strstr($haystack, $needle, $before_needle = false) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param bool $before_needle
+ * @return string|false
+ */
+function stristr($haystack, $needle, $before_needle = false) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @return string|false
+ */
+function strrchr($haystack, $needle) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @return bool
+ * @since 8.0
+ */
+function str_contains($haystack, $needle) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @return bool
+ * @since 8.0
+ */
+function str_starts_with($haystack, $needle) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @return bool
+ * @since 8.0
+ */
+function str_ends_with($haystack, $needle) {}
+
+/**
+ * @param string|string[] $search
+ * @param string|string[] $replace
+ * @param string|string[] $subject
+ * @param int &$count
+ * @return string|string[]
+ */
+function str_replace($search, $replace, $subject, &$count = null) {}
+
+/**
+ * @param string|string[] $search
+ * @param string|string[] $replace
+ * @param string|string[] $subject
+ * @param int &$count
+ * @return string|string[]
+ */
+function str_ireplace($search, $replace, $subject, &$count = null) {}
+
+/**
+ * @param string $string
+ * @param int $times
+ * @return string
+ */
+function str_repeat($string, $times) {}
+
+/**
+ * @param string $string
+ * @param int $length
+ * @param string $pad_string
+ * @param int $pad_type
+ * @return string
+ */
+function str_pad($string, $length, $pad_string = " ", $pad_type = STR_PAD_RIGHT) {}
+
+/**
+ * @param string $string
+ * @param int $length
+ * @return string[]
+ */
+function str_split($string, $length = 1) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function strrev($string) {}
+
+/**
+ * @param string $string
+ * @param int $format
+ * @return int|string[]
+ */
+function str_word_count($string, $format = 0, $characters = null) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function strtolower($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function strtoupper($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function ucfirst($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function lcfirst($string) {}
+
+/**
+ * @param string $string
+ * @param string $separators
+ * @return string
+ */
+function ucwords($string, $separators = " \t\r\n\f\v") {}
+
+/**
+ * @param string $string
+ * @param string $characters
+ * @return string
+ */
+function trim($string, $characters = " \n\r\t\v\0") {}
+
+/**
+ * @param string $string
+ * @param string $characters
+ * @return string
+ */
+function ltrim($string, $characters = " \n\r\t\v\0") {}
+
+/**
+ * @param string $string
+ * @param string $characters
+ * @return string
+ */
+function rtrim($string, $characters = " \n\r\t\v\0") {}
+
+/**
+ * @param string $string
+ * @param string $characters
+ * @return string
+ */
+function chop($string, $characters = " \n\r\t\v\0") {}
+
+/**
+ * @param string $separator
+ * @param string $string
+ * @param int $limit
+ * @return string[]|false
+ */
+function explode($separator, $string, $limit = PHP_INT_MAX) {}
+
+/**
+ * @param array|string $separator
+ * @param array|null $array
+ * @return string
+ */
+function implode($separator, $array = null) {}
+
+/**
+ * @param array|string $separator
+ * @param array|null $array
+ * @return string
+ */
+function join($separator, $array = null) {}
+
+/**
+ * @param string $format
+ * @param mixed ...$values
+ * @return string
+ */
+function sprintf($format, $values = null) {}
+
+/**
+ * @param string $format
+ * @param array $values
+ * @return string
+ */
+function vsprintf($format, $values) {}
+
+/**
+ * @param string $format
+ * @param mixed ...$values
+ * @return int
+ */
+function printf($format, $values = null) {}
+
+/**
+ * @param resource $stream
+ * @param string $format
+ * @param mixed ...$values
+ * @return int
+ */
+function fprintf($stream, $format, $values = null) {}
+
+/**
+ * @param string $string
+ * @param string $format
+ * @param mixed &...$vars
+ * @return array|int|null
+ */
+function sscanf($string, $format, &$vars = null) {}
+
+/**
+ * @param float $num
+ * @param int $decimals
+ * @param string|null $decimal_separator
+ * @param string|null $thousands_separator
+ * @return string
+ */
+function number_format($num, $decimals = 0, $decimal_separator = ".", $thousands_separator = ",") {}
+
+/**
+ * @param string $string
+ * @param int $flags
+ * @param string|null $encoding
+ * @param bool $double_encode
+ * @return string
+ */
+function htmlspecialchars($string, $flags = ENT_QUOTES | ENT_SUBSTITUTE | ENT_HTML401, $encoding = null, $double_encode = true) {}
+
+/**
+ * @param string $string
+ * @param int $flags
+ * @return string
+ */
+function htmlspecialchars_decode($string, $flags = ENT_QUOTES | ENT_SUBSTITUTE | ENT_HTML401) {}
+
+/**
+ * @param string $string
+ * @param int $flags
+ * @param string|null $encoding
+ * @param bool $double_encode
+ * @return string
+ */
+function htmlentities($string, $flags = ENT_QUOTES | ENT_SUBSTITUTE | ENT_HTML401, $encoding = null, $double_encode = true) {}
+
+/**
+ * @param string $string
+ * @param int $flags
+ * @param string|null $encoding
+ * @return string
+ */
+function html_entity_decode($string, $flags = ENT_QUOTES | ENT_SUBSTITUTE | ENT_HTML401, $encoding = null) {}
+
+/**
+ * @param string $string
+ * @param string[]|string|null $allowed_tags
+ * @return string
+ */
+function strip_tags($string, $allowed_tags = null) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function addslashes($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function stripslashes($string) {}
+
+/**
+ * @param string $string
+ * @param bool $use_xhtml
+ * @return string
+ */
+function nl2br($string, $use_xhtml = true) {}
+
+/**
+ * @param string $string
+ * @param int $width
+ * @param string $break
+ * @param bool $cut_long_words
+ * @return string
+ */
+function wordwrap($string, $width = 75, $break = "\n", $cut_long_words = false) {}
+
+/**
+ * @param string $string
+ * @param string|array $from
+ * @param string|null $to
+ * @return string
+ */
+function strtr($string, $from, $to = null) {}
+
+/**
+ * @param string|string[] $string
+ * @param string|string[] $replace
+ * @param int|int[] $offset
+ * @param int|int[]|null $length
+ * @return string|string[]
+ */
+function substr_replace($string, $replace, $offset, $length = null) {}
+
+/**
+ * @param string $haystack
+ * @param string $needle
+ * @param int $offset
+ * @param int|null $length
+ * @return int
+ */
+function substr_count($haystack, $needle, $offset = 0, $length = null) {}
+
+/**
+ * @param string $string
+ * @param string $characters
+ * @param int $offset
+ * @param int|null $length
+ * @return int
+ */
+function strspn($string, $characters, $offset = 0, $length = null) {}
+
+/**
+ * @param string $string
+ * @param string $characters
+ * @param int $offset
+ * @param int|null $length
+ * @return int
+ */
+function strcspn($string, $characters, $offset = 0, $length = null) {}
+
+/**
+ * @param string $string1
+ * @param string $string2
+ * @return int
+ */
+function strnatcmp($string1, $string2) {}
+
+/**
+ * @param string $string1
+ * @param string $string2
+ * @return int
+ */
+function strnatcasecmp($string1, $string2) {}
+
+/**
+ * @param string $string
+ * @return int
+ */
+function ord($string) {}
+
+/**
+ * @param int $codepoint
+ * @return string
+ */
+function chr($codepoint) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function bin2hex($string) {}
+
+/**
+ * @param string $string
+ * @return string|false
+ */
+function hex2bin($string) {}
+
+/**
+ * @param string $string
+ * @param bool $binary
+ * @return string
+ */
+function md5($string, $binary = false) {}
+
+/**
+ * @param string $string
+ * @param bool $binary
+ * @return string
+ */
+function sha1($string, $binary = false) {}
+
+/**
+ * @param string $string
+ * @return int
+ */
+function crc32($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function base64_encode($string) {}
+
+/**
+ * @param string $string
+ * @param bool $strict
+ * @return string|false
+ */
+function base64_decode($string, $strict = false) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function urlencode($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function urldecode($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function rawurlencode($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function rawurldecode($string) {}
+
+/**
+ * @param array|object $data
+ * @return string
+ */
+function http_build_query($data, $numeric_prefix = "", $arg_separator = null, $encoding_type = 1) {}
+
+/**
+ * @param string $url
+ * @param int $component
+ * @return mixed
+ */
+function parse_url($url, $component = -1) {}
+
+/**
+ * @param string $string
+ * @param array &$result
+ * @return void
+ */
+function parse_str($string, &$result) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function quotemeta($string) {}
+
+/**
+ * @param string $string
+ * @param string $characters
+ * @return string
+ */
+function addcslashes($string, $characters) {}
+
+/**
+ * @param string $string1
+ * @param string $string2
+ * @return int
+ */
+function levenshtein($string1, $string2) {}
+
+/**
+ * @param string $string1
+ * @param string $string2
+ * @param float &$percent
+ * @return int
+ */
+function similar_text($string1, $string2, &$percent = null) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function soundex($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function metaphone($string, $max_phonemes = 0) {}
+
+/**
+ * @param int $length
+ * @return string
+ * @since 7.0
+ */
+function random_bytes($length) {}
+
+/**
+ * @param string $prefix
+ * @param bool $more_entropy
+ * @return string
+ */
+function uniqid($prefix = "", $more_entropy = false) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function str_rot13($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function str_shuffle($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function utf8_encode($string) {}
+
+/**
+ * @param string $string
+ * @return string
+ */
+function utf8_decode($string) {}
+
+/**
+ * @param string $password
+ * @param string|int|null $algo
+ * @param array $options
+ * @return string
+ * @since 5.5
+ */
+function password_hash($password, $algo, $options = array()) {}
+
+/**
+ * @param string $password
+ * @param string $hash
+ * @return bool
+ * @since 5.5
+ */
+function password_verify($password, $hash) {}
+
+/**
+ * @param string $algo
+ * @param string $data
+ * @param bool $binary
+ * @return string
+ */
+function hash($algo, $data, $binary = false) {}
+
+/**
+ * @param string $algo
+ * @param string $data
+ * @param string $key
+ * @param bool $binary
+ * @return string
+ */
+function hash_hmac($algo, $data, $key, $binary = false) {}
+
+/**
+ * @param string $known_string
+ * @param string $user_string
+ * @return bool
+ * @since 5.6
+ */
+function hash_equals($known_string, $user_string) {}
+
+// Arrays
+
+/**
+ * @param array|Countable $value
+ * @param int $mode
+ * @return int
+ */
+function count($value, $mode = COUNT_NORMAL) {}
+
+/**
+ * @param array|Countable $value
+ * @param int $mode
+ * @return int
+ */
+function sizeof($value, $mode = COUNT_NORMAL) {}
+
+/**
+ * @param mixed $needle
+ * @param array $haystack
+ * @param bool $strict
+ * @return bool
+ */
+function in_array($needle, $haystack, $strict = false) {}
+
+/**
+ * @param mixed $needle
+ * @param array $haystack
+ * @param bool $strict
+ * @return int|string|false
+ */
+function array_search($needle, $haystack, $strict = false) {}
+
+/**
+ * @param int|string $key
+ * @param array $array
+ * @return bool
+ */
+function array_key_exists($key, $array) {}
+
+/**
+ * @param int|string $key
+ * @param array $array
+ * @return bool
+ */
+function key_exists($key, $array) {}
+
+/**
+ * @template K
+ * @param array<K, mixed> $array
+ * @return list<K>
+ */
+function array_keys($array, $filter_value = null, $strict = false) {}
+
+/**
+ * @template V
+ * @param array<V> $array
+ * @return list<V>
+ */
+function array_values($array) {}
+
+/**
+ * @param array ...$arrays
+ * @return array
+ */
+function array_merge($arrays = null) {}
+
+/**
+ * @param array ...$arrays
+ * @return array
+ */
+function array_merge_recursive($arrays = null) {}
+
+/**
+ * @param array $array
+ * @param array ...$replacements
+ * @return array
+ */
+function array_replace($array, $replacements = null) {}
+
+/**
+ * @param array $keys
+ * @param array $values
+ * @return array
+ */
+function array_combine($keys, $values) {}
+
+/**
+ * @param array $array
+ * @return array
+ */
+function array_flip($array) {}
+
+/**
+ * @param array $keys
+ * @param mixed $value
+ * @return array
+ */
+function array_fill_keys($keys, $value) {}
+
+/**
+ * @template V
+ * @param int $start_index
+ * @param int $count
+ * @param V $value
+ * @return array<int, V>
+ */
+function array_fill($start_index, $count, $value) {}
+
+/**
+ * @param callable|null $callback
+ * @param array $array
+ * @param array ...$arrays
+ * @return array
+ */
+function array_map($callback, $array, $arrays = null) {}
+
+/**
+ * @template K
+ * @template V
+ * @param array<K, V> $array
+ * @param callable|null $callback
+ * @param int $mode
+ * @return array<K, V>
+ */
+function array_filter($array, $callback = null, $mode = 0) {}
+
+/**
+ * @param array $array
+ * @param callable $callback
+ * @param mixed $initial
+ * @return mixed
+ */
+function array_reduce($array, $callback, $initial = null) {}
+
+/**
+ * @param array|object &$array
+ * @param callable $callback
+ * @param mixed $arg
+ * @return bool
+ */
+function array_walk(&$array, $callback, $arg = null) {}
+
+/**
+ * @param array|object &$array
+ * @param callable $callback
+ * @param mixed $arg
+ * @return bool
+ */
+function array_walk_recursive(&$array, $callback, $arg = null) {}
+
+/**
+ * @template K
+ * @template V
+ * @param array<K, V> $array
+ * @param int $offset
+ * @param int|null $length
+ * @param bool $preserve_keys
+ * @return array<K, V>
+ */
+function array_slice($array, $offset, $length = null, $preserve_keys = false) {}
+
+/**
+ * @param array &$array
+ * @param int $offset
+ * @param int|null $length
+ * @param mixed $replacement
+ * @return array
+ */
+function array_splice(&$array, $offset, $length = null, $replacement = array()) {}
+
+/**
+ * @template V
+ * @param array<V> &$array
+ * @return V|null
+ */
+function array_pop(&$array) {}
+
+/**
+ * @param array &$array
+ * @param mixed ...$values
+ * @return int
+ */
+function array_push(&$array, $values = null) {}
+
+/**
+ * @template V
+ * @param array<V> &$array
+ * @return V|null
+ */
+function array_shift(&$array) {}
+
+/**
+ * @param array &$array
+ * @param mixed ...$values
+ * @return int
+ */
+function array_unshift(&$array, $values = null) {}
+
+/**
+ * @template K
+ * @template V
+ * @param array<K, V> $array
+ * @param bool $preserve_keys
+ * @return array<K, V>
+ */
+function array_reverse($array, $preserve_keys = false) {}
+
+/**
+ * @template K
+ * @template V
+ * @param array<K, V> $array
+ * @param int $flags
+ * @return array<K, V>
+ */
+function array_unique($array, $flags = SORT_STRING) {}
+
+/**
+ * @param array $array
+ * @param array ...$arrays
+ * @return array
+ */
+function array_diff($array, $arrays = null) {}
+
+/**
+ * @param array $array
+ * @param array ...$arrays
+ * @return array
+ */
+function array_diff_key($array, $arrays = null) {}
+
+/**
+ * @param array $array
+ * @param array ...$arrays
+ * @return array
+ */
+function array_diff_assoc($array, $arrays = null) {}
+
+/**
+ * @param array $array
+ * @param array ...$arrays
+ * @return array
+ */
+function array_intersect($array, $arrays = null) {}
+
+/**
+ * @param array $array
+ * @param array ...$arrays
+ * @return array
+ */
+function array_intersect_key($array, $arrays = null) {}
+
+/**
+ * @param array $array
+ * @param int|string|null $column_key
+ * @param int|string|null $index_key
+ * @return array
+ */
+function array_column($array, $column_key, $index_key = null) {}
+
+/**
+ * @param array $array
+ * @param int $length
+ * @param bool $preserve_keys
+ * @return array[]
+ */
+function array_chunk($array, $length, $preserve_keys = false) {}
+
+/**
+ * @param array $array
+ * @param int $length
+ * @param mixed $value
+ * @return array
+ */
+function array_pad($array, $length, $value) {}
+
+/**
+ * @param array $array
+ * @return int|float
+ */
+function array_sum($array) {}
+
+/**
+ * @param array $array
+ * @return int|float
+ */
+function array_product($array) {}
+
+/**
+ * @param array $array
+ * @return array<int>
+ */
+function array_count_values($array) {}
+
+/**
+ * @param array $array
+ * @param int $num
+ * @return int|string|array
+ */
+function array_rand($array, $num = 1) {}
+
+/**
+ * @param array $array
+ * @return int|string|null
+ * @since 7.3
+ */
+function array_key_first($array) {}
+
+/**
+ * @param array $array
+ * @return int|string|null
+ * @since 7.3
+ */
+function array_key_last($array) {}
+
+/**
+ * @param array $array
+ * @return bool
+ * @since 8.1
+ */
+function array_is_list($array) {}
+
+/**
+ * @param array &$array
+ * @param int $flags
+ * @return bool
+ */
+function sort(&$array, $flags = SORT_REGULAR) {}
+
+/**
+ * @param array &$array
+ * @param int $flags
+ * @return bool
+ */
+function rsort(&$array, $flags = SORT_REGULAR) {}
+
+/**
+ * @param array &$array
+ * @param int $flags
+ * @return bool
+ */
+function asort(&$array, $flags = SORT_REGULAR) {}
+
+/**
+ * @param array &$array
+ * @param int $flags
+ * @return bool
+ */
+function arsort(&$array, $flags = SORT_REGULAR) {}
+
+/**
+ * @param array &$array
+ * @param int $flags
+ * @return bool
+ */
+function ksort(&$array, $flags = SORT_REGULAR) {}
+
+/**
+ * @param array &$array
+ * @param int $flags
+ * @return bool
+ */
+function krsort(&$array, $flags = SORT_REGULAR) {}
+
+/**
+ * @param array &$array
+ * @param callable $callback
+ * @return bool
+ */
+function usort(&$array, $callback) {}
+
+/**
+ * @param array &$array
+ * @param callable $callback
+ * @return bool
+ */
+function uasort(&$array, $callback) {}
+
+/**
+ * @param array &$array
+ * @param callable $callback
+ * @return bool
+ */
+function uksort(&$array, $callback) {}
+
+/**
+ * @param array &$array
+ * @return bool
+ */
+function shuffle(&$array) {}
+
+/**
+ * @param array &$array
+ * @return bool
+ */
+function natsort(&$array) {}
+
+/**
+ * @param array &$array
+ * @return bool
+ */
+function natcasesort(&$array) {}
+
+/**
+ * @param int|float|string $start
+ * @param int|float|string $end
+ * @param int|float $step
+ * @return array
+ */
+function range($start, $end, $step = 1) {}
+
+/**
+ * @param array|object $array
+ * @return mixed
+ */
+function current($array) {}
+
+/**
+ * @param array|object $array
+ * @return mixed
+ */
+function pos($array) {}
+
+/**
+ * @param array|object $array
+ * @return int|string|null
+ */
+function key($array) {}
+
+/**
+ * @param array|object &$array
+ * @return mixed
+ */
+function next(&$array) {}
+
+/**
+ * @param array|object &$array
+ * @return mixed
+ */
+function prev(&$array) {}
+
+/**
+ * @param array|object &$array
+ * @return mixed
+ */
+function reset(&$array) {}
+
+/**
+ * @param array|object &$array
+ * @return mixed
+ */
+function end(&$array) {}
+
+/**
+ * @param mixed ...$var_names
+ * @return array
+ */
+function compact($var_names = null) {}
+
+/**
+ * @param array &$array
+ * @param int $flags
+ * @param string $prefix
+ * @return int
+ */
+function extract(&$array, $flags = 0, $prefix = "") {}
+
+// Math
+
+/**
+ * @param int|float $num
+ * @return int|float
+ */
+function abs($num) {}
+
+/**
+ * @param int|float $num
+ * @return float
+ */
+function ceil($num) {}
+
+/**
+ * @param int|float $num
+ * @return float
+ */
+function floor($num) {}
+
+/**
+ * @param int|float $num
+ * @param int $precision
+ * @param int $mode
+ * @return float
+ */
+function round($num, $precision = 0, $mode = PHP_ROUND_HALF_UP) {}
+
+/**
+ * @param mixed $value
+ * @param mixed ...$values
+ * @return mixed
+ */
+function max($value, $values = null) {}
+
+/**
+ * @param mixed $value
+ * @param mixed ...$values
+ * @return mixed
+ */
+function min($value, $values = null) {}
+
+/**
+ * @param int|float $num
+ * @param int|float $exponent
+ * @return int|float
+ */
+function pow($num, $exponent) {}
+
+/**
+ * @param float $num
+ * @return float
+ */
+function sqrt($num) {}
+
+/**
+ * @param float $num
+ * @param float $base
+ * @return float
+ */
+function log($num, $base = M_E) {}
+
+/**
+ * @param float $num
+ * @return float
+ */
+function log10($num) {}
+
+/**
+ * @param float $num
+ * @return float
+ */
+function exp($num) {}
+
+/**
+ * @param float $num
+ * @return float
+ */
+function sin($num) {}
+
+/**
+ * @param float $num
+ * @return float
+ */
+function cos($num) {}
+
+/**
+ * @param float $num
+ * @return float
+ */
+function tan($num) {}
+
+/**
+ * @param float $y
+ * @param float $x
+ * @return float
+ */
+function atan2($y, $x) {}
+
+/** @return float */
+function pi() {}
+
+/**
+ * @param float $num1
+ * @param float $num2
+ * @return float
+ */
+function fmod($num1, $num2) {}
+
+/**
+ * @param int $num1
+ * @param int $num2
+ * @return int
+ * @since 7.0
+ */
+function intdiv($num1, $num2) {}
+
+/**
+ * @param float $num
+ * @return bool
+ */
+function is_nan($num) {}
+
+/**
+ * @param float $num
+ * @return bool
+ */
+function is_finite($num) {}
+
+/**
+ * @param float $num
+ * @return bool
+ */
+function is_infinite($num) {}
+
+/**
+ * @param int $min
+ * @param int $max
+ * @return int
+ */
+function rand($min = 0, $max = PHP_INT_MAX) {}
+
+/**
+ * @param int $min
+ * @param int $max
+ * @return int
+ */
+function mt_rand($min = 0, $max = PHP_INT_MAX) {}
+
+/**
+ * @param int $min
+ * @param int $max
+ * @return int
+ * @since 7.0
+ */
+function random_int($min, $max) {}
+
+/**
+ * @param int $seed
+ * @return void
+ */
+function mt_srand($seed = 0) {}
+
+/** @return int */
+function mt_getrandmax() {}
+
+/**
+ * @param int $num
+ * @return string
+ */
+function dechex($num) {}
+
+/**
+ * @param string $hex_string
+ * @return int|float
+ */
+function hexdec($hex_string) {}
+
+/**
+ * @param int $num
+ * @return string
+ */
+function decbin($num) {}
+
+/**
+ * @param string $binary_string
+ * @return int|float
+ */
+function bindec($binary_string) {}
+
+/**
+ * @param int $num
+ * @return string
+ */
+function decoct($num) {}
+
+/**
+ * @param string $octal_string
+ * @return int|float
+ */
+function octdec($octal_string) {}
+
+/**
+ * @param string $num
+ * @param int $from_base
+ * @param int $to_base
+ * @return string
+ */
+function base_convert($num, $from_base, $to_base) {}
+
+// Variables
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_string($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_int($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_integer($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_long($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_float($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_double($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ * @deprecated 7.4
+ * @removed 8.0
+ */
+function is_real($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_bool($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_array($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_object($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_null($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_numeric($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_scalar($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ */
+function is_resource($value) {}
+
+/**
+ * @param mixed $value
+ * @param bool $syntax_only
+ * @param string &$callable_name
+ * @return bool
+ */
+function is_callable($value, $syntax_only = false, &$callable_name = null) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ * @since 7.1
+ */
+function is_iterable($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ * @since 7.3
+ */
+function is_countable($value) {}
+
+/**
+ * @param mixed $value
+ * @param int $base
+ * @return int
+ */
+function intval($value, $base = 10) {}
+
+/**
+ * @param mixed $value
+ * @return float
+ */
+function floatval($value) {}
+
+/**
+ * @param mixed $value
+ * @return float
+ */
+function doubleval($value) {}
+
+/**
+ * @param mixed $value
+ * @return string
+ */
+function strval($value) {}
+
+/**
+ * @param mixed $value
+ * @return bool
+ * @since 5.5
+ */
+function boolval($value) {}
+
+/**
+ * @param mixed $value
+ * @return string
+ */
+function gettype($value) {}
+
+/**
+ * @param mixed $value
+ * @return string
+ * @since 8.0
+ */
+function get_debug_type($value) {}
+
+/**
+ * @param mixed &$var
+ * @param string $type
+ * @return bool
+ */
+function settype(&$var, $type) {}
+
+/**
+ * @param mixed $value
+ * @param bool $return
+ * @return string|bool
+ */
+function print_r($value, $return = false) {}
+
+/**
+ * @param mixed $value
+ * @param bool $return
+ * @return string|null
+ */
+function var_export($value, $return = false) {}
+
+/**
+ * @param mixed $value
+ * @param mixed ...$values
+ * @return void
+ */
+function var_dump($value, $values = null) {}
+
+/**
+ * @param mixed $value
+ * @return string
+ */
+function serialize($value) {}
+
+/**
+ * @param string $data
+ * @param array $options
+ * @return mixed
+ */
+function unserialize($data, $options = array()) {}
+
+// Files and streams
+
+/**
+ * @param string $filename
+ * @param string $mode
+ * @param bool $use_include_path
+ * @param resource|null $context
+ * @return resource|false
+ */
+function fopen($filename, $mode, $use_include_path = false, $context = null) {}
+
+/**
+ * @param resource $stream
+ * @return bool
+ */
+function fclose($stream) {}
+
+/**
+ * @param resource $stream
+ * @param int $length
+ * @return string|false
+ */
+function fread($stream, $length) {}
+
+/**
+ * @param resource $stream
+ * @param string $data
+ * @param int|null $length
+ * @return int|false
+ */
+function fwrite($stream, $data, $length = null) {}
+
+/**
+ * @param resource $stream
+ * @param string $data
+ * @param int|null $length
+ * @return int|false
+ */
+function fputs($stream, $data, $length = null) {}
+
+/**
+ * @param resource $stream
+ * @param int|null $length
+ * @return string|false
+ */
+function fgets($stream, $length = null) {}
+
+/**
+ * @param resource $stream
+ * @return string|false
+ */
+function fgetc($stream) {}
+
+/**
+ * @param resource $stream
+ * @param int|null $length
+ * @param string $separator
+ * @param string $enclosure
+ * @param string $escape
+ * @return array|false
+ */
+function fgetcsv($stream, $length = null, $separator = ",", $enclosure = "\"", $escape = "\\") {}
+
+/**
+ * @param resource $stream
+ * @param array $fields
+ * @param string $separator
+ * @param string $enclosure
+ * @return int|false
+ */
+function fputcsv($stream, $fields, $separator = ",", $enclosure = "\"") {}
+
+/**
+ * @param resource $stream
+ * @return bool
+ */
+function feof($stream) {}
+
+/**
+ * @param resource $stream
+ * @return bool
+ */
+function fflush($stream) {}
+
+/**
+ * @param resource $stream
+ * @param int $offset
+ * @param int $whence
+ * @return int
+ */
+function fseek($stream, $offset, $whence = SEEK_SET) {}
+
+/**
+ * @param resource $stream
+ * @return int|false
+ */
+function ftell($stream) {}
+
+/**
+ * @param resource $stream
+ * @return bool
+ */
+function rewind($stream) {}
+
+/**
+ * @param resource $stream
+ * @param int $operation
+ * @param int &$would_block
+ * @return bool
+ */
+function flock($stream, $operation, &$would_block = null) {}
+
+/**
+ * @param resource $stream
+ * @return array|false
+ */
+function fstat($stream) {}
+
+/**
+ * @param string $filename
+ * @param bool $use_include_path
+ * @param resource|null $context
+ * @param int $offset
+ * @param int|null $length
+ * @return string|false
+ */
+function file_get_contents($filename, $use_include_path = false, $context = null, $offset = 0, $length = null) {}
+
+/**
+ * @param string $filename
+ * @param mixed $data
+ * @param int $flags
+ * @param resource|null $context
+ * @return int|false
+ */
+function file_put_contents($filename, $data, $flags = 0, $context = null) {}
+
+/**
+ * @param string $filename
+ * @param int $flags
+ * @param resource|null $context
+ * @return string[]|false
+ */
+function file($filename, $flags = 0, $context = null) {}
+
+/**
+ * @param string $filename
+ * @return bool
+ */
+function file_exists($filename) {}
+
+/**
+ * @param string $filename
+ * @return bool
+ */
+function is_file($filename) {}
+
+/**
+ * @param string $filename
+ * @return bool
+ */
+function is_dir($filename) {}
+
+/**
+ * @param string $filename
+ * @return bool
+ */
+function is_link($filename) {}
+
+/**
+ * @param string $filename
+ * @return bool
+ */
+function is_readable($filename) {}
+
+/**
+ * @param string $filename
+ * @return bool
+ */
+function is_writable($filename) {}
+
+/**
+ * @param string $filename
+ * @return bool
+ */
+function is_writeable($filename) {}
+
+/**
+ * @param string $filename
+ * @return bool
+ */
+function is_executable($filename) {}
+
+/**
+ * @param string $filename
+ * @return int|false
+ */
+function filesize($filename) {}
+
+/**
+ * @param string $filename
+ * @return int|false
+ */
+function filemtime($filename) {}
+
+/**
+ * @param string $filename
+ * @return array|false
+ */
+function stat($filename) {}
+
+/**
+ * @param string $filename
+ * @param resource|null $context
+ * @return bool
+ */
+function unlink($filename, $context = null) {}
+
+/**
+ * @param string $from
+ * @param string $to
+ * @param resource|null $context
+ * @return bool
+ */
+function rename($from, $to, $context = null) {}
+
+/**
+ * @param string $from
+ * @param string $to
+ * @param resource|null $context
+ * @return bool
+ */
+function copy($from, $to, $context = null) {}
+
+/**
+ * @param string $directory
+ * @param int $permissions
+ * @param bool $recursive
+ * @param resource|null $context
+ * @return bool
+ */
+function mkdir($directory, $permissions = 0777, $recursive = false, $context = null) {}
+
+/**
+ * @param string $directory
+ * @param resource|null $context
+ * @return bool
+ */
+function rmdir($directory, $context = null) {}
+
+/**
+ * @param string $filename
+ * @param int|null $mtime
+ * @param int|null $atime
+ * @return bool
+ */
+function touch($filename, $mtime = null, $atime = null) {}
+
+/**
+ * @param string $filename
+ * @param int $permissions
+ * @return bool
+ */
+function chmod($filename, $permissions) {}
+
+/**
+ * @param string $directory
+ * @param int $sorting_order
+ * @param resource|null $context
+ * @return string[]|false
+ */
+function scandir($directory, $sorting_order = 0, $context = null) {}
+
+/**
+ * @param string $pattern
+ * @param int $flags
+ * @return string[]|false
+ */
+function glob($pattern, $flags = 0) {}
+
+/**
+ * @param string $directory
+ * @param resource|null $context
+ * @return resource|false
+ */
+function opendir($directory, $context = null) {}
+
+/**
+ * @param resource|null $dir_handle
+ * @return string|false
+ */
+function readdir($dir_handle = null) {}
+
+/**
+ * @param resource|null $dir_handle
+ * @return void
+ */
+function closedir($dir_handle = null) {}
+
+/**
+ * @param string $path
+ * @param string $suffix
+ * @return string
+ */
+function basename($path, $suffix = "") {}
+
+/**
+ * @param string $path
+ * @param int $levels
+ * @return string
+ */
+function dirname($path, $levels = 1) {}
+
+/**
+ * @param string $path
+ * @param int $flags
+ * @return string|string[]
+ */
+function pathinfo($path, $flags = 15) {}
+
+/**
+ * @param string $path
+ * @return string|false
+ */
+function realpath($path) {}
+
+/** @return string|false */
+function getcwd() {}
+
+/**
+ * @param string $directory
+ * @return bool
+ */
+function chdir($directory) {}
+
+/** @return string */
+function sys_get_temp_dir() {}
+
+/**
+ * @param string $directory
+ * @param string $prefix
+ * @return string|false
+ */
+function tempnam($directory, $prefix) {}
+
+/** @return resource|false */
+function tmpfile() {}
+
+/**
+ * @param string $filename
+ * @return int|false
+ */
+function readfile($filename, $use_include_path = false, $context = null) {}
+
+/**
+ * @param array $options
+ * @param array|null $params
+ * @return resource
+ */
+function stream_context_create($options = null, $params = null) {}
+
+/**
+ * @param resource $stream
+ * @param int|null $length
+ * @param int $offset
+ * @return string|false
+ */
+function stream_get_contents($stream, $length = null, $offset = -1) {}
+
+// Output and the environment
+
+/**
+ * @param callable|null $callback
+ * @return bool
+ */
+function ob_start($callback = null, $chunk_size = 0, $flags = 112) {}
+
+/** @return string|false */
+function ob_get_clean() {}
+
+/** @return string|false */
+function ob_get_contents() {}
+
+/** @return bool */
+function ob_end_clean() {}
+
+/** @return bool */
+function ob_end_flush() {}
+
+/** @return int */
+function ob_get_level() {}
+
+/** @return void */
+function flush() {}
+
+/**
+ * @param string $header
+ * @param bool $replace
+ * @param int $response_code
+ * @return void
+ */
+function header($header, $replace = true, $response_code = 0) {}
+
+/**
+ * @param string &$filename
+ * @param int &$line
+ * @return bool
+ */
+function headers_sent(&$filename = null, &$line = null) {}
+
+/**
+ * @param int $response_code
+ * @return int|bool
+ */
+function http_response_code($response_code = 0) {}
+
+/**
+ * @param string $name
+ * @param string $value
+ * @param int|array $expires_or_options
+ * @return bool
+ */
+function setcookie($name, $value = "", $expires_or_options = 0, $path = "", $domain = "", $secure = false, $httponly = false) {}
+
+/**
+ * @param string|null $name
+ * @param bool $local_only
+ * @return string|array|false
+ */
+function getenv($name = null, $local_only = false) {}
+
+/**
+ * @param string $assignment
+ * @return bool
+ */
+function putenv($assignment) {}
+
+/**
+ * @param string $option
+ * @return string|false
+ */
+function ini_get($option) {}
+
+/**
+ * @param string $option
+ * @param string|int|float|bool|null $value
+ * @return string|false
+ */
+function ini_set($option, $value) {}
+
+/**
+ * @param string $message
+ * @param int $message_type
+ * @param string|null $destination
+ * @param string|null $additional_headers
+ * @return bool
+ */
+function error_log($message, $message_type = 0, $destination = null, $additional_headers = null) {}
+
+/**
+ * @param int $seconds
+ * @return int
+ */
+function sleep($seconds) {}
+
+/**
+ * @param int $microseconds
+ * @return void
+ */
+function usleep($microseconds) {}
+
+/**
+ * @param int $seconds
+ * @return bool
+ */
+function set_time_limit($seconds) {}
+
+/**
+ * @param bool $as_float
+ * @return string|float
+ */
+function microtime($as_float = false) {}
+
+/**
+ * @param bool $as_number
+ * @return array|int|float
+ * @since 7.3
+ */
+function hrtime($as_number = false) {}
+
+/**
+ * @param bool $real_usage
+ * @return int
+ */
+function memory_get_usage($real_usage = false) {}
+
+/**
+ * @param bool $real_usage
+ * @return int
+ */
+function memory_get_peak_usage($real_usage = false) {}
+
+/** @return int|false */
+function getmypid() {}
+
+/** @return string */
+function php_uname($mode = "a") {}
+
+/** @return string|false */
+function php_sapi_name() {}
+
+/** @return string */
+function phpversion($extension = null) {}
+
+/**
+ * @param string $version1
+ * @param string $version2
+ * @param string|null $operator
+ * @return int|bool
+ */
+function version_compare($version1, $version2, $operator = null) {}
+
+/**
+ * @param string $command
+ * @param array &$output
+ * @param int &$result_code
+ * @return string|false
+ */
+function exec($command, &$output = null, &$result_code = null) {}
+
+/**
+ * @param string $command
+ * @param int &$result_code
+ * @return string|false
+ */
+function system($command, &$result_code = null) {}
+
+/**
+ * @param string $command
+ * @return string|false|null
+ */
+function shell_exec($command) {}
+
+/**
+ * @param string $command
+ * @param int &$result_code
+ * @return void
+ */
+function passthru($command, &$result_code = null) {}
+
+/**
+ * @param string $arg
+ * @return string
+ */
+function escapeshellarg($arg) {}
+
+/**
+ * @param string $command
+ * @return string
+ */
+function escapeshellcmd($command) {}
+
+/**
+ * @param string $hostname
+ * @return string
+ */
+function gethostbyname($hostname) {}
+
+/** @return string|false */
+function gethostname() {}
+
+/**
+ * @param string $to
+ * @param string $subject
+ * @param string $message
+ * @param array|string $additional_headers
+ * @param string $additional_params
+ * @return bool
+ */
+function mail($to, $subject, $message, $additional_headers = array(), $additional_params = "") {}
+
+/**
+ * @param mixed $value
+ * @param int $filter
+ * @param array|int $options
+ * @return mixed
+ */
+function filter_var($value, $filter = 516, $options = 0) {}
@@ -0,0 +1,300 @@
+// Command deadcode reports the code of a PHP project that cannot run: the
+// files, functions, classes, methods and constants that the code reachable
+// from the project's entry points never includes or uses, as found by the
+// deadcode package.
+//
+// Usage:
+//
+//	deadcode [flags] [path ...]
+//
+// The paths may be files or directories, which are searched recursively for
+// PHP files, and default to the current directory. The entry points are the
+// files matching the -entry patterns, which PHP runs first, such as public
+// scripts, and the classes that a framework uses without the code naming
+// them, which are those named by -class and those declared in the files
+// matching the -controllers patterns, which are entry points themselves.
+// Patterns are matched against the paths of the files as they are found,
+// and may use ** to match any number of directories.
+//
+// The flags may also be given by a JSON configuration file, read with
+// -config, whose fields are lists named like the repeatable flags:
+//
+//	{
+//		"entry": ["public/*.php"],
+//		"controllers": ["src/Controller/**/*.php"],
+//		"class": ["App\\Kernel"],
+//		"include-path": ["lib"],
+//		"allow": ["deadcode.allow"]
+//	}
+//
+// An allowlist file, read with -allow, lists the names of code that is used
+// in ways the analysis cannot see, such as by reflection, one per line, to
+// leave out of the report. Names are those reported, such as App\helper,
+// App\Model::save or lib/legacy.php, compared without regard to case, and
+// may use * to match any text. Lines starting with # are comments.
+//
+// The report is printed in the format named by -format: text, printing
+// each as file:line:column: dead kind name, json, or sarif, the Static
+// Analysis Results Interchange Format. The command exits with status 1 if
+// it finds dead code or fails to parse a file.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stephens2424/php/parser"
+	"github.com/stephens2424/php/passes/deadcode"
+	"github.com/stephens2424/php/passes/hierarchy"
+)
+
+// list is a flag that may be repeated, collecting its values.
+type list []string
+
+func (l *list) String() string { return strings.Join(*l, ",") }
+
+func (l *list) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// config holds the settings of the command, given by flags or by a
+// configuration file.
+type config struct {
+	Entry       list `json:"entry"`
+	Controllers list `json:"controllers"`
+	Class       list `json:"class"`
+	IncludePath list `json:"include-path"`
+	Allow       list `json:"allow"`
+}
+
+func main() {
+	var flags config
+	configFile := flag.String("config", "", "read settings from the JSON `file`")
+	format := flag.String("format", "text", "print the report as text, json or sarif")
+	flag.Var(&flags.Entry, "entry", "`pattern` of the files PHP runs first (repeatable)")
+	flag.Var(&flags.Controllers, "controllers", "`pattern` of the files declaring classes a framework uses (repeatable)")
+	flag.Var(&flags.Class, "class", "qualified `name` of a class a framework uses (repeatable)")
+	flag.Var(&flags.IncludePath, "include-path", "`dir` searched for included files, or a list of them separated by "+string(filepath.ListSeparator)+" (repeatable)")
+	flag.Var(&flags.Allow, "allow", "allowlist `file` of code to leave out of the report (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] [path ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(2)
+	}
+	if *configFile != "" {
+		if err := readConfig(*configFile, &flags); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	if len(flags.Entry) == 0 && len(flags.Controllers) == 0 && len(flags.Class) == 0 {
+		fmt.Fprintln(os.Stderr, "no entry points: use -entry, -controllers, -class or -config")
+		os.Exit(2)
+	}
+	allow, err := readAllowlists(flags.Allow)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	p := parser.NewParser()
+	failed := false
+	var files []string
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !strings.HasSuffix(path, ".php") {
+				return nil
+			}
+			path = filepath.Clean(path)
+			src, err := ioutil.ReadFile(path)
+			if err == nil {
+				_, err = p.Parse(path, string(src))
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+
+	options := deadcode.Options{
+		EntryPoints: matching(files, flags.Entry),
+		Classes:     flags.Class,
+	}
+	for _, dirs := range flags.IncludePath {
+		options.IncludePath = append(options.IncludePath, filepath.SplitList(dirs)...)
+	}
+	if controllers := matching(files, flags.Controllers); len(controllers) > 0 {
+		options.EntryPoints = append(options.EntryPoints, controllers...)
+		in := map[string]bool{}
+		for _, path := range controllers {
+			in[path] = true
+		}
+		for _, t := range hierarchy.New(p.FileSet).Types() {
+			if in[t.File] && t.Kind == hierarchy.Class {
+				options.Classes = append(options.Classes, t.Name)
+			}
+		}
+	}
+
+	result := deadcode.Analyze(p.FileSet, options)
+	for _, i := range result.Unresolved {
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: include of unknown path\n", i.Path, i.Span.Begin.Line, i.Span.Begin.Column)
+	}
+
+	findings := report(result, allow)
+	switch *format {
+	case "text":
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	case "json":
+		printJSON(findings)
+	case "sarif":
+		printJSON(sarif(findings))
+	}
+	if failed || len(findings) > 0 {
+		os.Exit(1)
+	}
+}
+
+// readConfig reads the configuration file path, adding its settings to c.
+func readConfig(path string, c *config) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file config
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	c.Entry = append(c.Entry, file.Entry...)
+	c.Controllers = append(c.Controllers, file.Controllers...)
+	c.Class = append(c.Class, file.Class...)
+	c.IncludePath = append(c.IncludePath, file.IncludePath...)
+	c.Allow = append(c.Allow, file.Allow...)
+	return nil
+}
+
+// readAllowlists reads the patterns of the allowlist files paths.
+func readAllowlists(paths []string) ([]string, error) {
+	var patterns []string
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			line := strings.TrimSpace(s.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				patterns = append(patterns, strings.ToLower(line))
+			}
+		}
+		f.Close()
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return patterns, nil
+}
+
+// allowed reports whether name matches one of the allowlist patterns.
+func allowed(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if wildcard(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcard reports whether s matches pattern, in which * matches any text.
+func wildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+// matching returns the files that match any of patterns, in order.
+func matching(files []string, patterns []string) []string {
+	var matched []string
+	for _, path := range files {
+		for _, pattern := range patterns {
+			if matchPath(filepath.ToSlash(filepath.Clean(pattern)), filepath.ToSlash(path)) {
+				matched = append(matched, path)
+				break
+			}
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// matchPath reports whether path matches pattern, whose elements are
+// matched by filepath.Match, except for **, which matches any number of
+// elements.
+func matchPath(pattern, path string) bool {
+	return matchElements(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+func matchElements(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchElements(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
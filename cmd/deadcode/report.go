@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/passes/deadcode"
+)
+
+// finding is a piece of dead code, as reported.
+type finding struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+
+	span ast.Span
+}
+
+func (f finding) String() string {
+	if f.Line == 0 {
+		return fmt.Sprintf("%s: dead %s", f.Path, f.Kind)
+	}
+	return fmt.Sprintf("%s:%d:%d: dead %s %s", f.Path, f.Line, f.Column, f.Kind, f.Name)
+}
+
+// report returns the findings of result, leaving out those allowed by the
+// allowlist patterns allow, ordered by kind and then position.
+func report(result *deadcode.Result, allow []string) []finding {
+	findings := []finding{}
+	for _, path := range result.Files {
+		if !allowed(allow, path) {
+			findings = append(findings, finding{Kind: "file", Name: path, Path: path})
+		}
+	}
+	add := func(kind string, ds []deadcode.Declaration) {
+		for _, d := range ds {
+			if allowed(allow, d.Name) {
+				continue
+			}
+			findings = append(findings, finding{
+				Kind:   kind,
+				Name:   d.Name,
+				Path:   d.Path,
+				Line:   d.Span.Begin.Line,
+				Column: d.Span.Begin.Column,
+				span:   d.Span,
+			})
+		}
+	}
+	add("function", result.Functions)
+	add("class", result.Classes)
+	add("method", result.Methods)
+	add("constant", result.Constants)
+	return findings
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// The types of a SARIF 2.1.0 log, as far as the report uses them.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+		EndLine     int `json:"endLine"`
+		EndColumn   int `json:"endColumn"`
+	}
+)
+
+// sarifKinds are the kinds of findings, each reported by its own rule.
+var sarifKinds = []string{"file", "function", "class", "method", "constant"}
+
+// sarif returns the findings as a SARIF log.
+func sarif(findings []finding) sarifLog {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "deadcode"}},
+		Results: []sarifResult{},
+	}
+	for _, kind := range sarifKinds {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               "dead-" + kind,
+			ShortDescription: sarifMessage{Text: "dead " + kind},
+		})
+	}
+	for _, f := range findings {
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: f.Path}}
+		if f.Line != 0 {
+			location.Region = &sarifRegion{
+				StartLine:   f.span.Begin.Line,
+				StartColumn: f.span.Begin.Column,
+				EndLine:     f.span.End.Line,
+				EndColumn:   f.span.End.Column,
+			}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    "dead-" + f.Kind,
+			Level:     "warning",
+			Message:   sarifMessage{Text: fmt.Sprintf("%s %s is never used", f.Kind, f.Name)},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
@@ -12,24 +12,41 @@ import (
 )
 
 func main() {
+	check := flag.Bool("check", false, "instead of printing, check that printing each file preserves its syntax tree and is stable")
+	minify := flag.Bool("minify", false, "print without comments or unnecessary whitespace")
+	renameLocals := flag.Bool("rename-locals", false, "with -minify, also shorten the names of local variables")
 	flag.Parse()
-	for _, arg := range flag.Args() {
-
-		fmt.Println(arg)
-		fmt.Println()
 
+	failed := false
+	for _, arg := range flag.Args() {
 		src, err := ioutil.ReadFile(arg)
 		if err != nil {
 			fmt.Println(err)
 			continue
 		}
+
+		if *check {
+			if err := printer.CheckRoundTrip(arg, string(src)); err != nil {
+				fmt.Println(err)
+				failed = true
+			}
+			continue
+		}
+
 		p := printer.NewPrinter(os.Stdout)
-		file, err := parser.NewParser().Parse("test.php", string(src))
+		if *minify {
+			p = printer.NewMinifier(os.Stdout, *renameLocals)
+		} else {
+			fmt.Println(arg)
+			fmt.Println()
+		}
+		file, err := parser.NewParser().Parse(arg, string(src))
 		if err != nil {
 			log.Fatal(err)
 		}
-		for _, node := range file.Nodes {
-			p.PrintNode(node)
-		}
+		p.PrintFile(file)
+	}
+	if failed {
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,18 @@
+// Command php-lsp is a Language Server Protocol server for PHP, as
+// implemented by the lsp package. It communicates with the editor over
+// standard input and output.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/stephens2424/php/lsp"
+)
+
+func main() {
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,194 @@
+// Package edit applies textual edits to source code and describes changes
+// as unified diffs.
+package edit
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Edit replaces the bytes from Begin up to End of a source with Text.
+type Edit struct {
+	Begin, End int
+	Text       string
+}
+
+// Apply returns src with edits applied. The edits may be in any order, but
+// must not overlap.
+func Apply(src string, edits []Edit) (string, error) {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Begin < sorted[j].Begin })
+
+	buf := &bytes.Buffer{}
+	last := 0
+	for _, e := range sorted {
+		if e.Begin < last || e.End < e.Begin || e.End > len(src) {
+			return "", fmt.Errorf("edit: invalid or overlapping edit of %d:%d", e.Begin, e.End)
+		}
+		buf.WriteString(src[last:e.Begin])
+		buf.WriteString(e.Text)
+		last = e.End
+	}
+	buf.WriteString(src[last:])
+	return buf.String(), nil
+}
+
+// contextLines is the number of unchanged lines shown around changes.
+const contextLines = 3
+
+// Diff returns a unified diff from a to b, which are the old and new
+// contents of the file name, or "" if they are the same.
+func Diff(name, a, b string) string {
+	if a == b {
+		return ""
+	}
+	x, y := splitLines(a), splitLines(b)
+	ops := diffLines(x, y)
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "--- a/%s\n+++ b/%s\n", name, name)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// extend the hunk over changes separated by little enough context
+		// that their hunks would overlap
+		last := i
+		for j := i + 1; j < len(ops) && j <= last+2*contextLines+1; j++ {
+			if ops[j].kind != ' ' {
+				last = j
+			}
+		}
+		begin, end := i-contextLines, last+contextLines+1
+		if begin < 0 {
+			begin = 0
+		}
+		if end > len(ops) {
+			end = len(ops)
+		}
+		writeHunk(buf, ops[begin:end])
+		i = end
+	}
+	return buf.String()
+}
+
+func writeHunk(buf *bytes.Buffer, ops []op) {
+	oldStart, newStart := ops[0].x+1, ops[0].y+1
+	oldLines, newLines := 0, 0
+	for _, o := range ops {
+		if o.kind != '+' {
+			oldLines++
+		}
+		if o.kind != '-' {
+			newLines++
+		}
+	}
+	if oldLines == 0 {
+		oldStart--
+	}
+	if newLines == 0 {
+		newStart--
+	}
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLines, newStart, newLines)
+	for _, o := range ops {
+		buf.WriteByte(o.kind)
+		buf.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits s after each newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// op is a line of a diff: kept (' '), removed ('-') or added ('+'). x and y
+// are the indexes of the line in the old and new lines, or of the next line
+// when it is absent from one of them.
+type op struct {
+	kind byte
+	x, y int
+	line string
+}
+
+// diffLines computes a shortest edit script from x to y using Myers'
+// algorithm.
+func diffLines(x, y []string) []op {
+	n, m := len(x), len(y)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+		for k := -d; k <= d; k += 2 {
+			var i int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				i = v[offset+k+1]
+			} else {
+				i = v[offset+k-1] + 1
+			}
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i++
+				j++
+			}
+			v[offset+k] = i
+			if i >= n && j >= m {
+				break search
+			}
+		}
+	}
+
+	// walk back through the trace to recover the edits
+	var ops []op
+	i, j := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := i - j
+		var prevK int
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevI := v[offset+prevK]
+		prevJ := prevI - prevK
+		for i > prevI && j > prevJ {
+			i--
+			j--
+			ops = append(ops, op{' ', i, j, x[i]})
+		}
+		if d == 0 {
+			break
+		}
+		if i == prevI {
+			j--
+			ops = append(ops, op{'+', i, j, y[j]})
+		} else {
+			i--
+			ops = append(ops, op{'-', i, j, x[i]})
+		}
+	}
+	for l, r := 0, len(ops)-1; l < r; l, r = l+1, r-1 {
+		ops[l], ops[r] = ops[r], ops[l]
+	}
+	return ops
+}
@@ -0,0 +1,69 @@
+package edit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	src := "mysql_query($a); mysql_query($b);"
+	out, err := Apply(src, []Edit{
+		{Begin: 17, End: 28, Text: "$db->query"},
+		{Begin: 0, End: 11, Text: "$db->query"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "$db->query($a); $db->query($b);"; out != expected {
+		t.Errorf("got %q, expected %q", out, expected)
+	}
+
+	if _, err := Apply(src, []Edit{{Begin: 0, End: 5}, {Begin: 4, End: 6}}); err == nil {
+		t.Error("expected an error for overlapping edits")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var a, b []string
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i)
+		a = append(a, line)
+		switch i {
+		case 2:
+			b = append(b, "changed")
+		case 15:
+		default:
+			b = append(b, line)
+		}
+	}
+	b = append(b, "added")
+
+	expected := `--- a/f.php
++++ b/f.php
+@@ -1,5 +1,5 @@
+ x
+-xx
++changed
+ xxx
+ xxxx
+ xxxxx
+@@ -12,9 +12,9 @@
+ xxxxxxxxxxxx
+ xxxxxxxxxxxxx
+ xxxxxxxxxxxxxx
+-xxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxxxx
++added
+`
+	got := Diff("f.php", strings.Join(a, "\n")+"\n", strings.Join(b, "\n")+"\n")
+	if got != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+	if Diff("f.php", "same\n", "same\n") != "" {
+		t.Error("expected no diff between identical sources")
+	}
+}
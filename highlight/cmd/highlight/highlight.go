@@ -0,0 +1,76 @@
+// Command highlight prints PHP files with syntax highlighting, either
+// colored for a terminal or as HTML.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/stephens2424/php/highlight"
+	"github.com/stephens2424/php/lexer"
+	"github.com/stephens2424/php/token"
+)
+
+func main() {
+	format := flag.String("format", "ansi", "output format, ansi or html")
+	page := flag.Bool("page", false, "with -format html, write a complete page including a stylesheet")
+	flag.Parse()
+
+	var render func(io.Writer, token.Stream) error
+	switch *format {
+	case "ansi":
+		render = highlight.ANSI
+	case "html":
+		render = highlight.HTML
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(2)
+	}
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	*page = *page && *format == "html"
+	if *page {
+		fmt.Printf("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<style>\n%s</style>\n</head>\n<body>\n", highlight.Stylesheet)
+	}
+
+	failed := false
+	for _, file := range files {
+		var src []byte
+		var err error
+		if file == "-" {
+			src, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			src, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+
+		if *format == "html" {
+			fmt.Print("<pre>")
+		}
+		err = render(os.Stdout, lexer.NewLexer(string(src)))
+		if *format == "html" {
+			fmt.Print("</pre>\n")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			failed = true
+		}
+	}
+	if *page {
+		fmt.Print("</body>\n</html>\n")
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
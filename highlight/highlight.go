@@ -0,0 +1,157 @@
+// Package highlight renders PHP source as syntax-highlighted HTML or as text
+// colored with ANSI escape codes, according to the tokens read by the lexer.
+package highlight
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+
+	"github.com/stephens2424/php/token"
+)
+
+// The classes of items, as returned by Class.
+const (
+	Keyword     = "keyword"
+	Operator    = "operator"
+	Punctuation = "punctuation"
+	Identifier  = "identifier"
+	Variable    = "variable"
+	String      = "string"
+	Number      = "number"
+	Constant    = "constant"
+	Comment     = "comment"
+	Tag         = "tag"
+	HTMLText    = "html"
+)
+
+// ClassPrefix is prepended to the class of each item to form its CSS class.
+const ClassPrefix = "php-"
+
+// Stylesheet is a default stylesheet for the output of HTML.
+const Stylesheet = `.php-keyword { color: #a626a4; font-weight: bold; }
+.php-operator { color: #0184bc; }
+.php-punctuation { color: #383a42; }
+.php-identifier { color: #4078f2; }
+.php-variable { color: #e45649; }
+.php-string { color: #50a14f; }
+.php-number { color: #986801; }
+.php-constant { color: #986801; }
+.php-comment { color: #a0a1a7; font-style: italic; }
+.php-tag { color: #c18401; }
+.php-html { color: #696c77; }
+`
+
+// ANSIColors maps each class to the escape code ANSI writes before items of
+// that class. Items of classes missing from the map are not colored.
+var ANSIColors = map[string]string{
+	Keyword:    "\x1b[1;35m",
+	Operator:   "\x1b[36m",
+	Identifier: "\x1b[34m",
+	Variable:   "\x1b[31m",
+	String:     "\x1b[32m",
+	Number:     "\x1b[33m",
+	Constant:   "\x1b[33m",
+	Comment:    "\x1b[90m",
+	Tag:        "\x1b[1;33m",
+	HTMLText:   "\x1b[37m",
+}
+
+const ansiReset = "\x1b[0m"
+
+// Class returns the class used to highlight an item of type t, or "" if it
+// is not highlighted, as with whitespace. The identifier naming a variable
+// is classified separately by the writers, since that depends on the
+// preceding item.
+func Class(t token.Token) string {
+	switch t {
+	case token.HTML:
+		return HTMLText
+	case token.PHPBegin, token.PHPEnd:
+		return Tag
+	case token.StringLiteral, token.ShellCommand:
+		return String
+	case token.NumberLiteral:
+		return Number
+	case token.BooleanLiteral, token.Null:
+		return Constant
+	case token.VariableOperator:
+		return Variable
+	case token.EOF, token.Error, token.Space:
+		return ""
+	}
+
+	switch typ := t.Type(); {
+	case typ.Is(token.CommentType):
+		return Comment
+	case typ.Is(token.KeywordType):
+		return Keyword
+	case typ.Is(token.OperatorType):
+		return Operator
+	case typ.Is(token.MarkerType):
+		return Punctuation
+	case typ.Is(token.IdentifierType):
+		return Identifier
+	}
+	return ""
+}
+
+// HTML writes the source text of the items in s to w, HTML-escaped, with
+// each highlighted item wrapped in a span of the CSS class ClassPrefix
+// followed by its class. If the lexer reported errors, the first one is
+// returned after everything else is written.
+func HTML(w io.Writer, s token.Stream) error {
+	return each(s, func(class, text string) error {
+		text = html.EscapeString(text)
+		var err error
+		if class == "" {
+			_, err = io.WriteString(w, text)
+		} else {
+			_, err = fmt.Fprintf(w, `<span class="%s%s">%s</span>`, ClassPrefix, class, text)
+		}
+		return err
+	})
+}
+
+// ANSI writes the source text of the items in s to w, colored according to
+// ANSIColors. Errors are reported as by HTML.
+func ANSI(w io.Writer, s token.Stream) error {
+	return each(s, func(class, text string) error {
+		var err error
+		if color, ok := ANSIColors[class]; ok {
+			_, err = io.WriteString(w, color+text+ansiReset)
+		} else {
+			_, err = io.WriteString(w, text)
+		}
+		return err
+	})
+}
+
+// each calls f with the class and text of each item in s until the end of
+// the stream.
+func each(s token.Stream, f func(class, text string) error) error {
+	var lexErr error
+	previous := token.EOF
+	for item := s.Next(); item.Typ != token.EOF; item = s.Next() {
+		if item.Typ == token.Error {
+			// errors describe the input rather than being part of it
+			if lexErr == nil {
+				lexErr = errors.New(item.Val)
+			}
+			continue
+		}
+
+		class := Class(item.Typ)
+		if previous == token.VariableOperator && item.Typ != token.Space {
+			// the name of a variable may be lexed as any identifier or keyword
+			class = Variable
+		}
+		if err := f(class, item.Val); err != nil {
+			s.Abort()
+			return err
+		}
+		previous = item.Typ
+	}
+	return lexErr
+}
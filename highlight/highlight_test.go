@@ -0,0 +1,54 @@
+package highlight
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"testing"
+
+	"github.com/stephens2424/php/lexer"
+)
+
+func TestHTML(t *testing.T) {
+	src := `<p><?php
+// greet
+$name = "a<b";
+if ($name) { echo 1.5; }
+?></p>`
+	buf := &bytes.Buffer{}
+	if err := HTML(buf, lexer.NewLexer(src)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, span := range []string{
+		`<span class="php-html">&lt;p&gt;</span>`,
+		`<span class="php-tag">&lt;?php</span>`,
+		`<span class="php-comment">// greet`,
+		`<span class="php-variable">$</span><span class="php-variable">name</span>`,
+		`<span class="php-string">&#34;a&lt;b&#34;</span>`,
+		`<span class="php-keyword">if</span>`,
+		`<span class="php-number">1.5</span>`,
+		`<span class="php-punctuation">;</span>`,
+	} {
+		if !bytes.Contains(buf.Bytes(), []byte(span)) {
+			t.Errorf("output does not contain %s:\n%s", span, out)
+		}
+	}
+
+	text := html.UnescapeString(regexp.MustCompile(`</?span[^>]*>`).ReplaceAllString(out, ""))
+	if text != src {
+		t.Errorf("output text does not match the source:\n%s", text)
+	}
+}
+
+func TestANSI(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := ANSI(buf, lexer.NewLexer(`<?php $a = true;`)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "\x1b[1;33m<?php\x1b[0m \x1b[31m$\x1b[0m\x1b[31ma\x1b[0m \x1b[36m=\x1b[0m \x1b[33mtrue\x1b[0m;"
+	if buf.String() != expected {
+		t.Errorf("got %q, expected %q", buf.String(), expected)
+	}
+}
@@ -97,7 +97,8 @@ func (l *lexer) emit(t token.Token) {
 }
 
 func (l *lexer) currentLocation() token.Position {
-	return token.Position{Position: l.start, Line: l.line, File: l.file}
+	column := l.start - strings.LastIndexByte(l.input[:l.start], '\n')
+	return token.Position{Position: l.start, Line: l.line, Column: column, File: l.file}
 }
 
 // Next returns the next token from the input and advances the lexer by a token.
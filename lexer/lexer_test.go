@@ -156,3 +156,29 @@ func TestPHPLexer(t *testing.T) {
 
 	i = assertNext(t, l, token.EOF)
 }
+
+func TestShortEchoTag(t *testing.T) {
+	l := token.Subset(NewLexer(`<b><?= $x ?></b>`), token.Significant)
+
+	i := assertNext(t, l, token.HTML)
+	assertItem(t, i, "<b>")
+	i = assertNext(t, l, token.PHPBegin)
+	assertItem(t, i, "<?")
+	i = assertNext(t, l, token.Echo)
+	assertItem(t, i, "=")
+	i = assertNext(t, l, token.VariableOperator)
+	i = assertNext(t, l, token.Identifier)
+	i = assertNext(t, l, token.PHPEnd)
+	i = assertNext(t, l, token.HTML)
+	assertItem(t, i, "</b>")
+	i = assertNext(t, l, token.EOF)
+}
+
+func TestKeywordEndingInSemicolon(t *testing.T) {
+	l := token.Subset(NewLexer(`<?php endif;if`), token.Significant)
+
+	assertNext(t, l, token.PHPBegin)
+	i := assertNext(t, l, token.EndIf)
+	assertItem(t, i, "endif;")
+	assertNext(t, l, token.If)
+}
@@ -13,6 +13,7 @@ var longestToken = 0
 
 const shortPHPBegin = "<?"
 const longPHPBegin = "<?php"
+const shortEchoBegin = "<?="
 const phpEnd = "?>"
 
 const eof = -1
@@ -47,6 +48,15 @@ func lexHTML(l *lexer) stateFn {
 }
 
 func lexPHPBegin(l *lexer) stateFn {
+	if strings.HasPrefix(l.input[l.pos:], shortEchoBegin) {
+		// <?= is lexed as the opening tag followed by an echo whose value is
+		// "=", distinguishing it from the echo keyword
+		l.pos += len(shortPHPBegin)
+		l.emit(token.PHPBegin)
+		l.pos += len(shortEchoBegin) - len(shortPHPBegin)
+		l.emit(token.Echo)
+		return lexPHP
+	}
 	if strings.HasPrefix(l.input[l.pos:], longPHPBegin) {
 		l.pos += len(longPHPBegin)
 	}
@@ -165,7 +175,8 @@ func hasKeyword(l *lexer) (token.Token, bool) {
 
 		// we think we're at a token of some kind
 		l.pos += len(tokenString)
-		if l.accept(alphabet + underscore + digits) {
+		wordEnd := strings.ContainsAny(tokenString[len(tokenString)-1:], alphabet+underscore+digits)
+		if wordEnd && l.accept(alphabet+underscore+digits) {
 			// but if the keyword actually continues on
 			// unexpectedly, roll back because this is
 			// actually an identifier
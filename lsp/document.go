@@ -0,0 +1,154 @@
+package lsp
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/parser"
+)
+
+// document is a PHP file known to the server, either open in the client or
+// read from the workspace.
+type document struct {
+	uri  string
+	path string
+	text string
+	open bool
+
+	file *ast.File
+	errs parser.ParseErrorList
+}
+
+// parse parses the text of d.
+func (d *document) parse() {
+	d.file, d.errs = nil, nil
+	file, err := parser.NewParser().Parse(d.path, d.text)
+	d.file = file
+	if list, ok := err.(parser.ParseErrorList); ok {
+		d.errs = list
+	}
+}
+
+// offset returns the byte offset in d of pos, which is clamped to the end
+// of its line and of the document.
+func (d *document) offset(pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(d.text[offset:], '\n')
+		if i < 0 {
+			return len(d.text)
+		}
+		offset += i + 1
+	}
+	for units := 0; units < pos.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+// position returns the position in d of the byte offset.
+func (d *document) position(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	before := d.text[:offset]
+	line := strings.Count(before, "\n")
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return Position{Line: line, Character: len(utf16.Encode([]rune(before[lineStart:])))}
+}
+
+// span returns the range of d covered by span.
+func (d *document) span(span ast.Span) Range {
+	return Range{Start: d.position(span.Begin.Position), End: d.position(span.End.Position)}
+}
+
+// lineColumn returns the 1-based line and byte column of pos, as they are
+// counted in token positions.
+func (d *document) lineColumn(pos Position) (line, column int) {
+	offset := d.offset(pos)
+	lineStart := strings.LastIndexByte(d.text[:offset], '\n') + 1
+	return pos.Line + 1, offset - lineStart + 1
+}
+
+// change applies a change sent by the client.
+func (d *document) change(c TextDocumentContentChangeEvent) {
+	if c.Range == nil {
+		d.text = c.Text
+		return
+	}
+	begin, end := d.offset(c.Range.Start), d.offset(c.Range.End)
+	if end < begin {
+		begin, end = end, begin
+	}
+	d.text = d.text[:begin] + c.Text + d.text[end:]
+}
+
+// diagnostics returns the parse errors of d as diagnostics.
+func (d *document) diagnostics() []Diagnostic {
+	diags := make([]Diagnostic, 0, len(d.errs))
+	for _, err := range d.errs {
+		line, column := err.Line, err.Column
+		if line < 1 {
+			line = 1
+		}
+		if column < 1 {
+			column = 1
+		}
+		pos := d.position(d.byteOffset(line, column))
+		diags = append(diags, Diagnostic{
+			Range:    Range{Start: pos, End: pos},
+			Severity: SeverityError,
+			Source:   "php",
+			Message:  err.Message(),
+		})
+	}
+	return diags
+}
+
+// byteOffset returns the offset of a 1-based line and byte column.
+func (d *document) byteOffset(line, column int) int {
+	offset := 0
+	for ; line > 1; line-- {
+		i := strings.IndexByte(d.text[offset:], '\n')
+		if i < 0 {
+			return len(d.text)
+		}
+		offset += i + 1
+	}
+	end := strings.IndexByte(d.text[offset:], '\n')
+	if end < 0 {
+		end = len(d.text) - offset
+	}
+	if column-1 > end {
+		column = end + 1
+	}
+	return offset + column - 1
+}
+
+// pathToURI returns the file URI of path.
+func pathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// uriToPath returns the path of a file URI.
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("lsp: unsupported URI %s", uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
@@ -0,0 +1,313 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClient is an in-process client of a Server, to which it is connected
+// by pipes.
+type fakeClient struct {
+	t    *testing.T
+	conn *conn
+	id   int
+
+	responses     chan *message
+	notifications chan *message
+	done          chan error
+}
+
+func newFakeClient(t *testing.T) *fakeClient {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &fakeClient{
+		t:             t,
+		conn:          newConn(clientIn, clientOut),
+		responses:     make(chan *message, 16),
+		notifications: make(chan *message, 16),
+		done:          make(chan error, 1),
+	}
+	go func() {
+		c.done <- NewServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+	}()
+	go func() {
+		for {
+			msg, err := c.conn.read()
+			if err != nil {
+				close(c.responses)
+				return
+			}
+			if msg.Method != "" {
+				c.notifications <- msg
+			} else {
+				c.responses <- msg
+			}
+		}
+	}()
+	return c
+}
+
+// call sends a request and decodes the result of its response into result.
+func (c *fakeClient) call(method string, params, result interface{}) *ResponseError {
+	c.t.Helper()
+	c.id++
+	id := json.RawMessage(strconv.Itoa(c.id))
+	raw, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.conn.write(&message{ID: &id, Method: method, Params: raw}); err != nil {
+		c.t.Fatal(err)
+	}
+
+	select {
+	case resp, ok := <-c.responses:
+		if !ok {
+			c.t.Fatalf("%s: connection closed", method)
+		}
+		if string(*resp.ID) != string(id) {
+			c.t.Fatalf("%s: response to request %s, expected %s", method, *resp.ID, id)
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+	case <-time.After(10 * time.Second):
+		c.t.Fatalf("%s: no response", method)
+	}
+	return nil
+}
+
+func (c *fakeClient) notify(method string, params interface{}) {
+	c.t.Helper()
+	raw, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.conn.write(&message{Method: method, Params: raw}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// diagnostics waits for the next diagnostics published by the server.
+func (c *fakeClient) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+	select {
+	case msg := <-c.notifications:
+		if msg.Method != "textDocument/publishDiagnostics" {
+			c.t.Fatalf("unexpected notification %s", msg.Method)
+		}
+		var p PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			c.t.Fatal(err)
+		}
+		return p
+	case <-time.After(10 * time.Second):
+		c.t.Fatal("no diagnostics")
+	}
+	return PublishDiagnosticsParams{}
+}
+
+func TestReadNegativeLength(t *testing.T) {
+	c := newConn(strings.NewReader("Content-Length: -1\r\n\r\n"), ioutil.Discard)
+	_, err := c.read()
+	if rerr, ok := err.(*ResponseError); !ok || rerr.Code != codeParseError {
+		t.Errorf("expected a parse error for a negative Content-Length, got %v", err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	root, err := ioutil.TempDir("", "php-lsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	lib := `<?php
+function greet($name, $greeting = "Hello") {
+	return $greeting . ", " . $name;
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(root, "lib.php"), []byte(lib), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newFakeClient(t)
+	var init InitializeResult
+	if err := c.call("initialize", InitializeParams{RootURI: pathToURI(root)}, &init); err != nil {
+		t.Fatal(err)
+	}
+	if init.Capabilities.TextDocumentSync.Change != SyncIncremental {
+		t.Errorf("document sync is %d, expected incremental", init.Capabilities.TextDocumentSync.Change)
+	}
+	c.notify("initialized", struct{}{})
+
+	uri := pathToURI(filepath.Join(root, "main.php"))
+	doc := TextDocumentIdentifier{URI: uri}
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+		URI:        uri,
+		LanguageID: "php",
+		Version:    1,
+		Text:       "<?php\nclass Greeter {\n\tfunction run() {\n\t\t$n = 1\n\t\techo greet($n);\n\t}\n}\n",
+	}})
+	diags := c.diagnostics()
+	if diags.URI != uri || len(diags.Diagnostics) == 0 {
+		t.Fatalf("expected diagnostics of the missing semicolon, got %+v", diags)
+	}
+	if line := diags.Diagnostics[0].Range.Start.Line; line != 4 {
+		t.Errorf("diagnostic on line %d, expected 4", line)
+	}
+
+	// insert the semicolon, and change the value of $n
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Range: &Range{Start: Position{3, 8}, End: Position{3, 8}}, Text: ";"},
+			{Range: &Range{Start: Position{3, 7}, End: Position{3, 8}}, Text: `"ö"`},
+		},
+	})
+	if diags := c.diagnostics(); len(diags.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics %+v", diags.Diagnostics)
+	}
+
+	var syms []DocumentSymbol
+	if err := c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: doc}, &syms); err != nil {
+		t.Fatal(err)
+	}
+	if len(syms) != 1 || syms[0].Name != "Greeter" || len(syms[0].Children) != 1 || syms[0].Children[0].Name != "run" {
+		t.Fatalf("unexpected symbols %+v", syms)
+	}
+	if r := syms[0].Children[0].SelectionRange; r.Start != (Position{2, 10}) || r.End != (Position{2, 13}) {
+		t.Errorf("method name at %+v", r)
+	}
+
+	var locs []Location
+	if err := c.call("textDocument/definition", TextDocumentPositionParams{TextDocument: doc, Position: Position{4, 9}}, &locs); err != nil {
+		t.Fatal(err)
+	}
+	if len(locs) != 1 || locs[0].URI != pathToURI(filepath.Join(root, "lib.php")) || locs[0].Range.Start != (Position{1, 9}) {
+		t.Fatalf("unexpected definition %+v", locs)
+	}
+
+	var hover Hover
+	if err := c.call("textDocument/hover", TextDocumentPositionParams{TextDocument: doc, Position: Position{4, 14}}, &hover); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hover.Contents.Value, "string $n") {
+		t.Errorf("hover of $n is %q", hover.Contents.Value)
+	}
+	if err := c.call("textDocument/hover", TextDocumentPositionParams{TextDocument: doc, Position: Position{4, 10}}, &hover); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hover.Contents.Value, `function greet($name, $greeting = "Hello")`) {
+		t.Errorf("hover of greet is %q", hover.Contents.Value)
+	}
+
+	var edits []TextEdit
+	if err := c.call("textDocument/formatting", DocumentFormattingParams{TextDocument: doc}, &edits); err != nil {
+		t.Fatal(err)
+	}
+	expected := "<?php\nclass Greeter {\n\tpublic function run() {\n\t\t$n = \"ö\";\n\t\techo greet($n);\n\t}\n}\n"
+	if len(edits) != 1 || edits[0].NewText != expected || edits[0].Range.End != (Position{7, 0}) {
+		t.Errorf("unexpected formatting %+v", edits)
+	}
+
+	notes := pathToURI(filepath.Join(root, "notes.php"))
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+		URI:        notes,
+		LanguageID: "php",
+		Version:    1,
+		Text:       "<?php\n// greet them\n$g = 1;\n$g = new Greeter;   # the greeter\n$g->run();\n",
+	}})
+	c.diagnostics()
+	if err := c.call("textDocument/formatting", DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: notes}}, &edits); err != nil {
+		t.Fatal(err)
+	}
+	expected = "<?php\n// greet them\n$g = 1;\n$g = new Greeter(); # the greeter\n$g->run();\n"
+	if len(edits) != 1 || edits[0].NewText != expected {
+		t.Errorf("unexpected formatting of comments %+v", edits)
+	}
+	for i, text := range []string{
+		"<?php\nfunction f($a /* inline */, $b) { // trailing\n\treturn $a;\n}\n",
+		"<?php\n$x = [1, // one\n 2];\n",
+		"<?php\n$y = $x + /* mid */ $a;\n",
+	} {
+		misplaced := pathToURI(filepath.Join(root, "misplaced"+strconv.Itoa(i)+".php"))
+		c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+			URI:        misplaced,
+			LanguageID: "php",
+			Version:    1,
+			Text:       text,
+		}})
+		c.diagnostics()
+		if err := c.call("textDocument/formatting", DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: misplaced}}, &edits); err != nil {
+			t.Fatal(err)
+		}
+		if len(edits) != 0 {
+			t.Errorf("formatting moved the comment of %q: %+v", text, edits)
+		}
+	}
+	if err := c.call("textDocument/hover", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: notes}, Position: Position{4, 1}}, &hover); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hover.Contents.Value, "\nGreeter $g") {
+		t.Errorf("hover of $g is %q", hover.Contents.Value)
+	}
+
+	var actions []CodeAction
+	selection := Range{Start: Position{3, 2}, End: Position{3, 11}}
+	if err := c.call("textDocument/codeAction", CodeActionParams{TextDocument: doc, Range: selection}, &actions); err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 2 || actions[0].Title != "Extract to extracted()" || actions[1].Title != "Inline variable" {
+		t.Fatalf("unexpected code actions %+v", actions)
+	}
+	inline := actions[1].Edit.Changes[uri]
+	if len(inline) != 2 || inline[1].NewText != `"ö"` || inline[1].Range.Start != (Position{4, 13}) {
+		t.Errorf("unexpected edits inlining $n %+v", inline)
+	}
+
+	taken := pathToURI(filepath.Join(root, "taken.php"))
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+		URI:        taken,
+		LanguageID: "php",
+		Version:    1,
+		Text:       "<?php\nfunction extracted() {}\nfunction h() {\n\t$a = 1;\n\techo $a;\n}\n",
+	}})
+	c.diagnostics()
+	selection = Range{Start: Position{3, 1}, End: Position{4, 9}}
+	if err := c.call("textDocument/codeAction", CodeActionParams{TextDocument: TextDocumentIdentifier{URI: taken}, Range: selection}, &actions); err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) == 0 || actions[0].Title != "Extract to extracted2()" {
+		t.Errorf("unexpected code actions extracting beside extracted() %+v", actions)
+	}
+
+	if err := c.call("textDocument/unknown", struct{}{}, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("expected an error for an unknown method, got %v", err)
+	}
+
+	if err := c.call("shutdown", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	c.notify("exit", nil)
+	select {
+	case err := <-c.done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the server did not exit")
+	}
+}
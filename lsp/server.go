@@ -0,0 +1,660 @@
+// Package lsp implements a Language Server Protocol server for PHP, which
+// provides editors with diagnostics, document symbols, go to definition,
+// hover, formatting, and code actions extracting functions and inlining
+// variables.
+//
+// The server reads the PHP files of the workspace when it is initialized,
+// and keeps the documents open in the client in sync incrementally.
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/ast/printer"
+	"github.com/stephens2424/php/lexer"
+	"github.com/stephens2424/php/passes/refactor"
+	"github.com/stephens2424/php/passes/symbols"
+	typecheck "github.com/stephens2424/php/passes/typechecking"
+	"github.com/stephens2424/php/token"
+)
+
+// Server is a language server communicating over a pair of streams.
+type Server struct {
+	conn *conn
+
+	// docs holds the documents by path.
+	docs map[string]*document
+
+	// index indexes the symbols of docs, and types holds their inferred
+	// types; each is nil if it must be rebuilt.
+	index *symbols.Index
+	types *typecheck.Info
+
+	shutdown bool
+}
+
+// NewServer returns a server reading messages from r and writing them to w.
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{conn: newConn(r, w), docs: map[string]*document{}}
+}
+
+// handler handles the parameters of a request or notification.
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+var handlers = map[string]handler{
+	"initialize":                  (*Server).initialize,
+	"initialized":                 nop,
+	"shutdown":                    (*Server).shutdownRequest,
+	"textDocument/didOpen":        (*Server).didOpen,
+	"textDocument/didChange":      (*Server).didChange,
+	"textDocument/didClose":       (*Server).didClose,
+	"textDocument/didSave":        nop,
+	"textDocument/documentSymbol": (*Server).documentSymbol,
+	"textDocument/definition":     (*Server).definition,
+	"textDocument/hover":          (*Server).hover,
+	"textDocument/formatting":     (*Server).formatting,
+	"textDocument/codeAction":     (*Server).codeAction,
+}
+
+func nop(*Server, json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+// errExitWithoutShutdown is returned by Serve when the client asks the
+// server to exit before shutting it down.
+var errExitWithoutShutdown = errors.New("lsp: exit without shutdown")
+
+// Serve handles messages until the client asks the server to exit or the
+// input ends.
+func (s *Server) Serve() error {
+	for {
+		msg, err := s.conn.read()
+		if rerr, ok := err.(*ResponseError); ok {
+			s.conn.write(&message{Error: rerr, ID: nullID()})
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errExitWithoutShutdown
+			}
+			return nil
+		}
+		if msg.Method == "" {
+			// a response to a request of the server, which sends none
+			continue
+		}
+
+		result, err := s.handle(msg)
+		if msg.ID == nil {
+			continue
+		}
+		resp := &message{ID: msg.ID}
+		if err != nil {
+			rerr, ok := err.(*ResponseError)
+			if !ok {
+				rerr = &ResponseError{Code: codeRequestFailed, Message: err.Error()}
+			}
+			resp.Error = rerr
+		} else if resp.Result, err = json.Marshal(result); err != nil {
+			resp.Result, resp.Error = nil, &ResponseError{Code: codeInternalError, Message: err.Error()}
+		}
+		if err := s.conn.write(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func nullID() *json.RawMessage {
+	id := json.RawMessage("null")
+	return &id
+}
+
+func (s *Server) handle(msg *message) (interface{}, error) {
+	h, ok := handlers[msg.Method]
+	if !ok {
+		if msg.ID == nil || strings.HasPrefix(msg.Method, "$/") {
+			return nil, nil
+		}
+		return nil, &ResponseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	return h(s, msg.Params)
+}
+
+// notify sends the client a notification.
+func (s *Server) notify(method string, params interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return s.conn.write(&message{Method: method, Params: raw})
+}
+
+// unmarshal decodes params into v, returning an error suitable for the
+// response if it fails.
+func unmarshal(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &ResponseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	var p InitializeParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if p.RootURI != "" {
+		root, err := uriToPath(p.RootURI)
+		if err != nil {
+			return nil, err
+		}
+		s.loadWorkspace(root)
+	}
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           TextDocumentSyncOptions{OpenClose: true, Change: SyncIncremental},
+			DocumentSymbolProvider:     true,
+			DefinitionProvider:         true,
+			HoverProvider:              true,
+			DocumentFormattingProvider: true,
+			CodeActionProvider:         true,
+		},
+		ServerInfo: ServerInfo{Name: "php-lsp"},
+	}, nil
+}
+
+// loadWorkspace reads the PHP files under root.
+func (s *Server) loadWorkspace(root string) {
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() && path != root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".php") {
+			s.readDocument(path)
+		}
+		return nil
+	})
+	s.index, s.types = nil, nil
+}
+
+// readDocument reads and parses the file path, unless it is open.
+func (s *Server) readDocument(path string) {
+	if d, ok := s.docs[path]; ok && d.open {
+		return
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		delete(s.docs, path)
+		return
+	}
+	d := &document{uri: pathToURI(path), path: path, text: string(src)}
+	d.parse()
+	s.docs[path] = d
+}
+
+func (s *Server) shutdownRequest(json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p DidOpenTextDocumentParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	path, err := uriToPath(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	d := &document{uri: p.TextDocument.URI, path: path, text: p.TextDocument.Text, open: true}
+	s.docs[path] = d
+	return nil, s.update(d)
+}
+
+func (s *Server) didChange(params json.RawMessage) (interface{}, error) {
+	var p DidChangeTextDocumentParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range p.ContentChanges {
+		d.change(c)
+	}
+	return nil, s.update(d)
+}
+
+func (s *Server) didClose(params json.RawMessage) (interface{}, error) {
+	var p DidCloseTextDocumentParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	// the file on disk replaces the closed document, if there is one
+	d.open = false
+	s.readDocument(d.path)
+	s.index, s.types = nil, nil
+	return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: d.uri, Diagnostics: []Diagnostic{}})
+}
+
+// update reparses d and publishes its diagnostics.
+func (s *Server) update(d *document) error {
+	d.parse()
+	s.index, s.types = nil, nil
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: d.uri, Diagnostics: d.diagnostics()})
+}
+
+// document returns the document with the given URI.
+func (s *Server) document(uri string) (*document, error) {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := s.docs[path]
+	if !ok {
+		return nil, &ResponseError{Code: codeInvalidParams, Message: "unknown document " + uri}
+	}
+	return d, nil
+}
+
+// fileSet returns the file set of the parsed documents.
+func (s *Server) fileSet() *ast.FileSet {
+	fs := ast.NewFileSet()
+	for path, d := range s.docs {
+		if d.file != nil {
+			fs.Files[path] = d.file
+		}
+	}
+	return fs
+}
+
+// symbols returns the index of the symbols of every document.
+func (s *Server) symbols() *symbols.Index {
+	if s.index == nil {
+		s.index = symbols.New(s.fileSet())
+	}
+	return s.index
+}
+
+// typeInfo returns the types inferred for every document.
+func (s *Server) typeInfo() *typecheck.Info {
+	if s.types == nil {
+		s.types = typecheck.Infer(s.fileSet())
+	}
+	return s.types
+}
+
+// location returns the location of o.
+func (s *Server) location(o symbols.Occurrence) (Location, bool) {
+	d, ok := s.docs[o.File]
+	if !ok {
+		return Location{}, false
+	}
+	return Location{URI: d.uri, Range: d.span(o.Span)}, true
+}
+
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	line, column := d.lineColumn(p.Position)
+	locations := []Location{}
+	for _, def := range s.symbols().DefinitionAt(d.path, line, column) {
+		if loc, ok := s.location(def); ok {
+			locations = append(locations, loc)
+		}
+	}
+	return locations, nil
+}
+
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	line, column := d.lineColumn(p.Position)
+	ix := s.symbols()
+	o, ok := ix.At(d.path, line, column)
+	if !ok {
+		return nil, nil
+	}
+
+	desc := describe(o)
+	if defs := ix.DefinitionAt(d.path, line, column); len(defs) == 1 {
+		desc = describe(defs[0])
+	}
+	if o.Symbol.Kind == symbols.Variable {
+		desc = fmt.Sprintf("%s $%s", s.variableType(o), o.Symbol.Name)
+	}
+	r := d.span(o.Span)
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```php\n" + desc + "\n```"},
+		Range:    &r,
+	}, nil
+}
+
+// variableType returns the type inferred for the variable of o where it
+// occurs.
+func (s *Server) variableType(o symbols.Occurrence) ast.Type {
+	d, ok := s.docs[o.File]
+	if !ok || d.file == nil {
+		return ast.Unknown
+	}
+	return s.typeInfo().TypeOf(d.file, o.Node)
+}
+
+// describe returns a description of the symbol of o, with the signature
+// or type of its declaration.
+func describe(o symbols.Occurrence) string {
+	sym := o.Symbol
+	switch n := o.Node.(type) {
+	case *ast.Variable:
+		return fmt.Sprintf("%s $%s", n.Type, sym.Name)
+	case *ast.FunctionDefinition:
+		prefix := ""
+		if sym.Class != "" {
+			prefix = sym.Class + "::"
+		}
+		return "function " + prefix + signature(n)
+	case *ast.Class:
+		desc := "class " + sym.Name
+		if n.Trait {
+			desc = "trait " + sym.Name
+		}
+		if n.Extends != "" {
+			desc += " extends " + n.Extends
+		}
+		if len(n.Implements) > 0 {
+			desc += " implements " + strings.Join(n.Implements, ", ")
+		}
+		return desc
+	case *ast.Interface:
+		desc := "interface " + sym.Name
+		if len(n.Inherits) > 0 {
+			desc += " extends " + strings.Join(n.Inherits, ", ")
+		}
+		return desc
+	case *ast.Property:
+		return fmt.Sprintf("%s %s::$%s", strings.ToLower(n.Visibility.Token().String()), sym.Class, sym.Name)
+	}
+	switch sym.Kind {
+	case symbols.Constant, symbols.ClassConstant:
+		return "const " + sym.String()
+	case symbols.Variable:
+		return sym.String()
+	}
+	return sym.Kind.String() + " " + sym.String()
+}
+
+// signature returns the name and arguments of the function f.
+func signature(f *ast.FunctionDefinition) string {
+	args := make([]string, len(f.Arguments))
+	for i, arg := range f.Arguments {
+		buf := &bytes.Buffer{}
+		if arg.TypeHint != "" {
+			buf.WriteString(arg.TypeHint + " ")
+		}
+		printer.NewPrinter(buf).PrintNode(arg.Variable)
+		if arg.Default != nil {
+			buf.WriteString(" = ")
+			printer.NewPrinter(buf).PrintNode(arg.Default)
+		}
+		args[i] = buf.String()
+	}
+	return fmt.Sprintf("%s(%s)", f.Name, strings.Join(args, ", "))
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p DocumentSymbolParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	syms := []DocumentSymbol{}
+	if d.file == nil {
+		return syms, nil
+	}
+
+	for _, n := range d.file.Nodes {
+		switch n := n.(type) {
+		case *ast.Class:
+			sym := d.symbol(n, n.Name, SymbolClass)
+			for _, k := range n.Constants {
+				sym.Children = append(sym.Children, d.symbol(k, k.Name, SymbolConstant))
+			}
+			for _, prop := range n.Properties {
+				sym.Children = append(sym.Children, d.symbol(prop, prop.Name, SymbolProperty))
+			}
+			for _, m := range n.Methods {
+				sym.Children = append(sym.Children, d.function(m, m.FunctionDefinition, SymbolMethod))
+			}
+			syms = append(syms, sym)
+		case *ast.Interface:
+			sym := d.symbol(n, n.Name, SymbolInterface)
+			for _, m := range n.Methods {
+				sym.Children = append(sym.Children, d.function(m.FunctionStmt, m.FunctionDefinition, SymbolMethod))
+			}
+			syms = append(syms, sym)
+		case *ast.FunctionStmt:
+			syms = append(syms, d.function(n, n.FunctionDefinition, SymbolFunction))
+		}
+	}
+
+	// constants defined by define()
+	for _, o := range s.symbols().Occurrences(d.path) {
+		if o.Definition && o.Symbol.Kind == symbols.Constant {
+			r := d.span(o.Span)
+			syms = append(syms, DocumentSymbol{Name: o.Symbol.Name, Kind: SymbolConstant, Range: r, SelectionRange: r})
+		}
+	}
+	sort.SliceStable(syms, func(i, j int) bool {
+		a, b := syms[i].Range.Start, syms[j].Range.Start
+		return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+	})
+	return syms, nil
+}
+
+// symbol returns the symbol declared by n with the given name.
+func (d *document) symbol(n ast.Node, name string, kind int) DocumentSymbol {
+	sym := DocumentSymbol{Name: name, Kind: kind}
+	if span, ok := d.file.Positions.Span(n); ok {
+		sym.Range = d.span(span)
+	}
+	sym.SelectionRange = sym.Range
+	if span, ok := d.file.Names[n]; ok {
+		sym.SelectionRange = d.span(span)
+	}
+	return sym
+}
+
+// function returns the symbol of the function or method n defined by def.
+func (d *document) function(n ast.Node, def *ast.FunctionDefinition, kind int) DocumentSymbol {
+	sym := d.symbol(n, def.Name, kind)
+	sym.Detail = signature(def)
+	if span, ok := d.file.Names[def]; ok {
+		sym.SelectionRange = d.span(span)
+	}
+	return sym
+}
+
+func (s *Server) formatting(params json.RawMessage) (interface{}, error) {
+	var p DocumentFormattingParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if d.file == nil || len(d.errs) > 0 {
+		return nil, errors.New("cannot format a document with syntax errors")
+	}
+	buf := &bytes.Buffer{}
+	printer.NewPrinter(buf).PrintFile(d.file)
+	if buf.String() == d.text || !commentsKept(d.text, buf.String()) {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{{
+		Range:   Range{End: d.position(len(d.text))},
+		NewText: buf.String(),
+	}}, nil
+}
+
+// commentsKept reports whether every comment of src is between the same
+// tokens in formatted. The printer keeps comments only between statements,
+// so a document with comments elsewhere, as between the parameters of a
+// function or the operands of an expression, is left as it is.
+func commentsKept(src, formatted string) bool {
+	a, b := commentPlaces(src), commentPlaces(formatted)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// commentPlaces returns each comment of src with the significant tokens
+// before and after it.
+func commentPlaces(src string) [][3]string {
+	var places [][3]string
+	var previous string
+	open := 0
+	l := lexer.NewLexer(src)
+	defer l.Abort()
+	for item := l.Next(); item.Typ != token.EOF && item.Typ != token.Error; item = l.Next() {
+		switch {
+		case item.Typ == token.CommentLine || item.Typ == token.CommentBlock:
+			places = append(places, [3]string{previous, strings.TrimSpace(item.Val)})
+		case item.Typ.Type().Is(token.Significant):
+			for ; open < len(places); open++ {
+				places[open][2] = item.Val
+			}
+			previous = item.Val
+		}
+	}
+	return places
+}
+
+// extractedName is the name of the functions that code actions extract,
+// which is numbered if it is taken.
+const extractedName = "extracted"
+
+func (s *Server) codeAction(params json.RawMessage) (interface{}, error) {
+	var p CodeActionParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	actions := []CodeAction{}
+	if d.file == nil || len(d.errs) > 0 {
+		return actions, nil
+	}
+	project := s.project()
+
+	begin, end := d.offset(p.Range.Start), d.offset(p.Range.End)
+	if begin < end {
+		for i := 1; i < 10; i++ {
+			name := extractedName
+			if i > 1 {
+				name += strconv.Itoa(i)
+			}
+			change, err := project.ExtractFunction(d.path, begin, end, name)
+			if err == nil {
+				actions = append(actions, CodeAction{
+					Title: "Extract to " + name + "()",
+					Kind:  CodeActionRefactorExtract,
+					Edit:  s.workspaceEdit(change),
+				})
+			}
+			if err == nil || !errors.Is(err, refactor.ErrNameTaken) {
+				break
+			}
+		}
+	}
+
+	line, column := d.lineColumn(p.Range.Start)
+	if change, err := project.InlineVariable(d.path, line, column); err == nil {
+		actions = append(actions, CodeAction{
+			Title: "Inline variable",
+			Kind:  CodeActionRefactorInline,
+			Edit:  s.workspaceEdit(change),
+		})
+	}
+	return actions, nil
+}
+
+// project returns the documents as a project to refactor.
+func (s *Server) project() *refactor.Project {
+	fs := ast.NewFileSet()
+	sources := map[string]string{}
+	for path, d := range s.docs {
+		if d.file != nil {
+			fs.Files[path] = d.file
+			sources[path] = d.text
+		}
+	}
+	return &refactor.Project{Files: fs, Sources: sources, Index: s.symbols()}
+}
+
+// workspaceEdit returns the edits of the documents made by c.
+func (s *Server) workspaceEdit(c *refactor.Change) *WorkspaceEdit {
+	w := &WorkspaceEdit{Changes: map[string][]TextEdit{}}
+	for path, edits := range c.Edits {
+		d, ok := s.docs[path]
+		if !ok {
+			continue
+		}
+		for _, e := range edits {
+			w.Changes[d.uri] = append(w.Changes[d.uri], TextEdit{
+				Range:   Range{Start: d.position(e.Begin), End: d.position(e.End)},
+				NewText: e.Text,
+			})
+		}
+	}
+	return w
+}
@@ -30,7 +30,7 @@ func TestList(t *testing.T) {
 		},
 	}
 
-	tree := ast.ExprStmt{Expr: expr}
+	tree := &ast.ExprStmt{Expr: expr}
 
 	if !assertEquals(a.Nodes[0], tree) {
 		t.Fatalf("Array bracked did not parse correctly")
@@ -49,7 +49,7 @@ func TestArrayBracket(t *testing.T) {
 	}
 
 	tree := []ast.Statement{
-		ast.ExprStmt{Expr: ast.AssignmentExpr{
+		&ast.ExprStmt{Expr: ast.AssignmentExpr{
 			Operator: "=",
 			Assignee: ast.NewVariable("arr"),
 			Value: &ast.ArrayExpr{
@@ -59,7 +59,7 @@ func TestArrayBracket(t *testing.T) {
 				},
 			},
 		}},
-		ast.ExprStmt{Expr: ast.AssignmentExpr{
+		&ast.ExprStmt{Expr: ast.AssignmentExpr{
 			Operator: "=",
 			Assignee: ast.NewVariable("arr2"),
 			Value: &ast.ArrayExpr{
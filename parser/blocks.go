@@ -7,8 +7,10 @@ import (
 
 func (p *Parser) parseBlock() *ast.Block {
 	p.expect(token.BlockBegin)
+	begin := p.current.Begin
 	b := p.parseStatementsUntil(token.BlockEnd)
 	p.expectCurrent(token.BlockEnd)
+	p.setSpan(b, begin)
 	return b
 }
 
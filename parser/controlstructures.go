@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/stephens2424/php/ast"
 	"github.com/stephens2424/php/token"
 )
@@ -8,51 +10,59 @@ import (
 func (p *Parser) parseIf() *ast.IfStmt {
 	n := &ast.IfStmt{Branches: make([]ast.IfBranch, 0, 1)}
 
-	n.Branches = append(n.Branches, p.parseIfBranch())
+	branch, alt := p.parseIfBranch()
+	n.Branches = append(n.Branches, branch)
+	n.AltSyntax = alt
 
 	for {
 		switch p.current.Typ {
 		case token.ElseIf:
-			n.Branches = append(n.Branches, p.parseIfBranch())
+			branch, _ := p.parseIfBranch()
+			n.Branches = append(n.Branches, branch)
 		case token.Else:
 			p.next()
 			if p.current.Typ == token.If {
-				n.Branches = append(n.Branches, p.parseIfBranch())
+				branch, _ := p.parseIfBranch()
+				n.Branches = append(n.Branches, branch)
 			} else {
 				n.ElseBlock = p.parseControlBlock(token.EndIf)
-				p.backup()
+				p.endControlBlock(n.AltSyntax)
 				return n
 			}
 		default:
-			if p.current.Typ != token.EndIf {
-				p.backup()
+			if n.AltSyntax {
+				p.expectCurrent(token.EndIf)
 			}
+			p.endControlBlock(n.AltSyntax)
 			return n
 		}
 	}
 }
 
-func (p *Parser) parseIfBranch() ast.IfBranch {
+// parseIfBranch parses a condition and its block, reporting whether the
+// block was written in the alternative syntax.
+func (p *Parser) parseIfBranch() (ast.IfBranch, bool) {
 	b := ast.IfBranch{}
 	p.expect(token.OpenParen)
 	b.Condition = p.parseNextExpression()
 	p.expect(token.CloseParen)
 
 	p.next()
+	alt := p.current.Typ == token.TernaryOperator2
 	b.Block = p.parseControlBlock(token.EndIf, token.ElseIf, token.Else)
-	return b
+	return b, alt
 }
 
 func (p *Parser) parseWhile() ast.Statement {
+	stmt := &ast.WhileStmt{}
 	p.expect(token.OpenParen)
-	term := p.parseNextExpression()
+	stmt.Termination = p.parseNextExpression()
 	p.expect(token.CloseParen)
 	p.next()
-	block := p.parseControlBlock(token.EndWhile)
-	return &ast.WhileStmt{
-		Termination: term,
-		LoopBlock:   block,
-	}
+	stmt.AltSyntax = p.current.Typ == token.TernaryOperator2
+	stmt.LoopBlock = p.parseControlBlock(token.EndWhile)
+	p.endControlBlock(stmt.AltSyntax)
+	return stmt
 }
 
 func (p *Parser) parseForeach() ast.Statement {
@@ -62,25 +72,33 @@ func (p *Parser) parseForeach() ast.Statement {
 	p.expect(token.AsOperator)
 	if p.peek().Typ == token.AmpersandOperator {
 		p.expect(token.AmpersandOperator)
+		stmt.Reference = true
 	}
 	p.expect(token.VariableOperator)
 	p.next()
-	first := ast.NewVariable(p.current.Val)
+	first := p.newVariable()
 	if p.peek().Typ == token.ArrayKeyOperator {
 		stmt.Key = first
 		p.expect(token.ArrayKeyOperator)
-		if p.peek().Typ == token.AmpersandOperator {
+		stmt.Reference = p.peek().Typ == token.AmpersandOperator
+		if stmt.Reference {
 			p.expect(token.AmpersandOperator)
 		}
 		p.expect(token.VariableOperator)
 		p.next()
-		stmt.Value = ast.NewVariable(p.current.Val)
+		stmt.Value = p.newVariable()
 	} else {
 		stmt.Value = first
 	}
+	if stmt.Key != nil {
+		p.scope.Variable(stmt.Key)
+	}
+	p.scope.Variable(stmt.Value)
 	p.expect(token.CloseParen)
 	p.next()
+	stmt.AltSyntax = p.current.Typ == token.TernaryOperator2
 	stmt.LoopBlock = p.parseControlBlock(token.EndForeach)
+	p.endControlBlock(stmt.AltSyntax)
 	return stmt
 }
 
@@ -94,6 +112,20 @@ func (p *Parser) parseControlBlock(end ...token.Token) ast.Statement {
 	return stmt
 }
 
+// endControlBlock leaves the parser on the last token of a statement whose
+// block was parsed by parseControlBlock. Blocks in the alternative syntax
+// end on a closing keyword such as endif, which the lexer includes the
+// following semicolon in when there is one.
+func (p *Parser) endControlBlock(alt bool) {
+	if !alt {
+		p.backup()
+		return
+	}
+	if !strings.HasSuffix(p.current.Val, ";") {
+		p.expectStmtEnd()
+	}
+}
+
 func (p *Parser) parseFor() ast.Statement {
 	stmt := &ast.ForStmt{}
 	p.expect(token.OpenParen)
@@ -102,8 +134,9 @@ func (p *Parser) parseFor() ast.Statement {
 	stmt.Iteration = p.parseExpressionsUntil(token.Comma, token.CloseParen)
 	p.expectCurrent(token.CloseParen)
 	p.next()
+	stmt.AltSyntax = p.current.Typ == token.TernaryOperator2
 	stmt.LoopBlock = p.parseControlBlock(token.EndFor)
-	p.backup()
+	p.endControlBlock(stmt.AltSyntax)
 	return stmt
 }
 
@@ -126,6 +159,7 @@ func (p *Parser) parseSwitch() ast.Statement {
 	stmt.Expr = p.parseExpression()
 	p.expectCurrent(token.CloseParen)
 	p.expect(token.BlockBegin, token.TernaryOperator2)
+	stmt.AltSyntax = p.current.Typ == token.TernaryOperator2
 	p.next()
 	for {
 		switch p.current.Typ {
@@ -141,7 +175,18 @@ func (p *Parser) parseSwitch() ast.Statement {
 			p.expect(token.TernaryOperator2, token.StatementEnd)
 			p.next()
 			stmt.DefaultCase = p.parseSwitchBlock()
-		case token.BlockEnd, token.EndSwitch:
+		case token.PHPEnd:
+			// PHP may be closed and reopened before the first case, as long
+			// as only whitespace is output in between
+			if p.accept(token.HTML) && strings.TrimSpace(p.current.Val) != "" {
+				p.errorf("Unexpected output in switch statement: %s", p.current)
+			}
+			p.expect(token.PHPBegin)
+			p.next()
+		case token.BlockEnd:
+			return stmt
+		case token.EndSwitch:
+			p.endControlBlock(true)
 			return stmt
 		default:
 			p.errorf("Unexpected token. in switch statement: %s", p.current)
@@ -48,6 +48,7 @@ var operatorPrecedence = map[token.Token]int{
 }
 
 func (p *Parser) parseExpression() (expr ast.Expr) {
+	defer func(begin token.Position) { p.setSpan(expr, begin) }(p.current.Begin)
 	originalParenLev := p.parenLevel
 
 	switch p.current.Typ {
@@ -55,10 +56,10 @@ func (p *Parser) parseExpression() (expr ast.Expr) {
 		return p.parseNextExpression()
 	case token.List:
 		expr = p.parseList()
-	case token.AmpersandOperator, token.SubtractionOperator:
-		expr := p.parseUnaryExpressionRight(p.parseOperand(), p.current)
-		return p.parseOperation(originalParenLev, expr)
 	case token.UnaryOperator,
+		token.AmpersandOperator,
+		token.AdditionOperator,
+		token.SubtractionOperator,
 		token.NegationOperator,
 		token.CastOperator,
 		token.BitwiseNotOperator,
@@ -77,12 +78,14 @@ func (p *Parser) parseExpression() (expr ast.Expr) {
 		token.Parent,
 		token.Include,
 		token.Exit,
+		token.Yield,
 		token.ShellCommand:
 		expr = p.parseOperation(originalParenLev, p.parseOperand())
 	case token.OpenParen:
 		// check for a cast operator that happens to have had spaces in it, and was thus lexed incorrectly
 		if op := p.checkForCast(); op != nil {
-			expr = p.parseUnaryExpressionRight(p.parseNextExpression(), *op)
+			p.next()
+			expr = p.parseOperation(originalParenLev, p.parseUnaryExpressionRight(p.parseUnaryOperand(), *op))
 			break
 		}
 		p.parenLevel++
@@ -184,6 +187,7 @@ func (p *Parser) parseAssignmentOperation(lhs, rhs ast.Expr, operator token.Item
 // expression for that token. That means an expression with no operators
 // except for the object operator.
 func (p *Parser) parseOperand() (expr ast.Expr) {
+	defer func(begin token.Position) { p.setSpan(expr, begin) }(p.current.Begin)
 
 	// These cases must come first and not repeat
 	switch p.current.Typ {
@@ -194,20 +198,23 @@ func (p *Parser) parseOperand() (expr ast.Expr) {
 		token.UnaryOperator,
 		token.NegationOperator,
 		token.CastOperator,
+		token.AdditionOperator,
 		token.SubtractionOperator,
 		token.AmpersandOperator,
 		token.BitwiseNotOperator:
 		op := p.current
 		p.next()
-		return p.parseUnaryExpressionRight(p.parseExpression(), op)
+		return p.parsePrefixOperation(p.parseUnaryOperand(), op)
 	case token.OpenParen:
 		if op := p.checkForCast(); op != nil {
 			p.next()
-			return p.parseUnaryExpressionRight(p.parseExpression(), *op)
+			return p.parseUnaryExpressionRight(p.parseUnaryOperand(), *op)
 		}
-		return p.parseExpression()
+		return p.parseParenthesizedOperand()
 	case token.Include:
 		return p.parseInclude()
+	case token.Yield:
+		return p.parseYield()
 	case token.Function:
 		return p.parseAnonymousFunction()
 	case token.NewOperator:
@@ -253,6 +260,32 @@ func (p *Parser) parseOperand() (expr ast.Expr) {
 	return p.parseOperandComponent(expr)
 }
 
+// parseUnaryOperand parses the operand of a prefix operator. Prefix operators
+// bind more tightly than any binary operator, except that their operand may
+// be assigned to, as in `!$a = foo()`.
+func (p *Parser) parseUnaryOperand() ast.Expr {
+	operand := p.parseOperand()
+	if p.peek().Typ == token.AssignmentOperator {
+		p.next()
+		return p.parseBinaryOperation(operand, p.current, p.parenLevel)
+	}
+	return operand
+}
+
+// parseParenthesizedOperand parses a parenthesized expression used as an
+// operand, leaving any operators that follow the closing paren to the
+// caller.
+func (p *Parser) parseParenthesizedOperand() ast.Expr {
+	p.expectCurrent(token.OpenParen)
+	p.parenLevel++
+	p.next()
+	expr := p.parseExpression()
+	p.expect(token.CloseParen)
+	p.parenLevel--
+	p.next()
+	return p.parseOperandComponent(expr)
+}
+
 func (p *Parser) parseOperandComponent(lhs ast.Expr) (expr ast.Expr) {
 	expr = lhs
 	for {
@@ -303,12 +336,14 @@ func (p *Parser) parseLiteral() ast.Expr {
 func (p *Parser) parseVariable() ast.Expr {
 	var expr *ast.Variable
 	p.expectCurrent(token.VariableOperator)
+	begin := p.current.Begin
+	defer func() { p.setSpan(expr, begin) }()
 	switch p.next(); {
 	case lexer.IsKeyword(p.current.Typ, p.current.Val):
 		// keywords are all valid variable names
 		fallthrough
 	case p.current.Typ == token.Identifier:
-		expr = ast.NewVariable(p.current.Val)
+		expr = p.newVariable()
 	case p.current.Typ == token.BlockBegin:
 		expr = &ast.Variable{Name: p.parseNextExpression()}
 		p.expect(token.BlockEnd)
@@ -335,25 +370,48 @@ func (p *Parser) parseInclude() ast.Expr {
 	return inc
 }
 
+// parseYield parses a yield expression: yield alone, yield with a value
+// and perhaps a key, or yield from.
+func (p *Parser) parseYield() ast.Expr {
+	y := &ast.YieldExpr{}
+	if next := p.peek(); next.Typ == token.Identifier && strings.EqualFold(next.Val, "from") {
+		p.next()
+		y.From = true
+	}
+	switch p.peek().Typ {
+	case token.StatementEnd, token.CloseParen, token.Comma, token.ArrayLookupOperatorRight, token.PHPEnd:
+		if !y.From {
+			return y
+		}
+	}
+	y.Value = p.parseNextExpression()
+	if !y.From && p.peek().Typ == token.ArrayKeyOperator {
+		p.next()
+		y.Key, y.Value = y.Value, p.parseNextExpression()
+	}
+	return y
+}
+
 func (p *Parser) parseIdentifier() (expr ast.Expr) {
 	switch typ := p.peek().Typ; {
 	case typ == token.OpenParen && !p.instantiation:
 		// Function calls are okay here because we know they came with
 		// a non-dynamic identifier.
-		expr = p.parseFunctionCall(&ast.Identifier{Value: p.current.Val})
+		expr = p.parseFunctionCall(p.newIdentifier())
 		p.next()
 	case typ == token.ScopeResolutionOperator:
-		classIdent := p.current.Val
+		classIdent := p.newIdentifier()
 		p.next() // get onto ::, then we get to the next expr
 		p.next()
-		expr = ast.NewClassExpression(classIdent, p.parseOperand())
+		expr = &ast.ClassExpr{Receiver: classIdent, Expr: p.parseOperand()}
 		p.next()
 	case p.instantiation:
 		defer p.next()
-		return &ast.Identifier{Value: p.current.Val}
+		return p.newIdentifier()
 	default:
 		name := p.current.Val
-		v := ast.NewVariable(p.current.Val)
+		v := &ast.Variable{Name: p.newIdentifier(), Type: ast.Unknown}
+		p.setSpan(v, p.current.Begin)
 		expr = ast.ConstantExpr{
 			Variable: v,
 		}
@@ -366,13 +424,18 @@ func (p *Parser) parseIdentifier() (expr ast.Expr) {
 // parseScopeResolutionFromKeyword specifically parses self::, static::, and parent::
 func (p *Parser) parseScopeResolutionFromKeyword() ast.Expr {
 	if p.peek().Typ == token.ScopeResolutionOperator {
-		r := p.current.Val
+		r := p.newIdentifier()
 		p.expect(token.ScopeResolutionOperator)
 		p.next()
-		expr := ast.NewClassExpression(r, p.parseOperand())
+		expr := &ast.ClassExpr{Receiver: r, Expr: p.parseOperand()}
 		p.next()
 		return expr
 	}
+	if p.instantiation {
+		// new self, new static or new parent
+		defer p.next()
+		return p.newIdentifier()
+	}
 	// TODO Error
 	p.next()
 	return nil
@@ -8,11 +8,13 @@ import (
 
 func (p *Parser) parseFunctionStmt(inMethod bool) *ast.FunctionStmt {
 	stmt := &ast.FunctionStmt{}
+	defer p.setSpan(stmt, p.current.Begin)
 	stmt.FunctionDefinition = p.parseFunctionDefinition()
 	if !inMethod {
 		p.namespace.Functions[stmt.Name] = stmt
 	}
 	p.scope = ast.NewScope(p.scope, p.FileSet.GlobalScope, p.FileSet.SuperGlobalScope)
+	p.declareArguments(stmt.Arguments)
 	stmt.Body = p.parseBlock()
 	p.scope = p.scope.EnclosingScope
 	return stmt
@@ -31,6 +33,7 @@ func (p *Parser) parseFunctionDefinition() *ast.FunctionDefinition {
 		}
 	}
 	def.Name = p.current.Val
+	p.setName(def)
 	def.Arguments = make([]*ast.FunctionArgument, 0)
 	p.expect(token.OpenParen)
 	if p.peek().Typ == token.CloseParen {
@@ -63,23 +66,31 @@ func (p *Parser) parseFunctionType() string {
 	// jump to the type declaration
 	p.next()
 	p.next()
-
+	if p.current.Typ == token.Identifier {
+		p.addClassName()
+	}
 	return p.current.Val
 }
 
 func (p *Parser) parseFunctionArgument() *ast.FunctionArgument {
 	arg := &ast.FunctionArgument{}
+	defer p.setSpan(arg, p.peek().Begin)
 	switch p.peek().Typ {
-	case token.Identifier, token.Array, token.Self:
+	case token.Identifier:
+		p.next()
+		arg.TypeHint = p.current.Val
+		p.addClassName()
+	case token.Array, token.Self:
 		p.next()
 		arg.TypeHint = p.current.Val
 	}
 	if p.peek().Typ == token.AmpersandOperator {
 		p.next()
+		arg.Reference = true
 	}
 	p.expect(token.VariableOperator)
 	p.next()
-	arg.Variable = ast.NewVariable(p.current.Val)
+	arg.Variable = p.newVariable()
 	if p.peek().Typ == token.AssignmentOperator {
 		p.expect(token.AssignmentOperator)
 		p.next()
@@ -91,6 +102,7 @@ func (p *Parser) parseFunctionArgument() *ast.FunctionArgument {
 func (p *Parser) parseFunctionCall(callable ast.Expr) *ast.FunctionCallExpr {
 	expr := &ast.FunctionCallExpr{}
 	expr.FunctionName = callable
+	defer p.setSpan(expr, p.beginningOf(callable))
 	return p.parseFunctionArguments(expr)
 }
 
@@ -160,8 +172,21 @@ Loop:
 		p.expect(token.CloseParen)
 	}
 
+	// closure variables read the variables of the enclosing scope
+	for _, v := range f.ClosureVariables {
+		p.scope.Variable(v.Variable)
+	}
 	p.scope = ast.NewScope(p.scope, p.FileSet.GlobalScope, p.FileSet.SuperGlobalScope)
+	p.declareArguments(f.Arguments)
 	f.Body = p.parseBlock()
 	p.scope = p.scope.EnclosingScope
 	return f
 }
+
+// declareArguments adds the variables of the arguments args to the scope of
+// the function they are the arguments of.
+func (p *Parser) declareArguments(args []*ast.FunctionArgument) {
+	for _, arg := range args {
+		p.scope.Variable(arg.Variable)
+	}
+}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/stephens2424/php/ast"
 	"github.com/stephens2424/php/lexer"
 	"github.com/stephens2424/php/token"
@@ -30,12 +32,11 @@ func (p *Parser) parseInstantiation() ast.Expr {
 }
 
 func (p *Parser) parseClass() *ast.Class {
-	if p.current.Typ == token.Abstract {
-		p.expect(token.Class)
-	}
-	if p.current.Typ == token.Final {
+	abstract, final := p.current.Typ == token.Abstract, p.current.Typ == token.Final
+	if abstract || final {
 		p.expect(token.Class)
 	}
+	trait := p.current.Typ == token.Trait
 	switch p.next(); {
 	case p.current.Typ == token.Identifier:
 	case lexer.IsKeyword(p.current.Typ, p.current.Val):
@@ -43,21 +44,28 @@ func (p *Parser) parseClass() *ast.Class {
 		p.errorf("unexpected variable operand %s", p.current)
 	}
 
-	name := p.current.Val
+	c := &ast.Class{Name: p.current.Val, Trait: trait, Abstract: abstract, Final: final}
+	p.setName(c)
 	if p.peek().Typ == token.Extends {
 		p.expect(token.Extends)
 		p.expect(token.Identifier)
+		c.Extends = p.current.Val
+		p.addClassName()
 	}
 	if p.peek().Typ == token.Implements {
 		p.expect(token.Implements)
 		p.expect(token.Identifier)
+		c.Implements = append(c.Implements, p.current.Val)
+		p.addClassName()
 		for p.peek().Typ == token.Comma {
 			p.expect(token.Comma)
 			p.expect(token.Identifier)
+			c.Implements = append(c.Implements, p.current.Val)
+			p.addClassName()
 		}
 	}
 	p.expect(token.BlockBegin)
-	c := p.parseClassFields(&ast.Class{Name: name})
+	c = p.parseClassFields(c)
 	p.namespace.ClassesAndInterfaces[c.Name] = c
 	return c
 }
@@ -75,7 +83,7 @@ func (p *Parser) parseObjectLookup(r ast.Expr) (expr ast.Expr) {
 	case token.VariableOperator:
 		prop.Name = p.parseExpression()
 	case token.Identifier:
-		prop.Name = &ast.Identifier{Value: p.current.Val}
+		prop.Name = p.newIdentifier()
 	default:
 		p.errorf("unexpected token following object operator: %v", p.current)
 	}
@@ -88,6 +96,7 @@ func (p *Parser) parseObjectLookup(r ast.Expr) (expr ast.Expr) {
 			FunctionCallExpr: p.parseFunctionCall(prop.Name),
 		}
 	}
+	p.setSpan(expr, p.beginningOf(r))
 	expr = p.parseOperation(p.parenLevel, expr)
 	return
 }
@@ -112,18 +121,30 @@ func (p *Parser) parseClassFields(c *ast.Class) *ast.Class {
 	c.Methods = make([]*ast.Method, 0)
 	c.Properties = make([]*ast.Property, 0)
 	for p.peek().Typ != token.BlockEnd {
-		vis, _, _, abstract := p.parseClassMemberSettings()
+		begin := p.peek().Begin
+		vis, static, final, abstract := p.parseClassMemberSettings()
 		p.next()
 		switch p.current.Typ {
 		case token.Function:
-			p.parseClassMethod(c, abstract, vis)
+			p.parseClassMethod(c, &ast.Method{Visibility: vis, Static: static, Abstract: abstract, Final: final})
+			p.setSpan(c.Methods[len(c.Methods)-1], begin)
 		case token.Var:
 			p.expect(token.VariableOperator)
 			fallthrough
 		case token.VariableOperator:
-			p.parseClassVariables(c, vis)
+			first := len(c.Properties)
+			p.parseClassVariables(c, vis, static)
+			if doc, ok := p.file.Docs[begin.Position]; ok {
+				for _, prop := range c.Properties[first:] {
+					if span, ok := p.file.Positions[prop]; ok {
+						p.file.Docs[span.Begin.Position] = doc
+					}
+				}
+			}
 		case token.Const:
 			p.parseClassConst(c)
+		case token.Use:
+			p.parseTraitUse(c)
 		default:
 			p.errorf("unexpected class member %v", p.current)
 			return c
@@ -133,29 +154,112 @@ func (p *Parser) parseClassFields(c *ast.Class) *ast.Class {
 	return c
 }
 
+// parseTraitUse parses the names of the traits that c uses, and the rules
+// resolving conflicts between their methods and aliasing them.
+func (p *Parser) parseTraitUse(c *ast.Class) {
+	use := &ast.TraitUse{}
+	for {
+		p.expect(token.Identifier)
+		use.Traits = append(use.Traits, p.current.Val)
+		p.addClassName()
+		if p.peek().Typ != token.Comma {
+			break
+		}
+		p.expect(token.Comma)
+	}
+	c.Traits = append(c.Traits, use.Traits...)
+	c.TraitUses = append(c.TraitUses, use)
+	if p.peek().Typ != token.BlockBegin {
+		p.expect(token.StatementEnd)
+		return
+	}
+	p.expect(token.BlockBegin)
+	for p.peek().Typ != token.BlockEnd {
+		if p.peek().Typ == token.EOF {
+			p.errorf("unexpected end of file in trait use")
+			return
+		}
+		use.Rules = append(use.Rules, p.parseTraitRule())
+	}
+	p.expect(token.BlockEnd)
+}
+
+// parseTraitRule parses a rule of a trait use, up to its semicolon.
+func (p *Parser) parseTraitRule() *ast.TraitRule {
+	r := &ast.TraitRule{}
+	p.next()
+	p.expectMemberName()
+	r.Method = p.current.Val
+	if p.peek().Typ == token.ScopeResolutionOperator {
+		r.Trait = r.Method
+		p.addClassName()
+		p.expect(token.ScopeResolutionOperator)
+		p.next()
+		p.expectMemberName()
+		r.Method = p.current.Val
+	}
+	switch p.next(); {
+	case p.current.Typ == token.Identifier && strings.EqualFold(p.current.Val, "insteadof"):
+		for {
+			p.expect(token.Identifier)
+			r.Insteadof = append(r.Insteadof, p.current.Val)
+			p.addClassName()
+			if !p.accept(token.Comma) {
+				break
+			}
+		}
+	case p.current.Typ == token.AsOperator:
+		r.Visibility, r.HasVisibility = p.parseVisibility()
+		if p.peek().Typ != token.StatementEnd {
+			p.next()
+			p.expectMemberName()
+			r.Alias = p.current.Val
+		}
+	default:
+		p.errorf("unexpected %s in trait use, expected insteadof or as", p.current)
+	}
+	p.expect(token.StatementEnd)
+	return r
+}
+
+// expectMemberName checks that the current token can name a method, which
+// may be a keyword.
+func (p *Parser) expectMemberName() {
+	if p.current.Typ != token.Identifier && !lexer.IsKeyword(p.current.Typ, p.current.Val) {
+		p.expected(token.Identifier)
+	}
+}
+
 func (p *Parser) parseClassConst(c *ast.Class) {
 	constant := &ast.Constant{}
+	begin := p.current.Begin
 	p.expect(token.Identifier)
 	constant.Name = p.current.Val
+	p.setName(constant)
 	if p.peek().Typ == token.AssignmentOperator {
 		p.expect(token.AssignmentOperator)
 		constant.Value = p.parseNextExpression()
 	}
 	c.Constants = append(c.Constants, constant)
 	p.expect(token.StatementEnd)
+	p.setSpan(constant, begin)
 }
 
-func (p *Parser) parseClassVariables(c *ast.Class, vis ast.Visibility) {
+func (p *Parser) parseClassVariables(c *ast.Class, vis ast.Visibility, static bool) {
 	for {
+		begin := p.current.Begin
 		p.expect(token.Identifier)
 		prop := &ast.Property{
 			Visibility: vis,
+			Static:     static,
 			Name:       "$" + p.current.Val,
 		}
+		p.setName(prop)
 		if p.peek().Typ == token.AssignmentOperator {
 			p.expect(token.AssignmentOperator)
 			prop.Initialization = p.parseNextExpression()
 		}
+		p.setSpan(prop, begin)
 		c.Properties = append(c.Properties, prop)
 		if p.accept(token.StatementEnd) {
 			break
@@ -165,21 +269,16 @@ func (p *Parser) parseClassVariables(c *ast.Class, vis ast.Visibility) {
 	}
 }
 
-func (p *Parser) parseClassMethod(c *ast.Class, abstract bool, vis ast.Visibility) {
-	if abstract {
-		f := p.parseFunctionDefinition()
-		m := &ast.Method{
-			Visibility:   vis,
-			FunctionStmt: &ast.FunctionStmt{FunctionDefinition: f},
-		}
-		c.Methods = append(c.Methods, m)
+// parseClassMethod parses the method m, whose modifiers are set, and adds
+// it to c.
+func (p *Parser) parseClassMethod(c *ast.Class, m *ast.Method) {
+	if m.Abstract {
+		m.FunctionStmt = &ast.FunctionStmt{FunctionDefinition: p.parseFunctionDefinition()}
 		p.expect(token.StatementEnd)
 	} else {
-		c.Methods = append(c.Methods, &ast.Method{
-			Visibility:   vis,
-			FunctionStmt: p.parseFunctionStmt(true),
-		})
+		m.FunctionStmt = p.parseFunctionStmt(true)
 	}
+	c.Methods = append(c.Methods, m)
 }
 
 func (p *Parser) parseInterface() *ast.Interface {
@@ -188,12 +287,14 @@ func (p *Parser) parseInterface() *ast.Interface {
 	}
 	p.expect(token.Identifier)
 	i.Name = p.current.Val
+	p.setName(i)
 	p.namespace.ClassesAndInterfaces[i.Name] = i
 	if p.peek().Typ == token.Extends {
 		p.expect(token.Extends)
 		for {
 			p.expect(token.Identifier)
 			i.Inherits = append(i.Inherits, p.current.Val)
+			p.addClassName()
 			if p.peek().Typ != token.Comma {
 				break
 			}
@@ -202,8 +303,10 @@ func (p *Parser) parseInterface() *ast.Interface {
 	}
 	p.expect(token.BlockBegin)
 	for p.peek().Typ != token.BlockEnd {
+		begin := p.peek().Begin
 		vis, _ := p.parseVisibility()
-		if p.peek().Typ == token.Static {
+		static := p.peek().Typ == token.Static
+		if static {
 			p.next()
 		}
 		p.next()
@@ -212,10 +315,12 @@ func (p *Parser) parseInterface() *ast.Interface {
 			f := p.parseFunctionDefinition()
 			m := ast.Method{
 				Visibility:   vis,
+				Static:       static,
 				FunctionStmt: &ast.FunctionStmt{FunctionDefinition: f},
 			}
 			i.Methods = append(i.Methods, m)
 			p.expect(token.StatementEnd)
+			p.setSpan(m.FunctionStmt, begin)
 		case token.Const:
 			constant := ast.Constant{}
 			p.expect(token.Identifier)
@@ -33,7 +33,8 @@ func TestClass(t *testing.T) {
 		t.Fatalf("Class did not correctly parse")
 	}
 	tree := &ast.Class{
-		Name: "TestClass",
+		Name:     "TestClass",
+		Abstract: true,
 		Constants: []*ast.Constant{
 			{
 				Name:  "my_const",
@@ -63,6 +64,7 @@ func TestClass(t *testing.T) {
 		Methods: []*ast.Method{
 			{
 				Visibility: ast.Public,
+				Abstract:   true,
 				FunctionStmt: &ast.FunctionStmt{
 					FunctionDefinition: &ast.FunctionDefinition{
 						Name: "method0",
@@ -123,6 +125,95 @@ func TestClass(t *testing.T) {
 	}
 }
 
+func TestClassInheritance(t *testing.T) {
+	testStr := `<?php
+    class Child extends Base implements Countable, ArrayAccess {
+    }`
+	p := NewParser()
+	p.disableScoping = true
+	a, err := p.Parse("test.php", testStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := &ast.Class{
+		Name:       "Child",
+		Extends:    "Base",
+		Implements: []string{"Countable", "ArrayAccess"},
+		Methods:    []*ast.Method{},
+		Properties: []*ast.Property{},
+	}
+	if !assertEquals(a.Nodes[0], tree) {
+		t.Fatalf("Class did not parse correctly")
+	}
+}
+
+func TestTrait(t *testing.T) {
+	testStr := `<?php
+    trait Greets {
+      use Names;
+      public function hello() {
+      }
+    }
+    class Greeter extends Base {
+      use Greets, Waves {
+        Greets::hello insteadof Waves;
+        Waves::hello as wave;
+        hello as protected;
+        Waves::wave as private goodbye;
+      }
+    }`
+	p := NewParser()
+	p.disableScoping = true
+	a, err := p.Parse("test.php", testStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Nodes) != 2 {
+		t.Fatalf("Traits did not correctly parse")
+	}
+	trait := &ast.Class{
+		Name:      "Greets",
+		Traits:    []string{"Names"},
+		TraitUses: []*ast.TraitUse{{Traits: []string{"Names"}}},
+		Methods: []*ast.Method{
+			{
+				Visibility: ast.Public,
+				FunctionStmt: &ast.FunctionStmt{
+					FunctionDefinition: &ast.FunctionDefinition{
+						Name:      "hello",
+						Arguments: []*ast.FunctionArgument{},
+					},
+					Body: &ast.Block{},
+				},
+			},
+		},
+		Properties: []*ast.Property{},
+		Trait:      true,
+	}
+	if !assertEquals(a.Nodes[0], trait) {
+		t.Fatalf("Trait did not parse correctly")
+	}
+	class := &ast.Class{
+		Name:    "Greeter",
+		Extends: "Base",
+		Traits:  []string{"Greets", "Waves"},
+		TraitUses: []*ast.TraitUse{{
+			Traits: []string{"Greets", "Waves"},
+			Rules: []*ast.TraitRule{
+				{Trait: "Greets", Method: "hello", Insteadof: []string{"Waves"}},
+				{Trait: "Waves", Method: "hello", Alias: "wave", Visibility: ast.Public},
+				{Method: "hello", Visibility: ast.Protected, HasVisibility: true},
+				{Trait: "Waves", Method: "wave", Alias: "goodbye", Visibility: ast.Private, HasVisibility: true},
+			},
+		}},
+		Methods:    []*ast.Method{},
+		Properties: []*ast.Property{},
+	}
+	if !assertEquals(a.Nodes[1], class) {
+		t.Fatalf("Trait use did not parse correctly")
+	}
+}
+
 func TestExtraModifiers(t *testing.T) {
 	testStr := `<?
   class myclass {
@@ -147,7 +238,7 @@ func TestInstantiation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Did not parse instantiation correctly: %s", err)
 	}
-	tree := ast.ExprStmt{Expr: ast.AssignmentExpr{
+	tree := &ast.ExprStmt{Expr: ast.AssignmentExpr{
 		Operator: "=",
 		Assignee: ast.NewVariable("obj"),
 		Value: &ast.NewCallExpr{
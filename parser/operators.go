@@ -79,6 +79,11 @@ func (p *Parser) parseBinaryOperation(lhs ast.Expr, operator token.Item, origina
 	p.next()
 	rhs := p.parseOperand()
 	currentPrecedence := operatorPrecedence[operator.Typ]
+	if operator.Typ == token.AssignmentOperator {
+		// The value being assigned extends over every operator except the
+		// written logical operators.
+		currentPrecedence = operatorPrecedence[token.WrittenAndOperator] + 1
+	}
 	for {
 		nextOperator := p.peek()
 		nextPrecedence, ok := operatorPrecedence[nextOperator.Typ]
@@ -114,6 +119,19 @@ func (p *Parser) parseUnaryExpressionRight(operand ast.Expr, operator token.Item
 	}
 }
 
+// parsePrefixOperation returns the operation for a unary operator found
+// before its operand.
+func (p *Parser) parsePrefixOperation(operand ast.Expr, operator token.Item) ast.Expr {
+	if operator.Typ != token.UnaryOperator {
+		return p.parseUnaryExpressionRight(operand, operator)
+	}
+	return ast.UnaryCallExpr{
+		Operand:   operand,
+		Operator:  operator.Val,
+		Preceding: true,
+	}
+}
+
 func (p *Parser) parseUnaryExpressionLeft(operand ast.Expr, operator token.Item) ast.Expr {
 	return ast.UnaryCallExpr{
 		Operand:   operand,
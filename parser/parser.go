@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"reflect"
+	"strings"
 
 	"github.com/stephens2424/php/ast"
 	"github.com/stephens2424/php/lexer"
@@ -86,13 +88,26 @@ func (p ParseError) String() string {
 	return p.Error()
 }
 
+// Message returns the description of the error, without its position.
+func (p ParseError) Message() string {
+	return p.error.Error()
+}
+
 // Parse consumes the input string to produce an AST that represents it.
 func (p *Parser) Parse(filepath, input string) (file *ast.File, err error) {
-	file = &ast.File{Namespace: p.FileSet.GlobalNamespace, Name: path.Base(filepath)}
+	file = &ast.File{
+		Namespace: p.FileSet.GlobalNamespace,
+		Name:      path.Base(filepath),
+		Positions: ast.Positions{},
+		Names:     ast.Positions{},
+		Docs:      map[int]string{},
+	}
 	p.file = file
+	p.previous, p.idx, p.errors, p.parenLevel = nil, -1, nil, 0
 	p.scope = p.FileSet.Scope
+	file.Scope = p.scope
 	p.namespace = p.FileSet.GlobalNamespace
-	p.lexer = token.Subset(lexer.NewLexer(input), token.Significant)
+	p.lexer = token.Subset(&docRecorder{Stream: lexer.NewLexer(input), file: file}, token.Significant)
 
 	p.FileSet.Files[filepath] = p.file
 	defer func() {
@@ -128,10 +143,38 @@ TokenLoop:
 	return
 }
 
-func (p *Parser) parseNode() ast.Node {
+// docRecorder passes on the tokens of a stream, recording the comments of
+// the file, and each doc comment by the offset of the significant token
+// that follows it.
+type docRecorder struct {
+	token.Stream
+	file    *ast.File
+	pending string
+}
+
+func (r *docRecorder) Next() token.Item {
+	item := r.Stream.Next()
+	switch {
+	case item.Typ == token.CommentBlock || item.Typ == token.CommentLine:
+		r.file.Comments = append(r.file.Comments, ast.Comment{
+			Text: strings.TrimRight(item.Val, " \t\r\n"),
+			Span: ast.Span{Begin: item.Begin, End: item.End},
+		})
+		if item.Typ == token.CommentBlock && strings.HasPrefix(item.Val, "/**") && item.Val != "/**/" {
+			r.pending = item.Val
+		}
+	case item.Typ.Type().Is(token.Significant) && r.pending != "":
+		r.file.Docs[item.Begin.Position] = r.pending
+		r.pending = ""
+	}
+	return item
+}
+
+func (p *Parser) parseNode() (n ast.Node) {
+	defer func(begin token.Position) { p.setSpan(n, begin) }(p.current.Begin)
 	switch p.current.Typ {
 	case token.HTML:
-		return ast.Echo(ast.Literal{Type: ast.String, Value: p.current.Val})
+		return &ast.InlineHTML{Value: p.current.Val}
 	case token.PHPBegin:
 		return nil
 	case token.PHPEnd:
@@ -140,6 +183,56 @@ func (p *Parser) parseNode() ast.Node {
 	return p.parseTopStmt()
 }
 
+// setSpan records that n was parsed from the source beginning at begin and
+// ending with the current token, unless its span was already recorded by a
+// more specific part of the parser.
+func (p *Parser) setSpan(n ast.Node, begin token.Position) {
+	if ast.IsNil(n) || reflect.ValueOf(n).Kind() != reflect.Ptr {
+		return
+	}
+	if _, ok := p.file.Positions[n]; !ok {
+		p.file.Positions[n] = ast.Span{Begin: begin, End: p.current.End}
+	}
+}
+
+// beginningOf returns the beginning of the span of n, which must already
+// have been parsed, or of the current token if it is unknown.
+func (p *Parser) beginningOf(n ast.Node) token.Position {
+	if span, ok := p.file.Positions.Span(n); ok {
+		return span.Begin
+	}
+	return p.current.Begin
+}
+
+// setName records the current token as the name of the declaration n.
+func (p *Parser) setName(n ast.Node) {
+	p.file.Names[n] = ast.Span{Begin: p.current.Begin, End: p.current.End}
+}
+
+// addClassName records the current token as a class name written in a
+// declaration.
+func (p *Parser) addClassName() {
+	p.file.ClassNames = append(p.file.ClassNames, ast.ClassName{
+		Name: p.current.Val,
+		Span: ast.Span{Begin: p.current.Begin, End: p.current.End},
+	})
+}
+
+// newIdentifier returns an identifier for the current token.
+func (p *Parser) newIdentifier() *ast.Identifier {
+	i := &ast.Identifier{Value: p.current.Val}
+	p.setSpan(i, p.current.Begin)
+	return i
+}
+
+// newVariable returns a variable named by the current token, which follows
+// its $.
+func (p *Parser) newVariable() *ast.Variable {
+	v := &ast.Variable{Name: p.newIdentifier(), Type: ast.Unknown}
+	p.setSpan(v, p.previous[p.idx-1].Begin)
+	return v
+}
+
 func (p *Parser) canceled() bool {
 	if p.Ctx != nil {
 		select {
@@ -233,6 +326,7 @@ func errorf(p *Parser, str string, args ...interface{}) ParseError {
 	if p != nil {
 		e.File = p.file
 		e.Line = p.current.Begin.Line
+		e.Column = p.current.Begin.Column
 	}
 	return e
 }
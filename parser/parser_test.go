@@ -50,7 +50,7 @@ func TestPHPParserHW(t *testing.T) {
 	p := NewParser()
 	p.disableScoping = true
 	a, _ := p.Parse("test.php", testStr)
-	tree := ast.Echo(ast.Literal{Type: ast.String, Value: `hello world`})
+	tree := &ast.InlineHTML{Value: `hello world`}
 	if !assertEquals(a.Nodes[0], tree) {
 		t.Fatalf("Hello world did not correctly parse")
 	}
@@ -205,7 +205,7 @@ func TestFunction(t *testing.T) {
 				Statements: []ast.Statement{ast.Echo(ast.NewVariable("arg"))},
 			},
 		},
-		ast.ExprStmt{
+		&ast.ExprStmt{
 			Expr: ast.AssignmentExpr{
 				Assignee: ast.NewVariable("var"),
 				Value: &ast.FunctionCallExpr{
@@ -362,7 +362,7 @@ func TestArray(t *testing.T) {
 	if len(a.Nodes) == 0 {
 		t.Fatalf("Array did not correctly parse")
 	}
-	tree := ast.ExprStmt{
+	tree := &ast.ExprStmt{
 		Expr: ast.AssignmentExpr{
 			Assignee: ast.NewVariable("var"),
 			Operator: "=",
@@ -392,7 +392,7 @@ func TestArrayKeys(t *testing.T) {
 	if len(a.Nodes) == 0 {
 		t.Fatalf("Array did not correctly parse")
 	}
-	tree := ast.ExprStmt{Expr: ast.AssignmentExpr{
+	tree := &ast.ExprStmt{Expr: ast.AssignmentExpr{
 		Assignee: ast.NewVariable("var"),
 		Operator: "=",
 		Value: &ast.ArrayExpr{
@@ -420,7 +420,7 @@ func TestMethodCall(t *testing.T) {
 	if len(a.Nodes) == 0 {
 		t.Fatalf("Method call did not correctly parse")
 	}
-	tree := ast.ExprStmt{Expr: ast.AssignmentExpr{
+	tree := &ast.ExprStmt{Expr: ast.AssignmentExpr{
 		Assignee: ast.NewVariable("res"),
 		Operator: "=",
 		Value: &ast.MethodCallExpr{
@@ -448,7 +448,7 @@ func TestProperty(t *testing.T) {
 	if len(a.Nodes) != 2 {
 		t.Fatalf("Property did not correctly parse")
 	}
-	tree := ast.ExprStmt{Expr: ast.AssignmentExpr{
+	tree := &ast.ExprStmt{Expr: ast.AssignmentExpr{
 		Assignee: ast.NewVariable("res"),
 		Operator: "=",
 		Value: &ast.PropertyCallExpr{
@@ -460,7 +460,7 @@ func TestProperty(t *testing.T) {
 		t.Fatalf("Property did not correctly parse")
 	}
 
-	tree = ast.ExprStmt{Expr: ast.AssignmentExpr{
+	tree = &ast.ExprStmt{Expr: ast.AssignmentExpr{
 		Assignee: &ast.PropertyCallExpr{
 			Receiver: ast.NewVariable("var"),
 			Name:     &ast.Identifier{Value: "go"},
@@ -550,6 +550,69 @@ func TestForeachLoop(t *testing.T) {
 	}
 }
 
+func TestForeachReference(t *testing.T) {
+	testStr := `<?
+  foreach ($arr as $key => &$val) {}
+  foreach ($arr as &$val) {}`
+	p := NewParser()
+	p.disableScoping = true
+	a, _ := p.Parse("test.php", testStr)
+	if len(a.Nodes) != 2 {
+		t.Fatalf("Foreach did not correctly parse")
+	}
+	tree := &ast.ForeachStmt{
+		Source:    ast.NewVariable("arr"),
+		Key:       ast.NewVariable("key"),
+		Value:     ast.NewVariable("val"),
+		LoopBlock: &ast.Block{},
+		Reference: true,
+	}
+	if !assertEquals(a.Nodes[0], tree) {
+		t.Fatalf("Foreach by reference did not correctly parse")
+	}
+	tree.Key = nil
+	if !assertEquals(a.Nodes[1], tree) {
+		t.Fatalf("Foreach by reference without key did not correctly parse")
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	testStr := `<ul>
+<?php foreach ($arr as $val): ?>
+  <li><?= $val ?></li>
+<?php endforeach ?>
+</ul>`
+	p := NewParser()
+	p.disableScoping = true
+	a, err := p.Parse("test.php", testStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo := ast.Echo(ast.NewVariable("val"))
+	echo.ShortTag = true
+	tree := []ast.Node{
+		&ast.InlineHTML{Value: "<ul>\n"},
+		&ast.ForeachStmt{
+			Source: ast.NewVariable("arr"),
+			Value:  ast.NewVariable("val"),
+			LoopBlock: &ast.Block{
+				Statements: []ast.Statement{
+					&ast.InlineHTML{Value: "\n  <li>"},
+					echo,
+					&ast.InlineHTML{Value: "</li>\n"},
+				},
+			},
+			AltSyntax: true,
+		},
+		&ast.InlineHTML{Value: "\n</ul>"},
+	}
+	if !reflect.DeepEqual(a.Nodes, tree) {
+		fmt.Printf("Found:    %+v\n", a.Nodes)
+		fmt.Printf("Expected: %+v\n", tree)
+		t.Fatalf("Template did not correctly parse")
+	}
+}
+
 func TestForLoop(t *testing.T) {
 	testStr := `<?
   for ($i = 0; $i < 10; $i++) {
@@ -638,7 +701,7 @@ func TestArrayLookup(t *testing.T) {
 		t.Fatalf("Array lookup did not correctly parse")
 	}
 	tree := []ast.Node{
-		ast.EchoStmt{
+		&ast.EchoStmt{
 			Expressions: []ast.Expr{&ast.ArrayLookupExpr{
 				Array: &ast.ArrayLookupExpr{
 					Array: ast.NewVariable("arr"),
@@ -647,7 +710,7 @@ func TestArrayLookup(t *testing.T) {
 				Index: ast.NewVariable("two"),
 			}},
 		},
-		ast.ExprStmt{
+		&ast.ExprStmt{
 			Expr: ast.AssignmentExpr{
 				Assignee: ast.ArrayAppendExpr{
 					Array: &ast.PropertyCallExpr{
@@ -729,22 +792,22 @@ func TestLiterals(t *testing.T) {
 		t.Fatalf("Literals did not correctly parse")
 	}
 	tree := []ast.Node{
-		ast.ExprStmt{Expr: ast.AssignmentExpr{
+		&ast.ExprStmt{Expr: ast.AssignmentExpr{
 			Assignee: ast.NewVariable("var"),
 			Value:    &ast.Literal{Type: ast.String, Value: `"one"`},
 			Operator: "=",
 		}},
-		ast.ExprStmt{Expr: ast.AssignmentExpr{
+		&ast.ExprStmt{Expr: ast.AssignmentExpr{
 			Assignee: ast.NewVariable("var"),
 			Value:    &ast.Literal{Type: ast.Float, Value: "2"},
 			Operator: "=",
 		}},
-		ast.ExprStmt{Expr: ast.AssignmentExpr{
+		&ast.ExprStmt{Expr: ast.AssignmentExpr{
 			Assignee: ast.NewVariable("var"),
 			Value:    &ast.Literal{Type: ast.Boolean, Value: "true"},
 			Operator: "=",
 		}},
-		ast.ExprStmt{Expr: ast.AssignmentExpr{
+		&ast.ExprStmt{Expr: ast.AssignmentExpr{
 			Assignee: ast.NewVariable("var"),
 			Value:    &ast.Literal{Type: ast.Null, Value: "null"},
 			Operator: "=",
@@ -765,7 +828,7 @@ func TestComments(t *testing.T) {
   */
   #line ?>html`
 	tree := []ast.Node{
-		ast.Echo(ast.Literal{Type: ast.String, Value: "html"}),
+		&ast.InlineHTML{Value: "html"},
 	}
 	p := NewParser()
 	p.disableScoping = true
@@ -786,7 +849,7 @@ func TestScopeResolutionOperator(t *testing.T) {
 	p.disableScoping = true
 	a, _ := p.Parse("test.php", testStr)
 	tree := []ast.Node{
-		ast.ExprStmt{
+		&ast.ExprStmt{
 			Expr: &ast.ClassExpr{
 				Receiver: &ast.Identifier{Value: "MyClass"},
 				Expr: &ast.FunctionCallExpr{
@@ -829,7 +892,7 @@ func TestCastOperator(t *testing.T) {
 	p.disableScoping = true
 	a, _ := p.Parse("test.php", testStr)
 	tree := []ast.Node{
-		ast.ExprStmt{Expr: ast.AssignmentExpr{
+		&ast.ExprStmt{Expr: ast.AssignmentExpr{
 			Assignee: ast.NewVariable("var"),
 			Value: ast.UnaryCallExpr{
 				Operand:   &ast.Literal{Type: ast.Float, Value: "1.0"},
@@ -898,3 +961,89 @@ func TestGlobal(t *testing.T) {
 		t.Fatalf("Global did not parse correctly")
 	}
 }
+
+func TestTryFinally(t *testing.T) {
+	testStr := `<?php
+  try {
+  } catch (Exception $e) {
+  } finally {
+    echo "done";
+  }
+  try {
+  } finally {
+  }`
+	p := NewParser()
+	p.disableScoping = true
+	a, err := p.Parse("test.php", testStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := &ast.TryStmt{
+		TryBlock: &ast.Block{},
+		CatchStmts: []*ast.CatchStmt{
+			{
+				CatchType:  "Exception",
+				CatchVar:   ast.NewVariable("e"),
+				CatchBlock: &ast.Block{},
+			},
+		},
+		FinallyBlock: &ast.Block{
+			Statements: []ast.Statement{ast.Echo(&ast.Literal{Type: ast.String, Value: `"done"`})},
+		},
+	}
+	if !assertEquals(a.Nodes[0], tree) {
+		t.Fatalf("Try with finally did not parse correctly")
+	}
+	tree = &ast.TryStmt{
+		TryBlock:     &ast.Block{},
+		FinallyBlock: &ast.Block{},
+	}
+	if !assertEquals(a.Nodes[1], tree) {
+		t.Fatalf("Try without catch did not parse correctly")
+	}
+}
+
+func TestYield(t *testing.T) {
+	testStr := `<?php
+  yield;
+  $sent = yield $a + 1;
+  yield $k => $v;
+  yield from gen();
+  f(yield);`
+	p := NewParser()
+	p.disableScoping = true
+	a, err := p.Parse("test.php", testStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trees := []ast.Node{
+		&ast.ExprStmt{Expr: &ast.YieldExpr{}},
+		&ast.ExprStmt{Expr: ast.AssignmentExpr{
+			Assignee: ast.NewVariable("sent"),
+			Operator: "=",
+			Value: &ast.YieldExpr{Value: ast.BinaryExpr{
+				Antecedent: ast.NewVariable("a"),
+				Subsequent: &ast.Literal{Type: ast.Float, Value: "1"},
+				Type:       ast.Numeric,
+				Operator:   "+",
+			}},
+		}},
+		&ast.ExprStmt{Expr: &ast.YieldExpr{Key: ast.NewVariable("k"), Value: ast.NewVariable("v")}},
+		&ast.ExprStmt{Expr: &ast.YieldExpr{
+			Value: &ast.FunctionCallExpr{FunctionName: &ast.Identifier{Value: "gen"}, Arguments: []ast.Expr{}},
+			From:  true,
+		}},
+		&ast.ExprStmt{Expr: &ast.FunctionCallExpr{
+			FunctionName: &ast.Identifier{Value: "f"},
+			Arguments:    []ast.Expr{&ast.YieldExpr{}},
+		}},
+	}
+	if len(a.Nodes) != len(trees) {
+		t.Fatalf("yield parsed as %d statements", len(a.Nodes))
+	}
+	for i, tree := range trees {
+		if !assertEquals(a.Nodes[i], tree) {
+			t.Errorf("yield %d did not parse correctly", i)
+		}
+	}
+}
@@ -1,6 +1,107 @@
 package parser
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/ast"
+)
 
 func TestPosition(t *testing.T) {
+	src := `<?php
+function greet($name) {
+  echo "Hello, " . $name;
+  return strlen($name);
+}
+throw greet("you");
+greet("me");`
+	file, err := NewParser().Parse("test.php", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := file.Nodes[0].(*ast.FunctionStmt)
+	ret := fn.Body.Statements[1].(*ast.ReturnStmt)
+	call := ret.Expr.(*ast.FunctionCallExpr)
+	echo := fn.Body.Statements[0].(*ast.EchoStmt)
+	throw := file.Nodes[1].(*ast.ThrowStmt)
+	expr := file.Nodes[2].(*ast.ExprStmt)
+	tests := []struct {
+		node         ast.Node
+		text         string
+		line, column int
+	}{
+		{fn, src[6:strings.Index(src, "\nthrow")], 2, 1},
+		{fn.Arguments[0], "$name", 2, 16},
+		{echo, `echo "Hello, " . $name;`, 3, 3},
+		{echo.Expressions[0], `"Hello, " . $name`, 3, 8},
+		{ret, "return strlen($name);", 4, 3},
+		{call, "strlen($name)", 4, 10},
+		{call.FunctionName, "strlen", 4, 10},
+		{call.Arguments[0], "$name", 4, 17},
+		{throw, `throw greet("you");`, 6, 1},
+		{throw.Expr, `greet("you")`, 6, 7},
+		{expr, `greet("me");`, 7, 1},
+	}
+	for _, test := range tests {
+		span, ok := file.Positions.Span(test.node)
+		if !ok {
+			t.Errorf("no span for %s", test.text)
+			continue
+		}
+		if text := src[span.Begin.Position:span.End.Position]; text != test.text {
+			t.Errorf("span is %q, expected %q", text, test.text)
+		}
+		if span.Begin.Line != test.line || span.Begin.Column != test.column {
+			t.Errorf("%s begins at %d:%d, expected %d:%d", test.text, span.Begin.Line, span.Begin.Column, test.line, test.column)
+		}
+	}
+}
+
+func TestDocs(t *testing.T) {
+	src := `<?php
+/** class */
+abstract class A {
+  /** property */
+  public $p, $q;
+  /** method */
+  abstract protected function m();
+}
+interface I {
+  /** interface method */
+  public function n();
+}
+/* not a doc */
+function f() {
+  /** @var int $x */
+  $x = g();
+  return $x;
+}
+`
+	file, err := NewParser().Parse("test.php", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	class := file.Nodes[0].(*ast.Class)
+	iface := file.Nodes[1].(*ast.Interface)
+	fn := file.Nodes[2].(*ast.FunctionStmt)
+	tests := []struct {
+		node ast.Node
+		doc  string
+	}{
+		{class, "/** class */"},
+		{class.Properties[0], "/** property */"},
+		{class.Properties[1], "/** property */"},
+		{class.Methods[0], "/** method */"},
+		{iface.Methods[0].FunctionStmt, "/** interface method */"},
+		{fn, ""},
+		{fn.Body.Statements[0], "/** @var int $x */"},
+		{fn.Body.Statements[1], ""},
+	}
+	for _, test := range tests {
+		if doc := file.Doc(test.node); doc != test.doc {
+			t.Errorf("doc of %s is %q, expected %q", test.node, doc, test.doc)
+		}
+	}
 }
@@ -36,6 +36,24 @@ func TestScope(t *testing.T) {
 	ExpectVariables(a.Namespace.ClassesAndInterfaces["fizz"].(*ast.Class).Methods[0].FunctionStmt.Body.Scope, []string{"var4"}, t)
 }
 
+func TestScopeDeclarations(t *testing.T) {
+	src := `<?php
+	function f($arg) {
+		foreach ($arg as $key => $value) {}
+		try {} catch (Exception $e) {}
+		return function($inner) use ($captured) {};
+	}
+	`
+
+	p := NewParser()
+	a, err := p.Parse("test.php", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ExpectVariables(a.Namespace.Functions["f"].Body.Scope, []string{"arg", "key", "value", "e", "captured"}, t)
+}
+
 func ExpectVariables(s *ast.Scope, variables []string, t *testing.T) {
 	expected := map[string]struct{}{}
 	hasError := false
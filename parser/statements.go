@@ -10,19 +10,21 @@ func (p *Parser) parseTopStmt() ast.Statement {
 	case token.Namespace:
 		// TODO check that this comes before anything but a declare statement
 		p.expect(token.Identifier)
-		p.namespace = ast.NewNamespace(p.current.Val)
+		p.namespace = p.FileSet.Namespace(p.current.Val)
 		p.file.Namespace = p.namespace
 		p.expectStmtEnd()
 		return nil
 	case token.Use:
 		p.expect(token.Identifier)
+		use := &ast.UseStmt{Name: p.current.Val}
+		p.setName(use)
 		if p.peek().Typ == token.AsOperator {
 			p.expect(token.AsOperator)
 			p.expect(token.Identifier)
+			use.Alias = p.current.Val
 		}
 		p.expectStmtEnd()
-		// We are ignoring this for now
-		return nil
+		return use
 	case token.Declare:
 		return p.parseDeclareBlock()
 	default:
@@ -30,7 +32,8 @@ func (p *Parser) parseTopStmt() ast.Statement {
 	}
 }
 
-func (p *Parser) parseStmt() ast.Statement {
+func (p *Parser) parseStmt() (stmt ast.Statement) {
+	defer func(begin token.Position) { p.setSpan(stmt, begin) }(p.current.Begin)
 	switch p.current.Typ {
 	case token.BlockBegin:
 		p.backup()
@@ -98,7 +101,7 @@ func (p *Parser) parseStmt() ast.Statement {
 		p.expectStmtEnd()
 		return s
 	case token.VariableOperator, token.UnaryOperator:
-		expr := ast.ExprStmt{Expr: p.parseExpression()}
+		expr := &ast.ExprStmt{Expr: p.parseExpression()}
 		p.expectStmtEnd()
 		return expr
 	case token.Print:
@@ -119,16 +122,18 @@ func (p *Parser) parseStmt() ast.Statement {
 		if p.peek().Typ == token.EOF {
 			return nil
 		}
-		var expr ast.Statement
+		var stmt ast.Statement
 		if p.accept(token.HTML) {
-			expr = ast.Echo(&ast.Literal{Type: ast.String, Value: p.current.Val})
+			stmt = &ast.InlineHTML{Value: p.current.Val}
 		}
 		p.next()
 		if p.current.Typ != token.EOF {
 			p.expectCurrent(token.PHPBegin)
 		}
-		return expr
+		return stmt
 	case token.Echo:
+		// the lexer gives the echo implied by <?= the value "="
+		shortTag := p.current.Val == "="
 		exprs := []ast.Expr{
 			p.parseNextExpression(),
 		}
@@ -138,6 +143,7 @@ func (p *Parser) parseStmt() ast.Statement {
 		}
 		p.expectStmtEnd()
 		echo := ast.Echo(exprs...)
+		echo.ShortTag = shortTag
 		return echo
 	case token.If:
 		return p.parseIf()
@@ -151,7 +157,7 @@ func (p *Parser) parseStmt() ast.Statement {
 		return p.parseForeach()
 	case token.Switch:
 		return p.parseSwitch()
-	case token.Abstract, token.Final, token.Class:
+	case token.Abstract, token.Final, token.Class, token.Trait:
 		return p.parseClass()
 	case token.Interface:
 		return p.parseInterface()
@@ -180,7 +186,7 @@ func (p *Parser) parseStmt() ast.Statement {
 		}
 		return stmt
 	case token.Throw:
-		stmt := ast.ThrowStmt{Expr: p.parseNextExpression()}
+		stmt := &ast.ThrowStmt{Expr: p.parseNextExpression()}
 		p.expectStmtEnd()
 		return stmt
 	case token.Exit:
@@ -197,18 +203,24 @@ func (p *Parser) parseStmt() ast.Statement {
 	case token.Try:
 		stmt := &ast.TryStmt{}
 		stmt.TryBlock = p.parseBlock()
-		for p.expect(token.Catch); p.current.Typ == token.Catch; p.next() {
+		for p.expect(token.Catch, token.Finally); p.current.Typ == token.Catch; p.next() {
 			caught := &ast.CatchStmt{}
 			p.expect(token.OpenParen)
 			p.expect(token.Identifier)
 			caught.CatchType = p.current.Val
+			p.addClassName()
 			p.expect(token.VariableOperator)
 			p.expect(token.Identifier)
-			caught.CatchVar = ast.NewVariable(p.current.Val)
+			caught.CatchVar = p.newVariable()
+			p.scope.Variable(caught.CatchVar)
 			p.expect(token.CloseParen)
 			caught.CatchBlock = p.parseBlock()
 			stmt.CatchStmts = append(stmt.CatchStmts, caught)
 		}
+		if p.current.Typ == token.Finally {
+			stmt.FinallyBlock = p.parseBlock()
+			return stmt
+		}
 		p.backup()
 		return stmt
 	case token.IgnoreErrorOperator:
@@ -222,7 +234,7 @@ func (p *Parser) parseStmt() ast.Statement {
 		expr := p.parseExpression()
 		if expr != nil {
 			p.expectStmtEnd()
-			return ast.ExprStmt{Expr: expr}
+			return &ast.ExprStmt{Expr: expr}
 		}
 		p.errorf("Found %s, statement or expression", p.current)
 		return nil
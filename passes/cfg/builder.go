@@ -0,0 +1,582 @@
+package cfg
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// builder builds a graph, block by block.
+type builder struct {
+	g *Graph
+
+	// cur is the block being built, or nil after code that never
+	// completes, where a block is begun for any code that follows.
+	cur *Block
+
+	loops []*loop
+	tries []*try
+}
+
+// loop is a loop or switch statement being built.
+type loop struct {
+	// brk is the block that break leaves to, and cont the block that
+	// continue leaves to.
+	brk, cont *Block
+
+	// tries is the number of try statements the loop is in.
+	tries int
+}
+
+// The phases of building a try statement.
+const (
+	inTry = iota
+	inCatch
+	inFinally
+)
+
+// try is a try statement being built.
+type try struct {
+	phase   int
+	catches []*Block
+	finally *Block
+
+	// normal is set if control enters the finally block by completing the
+	// try block or a catch clause.
+	normal bool
+
+	// pending holds where control goes after the finally block when it
+	// enters it by leaving the try statement otherwise.
+	pending []pending
+}
+
+// pending is a jump through a finally block, to the block to, in the
+// first tries try statements, or if throw is set, by throwing.
+type pending struct {
+	to    *Block
+	tries int
+	throw bool
+}
+
+func newBuilder() *builder {
+	b := &builder{g: &Graph{}}
+	b.g.Entry = b.newBlock("entry")
+	b.g.Exit = b.newBlock("exit")
+	b.cur = b.g.Entry
+	return b
+}
+
+func (b *builder) newBlock(kind string) *Block {
+	block := &Block{Index: len(b.g.Blocks), Kind: kind}
+	b.g.Blocks = append(b.g.Blocks, block)
+	return block
+}
+
+// edge adds an edge from the block from, if it is not nil, to the block to.
+func (b *builder) edge(from, to *Block, kind EdgeKind) {
+	if from == nil {
+		return
+	}
+	for _, e := range from.Succs {
+		if e.To == to && e.Kind == kind {
+			return
+		}
+	}
+	e := &Edge{From: from, To: to, Kind: kind}
+	from.Succs = append(from.Succs, e)
+	to.Preds = append(to.Preds, e)
+}
+
+// block returns the block being built, beginning one if there is none.
+func (b *builder) block() *Block {
+	if b.cur == nil {
+		b.cur = b.newBlock("unreachable")
+	}
+	return b.cur
+}
+
+// add adds the node n to the block being built, which may throw where n
+// may.
+func (b *builder) add(n ast.Node) {
+	block := b.block()
+	block.Nodes = append(block.Nodes, n)
+	if mayThrow(n) && b.catching() {
+		b.throw(block, len(b.tries))
+	}
+}
+
+// catching reports whether an exception thrown by the code being built
+// would be handled by a try statement it is in.
+func (b *builder) catching() bool {
+	for _, t := range b.tries {
+		if t.phase == inTry && len(t.catches) > 0 || t.phase != inFinally && t.finally != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// throw adds the edges along which an exception thrown by the block from,
+// in the first tries try statements, passes.
+func (b *builder) throw(from *Block, tries int) {
+	for i := tries - 1; i >= 0; i-- {
+		t := b.tries[i]
+		if t.phase == inTry {
+			for _, c := range t.catches {
+				b.edge(from, c, Exception)
+			}
+		}
+		if t.finally != nil && t.phase != inFinally {
+			b.edge(from, t.finally, Exception)
+			t.pending = append(t.pending, pending{tries: i, throw: true})
+			return
+		}
+	}
+	b.edge(from, b.g.Exit, Exception)
+}
+
+// jump ends the block being built with a jump to the block to, in the
+// first tries try statements, passing through the finally blocks of the
+// try statements it leaves.
+func (b *builder) jump(to *Block, tries int) {
+	for i := len(b.tries) - 1; i >= tries; i-- {
+		t := b.tries[i]
+		if t.finally != nil && t.phase != inFinally {
+			b.edge(b.cur, t.finally, Normal)
+			t.pending = append(t.pending, pending{to: to, tries: tries})
+			b.cur = nil
+			return
+		}
+	}
+	b.edge(b.cur, to, Normal)
+	b.cur = nil
+}
+
+// finish ends the graph, removing the empty blocks that control cannot
+// enter and ordering the others.
+func (b *builder) finish() *Graph {
+	b.edge(b.cur, b.g.Exit, Normal)
+	g := b.g
+	for removed := true; removed; {
+		removed = false
+		var blocks []*Block
+		for _, block := range g.Blocks {
+			if block != g.Entry && block != g.Exit && len(block.Preds) == 0 && len(block.Nodes) == 0 {
+				for _, e := range block.Succs {
+					e.To.Preds = removeEdge(e.To.Preds, e)
+				}
+				removed = true
+				continue
+			}
+			blocks = append(blocks, block)
+		}
+		g.Blocks = blocks
+	}
+	// order the blocks control can enter in reverse postorder, in which
+	// a block comes before those it leads to but through a loop
+	seen := map[*Block]bool{g.Exit: true}
+	var post []*Block
+	var visit func(block *Block)
+	visit = func(block *Block) {
+		seen[block] = true
+		for _, e := range block.Succs {
+			if !seen[e.To] {
+				visit(e.To)
+			}
+		}
+		post = append(post, block)
+	}
+	visit(g.Entry)
+	blocks := make([]*Block, 0, len(g.Blocks))
+	for i := len(post) - 1; i >= 0; i-- {
+		blocks = append(blocks, post[i])
+	}
+	for _, block := range g.Blocks {
+		if !seen[block] {
+			blocks = append(blocks, block)
+		}
+	}
+	g.Blocks = append(blocks, g.Exit)
+	for i, block := range g.Blocks {
+		block.Index = i
+	}
+	return g
+}
+
+func removeEdge(edges []*Edge, e *Edge) []*Edge {
+	for i, other := range edges {
+		if other == e {
+			return append(edges[:i:i], edges[i+1:]...)
+		}
+	}
+	return edges
+}
+
+// list builds the statements stmts.
+func (b *builder) list(stmts []ast.Node) {
+	for _, s := range stmts {
+		b.statement(s)
+	}
+}
+
+// body builds the statement s, the body of a branch or loop.
+func (b *builder) body(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.Block:
+		b.list(statements(s.Statements))
+	case ast.Block:
+		b.list(statements(s.Statements))
+	default:
+		if !ast.IsNil(s) {
+			b.statement(s)
+		}
+	}
+}
+
+func (b *builder) statement(s ast.Node) {
+	switch s := s.(type) {
+	case *ast.Block:
+		b.list(statements(s.Statements))
+	case ast.Block:
+		b.list(statements(s.Statements))
+	case *ast.IfStmt:
+		b.ifStmt(s)
+	case *ast.WhileStmt:
+		b.whileStmt(s)
+	case *ast.DoWhileStmt:
+		b.doWhileStmt(s)
+	case *ast.ForStmt:
+		b.forStmt(s)
+	case *ast.ForeachStmt:
+		b.foreachStmt(s)
+	case ast.SwitchStmt:
+		b.switchStmt(&s)
+	case *ast.SwitchStmt:
+		b.switchStmt(s)
+	case *ast.TryStmt:
+		b.tryStmt(s)
+	case ast.ReturnStmt, *ast.ReturnStmt:
+		b.add(s)
+		b.jump(b.g.Exit, 0)
+	case ast.ThrowStmt, *ast.ThrowStmt:
+		block := b.block()
+		block.Nodes = append(block.Nodes, s)
+		b.throw(block, len(b.tries))
+		b.cur = nil
+	case *ast.ExitStmt, ast.ExitStmt:
+		b.add(s)
+		b.edge(b.cur, b.g.Exit, Normal)
+		b.cur = nil
+	case *ast.BreakStmt:
+		b.add(s)
+		b.leave(s.Expr, true)
+	case ast.BreakStmt:
+		b.add(s)
+		b.leave(s.Expr, true)
+	case *ast.ContinueStmt:
+		b.add(s)
+		b.leave(s.Expr, false)
+	case ast.ContinueStmt:
+		b.add(s)
+		b.leave(s.Expr, false)
+	case *ast.ExprStmt:
+		b.add(s)
+		if dies(s.Expr) {
+			b.edge(b.cur, b.g.Exit, Normal)
+			b.cur = nil
+		}
+	case *ast.FunctionStmt, *ast.Class, *ast.Interface:
+		block := b.block()
+		block.Nodes = append(block.Nodes, s)
+	default:
+		b.add(s)
+	}
+}
+
+// leave ends the block being built with a break, if brk is set, or a
+// continue, leaving the number of levels given by the argument level.
+func (b *builder) leave(level ast.Expr, brk bool) {
+	n := 1
+	if l, ok := level.(*ast.Literal); ok {
+		if i, err := strconv.Atoi(l.Value); err == nil && i > 0 {
+			n = i
+		}
+	}
+	if n > len(b.loops) {
+		// PHP rejects this
+		b.jump(b.g.Exit, 0)
+		return
+	}
+	l := b.loops[len(b.loops)-n]
+	if brk {
+		b.jump(l.brk, l.tries)
+	} else {
+		b.jump(l.cont, l.tries)
+	}
+}
+
+// dies reports whether the expression e calls die or exit, which the
+// parser reads as a constant or a function call when it is written
+// without exit.
+func dies(e ast.Expr) bool {
+	var name ast.Dynamic
+	switch e := e.(type) {
+	case ast.ConstantExpr:
+		name = e.Name
+	case *ast.FunctionCallExpr:
+		name = e.FunctionName
+	default:
+		return false
+	}
+	id := ast.Static(name)
+	return id != nil && (strings.EqualFold(id.Value, "die") || strings.EqualFold(id.Value, "exit"))
+}
+
+// mayThrow reports whether running n may throw an exception, as it calls
+// a function or method, creates an object, includes a file or yields.
+func mayThrow(n ast.Node) bool {
+	if ast.IsNil(n) {
+		return false
+	}
+	switch n.(type) {
+	case *ast.FunctionCallExpr, *ast.MethodCallExpr, *ast.NewCallExpr, ast.Include, *ast.Include, ast.IncludeStmt, *ast.IncludeStmt, ast.ThrowStmt, *ast.ThrowStmt, *ast.YieldExpr:
+		return true
+	case *ast.AnonymousFunction, *ast.FunctionStmt, *ast.Class, *ast.Interface:
+		return false
+	}
+	for _, child := range n.Children() {
+		if mayThrow(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *builder) ifStmt(s *ast.IfStmt) {
+	done := b.newBlock("if.done")
+	for i, branch := range s.Branches {
+		b.add(branch.Condition)
+		cond := b.cur
+		then := b.newBlock("if.then")
+		b.edge(cond, then, True)
+		b.cur = then
+		b.body(branch.Block)
+		b.edge(b.cur, done, Normal)
+
+		next := done
+		switch {
+		case i < len(s.Branches)-1:
+			next = b.newBlock("if.elseif")
+		case !ast.IsNil(s.ElseBlock):
+			next = b.newBlock("if.else")
+		}
+		b.edge(cond, next, False)
+		b.cur = next
+	}
+	if !ast.IsNil(s.ElseBlock) {
+		b.body(s.ElseBlock)
+		b.edge(b.cur, done, Normal)
+	}
+	b.cur = done
+}
+
+func (b *builder) whileStmt(s *ast.WhileStmt) {
+	head := b.newBlock("while.head")
+	b.edge(b.cur, head, Normal)
+	b.cur = head
+	b.add(s.Termination)
+	cond := b.cur
+	body, done := b.newBlock("while.body"), b.newBlock("while.done")
+	b.edge(cond, body, True)
+	b.edge(cond, done, False)
+
+	b.loops = append(b.loops, &loop{brk: done, cont: head, tries: len(b.tries)})
+	b.cur = body
+	b.body(s.LoopBlock)
+	b.edge(b.cur, head, Normal)
+	b.loops = b.loops[:len(b.loops)-1]
+	b.cur = done
+}
+
+func (b *builder) doWhileStmt(s *ast.DoWhileStmt) {
+	body, cond, done := b.newBlock("do.body"), b.newBlock("do.cond"), b.newBlock("do.done")
+	b.edge(b.cur, body, Normal)
+
+	b.loops = append(b.loops, &loop{brk: done, cont: cond, tries: len(b.tries)})
+	b.cur = body
+	b.body(s.LoopBlock)
+	b.edge(b.cur, cond, Normal)
+	b.loops = b.loops[:len(b.loops)-1]
+
+	b.cur = cond
+	b.add(s.Termination)
+	b.edge(b.cur, body, True)
+	b.edge(b.cur, done, False)
+	b.cur = done
+}
+
+func (b *builder) forStmt(s *ast.ForStmt) {
+	for _, e := range s.Initialization {
+		b.add(e)
+	}
+	head := b.newBlock("for.head")
+	b.edge(b.cur, head, Normal)
+	b.cur = head
+	for _, e := range s.Termination {
+		b.add(e)
+	}
+	cond := b.cur
+	body, post, done := b.newBlock("for.body"), b.newBlock("for.post"), b.newBlock("for.done")
+	if len(s.Termination) > 0 {
+		b.edge(cond, body, True)
+		b.edge(cond, done, False)
+	} else {
+		b.edge(cond, body, Normal)
+	}
+
+	b.loops = append(b.loops, &loop{brk: done, cont: post, tries: len(b.tries)})
+	b.cur = body
+	b.body(s.LoopBlock)
+	b.edge(b.cur, post, Normal)
+	b.loops = b.loops[:len(b.loops)-1]
+
+	b.cur = post
+	for _, e := range s.Iteration {
+		b.add(e)
+	}
+	b.edge(b.cur, head, Normal)
+	b.cur = done
+}
+
+func (b *builder) foreachStmt(s *ast.ForeachStmt) {
+	b.add(s.Source)
+	head := b.newBlock("foreach.head")
+	b.edge(b.cur, head, Normal)
+	head.Nodes = append(head.Nodes, s)
+	body, done := b.newBlock("foreach.body"), b.newBlock("foreach.done")
+	b.edge(head, body, True)
+	b.edge(head, done, False)
+
+	b.loops = append(b.loops, &loop{brk: done, cont: head, tries: len(b.tries)})
+	b.cur = body
+	b.body(s.LoopBlock)
+	b.edge(b.cur, head, Normal)
+	b.loops = b.loops[:len(b.loops)-1]
+	b.cur = done
+}
+
+// switchStmt builds a switch statement, whose default case, if it has one,
+// is taken to follow its other cases.
+func (b *builder) switchStmt(s *ast.SwitchStmt) {
+	b.add(s.Expr)
+	done := b.newBlock("switch.done")
+	bodies := make([]*Block, len(s.Cases))
+	for i := range s.Cases {
+		bodies[i] = b.newBlock("switch.body")
+	}
+	var def *Block
+	if s.DefaultCase != nil {
+		def = b.newBlock("switch.default")
+	}
+
+	prev, kind := b.cur, Normal
+	for i, c := range s.Cases {
+		test := b.newBlock("switch.case")
+		b.edge(prev, test, kind)
+		b.cur = test
+		b.add(c.Expr)
+		b.edge(b.cur, bodies[i], True)
+		prev, kind = b.cur, False
+	}
+	if def != nil {
+		b.edge(prev, def, kind)
+	} else {
+		b.edge(prev, done, kind)
+	}
+
+	// continue leaves a switch like break
+	b.loops = append(b.loops, &loop{brk: done, cont: done, tries: len(b.tries)})
+	for i, c := range s.Cases {
+		b.cur = bodies[i]
+		b.list(statements(c.Block.Statements))
+		next := done
+		switch {
+		case i < len(s.Cases)-1:
+			next = bodies[i+1]
+		case def != nil:
+			next = def
+		}
+		b.edge(b.cur, next, Normal)
+	}
+	if def != nil {
+		b.cur = def
+		b.body(s.DefaultCase)
+		b.edge(b.cur, done, Normal)
+	}
+	b.loops = b.loops[:len(b.loops)-1]
+	b.cur = done
+}
+
+// tryStmt builds a try statement. As the classes of exceptions are not
+// known, any catch clause may catch an exception thrown in the try block,
+// and one that none catches may pass on to the finally block, or out of
+// the statement.
+func (b *builder) tryStmt(s *ast.TryStmt) {
+	t := &try{}
+	for range s.CatchStmts {
+		t.catches = append(t.catches, b.newBlock("catch"))
+	}
+	if s.FinallyBlock != nil {
+		t.finally = b.newBlock("finally")
+	}
+	done := b.newBlock("try.done")
+	leave := func() {
+		if b.cur == nil {
+			return
+		}
+		if t.finally != nil {
+			t.normal = true
+			b.edge(b.cur, t.finally, Normal)
+		} else {
+			b.edge(b.cur, done, Normal)
+		}
+	}
+
+	b.tries = append(b.tries, t)
+	body := b.newBlock("try.body")
+	b.edge(b.cur, body, Normal)
+	b.cur = body
+	b.body(s.TryBlock)
+	leave()
+
+	t.phase = inCatch
+	for i, c := range s.CatchStmts {
+		b.cur = t.catches[i]
+		b.cur.Nodes = append(b.cur.Nodes, c)
+		b.body(c.CatchBlock)
+		leave()
+	}
+
+	t.phase = inFinally
+	if t.finally != nil {
+		b.cur = t.finally
+		b.body(s.FinallyBlock)
+	}
+	b.tries = b.tries[:len(b.tries)-1]
+	if t.finally != nil && b.cur != nil {
+		end := b.cur
+		if t.normal {
+			b.edge(end, done, Normal)
+		}
+		for _, p := range t.pending {
+			b.cur = end
+			if p.throw {
+				b.throw(end, p.tries)
+			} else {
+				b.jump(p.to, p.tries)
+			}
+		}
+	}
+	b.cur = done
+}
@@ -0,0 +1,161 @@
+// Package cfg builds control-flow graphs of PHP code: the basic blocks of
+// the body of a function, method or closure, or of the code of a file
+// outside of functions, and the edges along which control passes between
+// them.
+//
+// The graph covers if, elseif and else, switch statements with cases
+// falling through, while, do while, for and foreach loops with break and
+// continue leaving any number of levels, return, exit and die, and try,
+// catch and finally with the exceptions thrown in the try block. Within a
+// try block, any statement that calls a function or method, creates an
+// object, includes a file or yields may throw; outside of one, only a throw
+// statement throws, as an exception that no catch clause handles leaves
+// the function either way.
+//
+// The bodies of functions, methods, closures and classes declared in the
+// code are not part of its graph; each has its own.
+package cfg
+
+import (
+	"fmt"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// EdgeKind is the condition on which control passes along an edge.
+type EdgeKind int
+
+const (
+	// Normal is an edge along which control always passes.
+	Normal EdgeKind = iota
+	// True is an edge taken when the condition ending its block holds:
+	// the condition of an if statement or loop, a case matching the
+	// subject of a switch, or a foreach loop having another element.
+	True
+	// False is an edge taken when the condition ending its block does not
+	// hold.
+	False
+	// Exception is an edge taken when the code of its block throws.
+	Exception
+)
+
+var edgeKindNames = map[EdgeKind]string{
+	Normal:    "normal",
+	True:      "true",
+	False:     "false",
+	Exception: "exception",
+}
+
+func (k EdgeKind) String() string {
+	return edgeKindNames[k]
+}
+
+// Edge is an edge of a graph, from one block to another.
+type Edge struct {
+	From, To *Block
+	Kind     EdgeKind
+}
+
+// Block is a basic block: code that runs in order, which control enters
+// only at its beginning.
+type Block struct {
+	// Index is the index of the block in the blocks of its graph.
+	Index int
+
+	// Kind describes the part of the code that the block begins, such as
+	// "if.then" or "for.body", for debugging.
+	Kind string
+
+	// Nodes holds the statements and expressions that the block runs, in
+	// order. A block ending with a condition holds it last: the condition
+	// of an if statement or loop, or the expression of a case. The first
+	// block of a foreach loop holds the *ast.ForeachStmt, which stands for
+	// assigning the next key and value of its source, and the first block
+	// of a catch clause holds the *ast.CatchStmt, which stands for
+	// assigning the exception caught to its variable; their bodies are in
+	// other blocks. Declarations of functions and classes are held as
+	// they are.
+	Nodes []ast.Node
+
+	// Succs and Preds hold the edges leaving and entering the block.
+	Succs []*Edge
+	Preds []*Edge
+}
+
+func (b *Block) String() string {
+	return fmt.Sprintf("B%d", b.Index)
+}
+
+// Graph is the control-flow graph of a piece of code.
+type Graph struct {
+	// Entry is the block at which the code begins, and Exit the empty
+	// block at which it ends, by returning, exiting, throwing an exception
+	// it does not catch, or running to its end.
+	Entry, Exit *Block
+
+	// Blocks holds the blocks of the graph: Entry and the blocks that
+	// control can enter from it in reverse postorder, then the blocks that
+	// control cannot enter, which hold code after a return or a break, and
+	// Exit last.
+	Blocks []*Block
+}
+
+// Reachable returns the blocks of g that control can enter from its entry,
+// passing along the edges for which follow returns true, or along every
+// edge if follow is nil.
+func (g *Graph) Reachable(follow func(e *Edge) bool) map[*Block]bool {
+	seen := map[*Block]bool{}
+	Reach(g.Entry, follow, seen)
+	return seen
+}
+
+// Reach adds the block b to seen, and the blocks that control can enter
+// from it that seen does not hold, passing along the edges for which
+// follow returns true, or along every edge if follow is nil.
+func Reach(b *Block, follow func(e *Edge) bool, seen map[*Block]bool) {
+	seen[b] = true
+	for _, e := range b.Succs {
+		if !seen[e.To] && (follow == nil || follow(e)) {
+			Reach(e.To, follow, seen)
+		}
+	}
+}
+
+// New returns the graph of the statements stmts.
+func New(stmts []ast.Node) *Graph {
+	b := newBuilder()
+	b.list(stmts)
+	return b.finish()
+}
+
+// Function returns the graph of the body of the function, method or
+// closure n, an *ast.FunctionStmt, *ast.Method or *ast.AnonymousFunction,
+// or nil if it has no body.
+func Function(n ast.Node) *Graph {
+	var body *ast.Block
+	switch n := n.(type) {
+	case *ast.FunctionStmt:
+		body = n.Body
+	case *ast.Method:
+		body = n.Body
+	case *ast.AnonymousFunction:
+		body = n.Body
+	}
+	if body == nil {
+		return nil
+	}
+	return New(statements(body.Statements))
+}
+
+// File returns the graph of the code of f outside of functions.
+func File(f *ast.File) *Graph {
+	return New(f.Nodes)
+}
+
+func statements(stmts []ast.Statement) []ast.Node {
+	nodes := make([]ast.Node, len(stmts))
+	for i, s := range stmts {
+		nodes[i] = s
+	}
+	return nodes
+}
@@ -0,0 +1,303 @@
+package cfg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/parser"
+)
+
+func parse(t *testing.T, src string) *ast.File {
+	p := parser.NewParser()
+	f, err := p.Parse("test.php", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func check(t *testing.T, g *Graph, want string) {
+	want = strings.Replace(strings.TrimPrefix(want, "\n"), "    ", "\t", -1)
+	if got := g.String(); got != want {
+		t.Errorf("graph:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestIf(t *testing.T) {
+	f := parse(t, `<?php
+	function f($a) {
+		if ($a) {
+			echo 1;
+		} elseif ($a > 2) {
+			echo 2;
+		} else {
+			return 3;
+		}
+		echo 4;
+	}`)
+	check(t, Function(f.Nodes[0]), `
+B0 entry
+    $a
+    -> B4 true
+    -> B1 false
+B1 if.elseif
+    $a > 2
+    -> B3 true
+    -> B2 false
+B2 if.else
+    return 3;
+    -> B6
+B3 if.then
+    echo 2;
+    -> B5
+B4 if.then
+    echo 1;
+    -> B5
+B5 if.done
+    echo 4;
+    -> B6
+B6 exit
+`)
+}
+
+func TestLoops(t *testing.T) {
+	f := parse(t, `<?php
+	function f($a) {
+		foreach ($a as $k => $v) {
+			while ($v) {
+				if ($k) break 2;
+				continue;
+			}
+		}
+		for ($i = 0; $i < 3; $i++) {
+			$a++;
+		}
+		do {
+			$a--;
+		} while ($a);
+		return $a;
+	}`)
+	check(t, Function(f.Nodes[0]), `
+B0 entry
+    $a
+    -> B1
+B1 foreach.head
+    foreach $k => $v
+    -> B2 true
+    -> B8 false
+B2 foreach.body
+    -> B3
+B3 while.head
+    $v
+    -> B5 true
+    -> B4 false
+B4 while.done
+    -> B1
+B5 while.body
+    $k
+    -> B7 true
+    -> B6 false
+B6 if.done
+    continue;
+    -> B3
+B7 if.then
+    break 2;
+    -> B8
+B8 foreach.done
+    $i = 0
+    -> B9
+B9 for.head
+    $i < 3
+    -> B14 true
+    -> B10 false
+B10 for.done
+    -> B11
+B11 do.body
+    $a--;
+    -> B12
+B12 do.cond
+    $a
+    -> B11 true
+    -> B13 false
+B13 do.done
+    return $a;
+    -> B16
+B14 for.body
+    $a++;
+    -> B15
+B15 for.post
+    $i++
+    -> B9
+B16 exit
+`)
+}
+
+func TestSwitch(t *testing.T) {
+	f := parse(t, `<?php
+	function f($a) {
+		switch ($a) {
+		case 1:
+			echo 1;
+		case 2:
+			echo 2;
+			break;
+		default:
+			echo 3;
+		}
+	}`)
+	check(t, Function(f.Nodes[0]), `
+B0 entry
+    $a
+    -> B1
+B1 switch.case
+    1
+    -> B4 true
+    -> B2 false
+B2 switch.case
+    2
+    -> B5 true
+    -> B3 false
+B3 switch.default
+    echo 3;
+    -> B6
+B4 switch.body
+    echo 1;
+    -> B5
+B5 switch.body
+    echo 2;
+    break;
+    -> B6
+B6 switch.done
+    -> B7
+B7 exit
+`)
+}
+
+func TestTry(t *testing.T) {
+	f := parse(t, `<?php
+	function f($a) {
+		foreach ($a as $v) {
+			try {
+				g($v);
+				continue;
+			} catch (E $e) {
+				throw $e;
+			} finally {
+				echo "x";
+			}
+			echo "y";
+		}
+		try {
+			$b = 1;
+			h($b);
+		} catch (E $e) {
+			return;
+		}
+	}`)
+	check(t, Function(f.Nodes[0]), `
+B0 entry
+    $a
+    -> B1
+B1 foreach.head
+    foreach $v
+    -> B6 true
+    -> B2 false
+B2 foreach.done
+    -> B3
+B3 try.body
+    $b = 1;
+    h($b);
+    -> B5 exception
+    -> B11 exception
+    -> B4
+B4 try.done
+    -> B11
+B5 catch
+    catch E $e
+    return;
+    -> B11
+B6 foreach.body
+    -> B7
+B7 try.body
+    g($v);
+    continue;
+    -> B8 exception
+    -> B9 exception
+    -> B9
+B8 catch
+    catch E $e
+    throw $e;
+    -> B9 exception
+B9 finally
+    echo "x";
+    -> B11 exception
+    -> B1
+B10 try.done
+    echo "y";
+    -> B1
+B11 exit
+`)
+}
+
+func TestFile(t *testing.T) {
+	f := parse(t, `<?php
+	function g() {
+		return 1;
+	}
+	if (!g()) die("no");
+	throw new Exception();
+	echo 2;`)
+	check(t, File(f), `
+B0 entry
+    function g
+    !g()
+    -> B2 true
+    -> B1 false
+B1 if.done
+    throw new Exception();
+    -> B4 exception
+B2 if.then
+    die("no");
+    -> B4
+B3 unreachable
+    echo 2;
+    -> B4
+B4 exit
+`)
+}
+
+func TestDot(t *testing.T) {
+	f := parse(t, `<?php
+	try {
+		f();
+	} catch (E $e) {
+	}`)
+	got := File(f).Dot()
+	for _, want := range []string{
+		`B1 [label="B1 try.body\lf();\l"];`,
+		`B1 -> B2 [label="exception" style=dashed];`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("dot:\n%s\nmissing: %s", got, want)
+		}
+	}
+}
+
+func TestReachable(t *testing.T) {
+	f := parse(t, `<?php
+	while (true) {
+		echo 1;
+	}
+	echo 2;`)
+	g := File(f)
+	if got := len(g.Reachable(nil)); got != len(g.Blocks) {
+		t.Errorf("reachable blocks: %d of %d", got, len(g.Blocks))
+	}
+	seen := g.Reachable(func(e *Edge) bool { return e.Kind != False })
+	for _, b := range g.Blocks {
+		if want := b.Kind != "while.done" && b != g.Exit; seen[b] != want {
+			t.Errorf("%v %s reachable = %v", b, b.Kind, seen[b])
+		}
+	}
+}
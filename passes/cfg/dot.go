@@ -0,0 +1,102 @@
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/ast/printer"
+)
+
+// String returns a listing of the blocks of g, with the code of each and
+// the edges leaving it.
+func (g *Graph) String() string {
+	buf := &bytes.Buffer{}
+	for _, block := range g.Blocks {
+		fmt.Fprintf(buf, "%s %s\n", block, block.Kind)
+		for _, n := range block.Nodes {
+			fmt.Fprintf(buf, "\t%s\n", Label(n))
+		}
+		for _, e := range block.Succs {
+			if e.Kind == Normal {
+				fmt.Fprintf(buf, "\t-> %s\n", e.To)
+			} else {
+				fmt.Fprintf(buf, "\t-> %s %s\n", e.To, e.Kind)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// WriteDot writes g to w in the DOT language of Graphviz, for debugging.
+func (g *Graph) WriteDot(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "digraph cfg {")
+	fmt.Fprintln(buf, "\tnode [shape=box fontname=monospace];")
+	for _, block := range g.Blocks {
+		label := fmt.Sprintf(`%s %s\l`, block, block.Kind)
+		for _, n := range block.Nodes {
+			label += dotEscape(Label(n)) + `\l`
+		}
+		fmt.Fprintf(buf, "\t%s [label=\"%s\"];\n", block, label)
+	}
+	for _, block := range g.Blocks {
+		for _, e := range block.Succs {
+			switch e.Kind {
+			case Normal:
+				fmt.Fprintf(buf, "\t%s -> %s;\n", e.From, e.To)
+			case Exception:
+				fmt.Fprintf(buf, "\t%s -> %s [label=%q style=dashed];\n", e.From, e.To, e.Kind.String())
+			default:
+				fmt.Fprintf(buf, "\t%s -> %s [label=%q];\n", e.From, e.To, e.Kind.String())
+			}
+		}
+	}
+	fmt.Fprintln(buf, "}")
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Dot returns g in the DOT language of Graphviz.
+func (g *Graph) Dot() string {
+	buf := &bytes.Buffer{}
+	g.WriteDot(buf)
+	return buf.String()
+}
+
+// Label returns the code of the node n of a block on one line, leaving out
+// the bodies of foreach loops, catch clauses and declarations.
+func Label(n ast.Node) string {
+	buf := &bytes.Buffer{}
+	p := printer.NewPrinter(buf)
+	switch n := n.(type) {
+	case *ast.ForeachStmt:
+		buf.WriteString("foreach ")
+		if n.Key != nil {
+			p.PrintNode(n.Key)
+			buf.WriteString(" => ")
+		}
+		if n.Reference {
+			buf.WriteString("&")
+		}
+		p.PrintNode(n.Value)
+	case *ast.CatchStmt:
+		fmt.Fprintf(buf, "catch %s ", n.CatchType)
+		p.PrintNode(n.CatchVar)
+	case *ast.FunctionStmt:
+		fmt.Fprintf(buf, "function %s", n.Name)
+	case *ast.Class:
+		fmt.Fprintf(buf, "class %s", n.Name)
+	case *ast.Interface:
+		fmt.Fprintf(buf, "interface %s", n.Name)
+	default:
+		p.PrintNode(n)
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,250 @@
+package dataflow
+
+import (
+	"github.com/stephens2424/php/passes/cfg"
+)
+
+// Vars is a set of variables, by name: a fact of liveness or definite
+// assignment.
+type Vars map[string]bool
+
+func (v Vars) copy() Vars {
+	c := make(Vars, len(v))
+	for name := range v {
+		c[name] = true
+	}
+	return c
+}
+
+// Defs is a set of definitions, the accesses that assign variables: a fact
+// of reaching definitions.
+type Defs map[*Access]bool
+
+func (d Defs) copy() Defs {
+	c := make(Defs, len(d))
+	for a := range d {
+		c[a] = true
+	}
+	return c
+}
+
+// Names returns the set of the variables that the definitions assign.
+func (d Defs) Names() Vars {
+	names := Vars{}
+	for a := range d {
+		names[a.Name] = true
+	}
+	return names
+}
+
+// ReachingDefinitions returns the definitions of the variables of f that
+// reach each point: the parameters, and the accesses that write, update,
+// may write or modify a variable, along a path from which no write or
+// update of the variable, or unset, since removes them. Its facts are Defs.
+func ReachingDefinitions(f *Func) *Result {
+	return Solve(f.Graph, reaching{f})
+}
+
+// Liveness returns the variables of f live at each point, whose values
+// may be used later: read, updated, modified or checked along a path on
+// which they are not written or unset before. The variables bound by
+// reference are live as f ends. Its facts are Vars.
+func Liveness(f *Func) *Result {
+	return Solve(f.Graph, liveness{f})
+}
+
+// DefiniteAssignment returns the variables of f definitely assigned at each
+// point, along every path to it: the parameters, and the variables
+// written, updated or modified, or found set by isset or empty in a
+// condition that control passed, and not unset since. In blocks that control
+// cannot enter, every variable is assigned. Its facts are Vars.
+func DefiniteAssignment(f *Func) *Result {
+	return Solve(f.Graph, assignment{f})
+}
+
+type reaching struct{ f *Func }
+
+func (reaching) Direction() Direction { return Forward }
+
+func (r reaching) Boundary() Fact {
+	defs := Defs{}
+	for _, p := range r.f.Params {
+		defs[p] = true
+	}
+	return defs
+}
+
+func (reaching) Top() Fact { return Defs{} }
+
+func (reaching) Meet(a, b Fact) Fact {
+	defs := a.(Defs).copy()
+	for d := range b.(Defs) {
+		defs[d] = true
+	}
+	return defs
+}
+
+func (reaching) Equal(a, b Fact) bool {
+	x, y := a.(Defs), b.(Defs)
+	if len(x) != len(y) {
+		return false
+	}
+	for d := range x {
+		if !y[d] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r reaching) Transfer(b *cfg.Block, i int, f Fact) Fact {
+	defs := f.(Defs).copy()
+	for _, a := range r.f.Accesses(b, i) {
+		defs.define(a)
+	}
+	return defs
+}
+
+// define adds the definition a, if it is one, removing those it replaces.
+func (d Defs) define(a *Access) {
+	switch a.Kind {
+	case Write, Update, Unset:
+		for def := range d {
+			if def.Name == a.Name {
+				delete(d, def)
+			}
+		}
+	}
+	switch a.Kind {
+	case Write, Update, MayWrite, Modify:
+		d[a] = true
+	}
+}
+
+type liveness struct{ f *Func }
+
+func (liveness) Direction() Direction { return Backward }
+
+func (l liveness) Boundary() Fact {
+	live := Vars{}
+	for name := range l.f.References {
+		live[name] = true
+	}
+	return live
+}
+
+func (liveness) Top() Fact { return Vars{} }
+
+func (liveness) Meet(a, b Fact) Fact { return union(a.(Vars), b.(Vars)) }
+
+func (liveness) Equal(a, b Fact) bool { return equal(a.(Vars), b.(Vars)) }
+
+func (l liveness) Transfer(b *cfg.Block, i int, f Fact) Fact {
+	live := f.(Vars).copy()
+	accesses := l.f.Accesses(b, i)
+	for j := len(accesses) - 1; j >= 0; j-- {
+		live.use(accesses[j])
+	}
+	return live
+}
+
+// use updates the live variables after the access a to those before it.
+func (v Vars) use(a *Access) {
+	switch a.Kind {
+	case Write, Unset:
+		delete(v, a.Name)
+	case Read, Update, Modify, Check:
+		v[a.Name] = true
+	}
+}
+
+type assignment struct{ f *Func }
+
+func (assignment) Direction() Direction { return Forward }
+
+func (s assignment) Boundary() Fact {
+	assigned := Vars{}
+	for _, p := range s.f.Params {
+		assigned[p.Name] = true
+	}
+	return assigned
+}
+
+func (s assignment) Top() Fact {
+	all := Vars{}
+	for _, name := range s.f.Vars {
+		all[name] = true
+	}
+	return all
+}
+
+func (assignment) Meet(a, b Fact) Fact {
+	x, y := a.(Vars), b.(Vars)
+	assigned := Vars{}
+	for name := range x {
+		if y[name] {
+			assigned[name] = true
+		}
+	}
+	return assigned
+}
+
+func (assignment) Equal(a, b Fact) bool { return equal(a.(Vars), b.(Vars)) }
+
+func (s assignment) Transfer(b *cfg.Block, i int, f Fact) Fact {
+	assigned := f.(Vars).copy()
+	for _, a := range s.f.Accesses(b, i) {
+		assigned.assign(a)
+	}
+	return assigned
+}
+
+// Edge adds the variables that isset or empty found set to those assigned
+// along the edge taken when the condition ending a block holds, or does
+// not. The expression ending a switch case is compared with the subject
+// of the switch rather than tested.
+func (s assignment) Edge(e *cfg.Edge, f Fact) Fact {
+	if e.Kind != cfg.True && e.Kind != cfg.False || e.From.Kind == "switch.case" || len(e.From.Nodes) == 0 {
+		return f
+	}
+	names := guards(e.From.Nodes[len(e.From.Nodes)-1], e.Kind == cfg.True)
+	if len(names) == 0 {
+		return f
+	}
+	assigned := f.(Vars).copy()
+	for _, name := range names {
+		assigned[name] = true
+	}
+	return assigned
+}
+
+// assign updates the assigned variables before the access a to those
+// after it.
+func (v Vars) assign(a *Access) {
+	switch a.Kind {
+	case Write, Update, Modify:
+		v[a.Name] = true
+	case Unset:
+		delete(v, a.Name)
+	}
+}
+
+func union(a, b Vars) Vars {
+	u := a.copy()
+	for name := range b {
+		u[name] = true
+	}
+	return u
+}
+
+func equal(a, b Vars) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name := range a {
+		if !b[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package dataflow
+
+import "github.com/stephens2424/php/ast"
+
+// Unassigned is a use of a variable that may not be assigned.
+type Unassigned struct {
+	*Access
+
+	// Never is set if no definition of the variable reaches the use, so
+	// that it is never assigned there.
+	Never bool
+}
+
+// UsesBeforeAssignment returns the reads and updates of the variables of f
+// that are not assigned along every path to them, in the order of the
+// blocks of its graph, or nil if f is dynamic. Checking whether a variable
+// is set, modifying it or assigning one of its elements is no use, and a
+// use where isset or empty found the variable set is not reported.
+func UsesBeforeAssignment(f *Func) []Unassigned {
+	if f.Dynamic {
+		return nil
+	}
+	assigned, reaching := DefiniteAssignment(f), ReachingDefinitions(f)
+	entered := f.Graph.Reachable(nil)
+	var uses []Unassigned
+	for _, b := range f.Graph.Blocks {
+		if !entered[b] {
+			continue
+		}
+		facts, defs := assigned.Facts(b), reaching.Facts(b)
+		for i := range b.Nodes {
+			in, names := facts[i].(Vars).copy(), defs[i].(Defs).Names()
+			for _, a := range f.Accesses(b, i) {
+				if (a.Kind == Read || a.Kind == Update) && !in[a.Name] && !a.Guarded {
+					uses = append(uses, Unassigned{Access: a, Never: !names[a.Name]})
+				}
+				in.assign(a)
+				switch a.Kind {
+				case Write, Update, MayWrite, Modify:
+					names[a.Name] = true
+				case Unset:
+					delete(names, a.Name)
+				}
+			}
+		}
+	}
+	return uses
+}
+
+// DeadStores returns the writes and updates of the variables of f whose
+// values are never used, as the variables are not live after them, in the
+// order of the blocks of its graph, or nil if f is dynamic. The variables
+// bound by reference and the variables of foreach loops and catch clauses
+// are left out.
+func DeadStores(f *Func) []*Access {
+	if f.Dynamic {
+		return nil
+	}
+	liveness := Liveness(f)
+	entered := f.Graph.Reachable(nil)
+	var dead []*Access
+	for _, b := range f.Graph.Blocks {
+		if !entered[b] {
+			continue
+		}
+		facts := liveness.Facts(b)
+		for i, n := range b.Nodes {
+			switch n.(type) {
+			case *ast.ForeachStmt, *ast.CatchStmt:
+				continue
+			}
+			live := facts[i+1].(Vars).copy()
+			accesses := f.Accesses(b, i)
+			var stores []*Access
+			for j := len(accesses) - 1; j >= 0; j-- {
+				a := accesses[j]
+				if (a.Kind == Write || a.Kind == Update) && !live[a.Name] && !f.References[a.Name] {
+					stores = append(stores, a)
+				}
+				live.use(a)
+			}
+			for j := len(stores) - 1; j >= 0; j-- {
+				dead = append(dead, stores[j])
+			}
+		}
+	}
+	return dead
+}
@@ -0,0 +1,226 @@
+// Package dataflow solves data-flow problems over the control-flow graphs
+// of package cfg, and provides the classic analyses of the variables of
+// PHP functions built on it: reaching definitions, liveness and definite
+// assignment, with which it finds the uses of variables that may not be
+// assigned yet and the values assigned that are never read.
+//
+// An analysis is given by a lattice of facts, with its meet, and by the
+// transfer of a fact across each node of a block; Solve finds the fixed
+// point by iterating over the blocks, forward or backward, until no fact
+// changes.
+package dataflow
+
+import (
+	"github.com/stephens2424/php/passes/cfg"
+)
+
+// Fact is a fact of an analysis, an element of its lattice.
+type Fact interface{}
+
+// Direction is the direction in which an analysis propagates facts.
+type Direction int
+
+const (
+	// Forward propagates facts from the entry of a graph along its edges.
+	Forward Direction = iota
+	// Backward propagates facts from the exit of a graph against its
+	// edges.
+	Backward
+)
+
+// Analysis is a data-flow problem.
+type Analysis interface {
+	Direction() Direction
+
+	// Boundary returns the fact at the entry of the graph of a forward
+	// analysis, or at its exit for a backward one.
+	Boundary() Fact
+
+	// Top returns the top of the lattice, the identity of Meet, which the
+	// facts of the other blocks start from.
+	Top() Fact
+
+	// Meet returns the greatest lower bound of a and b, the fact where
+	// paths with the facts a and b join.
+	Meet(a, b Fact) Fact
+
+	// Equal reports whether a and b are the same fact.
+	Equal(a, b Fact) bool
+
+	// Transfer returns the fact after the node of the block b at index i,
+	// in the direction of the analysis, given the fact f before it.
+	Transfer(b *cfg.Block, i int, f Fact) Fact
+}
+
+// EdgeAnalysis is an analysis that also learns from the edges along which
+// control passes, such as the edges taken when the condition ending a
+// block holds or does not.
+type EdgeAnalysis interface {
+	Analysis
+
+	// Edge returns the fact along the edge e, other than an exception
+	// edge, given the fact f where it begins, in the direction of the
+	// analysis.
+	Edge(e *cfg.Edge, f Fact) Fact
+}
+
+// Result is the solution of an analysis over a graph.
+type Result struct {
+	Graph    *cfg.Graph
+	Analysis Analysis
+
+	// In and Out hold the facts at the beginning and the end of each
+	// block, as it runs, whatever the direction of the analysis, by the
+	// index of the block.
+	In, Out []Fact
+
+	// thrown holds the facts along the exception edges leaving each block:
+	// for a forward analysis, the meet of the facts before each of its
+	// nodes, any of which may throw, and for a backward one, the meet of
+	// the facts at the beginning of the blocks they enter.
+	thrown []Fact
+}
+
+// Solve solves the analysis a over the graph g.
+func Solve(g *cfg.Graph, a Analysis) *Result {
+	n := len(g.Blocks)
+	r := &Result{
+		Graph:    g,
+		Analysis: a,
+		In:       make([]Fact, n),
+		Out:      make([]Fact, n),
+		thrown:   make([]Fact, n),
+	}
+	for i := range g.Blocks {
+		r.In[i], r.Out[i], r.thrown[i] = a.Top(), a.Top(), a.Top()
+	}
+
+	// the blocks are in reverse postorder, in which a forward analysis
+	// converges fastest, and a backward one in the reverse
+	order := g.Blocks
+	if a.Direction() == Backward {
+		order = make([]*cfg.Block, n)
+		for i, b := range g.Blocks {
+			order[n-1-i] = b
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range order {
+			var in, out, thrown Fact
+			if a.Direction() == Forward {
+				in = r.join(b)
+				out, thrown = r.forward(b, in)
+			} else {
+				out, thrown = r.join(b), r.joinThrown(b)
+				in = r.backward(b, out, thrown, nil)
+			}
+			i := b.Index
+			if !a.Equal(in, r.In[i]) || !a.Equal(out, r.Out[i]) || !a.Equal(thrown, r.thrown[i]) {
+				r.In[i], r.Out[i], r.thrown[i] = in, out, thrown
+				changed = true
+			}
+		}
+	}
+	return r
+}
+
+// join returns the meet of the facts along the edges entering the block b,
+// for a forward analysis, or along the edges other than exception edges
+// leaving it, for a backward one.
+func (r *Result) join(b *cfg.Block) Fact {
+	a := r.Analysis
+	f := a.Top()
+	if a.Direction() == Forward {
+		if b == r.Graph.Entry {
+			f = a.Boundary()
+		}
+		for _, e := range b.Preds {
+			if e.Kind == cfg.Exception {
+				f = a.Meet(f, r.thrown[e.From.Index])
+			} else {
+				f = a.Meet(f, r.edge(e, r.Out[e.From.Index]))
+			}
+		}
+		return f
+	}
+	if b == r.Graph.Exit {
+		f = a.Boundary()
+	}
+	for _, e := range b.Succs {
+		if e.Kind != cfg.Exception {
+			f = a.Meet(f, r.edge(e, r.In[e.To.Index]))
+		}
+	}
+	return f
+}
+
+// edge returns the fact along the edge e, given the fact f where it
+// begins, which is f unless the analysis learns from edges.
+func (r *Result) edge(e *cfg.Edge, f Fact) Fact {
+	if a, ok := r.Analysis.(EdgeAnalysis); ok {
+		return a.Edge(e, f)
+	}
+	return f
+}
+
+// joinThrown returns the meet of the facts at the beginning of the blocks
+// that the exception edges leaving the block b enter.
+func (r *Result) joinThrown(b *cfg.Block) Fact {
+	f := r.Analysis.Top()
+	for _, e := range b.Succs {
+		if e.Kind == cfg.Exception {
+			f = r.Analysis.Meet(f, r.In[e.To.Index])
+		}
+	}
+	return f
+}
+
+// forward returns the facts at the end of the block b and along the
+// exception edges leaving it, given the fact in at its beginning.
+func (r *Result) forward(b *cfg.Block, in Fact) (out, thrown Fact) {
+	a := r.Analysis
+	f, thrown := in, a.Top()
+	for i := range b.Nodes {
+		thrown = a.Meet(thrown, f)
+		f = a.Transfer(b, i, f)
+	}
+	return f, thrown
+}
+
+// backward returns the fact at the beginning of the block b, given the
+// facts at its end and along the exception edges leaving it, which hold
+// before each node as any may throw. If facts is not nil, it is called
+// with the fact before each node, last first.
+func (r *Result) backward(b *cfg.Block, out, thrown Fact, facts func(i int, f Fact)) Fact {
+	a := r.Analysis
+	f := out
+	for i := len(b.Nodes) - 1; i >= 0; i-- {
+		f = a.Meet(a.Transfer(b, i, f), thrown)
+		if facts != nil {
+			facts(i, f)
+		}
+	}
+	return f
+}
+
+// Facts returns the facts at each point of the block b, as it runs: the
+// fact before each of its nodes, then the fact after the last.
+func (r *Result) Facts(b *cfg.Block) []Fact {
+	a := r.Analysis
+	facts := make([]Fact, len(b.Nodes)+1)
+	if a.Direction() == Forward {
+		f := r.In[b.Index]
+		for i := range b.Nodes {
+			facts[i] = f
+			f = a.Transfer(b, i, f)
+		}
+		facts[len(b.Nodes)] = f
+		return facts
+	}
+	facts[len(b.Nodes)] = r.Out[b.Index]
+	r.backward(b, r.Out[b.Index], r.thrown[b.Index], func(i int, f Fact) {
+		facts[i] = f
+	})
+	return facts
+}
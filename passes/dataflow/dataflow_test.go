@@ -0,0 +1,182 @@
+package dataflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/parser"
+)
+
+func parse(t *testing.T, src string) (*ast.File, *Func) {
+	p := parser.NewParser()
+	f, err := p.Parse("test.php", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, NewFunc(f.Nodes[0])
+}
+
+// positions returns the positions and names of the accesses, ordered by
+// their positions, followed by the notes given for each.
+func positions(f *ast.File, accesses []*Access, notes map[*Access]string) []string {
+	sort.Slice(accesses, func(i, j int) bool {
+		a, _ := f.Positions.Span(accesses[i].Node)
+		b, _ := f.Positions.Span(accesses[j].Node)
+		return a.Begin.Position < b.Begin.Position
+	})
+	var got []string
+	for _, a := range accesses {
+		span, _ := f.Positions.Span(a.Node)
+		got = append(got, strings.TrimSpace(fmt.Sprintf("%d:%d %s %s", span.Begin.Line, span.Begin.Column, a.Name, notes[a])))
+	}
+	return got
+}
+
+func check(t *testing.T, name string, got, want []string) {
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%s:\n%s\nwant:\n%s", name, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestUsesBeforeAssignment(t *testing.T) {
+	file, f := parse(t, `<?php
+	function f($a) {
+		if ($a) {
+			$x = 1;
+		}
+		echo $x;
+		echo $y;
+		$count++;
+		$list[] = 1;
+		if (isset($z)) {}
+		preg_match('/a/', $a, $m);
+		echo $m[0];
+		$a && ($b = 1);
+		echo $b;
+		try {
+			$c = g();
+		} catch (E $e) {
+			echo $c, $e;
+		}
+		unset($a);
+		echo $a;
+		foreach ($list as $v) {
+			$w = $v;
+		}
+		echo $w, "$q";
+		if (isset($r)) {
+			echo $r;
+		}
+		echo isset($s) ? $s : 0, !empty($t) && $t, empty($u) || $u, isset($k) ? 0 : $k;
+		if (!empty($n)) echo $n;
+		if (!isset($o['key']) || !$p) {
+			return;
+		}
+		echo $o, $p;
+	}`)
+	var uses []*Access
+	notes := map[*Access]string{}
+	for _, u := range UsesBeforeAssignment(f) {
+		uses = append(uses, u.Access)
+		if u.Never {
+			notes[u.Access] = "never"
+		}
+	}
+	check(t, "unassigned", positions(file, uses, notes), []string{
+		"6:8 x",
+		"7:8 y never",
+		"8:3 count never",
+		"14:8 b",
+		"18:9 c never",
+		"21:8 a never",
+		"25:8 w",
+		"25:12 q never",
+		"29:79 k never",
+		"31:29 p never",
+		"34:12 p never",
+	})
+}
+
+func TestDeadStores(t *testing.T) {
+	file, f := parse(t, `<?php
+	function f($a, &$out) {
+		$x = 1;
+		$x = 2;
+		echo $x;
+		$y = 1;
+		$out = 3;
+		$i = 0;
+		while ($i < 3) {
+			$i++;
+		}
+		$s = "a";
+		$s .= "b";
+		try {
+			$t = 1;
+			g();
+			$t = 2;
+		} catch (E $e) {
+			echo $t;
+		}
+		static $n;
+		$n = 1;
+		$z = 1;
+		return function () use ($z) {};
+	}`)
+	check(t, "dead", positions(file, DeadStores(f), nil), []string{
+		"3:3 x",
+		"6:3 y",
+		"13:3 s",
+		"17:4 t",
+	})
+}
+
+func TestReachingDefinitions(t *testing.T) {
+	file, f := parse(t, `<?php
+	function f($a) {
+		$x = 1;
+		if ($a) {
+			$x = 2;
+		}
+		while ($a) {
+			$a--;
+		}
+		return $x;
+	}`)
+	r := ReachingDefinitions(f)
+	var got []string
+	for _, b := range f.Graph.Blocks {
+		for i, n := range b.Nodes {
+			if _, ok := n.(*ast.ReturnStmt); !ok {
+				continue
+			}
+			var defs []*Access
+			for d := range r.Facts(b)[i].(Defs) {
+				defs = append(defs, d)
+			}
+			got = positions(file, defs, nil)
+		}
+	}
+	check(t, "reaching", got, []string{"2:13 a", "3:3 x", "5:4 x", "8:4 a"})
+}
+
+func TestLiveness(t *testing.T) {
+	_, f := parse(t, `<?php
+	function f($a, $b, &$c) {
+		$b = $a;
+		foreach ($b as $v) {
+			$d = $v;
+		}
+		return $d;
+	}`)
+	r := Liveness(f)
+	var got []string
+	for name := range r.In[f.Graph.Entry.Index].(Vars) {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	check(t, "live", got, []string{"a", "c", "d"})
+}
@@ -0,0 +1,566 @@
+package dataflow
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/passes/cfg"
+)
+
+// Kind is how a node accesses a variable.
+type Kind int
+
+const (
+	// Read uses the value of the variable.
+	Read Kind = iota
+	// Write assigns the variable a value, replacing the one it had.
+	Write
+	// Update uses the value of the variable and assigns it a new one, as
+	// a compound assignment or an increment does.
+	Update
+	// MayWrite may assign the variable a value, as an assignment in the
+	// right operand of && does.
+	MayWrite
+	// Modify may use the variable and change its value in place, without
+	// a notice if it is not assigned, as passing it to a function, which
+	// may take it by reference, or assigning one of its elements does.
+	Modify
+	// Check tests whether the variable is set, as isset and empty do.
+	Check
+	// Unset unsets the variable.
+	Unset
+)
+
+var kindNames = map[Kind]string{
+	Read:     "read",
+	Write:    "write",
+	Update:   "update",
+	MayWrite: "may write",
+	Modify:   "modify",
+	Check:    "check",
+	Unset:    "unset",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Access is an access to a variable.
+type Access struct {
+	Kind Kind
+
+	// Name is the name of the variable, without its $.
+	Name string
+
+	// Node is the node naming the variable: an *ast.Variable, the
+	// *ast.Literal of a string interpolating it or naming it for compact,
+	// or the *ast.ShellCommand interpolating it.
+	Node ast.Node
+
+	// Guarded is set if the access is in an operand that runs only when
+	// isset or empty found the variable set, as in isset($a) ? $a : 0.
+	Guarded bool
+}
+
+// Func is a function, method or closure prepared for the analyses of its
+// variables.
+type Func struct {
+	// Node is the *ast.FunctionStmt, *ast.Method or *ast.AnonymousFunction.
+	Node  ast.Node
+	Graph *cfg.Graph
+
+	// Params holds the writes of the parameters and closure variables of
+	// the function, which are assigned as it begins.
+	Params []*Access
+
+	// Vars holds the names of the variables of the function, in order:
+	// those its scope references and those it accesses otherwise, other
+	// than $this and the superglobals.
+	Vars []string
+
+	// References holds the names of the variables bound by reference, by
+	// =&, global, static, foreach or a parameter or closure variable
+	// taken by reference, whose values other code may read and write.
+	References map[string]bool
+
+	// Dynamic is set if the function may access variables without naming
+	// them, through variable variables, extract, get_defined_vars, eval,
+	// include or compact with names not known statically, which the
+	// analyses do not account for.
+	Dynamic bool
+
+	// accesses holds the accesses of each node of each block of the
+	// graph, in the order in which they happen, by the index of the block.
+	accesses [][][]*Access
+}
+
+// NewFunc returns the function n, an *ast.FunctionStmt, *ast.Method or
+// *ast.AnonymousFunction, prepared for analysis, or nil if it has no body.
+func NewFunc(n ast.Node) *Func {
+	var args, closure []*ast.FunctionArgument
+	var body *ast.Block
+	switch n := n.(type) {
+	case *ast.FunctionStmt:
+		args, body = n.Arguments, n.Body
+	case *ast.Method:
+		args, body = n.Arguments, n.Body
+	case *ast.AnonymousFunction:
+		args, closure, body = n.Arguments, n.ClosureVariables, n.Body
+	}
+	if body == nil {
+		return nil
+	}
+	f := &Func{
+		Node:       n,
+		Graph:      cfg.Function(n),
+		References: map[string]bool{},
+	}
+	for _, arg := range append(append([]*ast.FunctionArgument{}, args...), closure...) {
+		name := ast.Static(arg.Variable.Name)
+		if name == nil {
+			continue
+		}
+		f.Params = append(f.Params, &Access{Kind: Write, Name: name.Value, Node: arg.Variable})
+		if arg.Reference {
+			f.References[name.Value] = true
+		}
+	}
+
+	vars := map[string]bool{}
+	if body.Scope != nil {
+		for name := range body.Scope.Identifiers {
+			vars[name] = true
+		}
+		f.Dynamic = len(body.Scope.DynamicVariables) > 0
+	}
+	for _, p := range f.Params {
+		vars[p.Name] = true
+	}
+	f.accesses = make([][][]*Access, len(f.Graph.Blocks))
+	for _, b := range f.Graph.Blocks {
+		f.accesses[b.Index] = make([][]*Access, len(b.Nodes))
+		for i, n := range b.Nodes {
+			w := &walker{f: f}
+			w.node(n)
+			f.accesses[b.Index][i] = w.accesses
+			for _, a := range w.accesses {
+				vars[a.Name] = true
+			}
+		}
+	}
+	for name := range vars {
+		if name != "this" && !ast.SuperGlobals[name] {
+			f.Vars = append(f.Vars, name)
+		}
+	}
+	sort.Strings(f.Vars)
+	return f
+}
+
+// Accesses returns the accesses to variables of the node of the block b of
+// the graph of f at index i, in the order in which they happen. The
+// accesses to $this and the superglobals are left out.
+func (f *Func) Accesses(b *cfg.Block, i int) []*Access {
+	return f.accesses[b.Index][i]
+}
+
+// walker finds the accesses of a node.
+type walker struct {
+	f        *Func
+	accesses []*Access
+
+	// maybe is set within an operand that may not be evaluated, where
+	// variables may not be assigned.
+	maybe bool
+
+	// guarded holds the variables that isset or empty found set, for the
+	// operand being walked.
+	guarded map[string]bool
+}
+
+// add records the access of kind k to the variable v, if it is named
+// statically, and reports whether it is.
+func (w *walker) add(v *ast.Variable, k Kind) bool {
+	name := ast.Static(v.Name)
+	if name == nil {
+		return false
+	}
+	w.access(k, name.Value, v)
+	return true
+}
+
+func (w *walker) access(k Kind, name string, n ast.Node) {
+	if name == "this" || ast.SuperGlobals[name] {
+		return
+	}
+	if w.maybe {
+		switch k {
+		case Write:
+			k = MayWrite
+		case Update:
+			w.accesses = append(w.accesses, &Access{Kind: Read, Name: name, Node: n})
+			k = MayWrite
+		}
+	}
+	w.accesses = append(w.accesses, &Access{Kind: k, Name: name, Node: n, Guarded: w.guarded[name]})
+}
+
+// reference records that the variable n, if it is one, is bound by
+// reference.
+func (w *walker) reference(n ast.Node) {
+	if v, ok := n.(*ast.Variable); ok {
+		if name := ast.Static(v.Name); name != nil {
+			w.f.References[name.Value] = true
+		}
+	}
+}
+
+// conditional walks n, which may not be evaluated, and when it is, finds
+// the variables of guards set.
+func (w *walker) conditional(n ast.Node, guards []string) {
+	maybe, guarded := w.maybe, w.guarded
+	w.maybe = true
+	if len(guards) > 0 {
+		w.guarded = map[string]bool{}
+		for name := range guarded {
+			w.guarded[name] = true
+		}
+		for _, name := range guards {
+			w.guarded[name] = true
+		}
+	}
+	w.walk(n, Read)
+	w.maybe, w.guarded = maybe, guarded
+}
+
+// node finds the accesses of the node n of a block, which stands only for
+// its header if it is a foreach loop or catch clause.
+func (w *walker) node(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.ForeachStmt:
+		if n.Key != nil {
+			w.add(n.Key, Write)
+		}
+		if n.Reference {
+			w.reference(n.Value)
+		}
+		w.add(n.Value, Write)
+	case *ast.CatchStmt:
+		if n.CatchVar != nil {
+			w.add(n.CatchVar, Write)
+		}
+	case *ast.FunctionStmt, *ast.Class, *ast.Interface:
+	default:
+		w.walk(n, Read)
+	}
+}
+
+// walk finds the accesses of the variables in n, which is accessed with
+// kind k if it is a variable.
+func (w *walker) walk(n ast.Node, k Kind) {
+	if ast.IsNil(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *ast.Variable:
+		if !w.add(n, k) {
+			w.f.Dynamic = true
+			w.walk(n.Name, Read)
+		}
+		return
+	case ast.AssignmentExpr:
+		if ref, ok := n.Value.(ast.UnaryCallExpr); ok && ref.Operator == "&" {
+			w.reference(n.Assignee)
+			w.reference(ref.Operand)
+			w.walk(ref.Operand, Modify)
+			w.walk(n.Assignee, Write)
+			return
+		}
+		w.walk(n.Value, Read)
+		if n.Operator == "=" {
+			w.walk(n.Assignee, Write)
+		} else {
+			w.walk(n.Assignee, Update)
+		}
+		return
+	case *ast.AssignmentExpr:
+		w.walk(*n, k)
+		return
+	case *ast.ListStatement:
+		w.walk(n.Value, Read)
+		for _, assignee := range n.Assignees {
+			w.walk(assignee, Write)
+		}
+		return
+	case ast.UnaryCallExpr:
+		if n.Operator == "++" || n.Operator == "--" {
+			w.walk(n.Operand, Update)
+			return
+		}
+	case ast.BinaryExpr:
+		switch strings.ToLower(n.Operator) {
+		case "&&", "and":
+			w.walk(n.Antecedent, Read)
+			w.conditional(n.Subsequent, guards(n.Antecedent, true))
+			return
+		case "||", "or":
+			w.walk(n.Antecedent, Read)
+			w.conditional(n.Subsequent, guards(n.Antecedent, false))
+			return
+		}
+	case ast.TernaryCallExpr:
+		w.walk(n.Condition, Read)
+		w.conditional(n.True, guards(n.Condition, true))
+		w.conditional(n.False, guards(n.Condition, false))
+		return
+	case *ast.TernaryCallExpr:
+		w.walk(*n, k)
+		return
+	case ast.ArrayAppendExpr:
+		w.element(n.Array, k)
+		return
+	case *ast.ArrayAppendExpr:
+		w.element(n.Array, k)
+		return
+	case *ast.ArrayLookupExpr:
+		w.walk(n.Index, Read)
+		w.element(n.Array, k)
+		return
+	case *ast.GlobalDeclaration:
+		for _, v := range n.Identifiers {
+			w.reference(v)
+			w.walk(v, Write)
+		}
+		return
+	case *ast.StaticVariableDeclaration:
+		for _, d := range n.Declarations {
+			if assignment, ok := d.(*ast.AssignmentExpr); ok {
+				w.walk(assignment.Value, Read)
+				d = assignment.Assignee
+			}
+			w.reference(d)
+			w.walk(d, Write)
+		}
+		return
+	case *ast.FunctionCallExpr:
+		if w.call(n) {
+			return
+		}
+		w.walk(n.FunctionName, Read)
+		w.arguments(n.Arguments)
+		return
+	case *ast.MethodCallExpr:
+		w.walk(n.Receiver, Read)
+		w.walk(n.FunctionName, Read)
+		w.arguments(n.Arguments)
+		return
+	case *ast.NewCallExpr:
+		w.walk(n.Class, Read)
+		w.arguments(n.Arguments)
+		return
+	case ast.Include, *ast.Include, ast.IncludeStmt, *ast.IncludeStmt:
+		w.f.Dynamic = true
+	case *ast.ClassExpr:
+		// a static property is not a variable
+		if _, ok := n.Expr.(*ast.Variable); ok {
+			w.walk(n.Receiver, Read)
+			return
+		}
+	case ast.ConstantExpr:
+		return
+	case *ast.AnonymousFunction:
+		for _, arg := range n.ClosureVariables {
+			if arg.Reference {
+				w.reference(arg.Variable)
+				w.walk(arg.Variable, Modify)
+			} else {
+				w.walk(arg.Variable, Read)
+			}
+		}
+		return
+	case *ast.FunctionStmt, *ast.Class, *ast.Interface:
+		return
+	case *ast.Literal, *ast.ShellCommand:
+		for _, name := range Interpolated(n) {
+			w.access(Read, name, n)
+		}
+		return
+	}
+	for _, child := range n.Children() {
+		w.walk(child, Read)
+	}
+}
+
+// element finds the accesses of the variables in the array n, one of whose
+// elements is accessed with kind k.
+func (w *walker) element(n ast.Node, k Kind) {
+	if k != Read && k != Check {
+		// assigning an element keeps the others, and an element of a
+		// variable not assigned makes it an array
+		k = Modify
+	}
+	w.walk(n, k)
+}
+
+// arguments finds the accesses of the arguments args of a call. A variable
+// passed, or one of whose elements is, may be taken by reference.
+func (w *walker) arguments(args []ast.Expr) {
+	for _, arg := range args {
+		switch arg.(type) {
+		case *ast.Variable, *ast.ArrayLookupExpr:
+			w.walk(arg, Modify)
+		default:
+			w.walk(arg, Read)
+		}
+	}
+}
+
+// call finds the accesses of the call c to a function that accesses
+// variables by name, and reports whether it is one.
+func (w *walker) call(c *ast.FunctionCallExpr) bool {
+	name := ast.Static(c.FunctionName)
+	if name == nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimPrefix(name.Value, `\`)) {
+	case "compact":
+		for _, arg := range c.Arguments {
+			w.compact(arg)
+		}
+		return true
+	case "isset", "empty":
+		for _, arg := range c.Arguments {
+			w.walk(arg, Check)
+		}
+		return true
+	case "unset":
+		for _, arg := range c.Arguments {
+			w.walk(arg, Unset)
+		}
+		return true
+	case "extract", "get_defined_vars", "eval":
+		w.f.Dynamic = true
+	case "parse_str":
+		if len(c.Arguments) < 2 {
+			w.f.Dynamic = true
+		}
+	}
+	return false
+}
+
+// guards returns the names of the variables that the condition cond finds
+// set, by isset or empty, when it evaluates to value, as isset($a) does
+// when true and empty($a) when false. A variable one of whose elements or
+// properties is found set is set itself.
+func guards(cond ast.Node, value bool) []string {
+	switch c := cond.(type) {
+	case *ast.FunctionCallExpr:
+		name := ast.Static(c.FunctionName)
+		if name == nil {
+			return nil
+		}
+		switch strings.ToLower(strings.TrimPrefix(name.Value, `\`)) {
+		case "isset":
+			if !value {
+				return nil
+			}
+		case "empty":
+			if value {
+				return nil
+			}
+		default:
+			return nil
+		}
+		var names []string
+		for _, arg := range c.Arguments {
+			if v := base(arg); v != nil {
+				if name := ast.Static(v.Name); name != nil {
+					names = append(names, name.Value)
+				}
+			}
+		}
+		return names
+	case ast.UnaryCallExpr:
+		if c.Operator == "!" {
+			return guards(c.Operand, !value)
+		}
+	case ast.BinaryExpr:
+		switch strings.ToLower(c.Operator) {
+		case "&&", "and":
+			if value {
+				return append(guards(c.Antecedent, true), guards(c.Subsequent, true)...)
+			}
+		case "||", "or":
+			if !value {
+				return append(guards(c.Antecedent, false), guards(c.Subsequent, false)...)
+			}
+		}
+	}
+	return nil
+}
+
+// base returns the variable whose element or property n is, or n if it is
+// a variable, or nil.
+func base(n ast.Node) *ast.Variable {
+	switch n := n.(type) {
+	case *ast.Variable:
+		return n
+	case *ast.ArrayLookupExpr:
+		return base(n.Array)
+	case *ast.PropertyCallExpr:
+		return base(n.Receiver)
+	}
+	return nil
+}
+
+// compact finds the variables named by the argument n of compact, which
+// may be a string or an array of them.
+func (w *walker) compact(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Literal:
+		if n.Type != ast.String || len(n.Value) < 2 {
+			w.f.Dynamic = true
+			return
+		}
+		w.access(Read, n.Value[1:len(n.Value)-1], n)
+	case *ast.ArrayExpr:
+		for _, pair := range n.Pairs {
+			w.compact(pair.Value)
+		}
+	default:
+		w.walk(n, Read)
+		w.f.Dynamic = true
+	}
+}
+
+// interpolation matches a variable interpolated in a string, as $name or
+// ${name}, with its name as the first group.
+var interpolation = regexp.MustCompile(`\$\{?([A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*)`)
+
+// Interpolated returns the names of the variables interpolated in n, a
+// string *ast.Literal in double quotes or a heredoc, or an
+// *ast.ShellCommand, in order, or nil if n interpolates none.
+func Interpolated(n ast.Node) []string {
+	var s string
+	switch n := n.(type) {
+	case *ast.Literal:
+		if n.Type != ast.String || !strings.HasPrefix(n.Value, `"`) && !strings.HasPrefix(n.Value, "<<<") || strings.HasPrefix(n.Value, "<<<'") {
+			return nil
+		}
+		s = n.Value
+	case *ast.ShellCommand:
+		s = n.Command
+	default:
+		return nil
+	}
+	var names []string
+	for _, m := range interpolation.FindAllStringSubmatchIndex(s, -1) {
+		if m[0] > 0 && s[m[0]-1] == '\\' {
+			continue
+		}
+		names = append(names, s[m[2]:m[3]])
+	}
+	return names
+}
@@ -2,24 +2,12 @@ package deadcode
 
 import "github.com/stephens2424/php/ast"
 
-// DeadClasses returns a list of dead classes
+// DeadClasses returns a list of dead classes, as found by Analyze
 func DeadClasses(fs *ast.FileSet, entryPoints []string) []ast.Node {
-	knownClasses := AllTheClasses(fs)
-
-	for _, filename := range entryPoints {
-		f, ok := fs.Files[filename]
-		if !ok {
-			continue
-		}
-
-		EliminateClasses(f.Nodes, knownClasses)
-	}
-
 	nodes := []ast.Node{}
-	for _, f := range knownClasses {
-		nodes = append(nodes, f)
+	for _, c := range Analyze(fs, Options{EntryPoints: entryPoints}).Classes {
+		nodes = append(nodes, c.Node)
 	}
-
 	return nodes
 }
 
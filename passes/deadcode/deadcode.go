@@ -2,22 +2,17 @@ package deadcode
 
 import "github.com/stephens2424/php/ast"
 
-// DeadFunctions returns a list of dead functions
+// DeadFunctions returns a list of dead functions, followed by the dead
+// methods found by DeadMethods, as reached by Analyze
 func DeadFunctions(fs *ast.FileSet, entryPoints []string) []ast.Node {
-	knownFunctions := AllTheFunctions(fs)
-
-	for _, filename := range entryPoints {
-		f, ok := fs.Files[filename]
-		if !ok {
-			continue
-		}
-
-		EliminateCalls(f.Nodes, knownFunctions)
-	}
+	result := Analyze(fs, Options{EntryPoints: entryPoints})
 
 	nodes := []ast.Node{}
-	for _, f := range knownFunctions {
-		nodes = append(nodes, f)
+	for _, f := range result.Functions {
+		nodes = append(nodes, f.Node)
+	}
+	for _, m := range result.Methods {
+		nodes = append(nodes, m.Node.(*ast.Method).FunctionStmt)
 	}
 
 	return nodes
@@ -35,38 +30,35 @@ func EliminateCalls(nodes []ast.Node, knownFunctions map[string]ast.Node) {
 			if static := ast.Static(node.FunctionName); static != nil {
 				delete(knownFunctions, static.Value)
 			}
+		case *ast.MethodCallExpr:
+			// the name of a method is not that of a function
+			EliminateCalls([]ast.Node{node.Receiver}, knownFunctions)
+			EliminateCalls(node.FunctionCallExpr.Children()[1:], knownFunctions)
+			continue
+		case *ast.ClassExpr:
+			if call, ok := node.Expr.(*ast.FunctionCallExpr); ok {
+				EliminateCalls([]ast.Node{node.Receiver}, knownFunctions)
+				EliminateCalls(call.Children()[1:], knownFunctions)
+				continue
+			}
 		}
 		EliminateCalls(node.Children(), knownFunctions)
 	}
 
 }
 
-// AllTheFunctions returns a list of all functions
+// AllTheFunctions returns a list of all functions, other than methods,
+// which are tracked by class
 func AllTheFunctions(fs *ast.FileSet) map[string]ast.Node {
 	namedFunctions := map[string]ast.Node{}
 	for f, n := range fs.GlobalNamespace.Functions {
 		namedFunctions[f] = n
 	}
 
-	for _, class := range fs.GlobalNamespace.ClassesAndInterfaces {
-		if class, ok := class.(*ast.Class); ok {
-			for _, f := range class.Methods {
-				namedFunctions[f.Name] = f.FunctionStmt
-			}
-		}
-	}
-
 	for _, ns := range fs.Namespaces {
 		for f, n := range ns.Functions {
 			namedFunctions[f] = n
 		}
-		for _, class := range ns.ClassesAndInterfaces {
-			if class, ok := class.(*ast.Class); ok {
-				for _, f := range class.Methods {
-					namedFunctions[f.Name] = f.FunctionStmt
-				}
-			}
-		}
 	}
 	return namedFunctions
 }
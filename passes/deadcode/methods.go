@@ -0,0 +1,148 @@
+package deadcode
+
+import (
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/passes/hierarchy"
+)
+
+// DeadMethods returns the methods of the classes and traits of fs that the
+// code reachable from the entry points never calls, ordered by their
+// positions.
+//
+// A call on an object calls the implementations of the method that the
+// class of the object and the classes extending it have, as far as the
+// class can be inferred. A call on an object of unknown class, and a
+// callable array or string naming a method, may call any method of that
+// name. Magic methods, which PHP calls implicitly, are never dead, and
+// neither are methods implementing those of built-in classes and
+// interfaces, or abstract methods that live methods implement.
+func DeadMethods(fs *ast.FileSet, entryPoints []string) []Declaration {
+	return Analyze(fs, Options{EntryPoints: entryPoints}).Methods
+}
+
+// callOn marks the methods name that calling it on a value of type t may
+// call.
+func (r *reacher) callOn(t ast.Type, name string) {
+	for _, member := range ast.Members(t) {
+		switch member := member.(type) {
+		case ast.ObjectType:
+			if !r.callClass(r.h.Type(member.Class), name, true) {
+				r.callNamed(name)
+			}
+		case ast.BasicType:
+			if member == ast.Object {
+				r.callNamed(name)
+			}
+		default:
+			if member == ast.Unknown {
+				r.callNamed(name)
+			}
+		}
+	}
+}
+
+// staticCall marks the methods that calling name on the class named by
+// receiver, as in A::name(), may call.
+func (r *reacher) staticCall(receiver ast.Dynamic, name string) {
+	id, ok := receiver.(*ast.Identifier)
+	if !ok {
+		r.callOn(r.info.TypeOf(r.file, receiver), name)
+		return
+	}
+	switch strings.ToLower(id.Value) {
+	case "self":
+		r.callClass(r.class, name, false)
+	case "static":
+		r.callClass(r.class, name, true)
+	case "parent":
+		if r.class != nil {
+			r.callClass(r.class.Parent, name, false)
+		}
+	default:
+		if !r.callClass(r.h.Resolve(r.path, id.Value), name, false) {
+			r.callNamed(name)
+		}
+	}
+}
+
+// callClass marks the method name of t, and with late binding those of the
+// descendants of t overriding it, and reports whether t is known.
+func (r *reacher) callClass(t *hierarchy.Type, name string, late bool) bool {
+	if t == nil {
+		return false
+	}
+	if m := t.Method(name); m != nil {
+		r.mark(m)
+	}
+	if late {
+		for _, m := range r.h.Dispatch(ast.ObjectType{Class: t.Name}, name) {
+			r.mark(m)
+		}
+	}
+	return true
+}
+
+// callNamed marks every method name.
+func (r *reacher) callNamed(name string) {
+	for _, m := range r.named[strings.ToLower(name)] {
+		r.mark(m)
+	}
+}
+
+// construct marks the constructor that instantiating the class named by c
+// calls.
+func (r *reacher) construct(c ast.Dynamic) {
+	id, ok := c.(*ast.Identifier)
+	if !ok {
+		r.callNamed("__construct")
+		return
+	}
+	switch strings.ToLower(id.Value) {
+	case "self":
+		r.callClass(r.class, "__construct", false)
+	case "static":
+		r.callClass(r.class, "__construct", true)
+	case "parent":
+		if r.class != nil {
+			r.callClass(r.class.Parent, "__construct", false)
+		}
+	default:
+		if !r.callClass(r.h.Resolve(r.path, id.Value), "__construct", false) {
+			r.callNamed("__construct")
+		}
+	}
+}
+
+// implicit reports whether the method m may be called without the code
+// naming it: if it is magic, implements a method of a built-in class or
+// interface, or is abstract and implemented by a live method.
+func (r *reacher) implicit(m *hierarchy.Member) bool {
+	if strings.HasPrefix(m.Name, "__") && !strings.EqualFold(m.Name, "__construct") {
+		return true
+	}
+	for _, o := range m.Type.Overrides(m.Name) {
+		if o.Type.Builtin {
+			return true
+		}
+	}
+	if m.Abstract() {
+		for _, o := range m.Type.Overriders(m.Name) {
+			if r.live[o] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stringLiteral returns the value of n if it is a string literal without
+// interpolation.
+func stringLiteral(n ast.Node) (string, bool) {
+	l, ok := n.(*ast.Literal)
+	if !ok || l.Type != ast.String || len(l.Value) < 2 {
+		return "", false
+	}
+	return l.Value[1 : len(l.Value)-1], true
+}
@@ -0,0 +1,139 @@
+package deadcode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+func TestDeadMethods(t *testing.T) {
+	src := `<?php
+	interface Store {
+		function save($item);
+	}
+
+	abstract class Base implements Store {
+		function __construct() {}
+		function __toString() { return ""; }
+		abstract function load();
+		function helper() {}
+		static function make() { return new static; }
+		function unused() {}
+	}
+
+	class Files extends Base {
+		function save($item) { $this->load(); }
+		function load() { parent::helper(); }
+		function count() {}
+		function unused() {}
+	}
+
+	class Memory extends Base implements Countable {
+		function save($item) {}
+		function load() {}
+		function count() { return 0; }
+	}
+
+	class Cache {
+		function save($item) {}
+		function flush() {}
+		function callback() {}
+		function named() {}
+	}
+
+	function save() {}
+
+	function store(Store $store) {
+		$store->save(1);
+	}
+
+	$files = Files::make();
+	store($files);
+	$c = new Cache;
+	$c->flush();
+	$f = array($c, "callback");
+	$g = 'Cache::named';
+	`
+
+	p := parser.NewParser()
+	if _, err := p.Parse("test.php", src); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, m := range DeadMethods(p.FileSet, []string{"test.php"}) {
+		got = append(got, m.Name)
+	}
+	want := []string{
+		"Base::unused",
+		"Files::count",
+		"Files::unused",
+		"Memory::load",
+		"Cache::save",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("dead methods:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDeadTraitMethods(t *testing.T) {
+	src := `<?php
+	trait T {
+		function hello() {}
+		function unused() {}
+	}
+	trait U {
+		function hello() {}
+	}
+	class C {
+		use T, U {
+			T::hello insteadof U;
+			U::hello as helloU;
+		}
+	}
+	$c = new C;
+	$c->helloU();
+	`
+
+	p := parser.NewParser()
+	if _, err := p.Parse("test.php", src); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, m := range DeadMethods(p.FileSet, []string{"test.php"}) {
+		got = append(got, m.Name)
+	}
+	want := []string{
+		"T::hello",
+		"T::unused",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("dead methods:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDeadFunctionsAndMethods(t *testing.T) {
+	src := `<?php
+	function save() {}
+	function load() {}
+
+	class Item {
+		function save() {}
+	}
+
+	$item = new Item;
+	$item->save();
+	load();
+	`
+
+	p := parser.NewParser()
+	if _, err := p.Parse("test.php", src); err != nil {
+		t.Fatal(err)
+	}
+	dead := DeadFunctions(p.FileSet, []string{"test.php"})
+	if len(dead) != 1 || dead[0].String() != "Func: save" {
+		t.Errorf("dead functions: %v", dead)
+	}
+}
@@ -0,0 +1,666 @@
+package deadcode
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/passes/hierarchy"
+	"github.com/stephens2424/php/passes/symbols"
+	typecheck "github.com/stephens2424/php/passes/typechecking"
+)
+
+// Options configures Analyze.
+type Options struct {
+	// EntryPoints holds the paths of the files that PHP runs first, such
+	// as the scripts a web server runs.
+	EntryPoints []string
+
+	// IncludePath holds the directories that include and require search
+	// for relative paths, as PHP's include_path setting does, before the
+	// directory of the including file.
+	IncludePath []string
+
+	// Classes holds the qualified names of classes that code outside of
+	// the file set uses, as a framework uses the controllers it is
+	// configured with, and whose public methods it may call.
+	Classes []string
+}
+
+// Declaration is a declaration of a file set.
+type Declaration struct {
+	// Name is the qualified name of the declaration, which for a method
+	// is that of its class followed by :: and its own.
+	Name string
+
+	// Path is the path of the file declaring it, and Span the span of its
+	// name there.
+	Path string
+	Span ast.Span
+
+	// Node is the *ast.FunctionStmt, *ast.Class, *ast.Method or
+	// *ast.Constant declared, or for a constant defined by define, the
+	// literal naming it.
+	Node ast.Node
+}
+
+// Include is an include or require whose path could not be resolved.
+type Include struct {
+	Path string
+	Span ast.Span
+	Node ast.Node
+}
+
+// Result is the dead code of a file set.
+type Result struct {
+	// Files holds the paths of the files that are neither entry points
+	// nor included by reachable code.
+	Files []string
+
+	// Functions, Classes, Methods and Constants hold the functions,
+	// classes and traits, methods, and constants defined by define or
+	// declared by classes that reachable code never uses, ordered by
+	// their positions.
+	Functions []Declaration
+	Classes   []Declaration
+	Methods   []Declaration
+	Constants []Declaration
+
+	// Unresolved holds the includes of reachable code whose paths depend
+	// on values not known statically, whose files are reported as dead
+	// unless something else includes them.
+	Unresolved []Include
+}
+
+// Analyze finds the code of fs that cannot run when PHP runs the entry
+// points of options.
+//
+// Code is reachable if it is the code of an entry point outside of
+// functions and classes, or of a file included by reachable code, or the
+// body of a function or method that reachable code calls. A class is used
+// if reachable code instantiates it, calls it statically, reads its
+// constants or tests for it with instanceof or catch, or if a used class
+// extends or uses it. Methods are resolved as DeadMethods describes, and a
+// string naming a function, method or constant may use it.
+//
+// The path of an include is resolved if it is made of string literals,
+// __DIR__, __FILE__ and calls to dirname, joined with the . operator.
+func Analyze(fs *ast.FileSet, options Options) *Result {
+	r := newReacher(fs, options)
+	for _, path := range options.EntryPoints {
+		r.reachFile(path)
+	}
+	for _, name := range options.Classes {
+		r.useExternally(name)
+	}
+	for len(r.queue) > 0 {
+		u := r.queue[0]
+		r.queue = r.queue[1:]
+		r.path, r.file, r.class = u.path, fs.Files[u.path], u.class
+		r.walk(u.nodes)
+	}
+	return r.result()
+}
+
+// reacher finds the code of a file set that is reachable from its entry
+// points.
+type reacher struct {
+	fs      *ast.FileSet
+	options Options
+	h       *hierarchy.Hierarchy
+	index   *symbols.Index
+	info    *typecheck.Info
+
+	// paths holds the paths of the files of the file set by their
+	// absolute forms, functions holds the functions by their lower case
+	// qualified names, and constants the constants defined by define by
+	// their names. named holds the methods of classes and traits by their
+	// lower case names, and their constants by their names.
+	paths     map[string]string
+	functions map[string]*function
+	constants map[string]*constant
+	named     map[string][]*hierarchy.Member
+
+	// files, called, used, live and read hold the files, functions, types,
+	// methods and constants that have been reached.
+	files  map[string]bool
+	called map[*function]bool
+	used   map[*hierarchy.Type]bool
+	live   map[*hierarchy.Member]bool
+	read   map[interface{}]bool
+
+	unresolved []Include
+	queue      []unit
+
+	// path and file are the file being walked, and class the class
+	// enclosing the code being walked, or nil.
+	path  string
+	file  *ast.File
+	class *hierarchy.Type
+}
+
+// function is a function of the file set.
+type function struct {
+	name string
+	path string
+	stmt *ast.FunctionStmt
+}
+
+// constant is a constant defined by define.
+type constant struct {
+	name string
+	path string
+	span ast.Span
+	node ast.Node
+}
+
+// unit is reachable code, to be walked.
+type unit struct {
+	path  string
+	class *hierarchy.Type
+	nodes []ast.Node
+}
+
+func newReacher(fs *ast.FileSet, options Options) *reacher {
+	r := &reacher{
+		fs:        fs,
+		options:   options,
+		h:         hierarchy.New(fs),
+		index:     symbols.New(fs),
+		info:      typecheck.Infer(fs),
+		paths:     map[string]string{},
+		functions: map[string]*function{},
+		constants: map[string]*constant{},
+		named:     map[string][]*hierarchy.Member{},
+		files:     map[string]bool{},
+		called:    map[*function]bool{},
+		used:      map[*hierarchy.Type]bool{},
+		live:      map[*hierarchy.Member]bool{},
+		read:      map[interface{}]bool{},
+	}
+	for path, f := range fs.Files {
+		r.paths[abs(path)] = path
+		stmts := map[*ast.FunctionDefinition]*ast.FunctionStmt{}
+		declarations(f.Nodes, func(n ast.Node) {
+			if fn, ok := n.(*ast.FunctionStmt); ok {
+				stmts[fn.FunctionDefinition] = fn
+			}
+		})
+		for _, o := range r.index.Occurrences(path) {
+			if !o.Definition {
+				continue
+			}
+			if o.Symbol.Kind == symbols.Constant {
+				if _, ok := r.constants[o.Symbol.Name]; !ok {
+					r.constants[o.Symbol.Name] = &constant{name: o.Symbol.Name, path: path, span: o.Span, node: o.Node}
+				}
+				continue
+			}
+			if o.Symbol.Kind != symbols.Function {
+				continue
+			}
+			if stmt, ok := stmts[o.Node.(*ast.FunctionDefinition)]; ok {
+				key := strings.ToLower(o.Symbol.Name)
+				if _, ok := r.functions[key]; !ok {
+					r.functions[key] = &function{name: o.Symbol.Name, path: path, stmt: stmt}
+				}
+			}
+		}
+	}
+	for _, t := range r.h.Types() {
+		if t.Kind == hierarchy.Interface {
+			continue
+		}
+		for _, m := range t.DeclaredMethods() {
+			key := strings.ToLower(m.Name)
+			r.named[key] = append(r.named[key], m)
+		}
+		for _, k := range t.DeclaredConstants() {
+			r.named[k.Name] = append(r.named[k.Name], k)
+		}
+	}
+	return r
+}
+
+// declarations calls f with the functions and classes declared in nodes,
+// which may be nested in conditions, but not in other functions.
+func declarations(nodes []ast.Node, f func(ast.Node)) {
+	for _, n := range nodes {
+		switch n.(type) {
+		case *ast.FunctionStmt, *ast.Class:
+			f(n)
+			continue
+		case *ast.AnonymousFunction:
+			continue
+		}
+		if !ast.IsNil(n) {
+			declarations(n.Children(), f)
+		}
+	}
+}
+
+// reachFile marks the file path as reached, walking its code outside of
+// functions and classes once.
+func (r *reacher) reachFile(path string) {
+	f, ok := r.fs.Files[path]
+	if !ok || r.files[path] {
+		return
+	}
+	r.files[path] = true
+	r.queue = append(r.queue, unit{path: path, nodes: f.Nodes})
+}
+
+// walk walks reachable nodes, marking what they reach.
+func (r *reacher) walk(nodes []ast.Node) {
+	for _, node := range nodes {
+		if ast.IsNil(node) {
+			continue
+		}
+		switch n := node.(type) {
+		case *ast.FunctionStmt, *ast.Class, *ast.Interface:
+			// reached only once called or used
+			continue
+		case *ast.FunctionCallExpr:
+			if id := ast.Static(n.FunctionName); id != nil {
+				r.call(id.Value)
+				if strings.EqualFold(id.Value, "define") && len(n.Arguments) > 0 {
+					// the name defined is not a use of it
+					r.walk(n.Children()[2:])
+					continue
+				}
+			}
+		case ast.Include:
+			r.include(n)
+		case ast.IncludeStmt:
+			r.include(n.Include)
+		case *ast.MethodCallExpr:
+			if id := ast.Static(n.FunctionName); id != nil {
+				r.callOn(r.info.TypeOf(r.file, n.Receiver), id.Value)
+			}
+			r.walk([]ast.Node{n.Receiver})
+			r.walk(n.FunctionCallExpr.Children()[1:])
+			continue
+		case *ast.ClassExpr:
+			r.use(n.Receiver)
+			if k, ok := n.Expr.(ast.ConstantExpr); ok {
+				if id := ast.Static(k.Name); id != nil {
+					r.classConstant(n.Receiver, id.Value)
+				}
+				r.walk([]ast.Node{n.Receiver})
+				continue
+			}
+			if call, ok := n.Expr.(*ast.FunctionCallExpr); ok {
+				if id := ast.Static(call.FunctionName); id != nil {
+					r.staticCall(n.Receiver, id.Value)
+				}
+				r.walk([]ast.Node{n.Receiver})
+				r.walk(call.Children()[1:])
+				continue
+			}
+		case *ast.NewCallExpr:
+			r.use(n.Class)
+			r.construct(n.Class)
+		case ast.NewCallExpr:
+			r.use(n.Class)
+			r.construct(n.Class)
+		case ast.ConstantExpr:
+			if id := ast.Static(n.Name); id != nil {
+				r.readConstant(id.Value)
+			}
+		case ast.BinaryExpr:
+			if k, ok := n.Subsequent.(ast.ConstantExpr); ok && strings.EqualFold(n.Operator, "instanceof") {
+				r.useName(k.Name)
+			}
+		case *ast.CatchStmt:
+			if n.CatchType != "" {
+				r.useName(&ast.Identifier{Value: n.CatchType})
+			}
+		case *ast.ArrayExpr:
+			if len(n.Pairs) == 2 && n.Pairs[0].Key == nil {
+				if name, ok := stringLiteral(n.Pairs[1].Value); ok {
+					r.callNamed(name)
+				}
+			}
+		case *ast.Literal:
+			if name, ok := stringLiteral(n); ok {
+				if i := strings.Index(name, "::"); i >= 0 {
+					r.callNamed(name[i+2:])
+					r.readNamed(name[i+2:])
+				} else {
+					r.call(name)
+					r.readConstant(name)
+				}
+			}
+		}
+		r.walk(node.Children())
+	}
+}
+
+// call marks the function called by name in the current file.
+func (r *reacher) call(name string) {
+	fn := r.lookupFunction(name)
+	if fn == nil || r.called[fn] {
+		return
+	}
+	r.called[fn] = true
+	r.queue = append(r.queue, unit{path: fn.path, nodes: []ast.Node{fn.stmt.Body}})
+}
+
+// lookupFunction returns the function called by name in the current
+// file's namespace, or nil.
+func (r *reacher) lookupFunction(name string) *function {
+	if strings.HasPrefix(name, `\`) {
+		return r.functions[strings.ToLower(name[1:])]
+	}
+	if ns := r.index.Namespace(r.path); ns != "" {
+		if fn, ok := r.functions[strings.ToLower(ns+`\`+name)]; ok {
+			return fn
+		}
+	}
+	return r.functions[strings.ToLower(name)]
+}
+
+// readConstant marks the constant defined by define that name refers to
+// in the current file.
+func (r *reacher) readConstant(name string) {
+	if strings.HasPrefix(name, `\`) {
+		name = name[1:]
+	} else if ns := r.index.Namespace(r.path); ns != "" {
+		if k, ok := r.constants[ns+`\`+name]; ok {
+			r.read[k] = true
+			return
+		}
+	}
+	if k, ok := r.constants[name]; ok {
+		r.read[k] = true
+	}
+}
+
+// classConstant marks the constant name of the class named by receiver,
+// as in A::NAME, which with static may be that of a descendant.
+func (r *reacher) classConstant(receiver ast.Dynamic, name string) {
+	id, ok := receiver.(*ast.Identifier)
+	if !ok {
+		r.readNamed(name)
+		return
+	}
+	var t *hierarchy.Type
+	switch strings.ToLower(id.Value) {
+	case "self", "static":
+		t = r.class
+	case "parent":
+		if r.class != nil {
+			t = r.class.Parent
+		}
+	default:
+		t = r.h.Resolve(r.path, id.Value)
+	}
+	if t == nil {
+		r.readNamed(name)
+		return
+	}
+	if k := t.Constant(name); k != nil {
+		r.read[k] = true
+	}
+	if strings.EqualFold(id.Value, "static") {
+		for _, d := range t.Descendants() {
+			if k := d.Constant(name); k != nil {
+				r.read[k] = true
+			}
+		}
+	}
+}
+
+// readNamed marks every class constant name.
+func (r *reacher) readNamed(name string) {
+	for _, k := range r.named[name] {
+		if k.Kind == hierarchy.Constant {
+			r.read[k] = true
+		}
+	}
+}
+
+// useExternally marks the class name as used by code outside of the file
+// set, which may call its public methods.
+func (r *reacher) useExternally(name string) {
+	t := r.h.Type(name)
+	if t == nil {
+		return
+	}
+	r.useName(&ast.Identifier{Value: `\` + t.Name})
+	for _, m := range t.Methods() {
+		if method, ok := m.Node.(*ast.Method); ok && method.Visibility == ast.Public {
+			r.mark(m)
+		}
+	}
+}
+
+// use marks the class named by n as used, if it is named statically.
+func (r *reacher) use(n ast.Node) {
+	if id, ok := n.(*ast.Identifier); ok {
+		r.useName(id)
+	}
+}
+
+// useName marks the class named by id as used, with the types it extends
+// and uses.
+func (r *reacher) useName(id ast.Dynamic) {
+	name := ast.Static(id)
+	if name == nil {
+		return
+	}
+	var t *hierarchy.Type
+	switch strings.ToLower(name.Value) {
+	case "self", "static":
+		t = r.class
+	case "parent":
+		if r.class != nil {
+			t = r.class.Parent
+		}
+	default:
+		t = r.h.Resolve(r.path, name.Value)
+	}
+	if t == nil {
+		return
+	}
+	for _, u := range append([]*hierarchy.Type{t}, t.Ancestors()...) {
+		if r.used[u] || u.Builtin {
+			continue
+		}
+		r.used[u] = true
+		if c, ok := u.Node.(*ast.Class); ok {
+			// constants and the initial values of properties
+			var nodes []ast.Node
+			for _, k := range c.Constants {
+				nodes = append(nodes, k)
+			}
+			for _, p := range c.Properties {
+				nodes = append(nodes, p)
+			}
+			r.queue = append(r.queue, unit{path: u.File, class: u, nodes: nodes})
+		}
+	}
+}
+
+// mark marks the method m as live, walking its body once.
+func (r *reacher) mark(m *hierarchy.Member) {
+	if r.live[m] {
+		return
+	}
+	r.live[m] = true
+	if method, ok := m.Node.(*ast.Method); ok && method.Body != nil && !m.Type.Builtin {
+		r.queue = append(r.queue, unit{path: m.Type.File, class: m.Type, nodes: []ast.Node{method.Body}})
+	}
+}
+
+// include marks the file that i includes, or records i as unresolved.
+func (r *reacher) include(i ast.Include) {
+	for _, expr := range i.Expressions {
+		if path, ok := r.includePath(expr); ok {
+			r.reachFile(path)
+			continue
+		}
+		span, _ := r.file.Positions.Span(i)
+		r.unresolved = append(r.unresolved, Include{Path: r.path, Span: span, Node: i})
+	}
+}
+
+// includePath returns the path in the file set of the file that including
+// expr includes, if it can be resolved.
+func (r *reacher) includePath(expr ast.Expr) (string, bool) {
+	name, ok := r.evaluate(expr)
+	if !ok || name == "" {
+		return "", false
+	}
+	var candidates []string
+	switch {
+	case filepath.IsAbs(name):
+		candidates = append(candidates, name)
+	case strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../"):
+		candidates = append(candidates, filepath.Join(filepath.Dir(abs(r.path)), name))
+	default:
+		for _, dir := range r.options.IncludePath {
+			candidates = append(candidates, filepath.Join(abs(dir), name))
+		}
+		candidates = append(candidates, filepath.Join(filepath.Dir(abs(r.path)), name))
+	}
+	for _, c := range candidates {
+		if path, ok := r.paths[filepath.Clean(c)]; ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// abs returns path as an absolute path, taking relative paths to be
+// relative to the root, where the files of a file set with relative paths
+// are taken to be.
+func abs(path string) string {
+	return filepath.Join("/", path)
+}
+
+// evaluate returns the value of the string expression expr in the current
+// file, if it is known statically.
+func (r *reacher) evaluate(expr ast.Node) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.Literal:
+		if e.Type != ast.String || strings.HasPrefix(e.Value, `"`) && strings.ContainsAny(e.Value, `$\`) {
+			return "", false
+		}
+		return stringLiteral(e)
+	case ast.ConstantExpr:
+		switch id := ast.Static(e.Name); {
+		case id == nil:
+		case id.Value == "__DIR__":
+			return filepath.Dir(abs(r.path)), true
+		case id.Value == "__FILE__":
+			return abs(r.path), true
+		}
+	case ast.BinaryExpr:
+		if e.Operator != "." {
+			return "", false
+		}
+		a, ok := r.evaluate(e.Antecedent)
+		if !ok {
+			return "", false
+		}
+		b, ok := r.evaluate(e.Subsequent)
+		return a + b, ok
+	case *ast.FunctionCallExpr:
+		id := ast.Static(e.FunctionName)
+		if id == nil || !strings.EqualFold(strings.TrimPrefix(id.Value, `\`), "dirname") || len(e.Arguments) == 0 {
+			return "", false
+		}
+		dir, ok := r.evaluate(e.Arguments[0])
+		if !ok {
+			return "", false
+		}
+		levels := 1
+		if len(e.Arguments) > 1 {
+			l, ok := e.Arguments[1].(*ast.Literal)
+			if !ok {
+				return "", false
+			}
+			if levels, ok = atoi(l.Value); !ok {
+				return "", false
+			}
+		}
+		for i := 0; i < levels; i++ {
+			dir = filepath.Dir(dir)
+		}
+		return dir, true
+	}
+	return "", false
+}
+
+func atoi(s string) (int, bool) {
+	i, err := strconv.Atoi(s)
+	return i, err == nil
+}
+
+// result returns what was not reached.
+func (r *reacher) result() *Result {
+	res := &Result{Unresolved: r.unresolved}
+	for path := range r.fs.Files {
+		if !r.files[path] {
+			res.Files = append(res.Files, path)
+		}
+	}
+	sort.Strings(res.Files)
+
+	for _, fn := range r.functions {
+		if !r.called[fn] {
+			res.Functions = append(res.Functions, r.declaration(fn.name, fn.path, fn.stmt, fn.stmt.FunctionDefinition))
+		}
+	}
+	for _, t := range r.h.Types() {
+		if c, ok := t.Node.(*ast.Class); ok && !r.used[t] {
+			res.Classes = append(res.Classes, r.declaration(t.Name, t.File, c, c))
+		}
+	}
+	for _, members := range r.named {
+		for _, m := range members {
+			switch {
+			case m.Kind == hierarchy.Constant && !r.read[m]:
+				res.Constants = append(res.Constants, r.declaration(m.String(), m.Type.File, m.Node, m.Node))
+			case m.Kind == hierarchy.Method && !r.live[m] && !r.implicit(m):
+				method := m.Node.(*ast.Method)
+				res.Methods = append(res.Methods, r.declaration(m.String(), m.Type.File, method, method.FunctionDefinition))
+			}
+		}
+	}
+	for _, k := range r.constants {
+		if !r.read[k] {
+			res.Constants = append(res.Constants, Declaration{Name: k.name, Path: k.path, Span: k.span, Node: k.node})
+		}
+	}
+	sortDeclarations(res.Functions)
+	sortDeclarations(res.Classes)
+	sortDeclarations(res.Methods)
+	sortDeclarations(res.Constants)
+	return res
+}
+
+// declaration returns the declaration of n, whose name is named by the
+// node name.
+func (r *reacher) declaration(qualified, path string, n, name ast.Node) Declaration {
+	d := Declaration{Name: qualified, Path: path, Node: n}
+	if f, ok := r.fs.Files[path]; ok {
+		d.Span = f.Names[name]
+	}
+	return d
+}
+
+func sortDeclarations(ds []Declaration) {
+	sort.Slice(ds, func(i, j int) bool {
+		if ds[i].Path != ds[j].Path {
+			return ds[i].Path < ds[j].Path
+		}
+		if ds[i].Span.Begin.Position != ds[j].Span.Begin.Position {
+			return ds[i].Span.Begin.Position < ds[j].Span.Begin.Position
+		}
+		return ds[i].Name < ds[j].Name
+	})
+}
@@ -0,0 +1,107 @@
+package deadcode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+func TestAnalyze(t *testing.T) {
+	files := map[string]string{
+		"web/index.php": `<?php
+require_once __DIR__ . "/../lib/app.php";
+include 'helpers.php';
+include dirname(__FILE__) . '/' . $page . '.php';
+App\run();
+`,
+		"lib/app.php": `<?php
+namespace App;
+
+require __DIR__ . "/db.php";
+
+define('App\SUFFIX', "!");
+define('UNUSED', 1);
+
+function run() {
+	$db = new Db\Connection;
+	$db->query(format("x"));
+	if ($db instanceof Db\Closeable) {}
+}
+
+function format($s) { return $s . SUFFIX; }
+function unused() { legacy(); }
+`,
+		"lib/db.php": `<?php
+namespace App\Db;
+
+interface Closeable {}
+
+class Connection {
+	const DRIVER = Driver::NAME;
+	function query($sql) { $this->log($sql); }
+	function log($sql) {}
+	function close() {}
+}
+
+class Driver { const NAME = "mysql"; }
+class Unused {}
+`,
+		"lib/framework.php": `<?php
+class Controller {
+	public function index() {}
+	private function helper() {}
+}
+`,
+		"lib/helpers.php": `<?php
+function helper() {}
+`,
+		"lib/legacy.php": `<?php
+function legacy() {}
+`,
+		"web/page.php": `<?php
+echo "page";
+`,
+	}
+
+	p := parser.NewParser()
+	for path, src := range files {
+		if _, err := p.Parse(path, src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result := Analyze(p.FileSet, Options{
+		EntryPoints: []string{"web/index.php"},
+		IncludePath: []string{"lib"},
+		Classes:     []string{"Controller"},
+	})
+
+	names := func(ds []Declaration) string {
+		var s []string
+		for _, d := range ds {
+			s = append(s, d.Name)
+		}
+		return strings.Join(s, " ")
+	}
+	if got := strings.Join(result.Files, " "); got != "lib/framework.php lib/legacy.php web/page.php" {
+		t.Errorf("dead files: %s", got)
+	}
+	if got := names(result.Functions); got != `App\unused helper legacy` {
+		t.Errorf("dead functions: %s", got)
+	}
+	if got := names(result.Classes); got != `App\Db\Unused` {
+		t.Errorf("dead classes: %s", got)
+	}
+	if got := names(result.Methods); got != `App\Db\Connection::close Controller::helper` {
+		t.Errorf("dead methods: %s", got)
+	}
+	if len(result.Unresolved) != 1 || result.Unresolved[0].Path != "web/index.php" || result.Unresolved[0].Span.Begin.Line != 4 {
+		t.Errorf("unresolved includes: %+v", result.Unresolved)
+	}
+	if got := names(result.Constants); got != `UNUSED App\Db\Connection::DRIVER` {
+		t.Errorf("dead constants: %s", got)
+	}
+	if d := result.Functions[0]; d.Path != "lib/app.php" || d.Span.Begin.Line != 16 {
+		t.Errorf("App\\unused at %s:%d", d.Path, d.Span.Begin.Line)
+	}
+}
@@ -0,0 +1,435 @@
+package deadcode
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/passes/cfg"
+)
+
+// Unreachable is a run of statements that cannot run, because the code
+// before them never completes or the condition guarding them is constant.
+type Unreachable struct {
+	// Path is the path of the file of the statements, in the file set, and
+	// Span spans them in it.
+	Path string
+	Span ast.Span
+
+	// Statements holds the unreachable statements, in order.
+	Statements []ast.Node
+
+	// Reason is why the statements cannot run, such as "after return" or
+	// "condition is always false".
+	Reason string
+}
+
+func (u Unreachable) String() string {
+	return fmt.Sprintf("%s:%d:%d: unreachable code %s", u.Path, u.Span.Begin.Line, u.Span.Begin.Column, u.Reason)
+}
+
+// The reasons that statements cannot run.
+const (
+	afterNever     = "after code that never completes"
+	alwaysFalse    = "condition is always false"
+	earlierTrue    = "earlier condition is always true"
+	afterLoop      = "after infinite loop"
+	afterPrefix    = "after "
+	reasonReturn   = "return"
+	reasonThrow    = "throw"
+	reasonExit     = "exit"
+	reasonBreak    = "break"
+	reasonContinue = "continue"
+)
+
+// UnreachableCode returns the statements of fs that cannot run, in the
+// code of files outside of functions and in the bodies of functions,
+// methods and closures, ordered by their positions.
+//
+// The statements are those whose code lies in blocks of the control-flow
+// graph of their function that control cannot enter, taking the branches
+// of if statements and loops whose conditions are the constants true or
+// false, as literals, only when they may hold. That leaves out statements
+// after return, throw, exit or die, break or continue, an infinite loop
+// that nothing breaks out of, or a statement whose branches all never
+// complete. Catch clauses may run whenever their try statement does, as
+// the graph cannot tell every statement that throws. Declarations of
+// functions, classes and interfaces are never unreachable, as PHP declares
+// them before running the code around them.
+func UnreachableCode(fs *ast.FileSet) []Unreachable {
+	paths := make([]string, 0, len(fs.Files))
+	for path := range fs.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var found []Unreachable
+	for _, path := range paths {
+		file := fs.Files[path]
+		var reported []Unreachable
+		report := func(g *cfg.Graph, stmts []ast.Node) {
+			f := newFlow(path, file, g)
+			f.list(stmts, "")
+			reported = append(reported, f.found...)
+		}
+		report(cfg.File(file), file.Nodes)
+		for _, n := range functions(file.Nodes) {
+			if g := cfg.Function(n); g != nil {
+				report(g, body(n))
+			}
+		}
+		sort.SliceStable(reported, func(i, j int) bool {
+			return reported[i].Span.Begin.Position < reported[j].Span.Begin.Position
+		})
+		found = append(found, reported...)
+	}
+	return found
+}
+
+// functions returns the functions, methods and closures declared in the
+// nodes nodes, at any depth.
+func functions(nodes []ast.Node) []ast.Node {
+	var found []ast.Node
+	var visit func(n ast.Node)
+	visit = func(n ast.Node) {
+		if ast.IsNil(n) {
+			return
+		}
+		switch n.(type) {
+		case *ast.FunctionStmt, *ast.Method, *ast.AnonymousFunction:
+			found = append(found, n)
+		}
+		for _, child := range n.Children() {
+			visit(child)
+		}
+	}
+	for _, n := range nodes {
+		visit(n)
+	}
+	return found
+}
+
+// body returns the statements of the body of the function, method or
+// closure n.
+func body(n ast.Node) []ast.Node {
+	var b *ast.Block
+	switch n := n.(type) {
+	case *ast.FunctionStmt:
+		b = n.Body
+	case *ast.Method:
+		b = n.Body
+	case *ast.AnonymousFunction:
+		b = n.Body
+	}
+	if b == nil {
+		return nil
+	}
+	return statements(b.Statements)
+}
+
+// flow finds the statements of a graph whose code control cannot reach.
+type flow struct {
+	path  string
+	file  *ast.File
+	found []Unreachable
+
+	// entered holds the blocks of the graph that control can enter.
+	entered map[*cfg.Block]bool
+
+	// nodes holds the nodes of the blocks of the graph, by the positions
+	// at which they begin, in order, and catches the blocks beginning the
+	// catch clauses.
+	nodes   []node
+	catches map[*ast.CatchStmt]*cfg.Block
+}
+
+// node is a node of the block Block, beginning at Begin.
+type node struct {
+	Begin int
+	Block *cfg.Block
+}
+
+func newFlow(path string, file *ast.File, g *cfg.Graph) *flow {
+	f := &flow{path: path, file: file, catches: map[*ast.CatchStmt]*cfg.Block{}}
+	for _, b := range g.Blocks {
+		for _, n := range b.Nodes {
+			if c, ok := n.(*ast.CatchStmt); ok {
+				f.catches[c] = b
+			}
+			if span, ok := file.Positions.Span(n); ok {
+				f.nodes = append(f.nodes, node{span.Begin.Position, b})
+			}
+		}
+	}
+	sort.SliceStable(f.nodes, func(i, j int) bool { return f.nodes[i].Begin < f.nodes[j].Begin })
+	f.entered = g.Reachable(follow)
+	return f
+}
+
+// follow reports whether control may pass along the edge e, which it
+// cannot if e is taken on a condition that is a constant of the other
+// value. The expressions of switch cases are compared with the subject
+// of the switch, rather than being conditions themselves.
+func follow(e *cfg.Edge) bool {
+	if (e.Kind != cfg.True && e.Kind != cfg.False) || e.From.Kind == "switch.case" || len(e.From.Nodes) == 0 {
+		return true
+	}
+	value, known := condition(e.From.Nodes[len(e.From.Nodes)-1])
+	return !known || value == (e.Kind == cfg.True)
+}
+
+// reached reports whether control may reach the code of the statement s,
+// and known whether any of its code is in the graph.
+func (f *flow) reached(s ast.Node) (reached, known bool) {
+	span, ok := f.file.Positions.Span(s)
+	if !ok {
+		return false, false
+	}
+	i := sort.Search(len(f.nodes), func(i int) bool { return f.nodes[i].Begin >= span.Begin.Position })
+	for ; i < len(f.nodes) && f.nodes[i].Begin < span.End.Position; i++ {
+		if f.entered[f.nodes[i].Block] {
+			return true, true
+		}
+		known = true
+	}
+	return false, known
+}
+
+// report records the statements stmts as unreachable for reason.
+func (f *flow) report(stmts []ast.Node, reason string) {
+	u := Unreachable{Path: f.path, Reason: reason}
+	for _, s := range stmts {
+		span, ok := f.file.Positions.Span(s)
+		if !ok {
+			continue
+		}
+		if len(u.Statements) == 0 || span.Begin.Position < u.Span.Begin.Position {
+			u.Span.Begin = span.Begin
+		}
+		if len(u.Statements) == 0 || span.End.Position > u.Span.End.Position {
+			u.Span.End = span.End
+		}
+		u.Statements = append(u.Statements, s)
+	}
+	if u.Reason == "" {
+		u.Reason = afterNever
+	}
+	if len(u.Statements) > 0 {
+		f.found = append(f.found, u)
+	}
+}
+
+// declaration reports whether s declares a function, class or interface, or
+// is not a statement that runs.
+func declaration(s ast.Node) bool {
+	switch s.(type) {
+	case *ast.FunctionStmt, *ast.Class, *ast.Interface, *ast.EmptyStatement, *ast.InlineHTML, ast.InlineHTML, *ast.UseStmt:
+		return true
+	}
+	return false
+}
+
+// list reports the statements of stmts that control cannot reach, where
+// reason is why the first of them may not run, or "" if it may, and
+// follows those it can reach.
+func (f *flow) list(stmts []ast.Node, reason string) {
+	var run []ast.Node
+	for _, s := range stmts {
+		if declaration(s) {
+			continue
+		}
+		reached, known := f.reached(s)
+		switch {
+		case !known && len(run) == 0:
+		case !reached:
+			run = append(run, s)
+		default:
+			f.report(run, reason)
+			run = nil
+			f.statement(s)
+			reason = after(s)
+		}
+	}
+	f.report(run, reason)
+}
+
+// block follows the statement s, the body of a branch or loop, whose
+// statements may not run for reason.
+func (f *flow) block(s ast.Statement, reason string) {
+	if ast.IsNil(s) {
+		return
+	}
+	switch b := s.(type) {
+	case *ast.Block:
+		f.list(statements(b.Statements), reason)
+	case ast.Block:
+		f.list(statements(b.Statements), reason)
+	default:
+		f.list([]ast.Node{s}, reason)
+	}
+}
+
+func statements(stmts []ast.Statement) []ast.Node {
+	nodes := make([]ast.Node, len(stmts))
+	for i, s := range stmts {
+		nodes[i] = s
+	}
+	return nodes
+}
+
+// after returns the reason that statements following the statement s
+// cannot run, if s never completes.
+func after(s ast.Node) string {
+	switch s := s.(type) {
+	case ast.ReturnStmt, *ast.ReturnStmt:
+		return afterPrefix + reasonReturn
+	case ast.ThrowStmt, *ast.ThrowStmt:
+		return afterPrefix + reasonThrow
+	case *ast.ExitStmt, ast.ExitStmt:
+		return afterPrefix + reasonExit
+	case *ast.BreakStmt, ast.BreakStmt:
+		return afterPrefix + reasonBreak
+	case *ast.ContinueStmt, ast.ContinueStmt:
+		return afterPrefix + reasonContinue
+	case *ast.ExprStmt:
+		if dies(s.Expr) {
+			return afterPrefix + reasonExit
+		}
+	case *ast.WhileStmt:
+		if value, known := condition(s.Termination); known && value {
+			return afterLoop
+		}
+	case *ast.DoWhileStmt:
+		if value, known := condition(s.Termination); known && value {
+			return afterLoop
+		}
+	case *ast.ForStmt:
+		n := len(s.Termination)
+		if n == 0 {
+			return afterLoop
+		}
+		if value, known := condition(s.Termination[n-1]); known && value {
+			return afterLoop
+		}
+	}
+	return afterNever
+}
+
+// statement follows the statement s, which control can reach.
+func (f *flow) statement(s ast.Node) {
+	switch s := s.(type) {
+	case *ast.Block:
+		f.list(statements(s.Statements), "")
+	case ast.Block:
+		f.list(statements(s.Statements), "")
+	case *ast.IfStmt:
+		taken := false
+		for _, b := range s.Branches {
+			value, known := condition(b.Condition)
+			switch {
+			case taken:
+				f.block(b.Block, earlierTrue)
+			case known && !value:
+				f.block(b.Block, alwaysFalse)
+			default:
+				f.block(b.Block, "")
+			}
+			taken = taken || known && value
+		}
+		if taken {
+			f.block(s.ElseBlock, earlierTrue)
+		} else {
+			f.block(s.ElseBlock, "")
+		}
+	case *ast.WhileStmt:
+		f.loop(s.Termination, s.LoopBlock)
+	case *ast.DoWhileStmt:
+		f.block(s.LoopBlock, "")
+	case *ast.ForStmt:
+		var cond ast.Expr
+		if n := len(s.Termination); n > 0 {
+			cond = s.Termination[n-1]
+		}
+		f.loop(cond, s.LoopBlock)
+	case *ast.ForeachStmt:
+		f.block(s.LoopBlock, "")
+	case ast.SwitchStmt:
+		f.switchStmt(&s)
+	case *ast.SwitchStmt:
+		f.switchStmt(s)
+	case *ast.TryStmt:
+		f.block(s.TryBlock, "")
+		for _, c := range s.CatchStmts {
+			if b := f.catches[c]; b != nil && !f.entered[b] {
+				cfg.Reach(b, follow, f.entered)
+			}
+			f.block(c.CatchBlock, "")
+		}
+		if s.FinallyBlock != nil {
+			f.block(s.FinallyBlock, "")
+		}
+	}
+}
+
+// loop follows a loop with the body body, which runs while cond holds.
+func (f *flow) loop(cond ast.Expr, body ast.Statement) {
+	if value, known := condition(cond); known && !value {
+		f.block(body, alwaysFalse)
+		return
+	}
+	f.block(body, "")
+}
+
+func (f *flow) switchStmt(s *ast.SwitchStmt) {
+	for _, c := range s.Cases {
+		f.list(statements(c.Block.Statements), "")
+	}
+	f.block(s.DefaultCase, "")
+}
+
+// dies reports whether the expression e calls die, which the parser reads
+// as a constant or a call when it is written without exit.
+func dies(e ast.Expr) bool {
+	var name ast.Dynamic
+	switch e := e.(type) {
+	case ast.ConstantExpr:
+		name = e.Name
+	case *ast.FunctionCallExpr:
+		name = e.FunctionName
+	default:
+		return false
+	}
+	id := ast.Static(name)
+	return id != nil && (strings.EqualFold(id.Value, "die") || strings.EqualFold(id.Value, "exit"))
+}
+
+// condition returns the value of the condition e, and whether it is the
+// constant true or false, a number or the negation of one.
+func condition(e ast.Node) (value, ok bool) {
+	switch e := e.(type) {
+	case *ast.Literal:
+		switch e.Type {
+		case ast.Boolean:
+			return strings.EqualFold(e.Value, "true"), true
+		case ast.Float, ast.Integer:
+			n, err := strconv.ParseFloat(e.Value, 64)
+			return n != 0, err == nil
+		}
+	case ast.ConstantExpr:
+		if id := ast.Static(e.Name); id != nil {
+			switch strings.ToLower(id.Value) {
+			case "true":
+				return true, true
+			case "false":
+				return false, true
+			}
+		}
+	case ast.UnaryCallExpr:
+		if e.Operator == "!" {
+			value, ok := condition(e.Operand)
+			return !value, ok
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,141 @@
+package deadcode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+func TestUnreachableCode(t *testing.T) {
+	src := `<?php
+	function f($x) {
+		if ($x) {
+			return 1;
+			echo "after return";
+		}
+		if (false) {
+			echo "never";
+		} elseif (true) {
+			echo "always";
+		} else {
+			echo "never either";
+		}
+		while (true) {
+			if ($x) {
+				break;
+			}
+		}
+		foreach ($x as $y) {
+			continue;
+			echo $y;
+		}
+		try {
+			g();
+		} catch (Exception $e) {
+			throw $e;
+		}
+		switch ($x) {
+		case 1:
+			return 1;
+		default:
+			return 2;
+		}
+		echo "after switch";
+	}
+
+	function g() {
+		while (1) {
+			h();
+		}
+		h();
+	}
+
+	function h($x) {
+		if ($x) {
+			throw new Exception();
+		} else {
+			die("no");
+		}
+		function declared() {
+			return;
+			echo "in declared";
+		}
+		echo "after if";
+	}
+
+	$f = function() {
+		exit;
+		echo "in closure";
+	};
+	exit(0);
+	echo "at the end";
+	`
+
+	p := parser.NewParser()
+	if _, err := p.Parse("test.php", src); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, u := range UnreachableCode(p.FileSet) {
+		got = append(got, u.String())
+	}
+	want := []string{
+		"test.php:5:4: unreachable code after return",
+		"test.php:8:4: unreachable code condition is always false",
+		"test.php:12:4: unreachable code earlier condition is always true",
+		"test.php:21:4: unreachable code after continue",
+		"test.php:34:3: unreachable code after code that never completes",
+		"test.php:41:3: unreachable code after infinite loop",
+		"test.php:52:4: unreachable code after return",
+		"test.php:54:3: unreachable code after code that never completes",
+		"test.php:59:3: unreachable code after exit",
+		"test.php:62:2: unreachable code after exit",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unreachable code:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestUnreachableCatch(t *testing.T) {
+	src := `<?php
+	function f($x) {
+		try {
+			$x = 1;
+			return $x;
+		} catch (Exception $e) {
+			echo "caught";
+		}
+		echo "after catch";
+		do {
+			echo $x;
+		} while (true);
+		echo "after do";
+	}
+
+	function g($x) {
+		switch ($x) {
+		case 1:
+			return 1;
+			echo "after return";
+		}
+		echo "after switch";
+	}
+	`
+
+	p := parser.NewParser()
+	if _, err := p.Parse("test.php", src); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, u := range UnreachableCode(p.FileSet) {
+		got = append(got, u.String())
+	}
+	want := []string{
+		"test.php:13:3: unreachable code after infinite loop",
+		"test.php:20:4: unreachable code after return",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unreachable code:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
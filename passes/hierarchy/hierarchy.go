@@ -0,0 +1,635 @@
+// Package hierarchy links the classes, interfaces and traits of a file set
+// into a hierarchy, which gives the ancestors and descendants of each, the
+// members each declares, inherits and overrides, and the implementations
+// of a method that a call may run.
+//
+// The classes and interfaces built into PHP, as described by package
+// builtins, are part of the hierarchy where the file set extends or
+// implements them. A trait counts as a supertype of the classes and traits
+// using it, though instanceof does not test for it.
+package hierarchy
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/builtins"
+	"github.com/stephens2424/php/passes/symbols"
+)
+
+// Kind is the kind of a type.
+type Kind int
+
+const (
+	Class Kind = iota
+	Interface
+	Trait
+)
+
+var kindNames = map[Kind]string{
+	Class:     "class",
+	Interface: "interface",
+	Trait:     "trait",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Type is a class, interface or trait.
+type Type struct {
+	// Name is the qualified name of the type, as in Foo\Bar.
+	Name string
+	Kind Kind
+
+	// Builtin reports whether the type is built into PHP, rather than
+	// declared by the file set.
+	Builtin bool
+
+	// Node is the declaration of the type, an *ast.Class or *ast.Interface,
+	// and File the path of the file declaring it, which is "" for a type
+	// built into PHP.
+	Node ast.Statement
+	File string
+
+	// Parent is the class that a class extends, Interfaces the interfaces
+	// that a class implements or an interface extends, and Traits the
+	// traits that a class or trait uses. Missing holds the names of those
+	// that are neither declared nor built into PHP.
+	Parent     *Type
+	Interfaces []*Type
+	Traits     []*Type
+	Missing    []string
+
+	// Subtypes holds the types that directly extend, implement or use the
+	// type, ordered by name.
+	Subtypes []*Type
+
+	// Cyclic reports whether the type is its own ancestor, which PHP
+	// rejects.
+	Cyclic bool
+
+	// methods, properties and constants hold the members the type declares
+	// by their keys, which are the lower case names of methods, and the
+	// names of properties, without the $, and of constants.
+	methods    map[string]*Member
+	properties map[string]*Member
+	constants  map[string]*Member
+
+	// aliases holds the methods that the rules of the trait uses of the
+	// type make available under other names, by the lower case aliases,
+	// and excluded the lower case names of the methods of each trait used
+	// that insteadof rules exclude.
+	aliases  map[string]alias
+	excluded map[*Type]map[string]bool
+}
+
+// alias is a method that a trait use makes available under another name:
+// the method key of the trait, or of any trait used if trait is nil.
+type alias struct {
+	trait *Type
+	key   string
+}
+
+func (t *Type) String() string {
+	return t.Name
+}
+
+// MemberKind is the kind of a member of a type.
+type MemberKind int
+
+const (
+	Method MemberKind = iota
+	Property
+	Constant
+)
+
+var memberKindNames = map[MemberKind]string{
+	Method:   "method",
+	Property: "property",
+	Constant: "constant",
+}
+
+func (k MemberKind) String() string {
+	return memberKindNames[k]
+}
+
+// Member is a method, property or constant of a type.
+type Member struct {
+	// Name is the name of the member, which for a property does not
+	// include the $.
+	Name string
+	Kind MemberKind
+
+	// Type is the type declaring the member, which for a member that a
+	// class has from a trait is the trait.
+	Type *Type
+
+	// Node is the declaration of the member, an *ast.Method, *ast.Property
+	// or *ast.Constant.
+	Node ast.Node
+}
+
+func (m *Member) String() string {
+	if m.Kind == Property {
+		return m.Type.Name + "::$" + m.Name
+	}
+	return m.Type.Name + "::" + m.Name
+}
+
+// Abstract reports whether m is a method without a body, as the methods
+// of interfaces are.
+func (m *Member) Abstract() bool {
+	method, ok := m.Node.(*ast.Method)
+	return ok && method.Body == nil
+}
+
+// Hierarchy is the hierarchy of the types of a file set.
+type Hierarchy struct {
+	// types holds the types by their lower case qualified names, with the
+	// built-in types added as they are first referred to.
+	types    map[string]*Type
+	declared []*Type
+	index    *symbols.Index
+	builtins *builtins.DB
+}
+
+// New returns the hierarchy of the types declared in fs. A type that is
+// declared more than once, conditionally, is the first declaration found.
+func New(fs *ast.FileSet) *Hierarchy {
+	h := &Hierarchy{
+		types:    map[string]*Type{},
+		index:    symbols.New(fs),
+		builtins: builtins.Load(),
+	}
+	for _, path := range h.index.Files() {
+		for _, o := range h.index.Occurrences(path) {
+			if !o.Definition || o.Symbol.Kind != symbols.Class {
+				continue
+			}
+			key := strings.ToLower(o.Symbol.Name)
+			if _, ok := h.types[key]; ok {
+				continue
+			}
+			t := newType(o.Symbol.Name, o.Node.(ast.Statement))
+			t.File = path
+			h.types[key] = t
+			h.declared = append(h.declared, t)
+		}
+	}
+	for _, t := range h.declared {
+		h.link(t)
+	}
+	for _, t := range h.types {
+		sort.Slice(t.Subtypes, func(i, j int) bool { return t.Subtypes[i].Name < t.Subtypes[j].Name })
+		t.Cyclic = t.Extends(t)
+	}
+	return h
+}
+
+// newType returns the type name declared by n, with the members it
+// declares.
+func newType(name string, n ast.Statement) *Type {
+	t := &Type{
+		Name:       name,
+		Node:       n,
+		methods:    map[string]*Member{},
+		properties: map[string]*Member{},
+		constants:  map[string]*Member{},
+	}
+	switch n := n.(type) {
+	case *ast.Class:
+		if n.Trait {
+			t.Kind = Trait
+		}
+		for _, m := range n.Methods {
+			t.methods[strings.ToLower(m.Name)] = &Member{Name: m.Name, Kind: Method, Type: t, Node: m}
+		}
+		for _, p := range n.Properties {
+			name := strings.TrimPrefix(p.Name, "$")
+			t.properties[name] = &Member{Name: name, Kind: Property, Type: t, Node: p}
+		}
+		for _, k := range n.Constants {
+			t.constants[k.Name] = &Member{Name: k.Name, Kind: Constant, Type: t, Node: k}
+		}
+	case *ast.Interface:
+		t.Kind = Interface
+		for i := range n.Methods {
+			m := &n.Methods[i]
+			t.methods[strings.ToLower(m.Name)] = &Member{Name: m.Name, Kind: Method, Type: t, Node: m}
+		}
+		for i := range n.Constants {
+			k := &n.Constants[i]
+			t.constants[k.Name] = &Member{Name: k.Name, Kind: Constant, Type: t, Node: k}
+		}
+	}
+	return t
+}
+
+// link links the declared type t to the types it extends, implements and
+// uses.
+func (h *Hierarchy) link(t *Type) {
+	resolve := func(name string) string {
+		return h.index.ResolveClass(t.File, name)
+	}
+	switch n := t.Node.(type) {
+	case *ast.Class:
+		if n.Extends != "" {
+			h.linkParent(t, resolve(n.Extends))
+		}
+		for _, name := range n.Implements {
+			h.linkInterface(t, resolve(name))
+		}
+		for _, name := range n.Traits {
+			if super := h.Type(resolve(name)); super != nil {
+				t.Traits = append(t.Traits, super)
+				super.Subtypes = append(super.Subtypes, t)
+			} else {
+				t.Missing = append(t.Missing, resolve(name))
+			}
+		}
+		for _, u := range n.TraitUses {
+			for _, r := range u.Rules {
+				h.linkRule(t, r, resolve)
+			}
+		}
+	case *ast.Interface:
+		for _, name := range n.Inherits {
+			h.linkInterface(t, resolve(name))
+		}
+	}
+}
+
+// linkRule records the rule r of a trait use of t, resolving the names of
+// traits with resolve.
+func (h *Hierarchy) linkRule(t *Type, r *ast.TraitRule, resolve func(string) string) {
+	var trait *Type
+	if r.Trait != "" {
+		if trait = h.Type(resolve(r.Trait)); trait == nil {
+			return
+		}
+	}
+	key := strings.ToLower(r.Method)
+	for _, name := range r.Insteadof {
+		if other := h.Type(resolve(name)); other != nil {
+			if t.excluded == nil {
+				t.excluded = map[*Type]map[string]bool{}
+			}
+			if t.excluded[other] == nil {
+				t.excluded[other] = map[string]bool{}
+			}
+			t.excluded[other][key] = true
+		}
+	}
+	if r.Alias != "" {
+		if t.aliases == nil {
+			t.aliases = map[string]alias{}
+		}
+		t.aliases[strings.ToLower(r.Alias)] = alias{trait: trait, key: key}
+	}
+}
+
+// linkParent links t to the class name that it extends.
+func (h *Hierarchy) linkParent(t *Type, name string) {
+	if super := h.Type(name); super != nil {
+		t.Parent = super
+		super.Subtypes = append(super.Subtypes, t)
+	} else {
+		t.Missing = append(t.Missing, name)
+	}
+}
+
+// linkInterface links t to the interface name that it implements or
+// extends.
+func (h *Hierarchy) linkInterface(t *Type, name string) {
+	if super := h.Type(name); super != nil {
+		t.Interfaces = append(t.Interfaces, super)
+		super.Subtypes = append(super.Subtypes, t)
+	} else {
+		t.Missing = append(t.Missing, name)
+	}
+}
+
+// Type returns the type with the qualified name, which may be built into
+// PHP, or nil if there is none.
+func (h *Hierarchy) Type(name string) *Type {
+	name = strings.TrimPrefix(name, `\`)
+	if t, ok := h.types[strings.ToLower(name)]; ok {
+		return t
+	}
+	c := h.builtins.Class(name)
+	if c == nil {
+		return nil
+	}
+	t := newType(c.Name, c.Node)
+	t.Builtin = true
+	h.types[strings.ToLower(name)] = t
+	if c.Parent != "" {
+		h.linkParent(t, c.Parent)
+	}
+	for _, name := range c.Interfaces {
+		h.linkInterface(t, name)
+	}
+	return t
+}
+
+// Resolve returns the type named name in the file path, following its
+// namespace and imports, or nil if there is none.
+func (h *Hierarchy) Resolve(path, name string) *Type {
+	return h.Type(h.index.ResolveClass(path, name))
+}
+
+// Declaration returns the type declared by n, an *ast.Class or
+// *ast.Interface of the file set, or nil if there is none.
+func (h *Hierarchy) Declaration(n ast.Statement) *Type {
+	for _, t := range h.declared {
+		if t.Node == n {
+			return t
+		}
+	}
+	return nil
+}
+
+// Types returns the types that the file set declares, ordered by name.
+func (h *Hierarchy) Types() []*Type {
+	types := append([]*Type(nil), h.declared...)
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+// Cycles returns the declared types that are their own ancestors, ordered
+// by name.
+func (h *Hierarchy) Cycles() []*Type {
+	var cyclic []*Type
+	for _, t := range h.Types() {
+		if t.Cyclic {
+			cyclic = append(cyclic, t)
+		}
+	}
+	return cyclic
+}
+
+// Supertypes returns the types that t directly extends, implements or
+// uses: its parent, then its interfaces, then its traits.
+func (t *Type) Supertypes() []*Type {
+	var supertypes []*Type
+	if t.Parent != nil {
+		supertypes = append(supertypes, t.Parent)
+	}
+	supertypes = append(supertypes, t.Interfaces...)
+	return append(supertypes, t.Traits...)
+}
+
+// Ancestors returns the types that t extends, implements or uses, directly
+// or not, nearest first. It includes t only if t is cyclic.
+func (t *Type) Ancestors() []*Type {
+	return walk(t, (*Type).Supertypes)
+}
+
+// Descendants returns the types that extend, implement or use t, directly
+// or not, nearest first. It includes t only if t is cyclic.
+func (t *Type) Descendants() []*Type {
+	return walk(t, func(t *Type) []*Type { return t.Subtypes })
+}
+
+// walk returns the types reachable from t by next, breadth first, each
+// once.
+func walk(t *Type, next func(*Type) []*Type) []*Type {
+	var found []*Type
+	seen := map[*Type]bool{}
+	queue := next(t)
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		found = append(found, u)
+		queue = append(queue, next(u)...)
+	}
+	return found
+}
+
+// Extends reports whether t extends, implements or uses super, directly or
+// not.
+func (t *Type) Extends(super *Type) bool {
+	for _, a := range t.Ancestors() {
+		if a == super {
+			return true
+		}
+	}
+	return false
+}
+
+// Method returns the method name of t, which it declares or has from a
+// trait, parent or interface, or nil if it has none. A method with a body
+// is preferred to an abstract one. The rules of the trait uses of t and
+// its ancestors are followed: a method excluded by insteadof is not found,
+// and an alias gives the method of the trait that it names, whose Name is
+// the name the trait declares.
+func (t *Type) Method(name string) *Member {
+	key := strings.ToLower(name)
+	if m := t.find(Method, key, func(m *Member) bool { return !m.Abstract() }); m != nil {
+		return m
+	}
+	return t.find(Method, key, nil)
+}
+
+// Property returns the property name, without the $, that t declares or
+// inherits, or nil if it has none.
+func (t *Type) Property(name string) *Member {
+	return t.find(Property, name, nil)
+}
+
+// Constant returns the constant name that t declares or inherits, or nil
+// if it has none.
+func (t *Type) Constant(name string) *Member {
+	return t.find(Constant, name, nil)
+}
+
+// members returns the members of the kind k that t declares, by their
+// keys.
+func (t *Type) members(k MemberKind) map[string]*Member {
+	switch k {
+	case Method:
+		return t.methods
+	case Property:
+		return t.properties
+	}
+	return t.constants
+}
+
+// find returns the first member of the kind k with key that keep accepts,
+// looking in t, then in its traits, then in its parent, then in its
+// interfaces, as PHP resolves members. A nil keep accepts every member.
+func (t *Type) find(k MemberKind, key string, keep func(*Member) bool) *Member {
+	var found *Member
+	seen := map[*Type]bool{}
+	var visit func(t *Type) bool
+	visit = func(t *Type) bool {
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		if m, ok := t.members(k)[key]; ok && (keep == nil || keep(m)) {
+			found = m
+			return true
+		}
+		if a, ok := t.aliases[key]; ok && k == Method {
+			if m := t.aliased(a, keep); m != nil {
+				found = m
+				return true
+			}
+		}
+		for _, super := range t.Traits {
+			if k == Method && t.excluded[super][key] {
+				continue
+			}
+			if visit(super) {
+				return true
+			}
+		}
+		if t.Parent != nil && visit(t.Parent) {
+			return true
+		}
+		for _, super := range t.Interfaces {
+			if visit(super) {
+				return true
+			}
+		}
+		return false
+	}
+	visit(t)
+	return found
+}
+
+// aliased returns the method that the alias a of a trait use of t names,
+// that keep accepts.
+func (t *Type) aliased(a alias, keep func(*Member) bool) *Member {
+	if a.trait != nil {
+		return a.trait.find(Method, a.key, keep)
+	}
+	for _, trait := range t.Traits {
+		if m := trait.find(Method, a.key, keep); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+// Methods returns the methods of t, which it declares or has from its
+// ancestors, including those it has under aliases, once each, ordered by
+// name, then by the names of their types.
+func (t *Type) Methods() []*Member {
+	var methods []*Member
+	seen, found := map[string]bool{}, map[*Member]bool{}
+	for _, u := range append([]*Type{t}, t.Ancestors()...) {
+		keys := make([]string, 0, len(u.methods)+len(u.aliases))
+		for key := range u.methods {
+			keys = append(keys, key)
+		}
+		for key := range u.aliases {
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if m := t.Method(key); m != nil && !found[m] {
+				found[m] = true
+				methods = append(methods, m)
+			}
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		a, b := strings.ToLower(methods[i].Name), strings.ToLower(methods[j].Name)
+		if a != b {
+			return a < b
+		}
+		return methods[i].Type.Name < methods[j].Type.Name
+	})
+	return methods
+}
+
+// DeclaredMethods returns the methods that t itself declares, ordered by
+// name.
+func (t *Type) DeclaredMethods() []*Member {
+	methods := make([]*Member, 0, len(t.methods))
+	for _, m := range t.methods {
+		methods = append(methods, m)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return strings.ToLower(methods[i].Name) < strings.ToLower(methods[j].Name)
+	})
+	return methods
+}
+
+// DeclaredConstants returns the constants that t itself declares, ordered
+// by name.
+func (t *Type) DeclaredConstants() []*Member {
+	constants := make([]*Member, 0, len(t.constants))
+	for _, k := range t.constants {
+		constants = append(constants, k)
+	}
+	sort.Slice(constants, func(i, j int) bool { return constants[i].Name < constants[j].Name })
+	return constants
+}
+
+// Overrides returns the methods name of the ancestors of t that the method
+// name of t overrides or implements, nearest first.
+func (t *Type) Overrides(name string) []*Member {
+	m := t.Method(name)
+	if m == nil {
+		return nil
+	}
+	var overridden []*Member
+	for _, a := range t.Ancestors() {
+		if o, ok := a.methods[strings.ToLower(name)]; ok && o != m {
+			overridden = append(overridden, o)
+		}
+	}
+	return overridden
+}
+
+// Overriders returns the methods name of the descendants of t that
+// override or implement the method name of t, nearest first.
+func (t *Type) Overriders(name string) []*Member {
+	var overriders []*Member
+	for _, d := range t.Descendants() {
+		if o, ok := d.methods[strings.ToLower(name)]; ok {
+			overriders = append(overriders, o)
+		}
+	}
+	return overriders
+}
+
+// Dispatch returns the implementations of the method name that calling it
+// on an object of type o may run, which are those of its class and of the
+// classes extending or implementing it, ordered by the names of their
+// types. It returns nil if the class of o is unknown.
+func (h *Hierarchy) Dispatch(o ast.ObjectType, name string) []*Member {
+	t := h.Type(o.Class)
+	if t == nil {
+		return nil
+	}
+	var implementations []*Member
+	seen := map[*Member]bool{}
+	for _, c := range append([]*Type{t}, t.Descendants()...) {
+		if c.Kind != Class {
+			continue
+		}
+		m := c.Method(name)
+		if m == nil || m.Abstract() || seen[m] {
+			continue
+		}
+		seen[m] = true
+		implementations = append(implementations, m)
+	}
+	sort.SliceStable(implementations, func(i, j int) bool {
+		return implementations[i].Type.Name < implementations[j].Type.Name
+	})
+	return implementations
+}
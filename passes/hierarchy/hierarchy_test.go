@@ -0,0 +1,261 @@
+package hierarchy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/parser"
+)
+
+func parse(t *testing.T, files map[string]string) *Hierarchy {
+	p := parser.NewParser()
+	for path, src := range files {
+		if _, err := p.Parse(path, src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return New(p.FileSet)
+}
+
+func names(types []*Type) string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = t.Name
+	}
+	return strings.Join(s, " ")
+}
+
+func members(ms []*Member) string {
+	s := make([]string, len(ms))
+	for i, m := range ms {
+		s[i] = m.String()
+	}
+	return strings.Join(s, " ")
+}
+
+const shapes = `<?php
+namespace Shapes;
+
+interface Shape {
+	const SIDES = 0;
+	function area();
+	function name();
+}
+
+trait Named {
+	public $label;
+	function name() { return static::class; }
+}
+
+abstract class Polygon implements Shape {
+	const SIDES = 3;
+	use Named;
+	abstract function sides();
+}
+
+class Square extends Polygon {
+	function area() { return 1; }
+	function sides() { return 4; }
+}
+
+class Cube extends Square {
+	function area() { return 6; }
+	function name() { return "cube"; }
+}
+
+class Circle implements Shape, \Countable {
+	use Named;
+	function area() { return 3; }
+	function count() { return 1; }
+}
+`
+
+func TestHierarchy(t *testing.T) {
+	h := parse(t, map[string]string{"shapes.php": shapes})
+
+	if got := names(h.Types()); got != `Shapes\Circle Shapes\Cube Shapes\Named Shapes\Polygon Shapes\Shape Shapes\Square` {
+		t.Errorf("types: %s", got)
+	}
+	cube := h.Type(`\shapes\CUBE`)
+	if cube == nil {
+		t.Fatal("no Cube")
+	}
+	if cube.Kind != Class || cube.File != "shapes.php" || cube.Parent.Name != `Shapes\Square` {
+		t.Errorf("Cube: %+v", cube)
+	}
+	if got := names(cube.Ancestors()); got != `Shapes\Square Shapes\Polygon Shapes\Shape Shapes\Named` {
+		t.Errorf("Cube ancestors: %s", got)
+	}
+	shape := h.Type(`Shapes\Shape`)
+	if got := names(shape.Descendants()); got != `Shapes\Circle Shapes\Polygon Shapes\Square Shapes\Cube` {
+		t.Errorf("Shape descendants: %s", got)
+	}
+	if got := names(h.Type(`Shapes\Named`).Subtypes); got != `Shapes\Circle Shapes\Polygon` {
+		t.Errorf("Named subtypes: %s", got)
+	}
+	if h.Type(`Shapes\Named`).Kind != Trait || shape.Kind != Interface {
+		t.Error("wrong kinds")
+	}
+	if !cube.Extends(shape) || shape.Extends(cube) {
+		t.Error("Cube does not extend Shape")
+	}
+
+	countable := h.Type("Countable")
+	if countable == nil || !countable.Builtin || countable.Kind != Interface {
+		t.Fatalf("Countable: %+v", countable)
+	}
+	if got := names(countable.Subtypes); got != `Shapes\Circle` {
+		t.Errorf("Countable subtypes: %s", got)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	h := parse(t, map[string]string{"shapes.php": shapes})
+	square := h.Type(`Shapes\Square`)
+	cube := h.Type(`Shapes\Cube`)
+
+	tests := []struct {
+		m    *Member
+		want string
+	}{
+		{square.Method("AREA"), `Shapes\Square::area`},
+		{square.Method("name"), `Shapes\Named::name`},
+		{square.Method("sides"), `Shapes\Square::sides`},
+		{h.Type(`Shapes\Polygon`).Method("sides"), `Shapes\Polygon::sides`},
+		{h.Type(`Shapes\Polygon`).Method("area"), `Shapes\Shape::area`},
+		{cube.Method("name"), `Shapes\Cube::name`},
+		{cube.Property("label"), `Shapes\Named::$label`},
+		{cube.Constant("SIDES"), `Shapes\Polygon::SIDES`},
+		{h.Type(`Shapes\Circle`).Constant("SIDES"), `Shapes\Shape::SIDES`},
+		{h.Type(`Shapes\Circle`).Method("count"), `Shapes\Circle::count`},
+	}
+	for _, test := range tests {
+		if test.m == nil {
+			t.Errorf("no member %s", test.want)
+			continue
+		}
+		if got := test.m.String(); got != test.want {
+			t.Errorf("got %s, want %s", got, test.want)
+		}
+	}
+	if m := cube.Method("volume"); m != nil {
+		t.Errorf("Cube::volume is %s", m)
+	}
+	if !h.Type(`Shapes\Polygon`).Method("sides").Abstract() || square.Method("sides").Abstract() {
+		t.Error("wrong abstract methods")
+	}
+
+	if got := members(cube.Methods()); got != `Shapes\Cube::area Shapes\Cube::name Shapes\Square::sides` {
+		t.Errorf("Cube methods: %s", got)
+	}
+	if got := members(cube.Overrides("area")); got != `Shapes\Square::area Shapes\Shape::area` {
+		t.Errorf("Cube::area overrides %s", got)
+	}
+	if got := members(cube.Overrides("name")); got != `Shapes\Shape::name Shapes\Named::name` {
+		t.Errorf("Cube::name overrides %s", got)
+	}
+	if got := members(h.Type(`Shapes\Shape`).Overriders("area")); got != `Shapes\Circle::area Shapes\Square::area Shapes\Cube::area` {
+		t.Errorf("Shape::area overriders: %s", got)
+	}
+}
+
+func TestTraitRules(t *testing.T) {
+	h := parse(t, map[string]string{"greet.php": `<?php
+trait Hello {
+	function hello() {}
+	function wave() {}
+}
+trait World {
+	function hello() {}
+}
+class Greeter {
+	use Hello, World {
+		Hello::hello insteadof World;
+		World::hello as protected helloWorld;
+		wave as bye;
+	}
+}
+class Loud extends Greeter {}
+`})
+	loud := h.Type("Loud")
+	tests := []struct {
+		name, want string
+	}{
+		{"hello", "Hello::hello"},
+		{"helloWorld", "World::hello"},
+		{"HELLOWORLD", "World::hello"},
+		{"bye", "Hello::wave"},
+		{"wave", "Hello::wave"},
+	}
+	for _, test := range tests {
+		m := loud.Method(test.name)
+		if m == nil {
+			t.Errorf("no method %s", test.name)
+			continue
+		}
+		if got := m.String(); got != test.want {
+			t.Errorf("Loud::%s is %s, want %s", test.name, got, test.want)
+		}
+	}
+	if got := members(loud.Methods()); got != "Hello::hello World::hello Hello::wave" {
+		t.Errorf("Loud methods: %s", got)
+	}
+	if got := members(h.Dispatch(ast.ObjectType{Class: "Greeter"}, "helloWorld")); got != "World::hello" {
+		t.Errorf("Greeter->helloWorld() runs %s", got)
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	h := parse(t, map[string]string{"shapes.php": shapes})
+	tests := []struct {
+		class, method, want string
+	}{
+		{`Shapes\Cube`, "area", `Shapes\Cube::area`},
+		{`Shapes\Square`, "area", `Shapes\Cube::area Shapes\Square::area`},
+		{`Shapes\Square`, "sides", `Shapes\Square::sides`},
+		{`Shapes\Shape`, "name", `Shapes\Cube::name Shapes\Named::name`},
+		{`Shapes\Shape`, "area", `Shapes\Circle::area Shapes\Cube::area Shapes\Square::area`},
+		{`Shapes\Polygon`, "volume", ``},
+		{`Shapes\Unknown`, "area", ``},
+		{`Countable`, "count", `Shapes\Circle::count`},
+	}
+	for _, test := range tests {
+		got := members(h.Dispatch(ast.ObjectType{Class: test.class}, test.method))
+		if got != test.want {
+			t.Errorf("%s->%s() runs %s, want %s", test.class, test.method, got, test.want)
+		}
+	}
+}
+
+func TestProblems(t *testing.T) {
+	h := parse(t, map[string]string{
+		"a.php": `<?php
+use Other\B;
+class A extends B {}
+class Orphan extends Missing implements Unknown { use NoTrait; }
+`,
+		"b.php": `<?php
+namespace Other;
+class B extends \A {}
+class C extends B {}
+`,
+	})
+	if got := names(h.Cycles()); got != `A Other\B` {
+		t.Errorf("cycles: %s", got)
+	}
+	c := h.Type(`Other\C`)
+	if c.Cyclic {
+		t.Error("C is cyclic")
+	}
+	if got := names(c.Ancestors()); got != `Other\B A` {
+		t.Errorf("C ancestors: %s", got)
+	}
+	if got := fmt.Sprint(h.Type("Orphan").Missing); got != "[Missing Unknown NoTrait]" {
+		t.Errorf("Orphan missing %s", got)
+	}
+	if m := c.Method("anything"); m != nil {
+		t.Errorf("C::anything is %s", m)
+	}
+}
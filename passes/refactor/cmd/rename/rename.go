@@ -0,0 +1,136 @@
+// Command rename renames the symbol at a position in PHP code, along with
+// every reference to it.
+//
+// Usage:
+//
+//	rename [-w] file:line:column name [path ...]
+//
+// The paths may be files or directories, which are searched recursively for
+// PHP files, and default to the current directory. Without -w, the changes
+// are printed as a unified diff rather than written. References that could
+// not be resolved, and so were left unchanged, are printed to standard error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stephens2424/php/edit"
+	"github.com/stephens2424/php/parser"
+	"github.com/stephens2424/php/passes/refactor"
+)
+
+func main() {
+	write := flag.Bool("w", false, "write the changes to the files instead of printing a diff")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] file:line:column name [path ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	file, line, column, err := parsePosition(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	paths := flag.Args()[2:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	p := parser.NewParser()
+	sources := map[string]string{}
+	failed := false
+	parse := func(path string) {
+		src, err := ioutil.ReadFile(path)
+		if err == nil {
+			sources[path] = string(src)
+			_, err = p.Parse(path, string(src))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.HasSuffix(path, ".php") && filepath.Clean(path) != file {
+				parse(filepath.Clean(path))
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	parse(file)
+
+	project := refactor.NewProject(p.FileSet, sources)
+	change, err := project.Rename(file, line, column, flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	changed, err := project.Apply(change)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	files := make([]string, 0, len(changed))
+	for path := range changed {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	for _, path := range files {
+		if *write {
+			info, err := os.Stat(path)
+			if err == nil {
+				err = ioutil.WriteFile(path, []byte(changed[path]), info.Mode())
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+			}
+			continue
+		}
+		fmt.Print(edit.Diff(filepath.ToSlash(path), sources[path], changed[path]))
+	}
+	for _, ref := range change.Unresolved {
+		fmt.Fprintf(os.Stderr, "%s (unchanged)\n", ref)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// parsePosition parses a position written as file:line:column.
+func parsePosition(s string) (file string, line, column int, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 3 {
+		return "", 0, 0, fmt.Errorf("invalid position %q, expected file:line:column", s)
+	}
+	n := len(parts)
+	if line, err = strconv.Atoi(parts[n-2]); err == nil {
+		column, err = strconv.Atoi(parts[n-1])
+	}
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid position %q, expected file:line:column", s)
+	}
+	return filepath.Clean(strings.Join(parts[:n-2], ":")), line, column, nil
+}
@@ -0,0 +1,489 @@
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/edit"
+	"github.com/stephens2424/php/passes/symbols"
+)
+
+// ExtractFunction returns the change moving the statements of file between
+// the byte offsets begin and end into a new function called name, which is
+// called in their place. Statements of a method are moved into a new
+// private method of its class.
+//
+// The variables that the statements use, and that are set before them,
+// become the parameters of the function. Those that the statements set, and
+// that are used after them, are returned, in an array if there are several.
+// Passing a variable by reference to a function sets it. The arguments of
+// the function passed by reference that the statements set are passed on
+// by reference, for the caller to see the changes.
+// Statements that return or break out of the selection, declare static or
+// global variables, or use variable variables cannot be extracted.
+func (p *Project) ExtractFunction(file string, begin, end int, name string) (*Change, error) {
+	f, ok := p.Files.Files[file]
+	src, hasSource := p.Sources[file]
+	if !ok || !hasSource {
+		return nil, fmt.Errorf("unknown file %s", file)
+	}
+	if !identifier.MatchString(name) {
+		return nil, fmt.Errorf("invalid name %q", name)
+	}
+
+	fn := enclosingFunction(f, begin, end, p.byRef(file))
+	if _, ok := fn.node.(*ast.AnonymousFunction); ok {
+		return nil, errors.New("cannot extract statements of a closure")
+	}
+	stmts, spans := selectStatements(src, fn, begin, end)
+	if len(stmts) == 0 {
+		return nil, errors.New("the selection does not contain whole statements")
+	}
+	selection := ast.Span{Begin: spans[0].Begin, End: spans[len(spans)-1].End}
+	if err := checkExtractable(stmts); err != nil {
+		return nil, err
+	}
+	if fn.dynamic(selection.Begin.Position, selection.End.Position) {
+		return nil, errors.New("cannot extract statements using variable variables")
+	}
+
+	x := &extraction{Project: p, fn: fn, src: src, name: name, selection: selection}
+	if err := x.checkName(file); err != nil {
+		return nil, err
+	}
+	x.variables(stmts, spans)
+	if x.this && fn.class == nil {
+		return nil, errors.New("cannot extract statements using $this outside of a method")
+	}
+
+	c := newChange()
+	c.add(file, edit.Edit{Begin: selection.Begin.Position, End: selection.End.Position, Text: x.call()})
+	at, decl := x.declaration()
+	c.add(file, edit.Edit{Begin: at, End: at, Text: decl})
+	c.sort()
+	return c, nil
+}
+
+// selectStatements returns the statements of fn between the offsets begin
+// and end of src, with their spans, which must be whole statements of one
+// list, with only whitespace and comments around them. The outermost list
+// of statements with such statements is chosen.
+func selectStatements(src string, fn *function, begin, end int) ([]ast.Node, []ast.Span) {
+Lists:
+	for _, list := range fn.statementLists() {
+		var stmts []ast.Node
+		var spans []ast.Span
+		for i, span := range statementSpans(src, fn.file, list.after, list.stmts) {
+			if span.End.Position <= begin || span.Begin.Position >= end {
+				continue
+			}
+			if span.Begin.Position < begin || span.End.Position > end {
+				continue Lists
+			}
+			stmts = append(stmts, list.stmts[i])
+			spans = append(spans, span)
+		}
+		if len(stmts) == 0 {
+			continue
+		}
+		first, last := spans[0], spans[len(spans)-1]
+		if !blank(src, fn.file, begin, first.Begin.Position) || !blank(src, fn.file, last.End.Position, end) {
+			return nil, nil
+		}
+		return stmts, spans
+	}
+	return nil, nil
+}
+
+// blank reports whether src between the offsets begin and end holds only
+// whitespace and comments of f.
+func blank(src string, f *ast.File, begin, end int) bool {
+	for _, c := range f.Comments {
+		if c.Span.End.Position <= begin || c.Span.Begin.Position >= end {
+			continue
+		}
+		if c.Span.Begin.Position > begin && strings.TrimSpace(src[begin:c.Span.Begin.Position]) != "" {
+			return false
+		}
+		begin = c.Span.End.Position
+	}
+	return begin >= end || strings.TrimSpace(src[begin:end]) == ""
+}
+
+// checkExtractable returns an error if stmts cannot be moved into another
+// function.
+func checkExtractable(stmts []ast.Node) error {
+	var err error
+	var walk func(n ast.Node, loops int)
+	walk = func(n ast.Node, loops int) {
+		if ast.IsNil(n) || err != nil {
+			return
+		}
+		switch n.(type) {
+		case ast.ReturnStmt, *ast.ReturnStmt:
+			err = errors.New("cannot extract a return statement")
+		case ast.BreakStmt, *ast.BreakStmt, ast.ContinueStmt, *ast.ContinueStmt:
+			if loops == 0 {
+				err = errors.New("cannot extract a break or continue statement out of its loop")
+			}
+		case *ast.StaticVariableDeclaration:
+			err = errors.New("cannot extract a static variable declaration")
+		case *ast.GlobalDeclaration:
+			err = errors.New("cannot extract a global declaration")
+		case *ast.FunctionStmt, *ast.Class, *ast.Interface:
+			err = errors.New("cannot extract the declaration of a function or class")
+		case *ast.AnonymousFunction:
+			return
+		case *ast.ForStmt, *ast.WhileStmt, *ast.DoWhileStmt, *ast.ForeachStmt, *ast.SwitchStmt:
+			loops++
+		}
+		for _, child := range n.Children() {
+			walk(child, loops)
+		}
+	}
+	for _, stmt := range stmts {
+		walk(stmt, 0)
+	}
+	return err
+}
+
+// extraction is the extraction of statements into a function.
+type extraction struct {
+	*Project
+	fn        *function
+	src       string
+	name      string
+	selection ast.Span
+
+	// params and results are the names of the variables passed to and
+	// returned from the function, refs holds the parameters passed by
+	// reference, and this is set if it uses $this.
+	params, results []string
+	refs            map[string]bool
+	this            bool
+}
+
+// checkName returns an error wrapping ErrNameTaken if the new function's
+// name is taken.
+func (x *extraction) checkName(file string) error {
+	if x.fn.class != nil {
+		for _, m := range x.fn.class.Methods {
+			if strings.EqualFold(m.Name, x.name) {
+				return fmt.Errorf("method %s %w", x.name, ErrNameTaken)
+			}
+		}
+		class := x.fn.class.Name
+		if ns := x.Index.Namespace(file); ns != "" {
+			class = ns + `\` + class
+		}
+		if len(x.Index.Definitions(symbols.Symbol{Kind: symbols.Method, Name: x.name, Class: class})) > 0 {
+			return fmt.Errorf("method %s %w in a parent class", x.name, ErrNameTaken)
+		}
+		return nil
+	}
+	qualified := x.name
+	if ns := x.Index.Namespace(file); ns != "" {
+		qualified = ns + `\` + x.name
+	}
+	if len(x.Index.Definitions(symbols.Symbol{Kind: symbols.Function, Name: qualified})) > 0 {
+		return fmt.Errorf("function %s %w", qualified, ErrNameTaken)
+	}
+	return nil
+}
+
+// variables finds the parameters and results of the function extracted
+// from stmts, whose spans are spans.
+func (x *extraction) variables(stmts []ast.Node, spans []ast.Span) {
+	begin, end := x.selection.Begin.Position, x.selection.End.Position
+
+	// the variables assigned by statements of the selection, which need
+	// not be passed in if they are not used before
+	assigned := map[*ast.Variable]ast.Span{}
+	for i, stmt := range stmts {
+		if s, ok := stmt.(*ast.ExprStmt); ok {
+			if a, ok := s.Expr.(ast.AssignmentExpr); ok && a.Operator == "=" {
+				if v, ok := a.Assignee.(*ast.Variable); ok {
+					assigned[v] = spans[i]
+				}
+			}
+		}
+	}
+
+	// a loop enclosing the selection may run statements before it again
+	// after it
+	loop := x.loop()
+
+	var names []string
+	seen := map[string]bool{}
+	var all []use
+	for _, u := range x.fn.uses {
+		all = append(all, u)
+	}
+	all = append(all, x.fn.other...)
+	sortUses(all)
+	for _, u := range all {
+		if u.span.Begin.Position >= begin && u.span.End.Position <= end && !seen[u.name] {
+			seen[u.name] = true
+			names = append(names, u.name)
+		}
+	}
+	x.this = seen["this"] || usesThis(stmts)
+
+	for _, name := range names {
+		if name == "this" || ast.SuperGlobals[name] {
+			continue
+		}
+		var before, within, after []use
+		for _, u := range x.fn.references(name) {
+			switch {
+			case u.span.End.Position <= begin:
+				before = append(before, u)
+				if loop != nil && u.span.Begin.Position >= loop.Begin.Position {
+					after = append(after, u)
+				}
+			case u.span.Begin.Position >= end:
+				after = append(after, u)
+			default:
+				within = append(within, u)
+			}
+		}
+
+		if len(within) == 0 {
+			continue
+		}
+		definedFirst := false
+		if span, ok := assigned[within[0].v]; ok {
+			definedFirst = true
+			for _, u := range within[1:] {
+				if u.span.End.Position <= span.End.Position {
+					definedFirst = false
+				}
+			}
+		}
+		written := false
+		for _, u := range within {
+			if u.access&write != 0 {
+				written = true
+			}
+		}
+		switch {
+		case written && x.fn.isReference(name):
+			if x.refs == nil {
+				x.refs = map[string]bool{}
+			}
+			x.refs[name] = true
+			x.params = append(x.params, name)
+			continue
+		case (len(before) > 0 || x.fn.isArgument(name)) && !definedFirst:
+			x.params = append(x.params, name)
+		}
+		if written && len(after) > 0 && (loop != nil || after[0].access != write) {
+			x.results = append(x.results, name)
+		}
+	}
+}
+
+// loop returns the span of the outermost loop of the function enclosing
+// the selection, or nil.
+func (x *extraction) loop() *ast.Span {
+	var found *ast.Span
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		if ast.IsNil(n) || found != nil {
+			return
+		}
+		switch n.(type) {
+		case *ast.FunctionStmt, *ast.AnonymousFunction, *ast.Class, *ast.Interface:
+			return
+		case *ast.ForStmt, *ast.WhileStmt, *ast.DoWhileStmt, *ast.ForeachStmt:
+			span, ok := x.fn.file.Positions.Span(n)
+			if ok && span.Begin.Position <= x.selection.Begin.Position && span.End.Position >= x.selection.End.Position {
+				found = &span
+				return
+			}
+		}
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	for _, n := range x.fn.body.stmts {
+		walk(n)
+	}
+	return found
+}
+
+// usesThis reports whether the closures of stmts use $this.
+func usesThis(stmts []ast.Node) bool {
+	found := false
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		if ast.IsNil(n) || found {
+			return
+		}
+		if v, ok := n.(*ast.Variable); ok {
+			if name := ast.Static(v.Name); name != nil && name.Value == "this" {
+				found = true
+			}
+		}
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	for _, stmt := range stmts {
+		walk(stmt)
+	}
+	return found
+}
+
+// staticModifier matches the static modifier of a method.
+var staticModifier = regexp.MustCompile(`(?i)\bstatic\b`)
+
+// isStatic reports whether the function is a static method.
+func (x *extraction) isStatic() bool {
+	m, ok := x.fn.node.(*ast.Method)
+	if !ok || m.Body == nil {
+		return false
+	}
+	header := x.src[x.fn.span.Begin.Position:]
+	if i := strings.Index(strings.ToLower(header), "function"); i >= 0 {
+		header = header[:i]
+	}
+	return staticModifier.MatchString(header)
+}
+
+// call returns the statement calling the function.
+func (x *extraction) call() string {
+	call := x.name + "(" + variableList(x.params) + ")"
+	switch {
+	case x.fn.class != nil && x.isStatic():
+		call = "self::" + call
+	case x.fn.class != nil:
+		call = "$this->" + call
+	}
+	switch len(x.results) {
+	case 0:
+		return call + ";"
+	case 1:
+		return "$" + x.results[0] + " = " + call + ";"
+	}
+	return "list(" + variableList(x.results) + ") = " + call + ";"
+}
+
+// variableList returns the variables names separated by commas.
+func variableList(names []string) string {
+	vars := make([]string, len(names))
+	for i, name := range names {
+		vars[i] = "$" + name
+	}
+	return strings.Join(vars, ", ")
+}
+
+// declaration returns the declaration of the function, and the offset to
+// insert it at, which is after the function the statements are extracted
+// from.
+func (x *extraction) declaration() (int, string) {
+	at := x.fn.span.End.Position
+	if x.fn.node == nil {
+		// after the last statement of the PHP code containing the selection
+		nodes := x.fn.file.Nodes
+		for i, span := range statementSpans(x.src, x.fn.file, 0, nodes) {
+			if _, html := nodes[i].(*ast.InlineHTML); !html {
+				at = span.End.Position
+			} else if span.Begin.Position >= x.selection.End.Position {
+				break
+			}
+		}
+	}
+	indent := lineIndent(x.src, x.fn.span.Begin.Position)
+	if x.fn.node == nil {
+		indent = ""
+	}
+	inner := indent + indentUnit(x.src)
+
+	params := make([]string, len(x.params))
+	for i, name := range x.params {
+		params[i] = "$" + name
+		if x.refs[name] {
+			params[i] = "&" + params[i]
+		}
+	}
+	header := "function " + x.name + "(" + strings.Join(params, ", ") + ")"
+	switch {
+	case x.fn.class != nil && x.isStatic():
+		header = "private static " + header
+	case x.fn.class != nil:
+		header = "private " + header
+	}
+
+	// the brace opening the body is placed as in the function the
+	// statements are extracted from
+	brace := "\n" + indent + "{"
+	if def := x.fn.definition(); def != nil {
+		if m, ok := x.fn.node.(*ast.Method); ok && m.Body != nil {
+			if span, ok := x.fn.file.Positions[m.Body]; ok && !startsLine(x.src, span.Begin.Position) {
+				brace = " {"
+			}
+		}
+		if f, ok := x.fn.node.(*ast.FunctionStmt); ok && f.Body != nil {
+			if span, ok := x.fn.file.Positions[f.Body]; ok && !startsLine(x.src, span.Begin.Position) {
+				brace = " {"
+			}
+		}
+	}
+
+	var body []string
+	first := x.selection.Begin.Position
+	for i, line := range strings.Split(x.src[first:x.selection.End.Position], "\n") {
+		if i > 0 {
+			line = strings.TrimPrefix(line, lineIndent(x.src, first))
+		}
+		if strings.TrimSpace(line) == "" {
+			body = append(body, "")
+			continue
+		}
+		body = append(body, inner+line)
+	}
+	switch len(x.results) {
+	case 0:
+	case 1:
+		body = append(body, inner+"return $"+x.results[0]+";")
+	default:
+		body = append(body, inner+"return array("+variableList(x.results)+");")
+	}
+
+	return at, "\n\n" + indent + header + brace + "\n" + strings.Join(body, "\n") + "\n" + indent + "}"
+}
+
+// lineIndent returns the whitespace beginning the line of src containing
+// offset, up to offset.
+func lineIndent(src string, offset int) string {
+	start := strings.LastIndex(src[:offset], "\n") + 1
+	line := src[start:offset]
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// startsLine reports whether only whitespace precedes offset on its line.
+func startsLine(src string, offset int) bool {
+	start := strings.LastIndex(src[:offset], "\n") + 1
+	return strings.TrimSpace(src[start:offset]) == ""
+}
+
+// indentUnit returns the unit of indentation of src, which is a tab unless
+// src is indented with spaces.
+func indentUnit(src string) string {
+	for _, line := range strings.Split(src, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			return "\t"
+		case strings.HasPrefix(line, " "):
+			n := len(line) - len(strings.TrimLeft(line, " "))
+			if n >= 2 {
+				return strings.Repeat(" ", n)
+			}
+		}
+	}
+	return "\t"
+}
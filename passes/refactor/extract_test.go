@@ -0,0 +1,294 @@
+package refactor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// selection returns the offsets of the first occurrence of s in src.
+func selection(src, s string) (begin, end int) {
+	begin = strings.Index(src, s)
+	return begin, begin + len(s)
+}
+
+func TestExtractFunction(t *testing.T) {
+	src := `<?php
+function report($items, $tax) {
+	$total = 0;
+	foreach ($items as $item) {
+		$total += $item->price;
+	}
+	$total = $total * (1 + $tax);
+	$count = count($items);
+	echo "$count items";
+	return $total;
+}
+`
+	p := newTestProject(t, map[string]string{"report.php": src})
+	begin, end := selection(src, `$total = 0;
+	foreach ($items as $item) {
+		$total += $item->price;
+	}
+	$total = $total * (1 + $tax);
+	$count = count($items);`)
+	change, err := p.ExtractFunction("report.php", begin, end, "sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := p.Apply(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?php
+function report($items, $tax) {
+	list($total, $count) = sum($items, $tax);
+	echo "$count items";
+	return $total;
+}
+
+function sum($items, $tax) {
+	$total = 0;
+	foreach ($items as $item) {
+		$total += $item->price;
+	}
+	$total = $total * (1 + $tax);
+	$count = count($items);
+	return array($total, $count);
+}
+`
+	if changed["report.php"] != expected {
+		t.Errorf("extracted to\n%s\nexpected\n%s", changed["report.php"], expected)
+	}
+}
+
+func TestExtractMethod(t *testing.T) {
+	src := `<?php
+class Cart {
+    private $items = array();
+
+    public function add($item, $quantity)
+    {
+        if ($quantity < 1) {
+            throw new Exception("invalid quantity");
+        }
+        $this->items[] = array($item, $quantity);
+    }
+}
+`
+	p := newTestProject(t, map[string]string{"cart.php": src})
+	begin, end := selection(src, `if ($quantity < 1) {
+            throw new Exception("invalid quantity");
+        }`)
+	change, err := p.ExtractFunction("cart.php", begin, end, "check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := p.Apply(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?php
+class Cart {
+    private $items = array();
+
+    public function add($item, $quantity)
+    {
+        $this->check($quantity);
+        $this->items[] = array($item, $quantity);
+    }
+
+    private function check($quantity)
+    {
+        if ($quantity < 1) {
+            throw new Exception("invalid quantity");
+        }
+    }
+}
+`
+	if changed["cart.php"] != expected {
+		t.Errorf("extracted to\n%s\nexpected\n%s", changed["cart.php"], expected)
+	}
+}
+
+func TestExtractReferences(t *testing.T) {
+	tests := []struct {
+		src, selection, expected string
+	}{
+		{
+			`<?php
+function sorted($a) {
+	sort($a);
+	return $a;
+}
+`,
+			"sort($a);",
+			`<?php
+function sorted($a) {
+	$a = ext($a);
+	return $a;
+}
+
+function ext($a) {
+	sort($a);
+	return $a;
+}
+`,
+		},
+		{
+			`<?php
+function push(&$a) {
+	$a[] = 1;
+}
+`,
+			"$a[] = 1;",
+			`<?php
+function push(&$a) {
+	ext($a);
+}
+
+function ext(&$a) {
+	$a[] = 1;
+}
+`,
+		},
+		{
+			`<?php
+function swap(&$a, &$b) {
+	$t = $a;
+	exchange($a, $b, $t);
+}
+function exchange(&$a, &$b, $t) {
+	$a = $b;
+	$b = $t;
+}
+`,
+			"exchange($a, $b, $t);",
+			`<?php
+function swap(&$a, &$b) {
+	$t = $a;
+	ext($a, $b, $t);
+}
+
+function ext(&$a, &$b, $t) {
+	exchange($a, $b, $t);
+}
+function exchange(&$a, &$b, $t) {
+	$a = $b;
+	$b = $t;
+}
+`,
+		},
+		{
+			`<?php
+function count_up($x) {
+	$x++;
+	return $x;
+}
+`,
+			"$x++;",
+			`<?php
+function count_up($x) {
+	$x = ext($x);
+	return $x;
+}
+
+function ext($x) {
+	$x++;
+	return $x;
+}
+`,
+		},
+	}
+	for _, test := range tests {
+		p := newTestProject(t, map[string]string{"f.php": test.src})
+		begin, end := selection(test.src, test.selection)
+		change, err := p.ExtractFunction("f.php", begin, end, "ext")
+		if err != nil {
+			t.Errorf("extracting %q: %s", test.selection, err)
+			continue
+		}
+		changed, err := p.Apply(change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed["f.php"] != test.expected {
+			t.Errorf("extracted to\n%s\nexpected\n%s", changed["f.php"], test.expected)
+		}
+	}
+}
+
+func TestExtractFunctionErrors(t *testing.T) {
+	src := `<?php
+function f($a) {
+	$b = $a + 1;
+	if ($b > 2) {
+		return $b;
+	}
+	$$a = 1;
+	foreach ($a as $x) {
+		break;
+	}
+}
+`
+	p := newTestProject(t, map[string]string{"f.php": src})
+	tests := []struct {
+		selection, name, err string
+	}{
+		{"$b = $a + 1;", "f", "already exists"},
+		{"$b = $a + 1;", "1b", "invalid name"},
+		{"b = $a", "g", "whole statements"},
+		{"if ($b > 2) {\n\t\treturn $b;\n\t}", "g", "return"},
+		{"$$a = 1;", "g", "variable variables"},
+		{"break;", "g", "break"},
+	}
+	for _, test := range tests {
+		begin, end := selection(src, test.selection)
+		_, err := p.ExtractFunction("f.php", begin, end, test.name)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("extracting %q: expected an error containing %q, got %v", test.selection, test.err, err)
+		}
+	}
+
+	begin, end := selection(src, "$b = $a + 1;")
+	if _, err := p.ExtractFunction("f.php", begin, end, "f"); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("extracting to a taken name: expected ErrNameTaken, got %v", err)
+	}
+}
+
+func TestExtractFunctionComments(t *testing.T) {
+	src := `<?php
+function f($a) {
+	// add one
+	$b = $a + 1;
+	echo $b; /* printed */
+	return $b;
+}
+`
+	p := newTestProject(t, map[string]string{"f.php": src})
+	begin, end := selection(src, "// add one\n\t$b = $a + 1;\n\techo $b; /* printed */")
+	change, err := p.ExtractFunction("f.php", begin, end, "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := p.Apply(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?php
+function f($a) {
+	// add one
+	$b = g($a); /* printed */
+	return $b;
+}
+
+function g($a) {
+	$b = $a + 1;
+	echo $b;
+	return $b;
+}
+`
+	if changed["f.php"] != expected {
+		t.Errorf("extracted to\n%s\nexpected\n%s", changed["f.php"], expected)
+	}
+}
@@ -0,0 +1,382 @@
+package refactor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/edit"
+)
+
+// InlineVariable returns the change replacing the uses of the local variable
+// at the given line and column of file with the value assigned to it, and
+// removing the assignment.
+//
+// The variable must be assigned once, by an assignment statement, and not
+// be an argument. A value with side effects, such as a call, may only be
+// inlined into a single use outside of any loop, and the variables of the
+// value must not change between the assignment and the uses. Nor may code
+// with side effects run between them if the value has side effects, or
+// reads properties or array elements.
+func (p *Project) InlineVariable(file string, line, column int) (*Change, error) {
+	f, ok := p.Files.Files[file]
+	src, hasSource := p.Sources[file]
+	if !ok || !hasSource {
+		return nil, fmt.Errorf("unknown file %s", file)
+	}
+	offset := byteOffset(src, line, column)
+	var v *ast.Variable
+	for n, span := range f.Positions {
+		if candidate, ok := n.(*ast.Variable); ok && span.Begin.Position <= offset && offset < span.End.Position {
+			v = candidate
+		}
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%s:%d:%d: no variable to inline", file, line, column)
+	}
+
+	fn := enclosingFunction(f, offset, offset, p.byRef(file))
+	u, ok := fn.uses[v]
+	switch {
+	case ast.Static(v.Name) != nil && fn.isArgument(ast.Static(v.Name).Value):
+		return nil, fmt.Errorf("cannot inline the argument %s", v)
+	case !ok:
+		return nil, fmt.Errorf("%s:%d:%d: %s is not a local variable", file, line, column, v)
+	case u.name == "this" || ast.SuperGlobals[u.name]:
+		return nil, fmt.Errorf("cannot inline %s", v)
+	}
+
+	in := &inlining{fn: fn, src: src, name: u.name}
+	if err := in.find(); err != nil {
+		return nil, err
+	}
+	if err := in.check(); err != nil {
+		return nil, err
+	}
+
+	c := newChange()
+	c.add(file, in.removal())
+	for _, r := range in.reads {
+		c.add(file, edit.Edit{Begin: r.span.Begin.Position, End: r.span.End.Position, Text: in.replacement()})
+	}
+	c.sort()
+	return c, nil
+}
+
+// byteOffset returns the offset in src of the line and column, which count
+// from 1.
+func byteOffset(src string, line, column int) int {
+	offset := 0
+	for ; line > 1; line-- {
+		i := strings.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return len(src)
+		}
+		offset += i + 1
+	}
+	if offset+column-1 > len(src) {
+		return len(src)
+	}
+	return offset + column - 1
+}
+
+// inlining is the inlining of a variable.
+type inlining struct {
+	fn   *function
+	src  string
+	name string
+
+	// assignment is the statement assigning the variable, whose span is
+	// span, and value is the value assigned.
+	assignment ast.AssignmentExpr
+	span       ast.Span
+	value      string
+
+	reads []use
+}
+
+// find finds the assignment and uses of the variable.
+func (in *inlining) find() error {
+	var assignee *ast.Variable
+	for _, u := range in.fn.references(in.name) {
+		switch {
+		case u.captured:
+			return fmt.Errorf("cannot inline $%s, which a closure uses", in.name)
+		case u.interpolated:
+			return fmt.Errorf("cannot inline $%s, which is interpolated in a string", in.name)
+		case u.checked:
+			return fmt.Errorf("cannot inline $%s, which is passed to isset, unset or empty", in.name)
+		case u.access&write != 0 && assignee != nil:
+			return fmt.Errorf("cannot inline $%s, which is assigned more than once", in.name)
+		case u.access&write != 0:
+			assignee = u.v
+		default:
+			in.reads = append(in.reads, u)
+		}
+	}
+	if assignee == nil {
+		return fmt.Errorf("cannot inline $%s, which is never assigned", in.name)
+	}
+
+	for _, list := range in.fn.statementLists() {
+		for i, stmt := range list.stmts {
+			s, ok := stmt.(*ast.ExprStmt)
+			if !ok {
+				continue
+			}
+			if a, ok := s.Expr.(ast.AssignmentExpr); ok && a.Operator == "=" && a.Assignee == ast.Assignable(assignee) {
+				in.assignment = a
+				in.span = statementSpans(in.src, in.fn.file, list.after, list.stmts)[i]
+			}
+		}
+	}
+	if in.assignment.Assignee == nil {
+		return fmt.Errorf("cannot inline $%s, which is not assigned by an assignment statement", in.name)
+	}
+
+	// the value is the source between the = and the end of the statement,
+	// as the recorded spans of expressions may omit their parentheses
+	assigneeSpan, _ := in.fn.file.Positions.Span(in.assignment.Assignee)
+	value := strings.TrimSpace(in.src[assigneeSpan.End.Position:in.span.End.Position])
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "="), ";")
+	in.value = strings.TrimSpace(value)
+	return nil
+}
+
+// check returns an error if inlining would change what the program does.
+func (in *inlining) check() error {
+	if len(in.reads) == 0 {
+		return fmt.Errorf("cannot inline $%s, which is never used", in.name)
+	}
+
+	// the value is evaluated where the variable is used, which must be
+	// after the assignment, and if in a loop that does not contain the
+	// assignment, is evaluated again on each iteration
+	end := in.span.End.Position
+	repeated := len(in.reads) > 1
+	loops := in.loops()
+	for _, r := range in.reads {
+		if r.span.Begin.Position < in.span.End.Position {
+			return fmt.Errorf("cannot inline $%s, which is used before it is assigned", in.name)
+		}
+		if r.span.End.Position > end {
+			end = r.span.End.Position
+		}
+		for _, loop := range loops {
+			if loop.Begin.Position <= r.span.Begin.Position && r.span.End.Position <= loop.End.Position &&
+				(in.span.Begin.Position < loop.Begin.Position || in.span.End.Position > loop.End.Position) {
+				repeated = true
+				if loop.End.Position > end {
+					end = loop.End.Position
+				}
+			}
+		}
+	}
+	effects := hasSideEffects(in.assignment.Value)
+	if repeated && effects {
+		return fmt.Errorf("cannot inline $%s, whose value has side effects and would be evaluated more than once", in.name)
+	}
+	if effects || readsState(in.assignment.Value) {
+		if span, ok := in.effectBetween(in.span.End.Position, end); ok {
+			return fmt.Errorf("cannot inline $%s, as the code at %d:%d, between the assignment and a use, may have side effects", in.name, span.Begin.Line, span.Begin.Column)
+		}
+	}
+
+	for _, name := range variablesOf(in.assignment.Value) {
+		for _, u := range in.fn.references(name) {
+			if u.access&write != 0 && u.span.Begin.Position >= in.span.End.Position && u.span.Begin.Position < end {
+				return fmt.Errorf("cannot inline $%s, as $%s changes before it is used", in.name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// loops returns the spans of the loops of the function.
+func (in *inlining) loops() []ast.Span {
+	var loops []ast.Span
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		if ast.IsNil(n) {
+			return
+		}
+		switch n.(type) {
+		case *ast.FunctionStmt, *ast.AnonymousFunction, *ast.Class, *ast.Interface:
+			return
+		case *ast.ForStmt, *ast.WhileStmt, *ast.DoWhileStmt, *ast.ForeachStmt:
+			if span, ok := in.fn.file.Positions.Span(n); ok {
+				loops = append(loops, span)
+			}
+		}
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	for _, n := range in.fn.body.stmts {
+		walk(n)
+	}
+	return loops
+}
+
+// effectBetween returns the span of a node of the function that may have
+// side effects, which lies between the offsets begin and end, outside of
+// the uses of the variable, and whether there is one. Code containing a
+// use runs after the value that replaces it.
+func (in *inlining) effectBetween(begin, end int) (ast.Span, bool) {
+	var found ast.Span
+	var walk func(n ast.Node) bool
+	walk = func(n ast.Node) bool {
+		if ast.IsNil(n) {
+			return false
+		}
+		switch n.(type) {
+		case *ast.FunctionStmt, *ast.AnonymousFunction, *ast.Class, *ast.Interface:
+			return false
+		}
+		if span, ok := in.fn.file.Positions.Span(n); ok {
+			if span.End.Position <= begin || span.Begin.Position >= end {
+				return false
+			}
+			if sideEffect(n) && span.Begin.Position >= begin && !in.containsRead(span) {
+				found = span
+				return true
+			}
+		}
+		for _, child := range n.Children() {
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, n := range in.fn.body.stmts {
+		if walk(n) {
+			return found, true
+		}
+	}
+	return ast.Span{}, false
+}
+
+// containsRead reports whether span contains a use of the variable.
+func (in *inlining) containsRead(span ast.Span) bool {
+	for _, r := range in.reads {
+		if span.Begin.Position <= r.span.Begin.Position && r.span.End.Position <= span.End.Position {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSideEffects reports whether evaluating n may change the state of the
+// program, or give a different result each time.
+func hasSideEffects(n ast.Node) bool {
+	if ast.IsNil(n) {
+		return false
+	}
+	if sideEffect(n) {
+		return true
+	}
+	if _, ok := n.(*ast.AnonymousFunction); ok {
+		return false
+	}
+	for _, child := range n.Children() {
+		if hasSideEffects(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// sideEffect reports whether n itself, apart from its children, may change
+// the state of the program, or give a different result each time.
+func sideEffect(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.FunctionCallExpr, *ast.MethodCallExpr, *ast.NewCallExpr, ast.AssignmentExpr, *ast.AssignmentExpr,
+		*ast.ListStatement, ast.Include, *ast.Include, ast.ShellCommand, *ast.ShellCommand, ast.ExitStmt, *ast.ExitStmt, *ast.YieldExpr:
+		return true
+	case ast.UnaryCallExpr:
+		return n.Operator == "++" || n.Operator == "--"
+	case *ast.ClassExpr:
+		_, ok := n.Expr.(*ast.FunctionCallExpr)
+		return ok
+	}
+	return false
+}
+
+// readsState reports whether n reads a property, a static property or an
+// element of an array, which code may change without assigning a
+// variable.
+func readsState(n ast.Node) bool {
+	if ast.IsNil(n) {
+		return false
+	}
+	switch n := n.(type) {
+	case *ast.PropertyCallExpr, *ast.ArrayLookupExpr:
+		return true
+	case *ast.ClassExpr:
+		if _, ok := n.Expr.(*ast.Variable); ok {
+			return true
+		}
+	case *ast.AnonymousFunction:
+		return false
+	}
+	for _, child := range n.Children() {
+		if readsState(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// variablesOf returns the names of the variables that n reads.
+func variablesOf(n ast.Node) []string {
+	var names []string
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		if ast.IsNil(n) {
+			return
+		}
+		switch n := n.(type) {
+		case *ast.Variable:
+			if name := ast.Static(n.Name); name != nil {
+				names = append(names, name.Value)
+			}
+		case *ast.AnonymousFunction:
+			for _, v := range n.ClosureVariables {
+				walk(v)
+			}
+			return
+		}
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	walk(n)
+	return names
+}
+
+// removal returns the edit removing the assignment, with its line if
+// nothing else is on it.
+func (in *inlining) removal() edit.Edit {
+	begin, end := in.span.Begin.Position, in.span.End.Position
+	lineEnd := len(in.src)
+	if i := strings.IndexByte(in.src[end:], '\n'); i >= 0 {
+		lineEnd = end + i + 1
+	}
+	if startsLine(in.src, begin) && strings.TrimSpace(in.src[end:lineEnd]) == "" {
+		begin = strings.LastIndex(in.src[:begin], "\n") + 1
+		end = lineEnd
+	}
+	return edit.Edit{Begin: begin, End: end}
+}
+
+// replacement returns the text replacing the uses of the variable, which
+// is the value, in parentheses unless it is a single operand.
+func (in *inlining) replacement() string {
+	switch in.assignment.Value.(type) {
+	case *ast.Literal, *ast.Variable, *ast.FunctionCallExpr, *ast.MethodCallExpr, *ast.PropertyCallExpr,
+		*ast.ClassExpr, ast.ConstantExpr, *ast.ConstantExpr, *ast.ArrayLookupExpr, *ast.ArrayExpr, ast.ArrayExpr:
+		return in.value
+	}
+	return "(" + in.value + ")"
+}
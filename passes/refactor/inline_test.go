@@ -0,0 +1,140 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInlineVariable(t *testing.T) {
+	src := `<?php
+function area($w, $h) {
+	$size = $w * $h;
+	$name = 'square';
+	echo strlen($name), $size;
+	return $size / 2;
+}
+`
+	p := newTestProject(t, map[string]string{"area.php": src})
+	line, column := position(src, "$size =")
+	change, err := p.InlineVariable("area.php", line, column)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := p.Apply(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?php
+function area($w, $h) {
+	$name = 'square';
+	echo strlen($name), ($w * $h);
+	return ($w * $h) / 2;
+}
+`
+	if changed["area.php"] != expected {
+		t.Errorf("inlined to\n%s\nexpected\n%s", changed["area.php"], expected)
+	}
+
+	line, column = position(src, "$name)")
+	change, err = p.InlineVariable("area.php", line, column)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err = p.Apply(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(changed["area.php"], "echo strlen('square'), $size;") {
+		t.Errorf("inlined to\n%s", changed["area.php"])
+	}
+}
+
+func TestInlineVariableState(t *testing.T) {
+	src := `<?php
+class C {
+	public $x;
+	function m() {
+		$v = $this->x;
+		echo $v;
+		$w = g();
+		h($w);
+		return 1;
+	}
+}
+`
+	p := newTestProject(t, map[string]string{"c.php": src})
+	for _, test := range []struct {
+		at, expected string
+	}{
+		{"$v =", "echo $this->x;"},
+		{"$w =", "h(g());"},
+	} {
+		line, column := position(src, test.at)
+		change, err := p.InlineVariable("c.php", line, column)
+		if err != nil {
+			t.Errorf("inlining %s: %v", test.at, err)
+			continue
+		}
+		changed, err := p.Apply(change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(changed["c.php"], test.expected) {
+			t.Errorf("inlined %s to\n%s", test.at, changed["c.php"])
+		}
+	}
+}
+
+func TestInlineVariableErrors(t *testing.T) {
+	src := `<?php
+function f($a) {
+	$b = 1;
+	$b = 2;
+	$c = g();
+	echo $c, $c;
+	$d = $a;
+	$a = 3;
+	echo $d;
+	$e = 4;
+	$h = function() use ($e) { return $e; };
+	$i = 5;
+	echo "$i";
+	$j = 6;
+	$k = g();
+	h();
+	return $k;
+}
+
+class C {
+	public $x;
+	function m() {
+		$v = $this->x;
+		$this->x = 5;
+		$w = $this->x;
+		echo $w;
+		return $v;
+	}
+}
+`
+	p := newTestProject(t, map[string]string{"f.php": src})
+	tests := []struct {
+		at, err string
+	}{
+		{"$a)", "argument"},
+		{"$b = 1", "more than once"},
+		{"$c = g()", "side effects"},
+		{"$d = $a", "$a changes"},
+		{"$e = 4", "closure"},
+		{"$i = 5", "interpolated"},
+		{"$j = 6", "never used"},
+		{"$k = g()", "16:2"},
+		{"$v = $this->x", "24:3"},
+	}
+	for _, test := range tests {
+		line, column := position(src, test.at)
+		_, err := p.InlineVariable("f.php", line, column)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("inlining %s: expected an error containing %q, got %v", test.at, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package refactor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/lexer"
+	"github.com/stephens2424/php/token"
+)
+
+// function is a function, method or closure, or the code of a file outside
+// of functions, whose local variables a refactoring changes.
+type function struct {
+	file *ast.File
+
+	// node is the *ast.FunctionStmt, *ast.Method or *ast.AnonymousFunction,
+	// or nil for the code outside of functions.
+	node ast.Node
+	span ast.Span
+
+	// class is the class of a method.
+	class *ast.Class
+
+	args  []*ast.FunctionArgument
+	body  statementList
+	scope *ast.Scope
+
+	// uses holds the uses of the variables of the function, found by
+	// walking it, by the variable nodes that the scope references.
+	uses map[*ast.Variable]use
+
+	// other holds the uses that the scope does not reference: variables
+	// captured by closures and interpolated in strings.
+	other []use
+
+	// byRef reports whether the argument i of a call is passed by
+	// reference, which writes the variable passed.
+	byRef func(call *ast.FunctionCallExpr, i int) bool
+}
+
+// access is how a variable is used.
+type access int
+
+const (
+	read access = 1 << iota
+	write
+)
+
+// use is a use of a local variable.
+type use struct {
+	v      *ast.Variable
+	name   string
+	access access
+	span   ast.Span
+
+	// captured is set for a variable a closure uses, and interpolated for a
+	// variable in a string.
+	captured, interpolated bool
+
+	// checked is set for the argument of isset, unset or empty, which must
+	// be a variable.
+	checked bool
+}
+
+// enclosingFunction returns the innermost function of f enclosing the
+// source between the offsets begin and end. byRef reports which arguments
+// of calls are passed by reference.
+func enclosingFunction(f *ast.File, begin, end int, byRef func(*ast.FunctionCallExpr, int) bool) *function {
+	fn := &function{file: f, scope: f.Scope, body: statementList{stmts: f.Nodes}}
+	var walk func(n ast.Node, class *ast.Class)
+	walk = func(n ast.Node, class *ast.Class) {
+		if ast.IsNil(n) {
+			return
+		}
+		span, ok := f.Positions.Span(n)
+		if !ok || span.Begin.Position > begin || span.End.Position < end {
+			return
+		}
+		switch n := n.(type) {
+		case *ast.Class:
+			class = n
+		case *ast.Method:
+			fn = &function{file: f, node: n, span: span, class: class, args: n.Arguments}
+			fn.setBody(n.Body)
+		case *ast.FunctionStmt:
+			fn = &function{file: f, node: n, span: span, args: n.Arguments}
+			fn.setBody(n.Body)
+		case *ast.AnonymousFunction:
+			fn = &function{file: f, node: n, span: span, args: n.Arguments}
+			fn.setBody(n.Body)
+		}
+		for _, child := range n.Children() {
+			walk(child, class)
+		}
+	}
+	for _, n := range f.Nodes {
+		walk(n, nil)
+	}
+	fn.byRef = byRef
+	fn.walk()
+	return fn
+}
+
+func (fn *function) setBody(b *ast.Block) {
+	if b != nil {
+		fn.scope = b.Scope
+		fn.body = fn.block(b)
+	}
+}
+
+// definition returns the definition of a function or method, or nil.
+func (fn *function) definition() *ast.FunctionDefinition {
+	switch n := fn.node.(type) {
+	case *ast.Method:
+		return n.FunctionDefinition
+	case *ast.FunctionStmt:
+		return n.FunctionDefinition
+	}
+	return nil
+}
+
+// isArgument reports whether name is an argument of the function.
+func (fn *function) isArgument(name string) bool {
+	for _, arg := range fn.args {
+		if v := ast.Static(arg.Variable.Name); v != nil && v.Value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isReference reports whether name is an argument of the function passed
+// by reference, which the caller sees the writes to.
+func (fn *function) isReference(name string) bool {
+	for _, arg := range fn.args {
+		if v := ast.Static(arg.Variable.Name); v != nil && v.Value == name {
+			return arg.Reference
+		}
+	}
+	return false
+}
+
+// references returns the uses of the variable name, in order.
+func (fn *function) references(name string) []use {
+	var uses []use
+	if fn.scope != nil {
+		for _, v := range fn.scope.Identifiers[name].References {
+			if u, ok := fn.uses[v]; ok {
+				uses = append(uses, u)
+			}
+		}
+	}
+	for _, u := range fn.other {
+		if u.name == name {
+			uses = append(uses, u)
+		}
+	}
+	sortUses(uses)
+	return uses
+}
+
+// dynamic reports whether the function uses variable variables between the
+// offsets begin and end.
+func (fn *function) dynamic(begin, end int) bool {
+	if fn.scope == nil {
+		return false
+	}
+	for _, v := range fn.scope.DynamicVariables {
+		if span, ok := fn.file.Positions[v]; ok && span.Begin.Position >= begin && span.End.Position <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// walk finds the uses of the variables of the function.
+func (fn *function) walk() {
+	fn.uses = map[*ast.Variable]use{}
+	for _, n := range fn.body.stmts {
+		fn.walkNode(n, read)
+	}
+}
+
+// add records the use of v, unless it is dynamic, and reports whether it
+// did.
+func (fn *function) add(v *ast.Variable, a access) bool {
+	name := ast.Static(v.Name)
+	span, ok := fn.file.Positions[v]
+	if name == nil || !ok {
+		return false
+	}
+	fn.uses[v] = use{v: v, name: name.Value, access: a, span: span}
+	return true
+}
+
+// walkNode finds the uses of variables in n, which is used with access a.
+func (fn *function) walkNode(n ast.Node, a access) {
+	if ast.IsNil(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *ast.Variable:
+		if !fn.add(n, a) {
+			fn.walkNode(n.Name, read)
+		}
+		return
+	case ast.AssignmentExpr:
+		fn.walkNode(n.Value, read)
+		if _, ok := n.Assignee.(*ast.Variable); ok && n.Operator == "=" {
+			fn.walkNode(n.Assignee, write)
+		} else {
+			fn.walkNode(n.Assignee, read|write)
+		}
+		return
+	case *ast.AssignmentExpr:
+		fn.walkNode(*n, a)
+		return
+	case *ast.ListStatement:
+		for _, assignee := range n.Assignees {
+			fn.walkNode(assignee, write)
+		}
+		fn.walkNode(n.Value, read)
+		return
+	case ast.UnaryCallExpr:
+		if n.Operator == "++" || n.Operator == "--" {
+			fn.walkNode(n.Operand, read|write)
+			return
+		}
+	case ast.ArrayAppendExpr:
+		fn.walkNode(n.Array, a)
+		return
+	case *ast.ArrayLookupExpr:
+		fn.walkNode(n.Array, a)
+		fn.walkNode(n.Index, read)
+		return
+	case *ast.ForeachStmt:
+		fn.walkNode(n.Source, read)
+		fn.walkNode(n.Key, write)
+		fn.walkNode(n.Value, write)
+		fn.walkNode(n.LoopBlock, read)
+		return
+	case *ast.CatchStmt:
+		fn.walkNode(n.CatchVar, write)
+		fn.walkNode(n.CatchBlock, read)
+		return
+	case *ast.GlobalDeclaration:
+		for _, v := range n.Identifiers {
+			fn.walkNode(v, write)
+		}
+		return
+	case *ast.StaticVariableDeclaration:
+		for _, d := range n.Declarations {
+			if assignment, ok := d.(*ast.AssignmentExpr); ok {
+				fn.walkNode(assignment.Value, read)
+				d = assignment.Assignee
+			}
+			fn.walkNode(d, write)
+		}
+		return
+	case *ast.FunctionCallExpr:
+		if name := ast.Static(n.FunctionName); name != nil {
+			switch strings.ToLower(name.Value) {
+			case "isset", "unset", "empty":
+				for _, arg := range n.Arguments {
+					fn.walkChecked(arg, strings.ToLower(name.Value) == "unset")
+				}
+				return
+			}
+		}
+		fn.walkNode(n.FunctionName, read)
+		for i, arg := range n.Arguments {
+			if fn.byRef != nil && fn.byRef(n, i) {
+				fn.walkNode(arg, read|write)
+			} else {
+				fn.walkNode(arg, read)
+			}
+		}
+		return
+	case *ast.ClassExpr:
+		// a static property is not a variable
+		if _, ok := n.Expr.(*ast.Variable); ok {
+			fn.walkNode(n.Receiver, read)
+			return
+		}
+	case *ast.AnonymousFunction:
+		for _, arg := range n.ClosureVariables {
+			if name := ast.Static(arg.Variable.Name); name != nil {
+				if span, ok := fn.file.Positions[arg.Variable]; ok {
+					fn.other = append(fn.other, use{name: name.Value, access: read, span: span, captured: true})
+				}
+			}
+		}
+		return
+	case *ast.FunctionStmt, *ast.Class, *ast.Interface:
+		return
+	case *ast.Literal:
+		fn.interpolated(n)
+		return
+	}
+	for _, child := range n.Children() {
+		fn.walkNode(child, read)
+	}
+}
+
+// walkChecked finds the uses of variables in the argument n of isset,
+// unset or empty.
+func (fn *function) walkChecked(n ast.Node, unset bool) {
+	v, ok := n.(*ast.Variable)
+	if !ok || ast.Static(v.Name) == nil {
+		fn.walkNode(n, read)
+		return
+	}
+	a := read
+	if unset {
+		a = write
+	}
+	if fn.add(v, a) {
+		u := fn.uses[v]
+		u.checked = true
+		fn.uses[v] = u
+	}
+}
+
+// interpolation matches a variable interpolated in a string, with its name
+// as the first group.
+var interpolation = regexp.MustCompile(`\$([A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*)`)
+
+// interpolated records the variables interpolated in the string l.
+func (fn *function) interpolated(l *ast.Literal) {
+	if l.Type != ast.String || !strings.HasPrefix(l.Value, `"`) && !strings.HasPrefix(l.Value, "<<<") || strings.HasPrefix(l.Value, "<<<'") {
+		return
+	}
+	span, ok := fn.file.Positions[l]
+	if !ok {
+		return
+	}
+	for _, m := range interpolation.FindAllStringSubmatchIndex(l.Value, -1) {
+		if m[0] > 0 && l.Value[m[0]-1] == '\\' {
+			continue
+		}
+		fn.other = append(fn.other, use{name: l.Value[m[2]:m[3]], access: read, span: span, interpolated: true})
+	}
+}
+
+func sortUses(uses []use) {
+	sort.SliceStable(uses, func(i, j int) bool { return uses[i].span.Begin.Position < uses[j].span.Begin.Position })
+}
+
+// statementList is a list of statements, such as those of a block, which
+// begin after the offset after.
+type statementList struct {
+	after int
+	stmts []ast.Node
+}
+
+// block returns the statements of b.
+func (fn *function) block(b *ast.Block) statementList {
+	list := statementList{stmts: make([]ast.Node, len(b.Statements))}
+	for i, s := range b.Statements {
+		list.stmts[i] = s
+	}
+	if span, ok := fn.file.Positions[b]; ok {
+		// after the opening brace
+		list.after = span.Begin.Position + 1
+	} else if span, ok := fn.file.Positions.Span(b); ok {
+		list.after = span.Begin.Position
+	}
+	return list
+}
+
+// statementLists returns the lists of statements of the function, outer
+// lists before the lists they contain.
+func (fn *function) statementLists() []statementList {
+	lists := []statementList{fn.body}
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		if ast.IsNil(n) {
+			return
+		}
+		switch n := n.(type) {
+		case *ast.FunctionStmt, *ast.AnonymousFunction, *ast.Class, *ast.Interface:
+			return
+		case *ast.Block:
+			lists = append(lists, fn.block(n))
+		case ast.Block:
+			lists = append(lists, fn.block(&n))
+		}
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	for _, n := range fn.body.stmts {
+		walk(n)
+	}
+	return lists
+}
+
+// statementSpans returns the spans of the statements stmts of f, whose
+// source is src. The recorded spans of statements may omit their keywords
+// and terminating semicolons, so each is extended to the tokens between
+// it and the previous statement, beginning at the offset after, and to a
+// semicolon that follows it.
+func statementSpans(src string, f *ast.File, after int, stmts []ast.Node) []ast.Span {
+	var tokens []token.Item
+	l := lexer.NewLexer(src)
+	for item := l.Next(); item.Typ != token.EOF; item = l.Next() {
+		switch item.Typ {
+		case token.CommentBlock, token.CommentLine, token.PHPBegin, token.PHPEnd:
+			continue
+		}
+		if item.Typ.Type().Is(token.Significant) {
+			tokens = append(tokens, item)
+		}
+	}
+	l.Abort()
+
+	spans := make([]ast.Span, len(stmts))
+	i := 0
+	for n, stmt := range stmts {
+		span, ok := f.Positions.Span(stmt)
+		if !ok {
+			continue
+		}
+		for i < len(tokens) && tokens[i].Begin.Position < after {
+			i++
+		}
+		if i < len(tokens) && tokens[i].Begin.Position < span.Begin.Position {
+			span.Begin = tokens[i].Begin
+		}
+		for i < len(tokens) && tokens[i].Begin.Position < span.End.Position {
+			i++
+		}
+		if i < len(tokens) && tokens[i].Typ == token.StatementEnd {
+			span.End = tokens[i].End
+		}
+		spans[n] = span
+		after = span.End.Position
+	}
+	return spans
+}
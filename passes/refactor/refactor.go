@@ -0,0 +1,146 @@
+// Package refactor implements refactorings of PHP code. Each returns the
+// textual edits that make it, which may be applied with the edit package.
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/builtins"
+	"github.com/stephens2424/php/edit"
+	"github.com/stephens2424/php/passes/symbols"
+)
+
+// ErrNameTaken is returned, wrapped, by refactorings when the name they
+// would give a function, method or variable is already used.
+var ErrNameTaken = errors.New("already exists")
+
+// Project is a set of parsed PHP files with their sources.
+type Project struct {
+	Files   *ast.FileSet
+	Sources map[string]string
+	Index   *symbols.Index
+}
+
+// NewProject returns a project of the files in fs, whose sources are in
+// sources by file name.
+func NewProject(fs *ast.FileSet, sources map[string]string) *Project {
+	return &Project{Files: fs, Sources: sources, Index: symbols.New(fs)}
+}
+
+// byRef returns a function reporting whether the argument i of a call in
+// file is passed by reference, to a function the project declares, or
+// else to one built into PHP.
+func (p *Project) byRef(file string) func(call *ast.FunctionCallExpr, i int) bool {
+	called := map[ast.Node]symbols.Symbol{}
+	for _, o := range p.Index.Occurrences(file) {
+		if o.Symbol.Kind == symbols.Function && !o.Definition {
+			called[o.Node] = o.Symbol
+		}
+	}
+	return func(call *ast.FunctionCallExpr, i int) bool {
+		id := ast.Static(call.FunctionName)
+		if id == nil {
+			return false
+		}
+		if s, ok := called[id]; ok {
+			if defs := p.Index.Definitions(s); len(defs) > 0 {
+				for _, d := range defs {
+					def, ok := d.Node.(*ast.FunctionDefinition)
+					if ok && i < len(def.Arguments) && def.Arguments[i].Reference {
+						return true
+					}
+				}
+				return false
+			}
+		}
+		name := strings.TrimPrefix(id.Value, `\`)
+		if strings.Contains(name, `\`) {
+			return false
+		}
+		fn := builtins.Load().Function(name)
+		if fn == nil {
+			return false
+		}
+		if i < len(fn.Params) {
+			return fn.Params[i].ByRef
+		}
+		n := len(fn.Params)
+		return n > 0 && fn.Params[n-1].Variadic && fn.Params[n-1].ByRef
+	}
+}
+
+// Change is the result of a refactoring.
+type Change struct {
+	// Edits holds the edits to each file, in order.
+	Edits map[string][]edit.Edit
+
+	// Unresolved lists places that may be affected by the refactoring, but
+	// could not be resolved statically, so were left unchanged.
+	Unresolved []Reference
+}
+
+// Reference is a place in the source of a project.
+type Reference struct {
+	File   string
+	Span   ast.Span
+	Reason string
+}
+
+func (r Reference) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", r.File, r.Span.Begin.Line, r.Span.Begin.Column, r.Reason)
+}
+
+func newChange() *Change {
+	return &Change{Edits: map[string][]edit.Edit{}}
+}
+
+// add adds an edit of file, unless there is already one at the same place.
+func (c *Change) add(file string, e edit.Edit) {
+	for _, existing := range c.Edits[file] {
+		if existing.Begin == e.Begin && existing.End == e.End {
+			return
+		}
+	}
+	c.Edits[file] = append(c.Edits[file], e)
+}
+
+// addUnresolved adds an unresolved reference, unless it is already listed.
+func (c *Change) addUnresolved(ref Reference) {
+	for _, existing := range c.Unresolved {
+		if existing == ref {
+			return
+		}
+	}
+	c.Unresolved = append(c.Unresolved, ref)
+}
+
+// sort sorts the edits and unresolved references in order of position.
+func (c *Change) sort() {
+	for _, edits := range c.Edits {
+		sort.Slice(edits, func(i, j int) bool { return edits[i].Begin < edits[j].Begin })
+	}
+	sort.SliceStable(c.Unresolved, func(i, j int) bool {
+		a, b := c.Unresolved[i], c.Unresolved[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Span.Begin.Position < b.Span.Begin.Position
+	})
+}
+
+// Apply returns the sources of the files changed by c.
+func (p *Project) Apply(c *Change) (map[string]string, error) {
+	changed := map[string]string{}
+	for file, edits := range c.Edits {
+		out, err := edit.Apply(p.Sources[file], edits)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		changed[file] = out
+	}
+	return changed, nil
+}
@@ -0,0 +1,262 @@
+package refactor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/edit"
+	"github.com/stephens2424/php/lexer"
+	"github.com/stephens2424/php/passes/symbols"
+	"github.com/stephens2424/php/token"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*$`)
+
+// Rename returns the change renaming the symbol at the given line and
+// column of file to name. Classes and functions keep their namespace, so
+// name is unqualified.
+//
+// Every declaration of and reference to the symbol is renamed, including
+// in use declarations, type hints, docblocks and strings containing the
+// qualified name of a class. Renaming a method also renames the methods it
+// overrides and that override it. References that might be to the symbol,
+// such as calls of methods of the same name on objects of unknown class and
+// dynamic calls, are listed as unresolved.
+func (p *Project) Rename(file string, line, column int, name string) (*Change, error) {
+	o, ok := p.Index.At(file, line, column)
+	if !ok {
+		return nil, fmt.Errorf("%s:%d:%d: no symbol to rename", file, line, column)
+	}
+	defs := p.Index.DefinitionAt(file, line, column)
+	switch {
+	case len(defs) == 0:
+		return nil, fmt.Errorf("%s:%d:%d: %s is not defined in the project", file, line, column, o.Symbol)
+	case len(defs) > 1 && o.Symbol.Kind != symbols.Variable:
+		return nil, fmt.Errorf("%s:%d:%d: %s is ambiguous, with %d definitions", file, line, column, o.Symbol, len(defs))
+	}
+	sym := defs[0].Symbol
+
+	name = strings.TrimPrefix(name, "$")
+	if !identifier.MatchString(name) || sym.Kind == symbols.Variable && name == "this" {
+		return nil, fmt.Errorf("invalid name %q", name)
+	}
+
+	r := &renamer{Project: p, change: newChange(), old: shortName(sym.Name), name: name}
+	targets := r.related(sym)
+	for _, target := range targets {
+		renamed := target
+		renamed.Name = strings.TrimSuffix(target.Name, r.old) + name
+		if !strings.EqualFold(target.Name, renamed.Name) && len(p.Index.Definitions(renamed)) > 0 {
+			return nil, fmt.Errorf("cannot rename %s to %s, which %w", target, name, ErrNameTaken)
+		}
+		r.rename(target)
+	}
+	r.unresolved(sym)
+	r.change.sort()
+	return r.change, nil
+}
+
+// shortName returns name without its namespace.
+func shortName(name string) string {
+	return name[strings.LastIndex(name, `\`)+1:]
+}
+
+type renamer struct {
+	*Project
+	change    *Change
+	old, name string
+}
+
+// related returns the symbols to rename with s: s itself, and for a member,
+// the members of the same name of the classes that s's class extends or is
+// extended by.
+func (r *renamer) related(s symbols.Symbol) []symbols.Symbol {
+	switch s.Kind {
+	case symbols.Method, symbols.Property, symbols.ClassConstant:
+	default:
+		return []symbols.Symbol{s}
+	}
+
+	var related []symbols.Symbol
+	seen := map[string]bool{}
+	queue := []string{s.Class}
+	for len(queue) > 0 {
+		class := queue[0]
+		queue = queue[1:]
+		if seen[strings.ToLower(class)] {
+			continue
+		}
+		seen[strings.ToLower(class)] = true
+
+		member := s
+		member.Class = class
+		for _, def := range r.Index.Definitions(member) {
+			if strings.EqualFold(def.Symbol.Class, class) {
+				related = append(related, def.Symbol)
+				break
+			}
+		}
+		queue = append(queue, r.Index.Supertypes(class)...)
+		queue = append(queue, r.Index.Subtypes(class)...)
+	}
+	return related
+}
+
+// rename adds the edits renaming the declaration of and references to s.
+func (r *renamer) rename(s symbols.Symbol) {
+	for _, def := range r.Index.Definitions(s) {
+		if s.Kind != symbols.Variable && !strings.EqualFold(def.Symbol.Class, s.Class) {
+			continue
+		}
+		r.replace(def.File, def.Span)
+	}
+	for _, ref := range r.Index.References(s) {
+		if ref.Symbol.Class == "" && s.Class != "" {
+			r.change.addUnresolved(Reference{
+				File:   ref.File,
+				Span:   ref.Span,
+				Reason: fmt.Sprintf("%s of an object of unknown class", ref.Symbol.Name),
+			})
+			continue
+		}
+		r.replace(ref.File, ref.Span)
+	}
+	if s.Kind == symbols.Class {
+		for file := range r.Sources {
+			r.docblocks(file, s)
+		}
+	}
+}
+
+// replace adds the edit replacing the old name at the end of span, unless
+// span names the symbol otherwise, such as by an alias.
+func (r *renamer) replace(file string, span ast.Span) {
+	src, ok := r.Sources[file]
+	if !ok || span.End.Position > len(src) {
+		return
+	}
+	text := src[span.Begin.Position:span.End.Position]
+	if len(text) < len(r.old) || !strings.EqualFold(text[len(text)-len(r.old):], r.old) {
+		return
+	}
+	if before := text[:len(text)-len(r.old)]; before != "" && !strings.HasSuffix(before, `\`) && before != "$" {
+		return
+	}
+	r.change.add(file, edit.Edit{Begin: span.End.Position - len(r.old), End: span.End.Position, Text: r.name})
+}
+
+// docblockType matches the tags of docblocks followed by types, with the
+// type as its first group.
+var docblockType = regexp.MustCompile(`@(?:param|return|var|throws|property(?:-read|-write)?|method)\s+([^\s$]+)`)
+
+// docblockName matches a class name within a docblock type.
+var docblockName = regexp.MustCompile(`\\?[A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*(\\[A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*)*`)
+
+// docblocks adds the edits renaming the class s in the types of the
+// docblocks of file.
+func (r *renamer) docblocks(file string, s symbols.Symbol) {
+	l := lexer.NewLexer(r.Sources[file])
+	defer l.Abort()
+	for item := l.Next(); item.Typ != token.EOF; item = l.Next() {
+		if item.Typ != token.CommentBlock || !strings.HasPrefix(item.Val, "/**") {
+			continue
+		}
+		for _, tag := range docblockType.FindAllStringSubmatchIndex(item.Val, -1) {
+			typ := item.Val[tag[2]:tag[3]]
+			for _, m := range docblockName.FindAllStringIndex(typ, -1) {
+				name := typ[m[0]:m[1]]
+				if !strings.EqualFold(r.Index.ResolveClass(file, name), s.Name) {
+					continue
+				}
+				end := item.Begin.Position + tag[2] + m[1]
+				r.change.add(file, edit.Edit{Begin: end - len(r.old), End: end, Text: r.name})
+			}
+		}
+	}
+}
+
+// unresolved adds the references that may be to s, but are dynamic.
+func (r *renamer) unresolved(s symbols.Symbol) {
+	for file, f := range r.Files.Files {
+		var walk func(n ast.Node)
+		walk = func(n ast.Node) {
+			if ast.IsNil(n) {
+				return
+			}
+			if reason := dynamicReference(n, s, r.old); reason != "" {
+				if span, ok := f.Positions.Span(n); ok {
+					r.change.addUnresolved(Reference{File: file, Span: span, Reason: reason})
+				}
+			}
+			children := n.Children()
+			switch n := n.(type) {
+			case *ast.MethodCallExpr:
+				// the call of a method is not a call of a function
+				children = append([]ast.Node{n.Receiver}, n.FunctionCallExpr.Children()[1:]...)
+			case *ast.PropertyCallExpr:
+				if call, ok := n.Name.(*ast.FunctionCallExpr); ok {
+					children = append([]ast.Node{n.Receiver}, call.Children()...)
+				}
+			case *ast.ClassExpr:
+				if call, ok := n.Expr.(*ast.FunctionCallExpr); ok {
+					children = append([]ast.Node{n.Receiver}, call.Children()[1:]...)
+				}
+			}
+			for _, child := range children {
+				walk(child)
+			}
+		}
+		for _, n := range f.Nodes {
+			walk(n)
+		}
+	}
+}
+
+// dynamicReference returns why n might refer to s, whose unqualified name
+// is name, or "" if it does not.
+func dynamicReference(n ast.Node, s symbols.Symbol, name string) string {
+	switch n := n.(type) {
+	case *ast.MethodCallExpr:
+		if s.Kind == symbols.Method && ast.Static(n.FunctionName) == nil {
+			return "dynamic method call"
+		}
+	case *ast.ClassExpr:
+		if call, ok := n.Expr.(*ast.FunctionCallExpr); ok && s.Kind == symbols.Method && ast.Static(call.FunctionName) == nil {
+			return "dynamic static method call"
+		}
+		if s.Kind == symbols.Class && ast.Static(n.Receiver) == nil {
+			return "dynamic class"
+		}
+	case *ast.PropertyCallExpr:
+		// the parser represents $object->$name() as a property whose name
+		// is a function call
+		_, call := n.Name.(*ast.FunctionCallExpr)
+		if s.Kind == symbols.Method && call {
+			return "dynamic method call"
+		}
+		if s.Kind == symbols.Property && !call && ast.Static(n.Name) == nil {
+			return "dynamic property"
+		}
+	case *ast.FunctionCallExpr:
+		if s.Kind == symbols.Function && ast.Static(n.FunctionName) == nil {
+			return "dynamic function call"
+		}
+	case *ast.NewCallExpr:
+		if s.Kind == symbols.Class && ast.Static(n.Class) == nil {
+			return "instantiation of a dynamic class"
+		}
+	case *ast.Literal:
+		if n.Type != ast.String || len(n.Value) < 2 {
+			return ""
+		}
+		switch s.Kind {
+		case symbols.Function, symbols.Method, symbols.Class:
+			if contents := n.Value[1 : len(n.Value)-1]; strings.EqualFold(contents, name) {
+				return fmt.Sprintf("string %s, which may name the %s", n.Value, s.Kind)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,243 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+// newTestProject parses the sources into a project.
+func newTestProject(t *testing.T, sources map[string]string) *Project {
+	p := parser.NewParser()
+	for name, src := range sources {
+		if _, err := p.Parse(name, src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewProject(p.FileSet, sources)
+}
+
+// position returns the line and column of the first occurrence of s in src.
+func position(src, s string) (line, column int) {
+	offset := strings.Index(src, s)
+	line = 1 + strings.Count(src[:offset], "\n")
+	column = offset - strings.LastIndex(src[:offset], "\n")
+	return line, column
+}
+
+func TestRenameClass(t *testing.T) {
+	sources := map[string]string{
+		"models.php": `<?php
+namespace App\Models;
+
+class User {
+	/** @var User[] */
+	public static $all = [];
+
+	/**
+	 * @param \App\Models\User|null $other
+	 * @return User
+	 */
+	static function find(User $other) {
+		return new User();
+	}
+}
+`,
+		"main.php": `<?php
+namespace App;
+
+use App\Models\User;
+use App\Models\User as Account;
+
+class Admin extends User {}
+
+$u = User::find(null);
+$a = new Account();
+if ($u instanceof Models\User) {
+	echo User::class, 'App\Models\User', "App\\Models\\User";
+}
+try {
+} catch (User $e) {
+}
+$class = 'User';
+`,
+	}
+	p := newTestProject(t, sources)
+	line, column := position(sources["models.php"], "User {")
+	change, err := p.Rename("models.php", line, column, "Member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := p.Apply(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"models.php": `<?php
+namespace App\Models;
+
+class Member {
+	/** @var Member[] */
+	public static $all = [];
+
+	/**
+	 * @param \App\Models\Member|null $other
+	 * @return Member
+	 */
+	static function find(Member $other) {
+		return new Member();
+	}
+}
+`,
+		"main.php": `<?php
+namespace App;
+
+use App\Models\Member;
+use App\Models\Member as Account;
+
+class Admin extends Member {}
+
+$u = Member::find(null);
+$a = new Account();
+if ($u instanceof Models\Member) {
+	echo Member::class, 'App\Models\Member', "App\\Models\\Member";
+}
+try {
+} catch (Member $e) {
+}
+$class = 'User';
+`,
+	}
+	for file, src := range expected {
+		if changed[file] != src {
+			t.Errorf("renamed %s to\n%s\nexpected\n%s", file, changed[file], src)
+		}
+	}
+	if len(change.Unresolved) != 1 || !strings.Contains(change.Unresolved[0].String(), "main.php:17:10: string 'User'") {
+		t.Errorf("unexpected unresolved references %v", change.Unresolved)
+	}
+}
+
+func TestRenameMethod(t *testing.T) {
+	src := `<?php
+interface Shape {
+	function area();
+}
+
+class Square implements Shape {
+	public $side;
+	function area() {
+		return $this->side * $this->side;
+	}
+}
+
+class Cube extends Square {
+	function area() {
+		return 6 * parent::area();
+	}
+	function volume() {
+		return $this->area() * $this->side;
+	}
+}
+
+function total($shapes, $method) {
+	$sum = 0;
+	foreach ($shapes as $shape) {
+		$sum += $shape->area() + $shape->$method();
+	}
+	return $sum;
+}
+`
+	p := newTestProject(t, map[string]string{"shapes.php": src})
+	line, column := position(src, "area() {")
+	change, err := p.Rename("shapes.php", line, column, "surface")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := p.Apply(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Replace(src, "area()", "surface()", -1)
+	expected = strings.Replace(expected, "$shape->surface()", "$shape->area()", 1)
+	if changed["shapes.php"] != expected {
+		t.Errorf("renamed to\n%s\nexpected\n%s", changed["shapes.php"], expected)
+	}
+
+	var unresolved []string
+	for _, ref := range change.Unresolved {
+		unresolved = append(unresolved, ref.String())
+	}
+	expectedUnresolved := []string{
+		"shapes.php:25:19: area of an object of unknown class",
+		"shapes.php:25:28: dynamic method call",
+	}
+	if strings.Join(unresolved, "\n") != strings.Join(expectedUnresolved, "\n") {
+		t.Errorf("unresolved references are\n%s\nexpected\n%s", strings.Join(unresolved, "\n"), strings.Join(expectedUnresolved, "\n"))
+	}
+}
+
+func TestRenameVariable(t *testing.T) {
+	src := `<?php
+function f($a) {
+	$b = $a + 1;
+	$g = function() use ($b) {
+		return $b * 2;
+	};
+	return $g() + $b;
+}
+$b = 3;
+`
+	p := newTestProject(t, map[string]string{"f.php": src})
+	line, column := position(src, "$b = $a")
+	change, err := p.Rename("f.php", line, column, "$count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := p.Apply(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?php
+function f($a) {
+	$count = $a + 1;
+	$g = function() use ($count) {
+		return $count * 2;
+	};
+	return $g() + $count;
+}
+$b = 3;
+`
+	if changed["f.php"] != expected {
+		t.Errorf("renamed to\n%s\nexpected\n%s", changed["f.php"], expected)
+	}
+}
+
+func TestRenameErrors(t *testing.T) {
+	src := `<?php
+class A {
+	function a() {}
+	function b() {}
+}
+strlen("x");
+`
+	p := newTestProject(t, map[string]string{"a.php": src})
+	tests := []struct {
+		at, name, err string
+	}{
+		{"a() {}", "b", "already exists"},
+		{"a() {}", "not-a-name", "invalid name"},
+		{"strlen", "length", "not defined in the project"},
+		{"<?php", "x", "no symbol"},
+	}
+	for _, test := range tests {
+		line, column := position(src, test.at)
+		_, err := p.Rename("a.php", line, column, test.name)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("renaming %s to %s: expected an error containing %q, got %v", test.at, test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Command symbols finds the definition of or references to the symbol at a
+// position in PHP code.
+//
+// Usage:
+//
+//	symbols [-refs] file:line:column [path ...]
+//
+// The paths may be files or directories, which are searched recursively for
+// PHP files, and default to the current directory. Each definition, or with
+// -refs each reference, is printed as file:line:column: kind symbol.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stephens2424/php/parser"
+	"github.com/stephens2424/php/passes/symbols"
+)
+
+func main() {
+	refs := flag.Bool("refs", false, "print the references to the symbol instead of its definitions")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] file:line:column [path ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	file, line, column, err := parsePosition(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	paths := flag.Args()[1:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	p := parser.NewParser()
+	failed := false
+	parse := func(path string) {
+		src, err := ioutil.ReadFile(path)
+		if err == nil {
+			_, err = p.Parse(path, string(src))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.HasSuffix(path, ".php") && filepath.Clean(path) != file {
+				parse(filepath.Clean(path))
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	parse(file)
+
+	ix := symbols.New(p.FileSet)
+	o, ok := ix.At(file, line, column)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: no symbol found\n", file, line, column)
+		os.Exit(1)
+	}
+
+	found := ix.DefinitionAt(file, line, column)
+	if *refs {
+		found = ix.References(o.Symbol)
+	}
+	for _, o := range found {
+		fmt.Printf("%s:%d:%d: %s %s\n", o.File, o.Span.Begin.Line, o.Span.Begin.Column, o.Symbol.Kind, o.Symbol)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// parsePosition parses a position written as file:line:column.
+func parsePosition(s string) (file string, line, column int, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 3 {
+		return "", 0, 0, fmt.Errorf("invalid position %q, expected file:line:column", s)
+	}
+	n := len(parts)
+	if line, err = strconv.Atoi(parts[n-2]); err == nil {
+		column, err = strconv.Atoi(parts[n-1])
+	}
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid position %q, expected file:line:column", s)
+	}
+	return filepath.Clean(strings.Join(parts[:n-2], ":")), line, column, nil
+}
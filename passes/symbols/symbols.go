@@ -0,0 +1,354 @@
+// Package symbols indexes the definitions of and references to the named
+// entities of PHP code, such as functions, classes and variables, so that a
+// reference can be followed to its definition and the references to a
+// definition can be found.
+//
+// References are resolved statically. A method called on anything but
+// $this, self, static, parent or a named class is indexed without a class,
+// and matches methods of the same name in any class.
+package symbols
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/token"
+)
+
+// Kind is the kind of a symbol.
+type Kind int
+
+const (
+	Function Kind = iota
+	Class
+	Method
+	Property
+	ClassConstant
+	Constant
+	Variable
+)
+
+var kindNames = map[Kind]string{
+	Function:      "function",
+	Class:         "class",
+	Method:        "method",
+	Property:      "property",
+	ClassConstant: "class constant",
+	Constant:      "constant",
+	Variable:      "variable",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Symbol identifies a named entity. Names of classes and functions include
+// their namespace, as in Foo\Bar, and names of properties and variables do
+// not include the $.
+type Symbol struct {
+	Kind Kind
+	Name string
+
+	// Class is the class of a method, property or class constant, or "" if
+	// it is unknown.
+	Class string
+
+	// Func is the function, method or closure that a variable is local to,
+	// or nil for global variables.
+	Func ast.Node
+}
+
+func (s Symbol) String() string {
+	switch {
+	case s.Kind == Variable:
+		return "$" + s.Name
+	case s.Kind == Property && s.Class != "":
+		return s.Class + "->" + s.Name
+	case s.Class != "":
+		return s.Class + "::" + s.Name
+	}
+	return s.Name
+}
+
+// key returns s with its name folded to lower case if PHP compares it
+// without regard to case.
+func (s Symbol) key() Symbol {
+	switch s.Kind {
+	case Function, Class, Method:
+		s.Name = strings.ToLower(s.Name)
+	}
+	s.Class = strings.ToLower(s.Class)
+	return s
+}
+
+// Occurrence is a definition of or reference to a symbol.
+type Occurrence struct {
+	Symbol     Symbol
+	File       string
+	Span       ast.Span
+	Definition bool
+
+	// Node is the declaration of a definition, or the node naming the
+	// symbol in a reference.
+	Node ast.Node
+
+	// namespace is the namespace of an unqualified reference to a function
+	// or constant, which is resolved once every definition is known.
+	namespace string
+}
+
+// Index holds the occurrences of the symbols in a set of files.
+type Index struct {
+	files       map[string][]Occurrence
+	definitions map[Symbol][]Occurrence
+	references  map[Symbol][]Occurrence
+
+	// parents maps each class to the class it extends, both in lower case,
+	// and supertypes maps each class in lower case to the classes and
+	// interfaces it extends or implements.
+	parents    map[string]string
+	supertypes map[string][]string
+
+	names map[string]*names
+}
+
+// New returns an index of the files in fs.
+func New(fs *ast.FileSet) *Index {
+	ix := &Index{
+		files:       map[string][]Occurrence{},
+		definitions: map[Symbol][]Occurrence{},
+		references:  map[Symbol][]Occurrence{},
+		parents:     map[string]string{},
+		supertypes:  map[string][]string{},
+		names:       map[string]*names{},
+	}
+
+	paths := make([]string, 0, len(fs.Files))
+	for path := range fs.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var walkers []*walker
+	for _, path := range paths {
+		w := newWalker(path, fs.Files[path])
+		w.walkFile()
+		walkers = append(walkers, w)
+		ix.names[path] = &w.names
+	}
+
+	for _, w := range walkers {
+		for _, o := range w.occurrences {
+			if o.Definition {
+				key := o.Symbol.key()
+				ix.definitions[key] = append(ix.definitions[key], o)
+			}
+		}
+		for class, parent := range w.parents {
+			ix.parents[class] = parent
+		}
+		for class, supertypes := range w.supertypes {
+			ix.supertypes[class] = append(ix.supertypes[class], supertypes...)
+		}
+	}
+
+	for _, w := range walkers {
+		for i, o := range w.occurrences {
+			if o.namespace != "" {
+				o.Symbol = ix.resolveGlobal(o.Symbol, o.namespace)
+				w.occurrences[i] = o
+			}
+			if !o.Definition {
+				key := ix.resolve(o.Symbol).key()
+				ix.references[key] = append(ix.references[key], o)
+			}
+		}
+		sort.SliceStable(w.occurrences, func(i, j int) bool {
+			return w.occurrences[i].Span.Begin.Position < w.occurrences[j].Span.Begin.Position
+		})
+		ix.files[w.path] = w.occurrences
+	}
+	return ix
+}
+
+// resolveGlobal returns the function or constant referred to by an
+// unqualified name in namespace ns, which is the one in ns if there is one,
+// or else the global one.
+func (ix *Index) resolveGlobal(s Symbol, ns string) Symbol {
+	qualified := s
+	qualified.Name = ns + `\` + s.Name
+	if _, ok := ix.definitions[qualified.key()]; ok {
+		return qualified
+	}
+	return s
+}
+
+// resolve returns the symbol defining the member s, which may belong to a
+// class that s's class extends. Other symbols are returned as they are.
+func (ix *Index) resolve(s Symbol) Symbol {
+	switch s.Kind {
+	case Method, Property, ClassConstant:
+	default:
+		return s
+	}
+	seen := map[string]bool{}
+	for class := s.Class; class != "" && !seen[strings.ToLower(class)]; class = ix.parents[strings.ToLower(class)] {
+		seen[strings.ToLower(class)] = true
+		candidate := s
+		candidate.Class = class
+		if _, ok := ix.definitions[candidate.key()]; ok {
+			return candidate
+		}
+	}
+	return s
+}
+
+// ResolveClass returns the fully qualified name of the class name as
+// written in file, following its namespace and imports.
+func (ix *Index) ResolveClass(file, name string) string {
+	n, ok := ix.names[file]
+	if !ok {
+		return strings.TrimPrefix(name, `\`)
+	}
+	return n.qualifyClass(name)
+}
+
+// Namespace returns the namespace of the code at the end of file, or "" for
+// the global namespace.
+func (ix *Index) Namespace(file string) string {
+	if n, ok := ix.names[file]; ok {
+		return n.namespace
+	}
+	return ""
+}
+
+// Supertypes returns the classes and interfaces that class directly extends
+// or implements.
+func (ix *Index) Supertypes(class string) []string {
+	return ix.supertypes[strings.ToLower(class)]
+}
+
+// Subtypes returns the classes and interfaces that directly extend or
+// implement class, in lower case.
+func (ix *Index) Subtypes(class string) []string {
+	class = strings.ToLower(class)
+	var subtypes []string
+	for sub, supertypes := range ix.supertypes {
+		for _, super := range supertypes {
+			if strings.EqualFold(super, class) {
+				subtypes = append(subtypes, sub)
+				break
+			}
+		}
+	}
+	sort.Strings(subtypes)
+	return subtypes
+}
+
+// Files returns the names of the indexed files.
+func (ix *Index) Files() []string {
+	files := make([]string, 0, len(ix.files))
+	for f := range ix.files {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Occurrences returns the occurrences of symbols in file, in the order
+// they appear.
+func (ix *Index) Occurrences(file string) []Occurrence {
+	return ix.files[file]
+}
+
+// At returns the occurrence in file at the given line and column, which
+// count from 1.
+func (ix *Index) At(file string, line, column int) (Occurrence, bool) {
+	for _, o := range ix.files[file] {
+		if before(line, column, o.Span.Begin) || !before(line, column, o.Span.End) {
+			continue
+		}
+		return o, true
+	}
+	return Occurrence{}, false
+}
+
+// before reports whether line and column are before pos.
+func before(line, column int, pos token.Position) bool {
+	return line < pos.Line || line == pos.Line && column < pos.Column
+}
+
+// Definitions returns the definitions of s. A method, property or class
+// constant that is not defined by its class may be inherited from a class
+// it extends. A method of unknown class is defined by methods of the same
+// name in every class.
+func (ix *Index) Definitions(s Symbol) []Occurrence {
+	s = ix.resolve(s)
+	if defs, ok := ix.definitions[s.key()]; ok || s.Class != "" || !isMember(s.Kind) {
+		return defs
+	}
+
+	var defs []Occurrence
+	name := s.key().Name
+	for key, d := range ix.definitions {
+		if key.Kind == s.Kind && key.Name == name {
+			defs = append(defs, d...)
+		}
+	}
+	sortOccurrences(defs)
+	return defs
+}
+
+// DefinitionAt returns the definitions of the symbol at the given position
+// in file.
+func (ix *Index) DefinitionAt(file string, line, column int) []Occurrence {
+	o, ok := ix.At(file, line, column)
+	if !ok {
+		return nil
+	}
+	if o.Definition {
+		return []Occurrence{o}
+	}
+	return ix.Definitions(o.Symbol)
+}
+
+// References returns the references to s. The references to a member
+// include those of unknown class that have the same name, which may or may
+// not refer to it, and those to a member of unknown class include every
+// reference to a member of the same name.
+func (ix *Index) References(s Symbol) []Occurrence {
+	s = ix.resolve(s)
+	var refs []Occurrence
+	switch {
+	case isMember(s.Kind) && s.Class == "":
+		name := s.key().Name
+		for key, r := range ix.references {
+			if key.Kind == s.Kind && key.Name == name {
+				refs = append(refs, r...)
+			}
+		}
+	case isMember(s.Kind):
+		unknown := s
+		unknown.Class = ""
+		refs = append(refs, ix.references[s.key()]...)
+		refs = append(refs, ix.references[unknown.key()]...)
+	default:
+		refs = append(refs, ix.references[s.key()]...)
+	}
+	sortOccurrences(refs)
+	return refs
+}
+
+func isMember(k Kind) bool {
+	return k == Method || k == Property || k == ClassConstant
+}
+
+func sortOccurrences(os []Occurrence) {
+	sort.SliceStable(os, func(i, j int) bool {
+		if os[i].File != os[j].File {
+			return os[i].File < os[j].File
+		}
+		return os[i].Span.Begin.Position < os[j].Span.Begin.Position
+	})
+}
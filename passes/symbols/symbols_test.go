@@ -0,0 +1,137 @@
+package symbols
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+var testFiles = map[string]string{
+	"a.php": `<?php
+namespace App;
+
+class Base {
+	const LIMIT = 10;
+	public $count;
+	function reset() {
+		$this->count = 0;
+	}
+}
+
+class Counter extends Base {
+	function add($n) {
+		$total = $this->count + $n;
+		$this->count = $total;
+		if ($total > self::LIMIT) {
+			$this->reset();
+		}
+		return $total;
+	}
+}
+
+function counter() {
+	return new Counter();
+}
+`,
+	"b.php": `<?php
+namespace App;
+
+define('DEBUG', true);
+
+$c = counter();
+$c->add(DEBUG ? 1 : 2);
+$f = function($x) use ($c) {
+	return $c->reset() . strlen($x);
+};
+`,
+}
+
+func parseTestFiles(t *testing.T) *Index {
+	p := parser.NewParser()
+	for name, src := range testFiles {
+		if _, err := p.Parse(name, src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return New(p.FileSet)
+}
+
+// position returns the line and column of the nth occurrence of s in file.
+func position(file, s string, nth int) (line, column int) {
+	src := testFiles[file]
+	offset := -1
+	for i := 0; i <= nth; i++ {
+		offset += 1 + strings.Index(src[offset+1:], s)
+	}
+	line = 1 + strings.Count(src[:offset], "\n")
+	column = offset - strings.LastIndex(src[:offset], "\n")
+	return line, column
+}
+
+func TestDefinition(t *testing.T) {
+	ix := parseTestFiles(t)
+	tests := []struct {
+		file, text string
+		nth        int
+		def        string
+		sym        string
+	}{
+		{"a.php", "count", 2, "count", `App\Base->count`},
+		{"a.php", "LIMIT", 1, "LIMIT", `App\Base::LIMIT`},
+		{"a.php", "reset", 1, "reset", `App\Base::reset`},
+		{"a.php", "Counter", 2, "Counter", `App\Counter`},
+		{"a.php", "$total", 2, "$total", "$total"},
+		{"a.php", "$n", 1, "$n", "$n"},
+		{"b.php", "counter", 0, "counter", `App\counter`},
+		{"b.php", "DEBUG", 1, "DEBUG", "DEBUG"},
+		{"b.php", "$c", 3, "$c", "$c"},
+		{"b.php", "reset", 0, "reset", `App\Base::reset`},
+	}
+	for _, test := range tests {
+		line, column := position(test.file, test.text, test.nth)
+		o, ok := ix.At(test.file, line, column)
+		if !ok {
+			t.Errorf("no symbol at %s:%d:%d", test.file, line, column)
+			continue
+		}
+		defs := ix.DefinitionAt(test.file, line, column)
+		if len(defs) != 1 {
+			t.Errorf("found %d definitions of %s, expected 1", len(defs), o.Symbol)
+			continue
+		}
+		def := defs[0]
+		if s := def.Symbol.String(); s != test.sym {
+			t.Errorf("%s:%d:%d defined as %s, expected %s", test.file, line, column, s, test.sym)
+		}
+		src := testFiles[def.File]
+		if text := src[def.Span.Begin.Position:def.Span.End.Position]; text != test.def {
+			t.Errorf("definition of %s is %q, expected %q", def.Symbol, text, test.def)
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	ix := parseTestFiles(t)
+	tests := []struct {
+		sym  Symbol
+		refs []string
+	}{
+		{Symbol{Kind: Property, Class: `App\Counter`, Name: "count"}, []string{"a.php:8:10", "a.php:14:19", "a.php:15:10"}},
+		{Symbol{Kind: Method, Class: `App\Base`, Name: "RESET"}, []string{"a.php:17:11", "b.php:9:13"}},
+		{Symbol{Kind: Class, Name: `App\Counter`}, []string{"a.php:24:13"}},
+		{Symbol{Kind: Function, Name: `App\counter`}, []string{"b.php:6:6"}},
+		{Symbol{Kind: Function, Name: "strlen"}, []string{"b.php:9:23"}},
+		{Symbol{Kind: Constant, Name: "DEBUG"}, []string{"b.php:7:9"}},
+	}
+	for _, test := range tests {
+		var refs []string
+		for _, o := range ix.References(test.sym) {
+			refs = append(refs, fmt.Sprintf("%s:%d:%d", o.File, o.Span.Begin.Line, o.Span.Begin.Column))
+		}
+		if strings.Join(refs, " ") != strings.Join(test.refs, " ") {
+			t.Errorf("references of %s are %v, expected %v", test.sym, refs, test.refs)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// names resolves the names written in a file to fully qualified names.
+type names struct {
+	namespace string
+
+	// imports maps the lower case names imported by use declarations to
+	// the names they refer to.
+	imports map[string]string
+}
+
+// qualify returns the fully qualified name of the function or constant
+// name as written in the namespace, and whether it was qualified already.
+// An unqualified name may instead refer to a global function or constant.
+func (n *names) qualify(name string) (string, bool) {
+	switch {
+	case strings.HasPrefix(name, `\`):
+		return name[1:], true
+	case n.namespace == "":
+		return name, true
+	case strings.Contains(name, `\`):
+		return n.namespace + `\` + name, true
+	}
+	return n.namespace + `\` + name, false
+}
+
+// qualifyClass returns the fully qualified name of the class name as
+// written in the namespace, following the imports.
+func (n *names) qualifyClass(name string) string {
+	if strings.HasPrefix(name, `\`) {
+		return name[1:]
+	}
+	first, rest := name, ""
+	if i := strings.Index(name, `\`); i >= 0 {
+		first, rest = name[:i], name[i:]
+	}
+	if imported, ok := n.imports[strings.ToLower(first)]; ok {
+		return imported + rest
+	}
+	qualified, _ := n.qualify(name)
+	return qualified
+}
+
+// reservedTypes are the type names that may be written in declarations
+// without naming a class.
+var reservedTypes = map[string]bool{
+	"array": true, "callable": true, "bool": true, "int": true, "float": true,
+	"string": true, "iterable": true, "object": true, "void": true,
+	"mixed": true, "null": true, "false": true, "true": true, "never": true,
+	"self": true, "static": true, "parent": true,
+}
+
+// classString matches the contents of string literals that are written like
+// qualified class names, such as 'Foo\Bar' or "Foo\\Bar".
+var classString = regexp.MustCompile(`^\\{0,2}[A-Za-z_][A-Za-z0-9_]*(\\{1,2}[A-Za-z_][A-Za-z0-9_]*)+$`)
+
+// walker gathers the occurrences of symbols in a file.
+type walker struct {
+	names
+	path string
+	file *ast.File
+
+	// class and parent are the names of the class being walked and of the
+	// class it extends.
+	class, parent string
+
+	// fn is the function being walked, or nil at the top level, and vars
+	// maps the names of the variables seen in it to their symbols.
+	fn   ast.Node
+	vars map[string]Symbol
+
+	globals     map[string]Symbol
+	occurrences []Occurrence
+
+	// parents maps each class to the class it extends, both in lower case,
+	// and supertypes maps each class to the classes and interfaces it
+	// extends or implements.
+	parents    map[string]string
+	supertypes map[string][]string
+}
+
+func newWalker(path string, file *ast.File) *walker {
+	w := &walker{
+		names:      names{imports: map[string]string{}},
+		path:       path,
+		file:       file,
+		globals:    map[string]Symbol{},
+		parents:    map[string]string{},
+		supertypes: map[string][]string{},
+	}
+	if file.Namespace != nil && file.Namespace.Name != "/" {
+		w.namespace = file.Namespace.Name
+	}
+	w.vars = w.globals
+	return w
+}
+
+// walkFile walks the nodes of the file, and then the class names of its
+// declarations.
+func (w *walker) walkFile() {
+	w.walkNodes(w.file.Nodes)
+	for _, name := range w.file.ClassNames {
+		if reservedTypes[strings.ToLower(name.Name)] {
+			continue
+		}
+		w.addSpan(Symbol{Kind: Class, Name: w.qualifyClass(name.Name)}, nil, name.Span, false)
+	}
+}
+
+// add records an occurrence of s named by n, whose span is found in spans.
+func (w *walker) add(s Symbol, n ast.Node, spans ast.Positions, definition bool) *Occurrence {
+	span, ok := spans[n]
+	if !ok {
+		return nil
+	}
+	return w.addSpan(s, n, span, definition)
+}
+
+// addSpan records an occurrence of s named by n, spanning span.
+func (w *walker) addSpan(s Symbol, n ast.Node, span ast.Span, definition bool) *Occurrence {
+	w.occurrences = append(w.occurrences, Occurrence{
+		Symbol:     s,
+		File:       w.path,
+		Span:       span,
+		Definition: definition,
+		Node:       n,
+	})
+	return &w.occurrences[len(w.occurrences)-1]
+}
+
+// addGlobal records a reference to the function or constant name, which
+// if unqualified may refer to either a name in the current namespace or a
+// global one.
+func (w *walker) addGlobal(kind Kind, name string, n ast.Node) {
+	qualified, ok := w.qualify(name)
+	if ok {
+		w.add(Symbol{Kind: kind, Name: qualified}, n, w.file.Positions, false)
+		return
+	}
+	if o := w.add(Symbol{Kind: kind, Name: name}, n, w.file.Positions, false); o != nil {
+		o.namespace = w.namespace
+	}
+}
+
+// className returns the name of the class named by n, resolving self,
+// static and parent, or "" if it is not known statically.
+func (w *walker) className(n ast.Dynamic) string {
+	id := ast.Static(n)
+	if id == nil {
+		w.walk(n)
+		return ""
+	}
+	switch strings.ToLower(id.Value) {
+	case "self", "static":
+		return w.class
+	case "parent":
+		return w.parent
+	}
+	name := w.qualifyClass(id.Value)
+	w.add(Symbol{Kind: Class, Name: name}, id, w.file.Positions, false)
+	return name
+}
+
+// receiverClass returns the class of the object r, which is known only for
+// $this.
+func (w *walker) receiverClass(r ast.Dynamic) string {
+	if v, ok := r.(*ast.Variable); ok {
+		if id := ast.Static(v.Name); id != nil && id.Value == "this" {
+			return w.class
+		}
+	}
+	w.walk(r)
+	return ""
+}
+
+// variable records an occurrence of the variable v. The first occurrence
+// of a variable in a function defines it.
+func (w *walker) variable(v *ast.Variable, definition bool) {
+	id := ast.Static(v.Name)
+	if id == nil {
+		w.walk(v.Name)
+		return
+	}
+	if id.Value == "this" {
+		return
+	}
+	if ast.SuperGlobals[id.Value] {
+		w.add(Symbol{Kind: Variable, Name: id.Value}, v, w.file.Positions, false)
+		return
+	}
+	s, ok := w.vars[id.Value]
+	if !ok {
+		s = Symbol{Kind: Variable, Name: id.Value, Func: w.fn}
+		w.vars[id.Value] = s
+		definition = true
+	}
+	w.add(s, v, w.file.Positions, definition)
+}
+
+func (w *walker) walkNodes(nodes []ast.Node) {
+	for _, n := range nodes {
+		w.walk(n)
+	}
+}
+
+func (w *walker) walk(n ast.Node) {
+	if ast.IsNil(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *ast.UseStmt:
+		name := strings.TrimPrefix(n.Name, `\`)
+		alias := n.Alias
+		if alias == "" {
+			alias = name[strings.LastIndex(name, `\`)+1:]
+		}
+		w.imports[strings.ToLower(alias)] = name
+		w.add(Symbol{Kind: Class, Name: name}, n, w.file.Names, false)
+	case *ast.Class:
+		w.walkClass(n)
+	case *ast.Interface:
+		name, _ := w.qualify(n.Name)
+		w.add(Symbol{Kind: Class, Name: name}, n, w.file.Names, true)
+		for _, super := range n.Inherits {
+			w.supertypes[strings.ToLower(name)] = append(w.supertypes[strings.ToLower(name)], w.qualifyClass(super))
+		}
+		class := w.class
+		w.class = name
+		for _, m := range n.Methods {
+			w.add(Symbol{Kind: Method, Class: name, Name: m.Name}, m.FunctionDefinition, w.file.Names, true)
+			w.walkFunction(m.FunctionStmt, m.Arguments, nil, nil)
+		}
+		w.class = class
+	case *ast.FunctionStmt:
+		name, _ := w.qualify(n.Name)
+		w.add(Symbol{Kind: Function, Name: name}, n.FunctionDefinition, w.file.Names, true)
+		w.walkFunction(n, n.Arguments, nil, n.Body)
+	case *ast.AnonymousFunction:
+		w.walkFunction(n, n.Arguments, n.ClosureVariables, n.Body)
+	case *ast.GlobalDeclaration:
+		for _, v := range n.Identifiers {
+			id := ast.Static(v.Name)
+			if id == nil {
+				continue
+			}
+			s, ok := w.globals[id.Value]
+			if !ok {
+				s = Symbol{Kind: Variable, Name: id.Value}
+				w.globals[id.Value] = s
+			}
+			w.vars[id.Value] = s
+			w.add(s, v, w.file.Positions, false)
+		}
+	case *ast.Variable:
+		w.variable(n, false)
+	case *ast.PropertyCallExpr:
+		class := w.receiverClass(n.Receiver)
+		if id := ast.Static(n.Name); id != nil {
+			w.add(Symbol{Kind: Property, Class: class, Name: id.Value}, id, w.file.Positions, false)
+		} else {
+			w.walk(n.Name)
+		}
+	case *ast.MethodCallExpr:
+		class := w.receiverClass(n.Receiver)
+		w.call(Method, class, n.FunctionCallExpr)
+	case *ast.ClassExpr:
+		w.walkClassExpr(n)
+	case *ast.NewCallExpr:
+		w.className(n.Class)
+		for _, arg := range n.Arguments {
+			w.walk(arg)
+		}
+	case *ast.FunctionCallExpr:
+		w.walkFunctionCall(n)
+	case *ast.ExprStmt:
+		w.walk(n.Expr)
+	case ast.BinaryExpr:
+		w.walk(n.Antecedent)
+		if c, ok := n.Subsequent.(ast.ConstantExpr); ok && n.Operator == "instanceof" {
+			w.className(c.Name)
+			break
+		}
+		w.walk(n.Subsequent)
+	case ast.ConstantExpr:
+		id := ast.Static(n.Name)
+		if id == nil {
+			return
+		}
+		switch strings.ToLower(id.Value) {
+		case "true", "false", "null":
+			return
+		}
+		w.addGlobal(Constant, id.Value, n.Variable)
+	case *ast.Literal:
+		w.literal(n)
+	case *ast.CatchStmt:
+		if n.CatchVar != nil {
+			w.variable(n.CatchVar, true)
+		}
+		w.walk(n.CatchBlock)
+	default:
+		w.walkNodes(n.Children())
+	}
+}
+
+func (w *walker) walkClass(c *ast.Class) {
+	name, _ := w.qualify(c.Name)
+	w.add(Symbol{Kind: Class, Name: name}, c, w.file.Names, true)
+	class, parent := w.class, w.parent
+	w.class, w.parent = name, ""
+	if c.Extends != "" {
+		w.parent = w.qualifyClass(c.Extends)
+		w.parents[strings.ToLower(name)] = strings.ToLower(w.parent)
+		w.supertypes[strings.ToLower(name)] = append(w.supertypes[strings.ToLower(name)], w.parent)
+	}
+	for _, iface := range c.Implements {
+		w.supertypes[strings.ToLower(name)] = append(w.supertypes[strings.ToLower(name)], w.qualifyClass(iface))
+	}
+
+	for _, k := range c.Constants {
+		w.add(Symbol{Kind: ClassConstant, Class: name, Name: k.Name}, k, w.file.Names, true)
+		w.walkNodes(k.Children())
+	}
+	for _, p := range c.Properties {
+		w.add(Symbol{Kind: Property, Class: name, Name: strings.TrimPrefix(p.Name, "$")}, p, w.file.Names, true)
+		w.walk(p.Initialization)
+	}
+	for _, m := range c.Methods {
+		w.add(Symbol{Kind: Method, Class: name, Name: m.Name}, m.FunctionDefinition, w.file.Names, true)
+		w.walkFunction(m, m.Arguments, nil, m.Body)
+	}
+	w.class, w.parent = class, parent
+}
+
+// walkFunction walks a function, method or closure fn with its own
+// variables. Closure variables refer to the variables of the enclosing
+// function.
+func (w *walker) walkFunction(fn ast.Node, args, closure []*ast.FunctionArgument, body *ast.Block) {
+	outer := w.vars
+	inner := map[string]Symbol{}
+	for _, c := range closure {
+		w.vars = outer
+		w.variable(c.Variable, false)
+		if id := ast.Static(c.Variable.Name); id != nil {
+			if s, ok := outer[id.Value]; ok {
+				inner[id.Value] = s
+			}
+		}
+	}
+
+	f := w.fn
+	w.fn, w.vars = fn, inner
+	for _, arg := range args {
+		w.walk(arg.Default)
+		if arg.Variable != nil {
+			w.variable(arg.Variable, true)
+		}
+	}
+	if body != nil {
+		w.walk(body)
+	}
+	w.fn, w.vars = f, outer
+}
+
+func (w *walker) walkClassExpr(n *ast.ClassExpr) {
+	class := w.className(n.Receiver)
+	switch e := n.Expr.(type) {
+	case *ast.FunctionCallExpr:
+		w.call(Method, class, e)
+	case ast.ConstantExpr:
+		if id := ast.Static(e.Name); id != nil && id.Value != "class" {
+			w.add(Symbol{Kind: ClassConstant, Class: class, Name: id.Value}, e.Variable, w.file.Positions, false)
+		}
+	case *ast.Variable:
+		if id := ast.Static(e.Name); id != nil {
+			w.add(Symbol{Kind: Property, Class: class, Name: id.Value}, e, w.file.Positions, false)
+		} else {
+			w.walk(e.Name)
+		}
+	default:
+		w.walk(e)
+	}
+}
+
+// call records a call to the method named by f, of the given class.
+func (w *walker) call(kind Kind, class string, f *ast.FunctionCallExpr) {
+	if id := ast.Static(f.FunctionName); id != nil {
+		w.add(Symbol{Kind: kind, Class: class, Name: id.Value}, id, w.file.Positions, false)
+	} else {
+		w.walk(f.FunctionName)
+	}
+	for _, arg := range f.Arguments {
+		w.walk(arg)
+	}
+}
+
+func (w *walker) walkFunctionCall(f *ast.FunctionCallExpr) {
+	id := ast.Static(f.FunctionName)
+	if id == nil {
+		w.walk(f.FunctionName)
+		w.walkNodes(f.Children()[1:])
+		return
+	}
+	w.addGlobal(Function, id.Value, id)
+
+	// define('NAME', value) defines a constant
+	if strings.EqualFold(id.Value, "define") && len(f.Arguments) > 0 {
+		if lit, ok := f.Arguments[0].(*ast.Literal); ok {
+			if span, ok := w.stringContents(lit); ok {
+				w.addSpan(Symbol{Kind: Constant, Name: lit.Value[1 : len(lit.Value)-1]}, lit, span, true)
+			}
+			for _, arg := range f.Arguments[1:] {
+				w.walk(arg)
+			}
+			return
+		}
+	}
+	for _, arg := range f.Arguments {
+		w.walk(arg)
+	}
+}
+
+// literal records a reference to a class by a string literal containing its
+// qualified name.
+func (w *walker) literal(lit *ast.Literal) {
+	span, ok := w.stringContents(lit)
+	if !ok {
+		return
+	}
+	contents := lit.Value[1 : len(lit.Value)-1]
+	if !classString.MatchString(contents) {
+		return
+	}
+	if lit.Value[0] == '"' {
+		contents = strings.Replace(contents, `\\`, `\`, -1)
+	}
+	w.addSpan(Symbol{Kind: Class, Name: strings.TrimPrefix(contents, `\`)}, lit, span, false)
+}
+
+// stringContents returns the span of the contents of the quoted string
+// literal lit, if it is written on a single line.
+func (w *walker) stringContents(lit *ast.Literal) (ast.Span, bool) {
+	if lit.Type != ast.String || len(lit.Value) < 2 {
+		return ast.Span{}, false
+	}
+	if q := lit.Value[0]; q != '\'' && q != '"' || lit.Value[len(lit.Value)-1] != q {
+		return ast.Span{}, false
+	}
+	span, ok := w.file.Positions[lit]
+	if !ok || span.Begin.Line != span.End.Line {
+		return ast.Span{}, false
+	}
+	span.Begin.Position++
+	span.Begin.Column++
+	span.End.Position--
+	span.End.Column--
+	return span, true
+}
@@ -0,0 +1,381 @@
+package taint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/passes/cfg"
+	"github.com/stephens2424/php/passes/dataflow"
+)
+
+// analyzer analyzes a file set.
+type analyzer struct {
+	config Config
+
+	// kinds holds the bit of each kind of sink, and all those of all
+	// kinds.
+	kinds map[string]uint64
+	all   uint64
+
+	units     []*unit
+	functions map[string][]*unit
+	methods   map[string][]*unit
+
+	// classMethods holds the methods by their class and name, as in
+	// "class::method", lower case.
+	classMethods map[string][]*unit
+
+	origins map[originKey]*origin
+
+	// report is set once the summaries are complete, for the findings to
+	// be recorded.
+	report   bool
+	findings map[string]Finding
+}
+
+// unit is a function, method, closure or the code of a file outside of
+// functions, analyzed on its own.
+type unit struct {
+	path string
+	file *ast.File
+
+	// name is the name of the function, or "" for a file.
+	name   string
+	params []string
+	graph  *cfg.Graph
+
+	// returns holds the taint of the result of the function, and hits the
+	// sinks that its parameters reach, with the path from the parameter
+	// to each.
+	returns set
+	hits    map[hit]*trace
+}
+
+// hit is a parameter of a function reaching a sink of the kind kind,
+// clean for the kinds of sinks in mask.
+type hit struct {
+	param int
+	mask  uint64
+	kind  string
+}
+
+// trace is the path to a sink named sink.
+type trace struct {
+	steps []Step
+	sink  string
+}
+
+// origin is where a tainted value comes from: a source, or a parameter of
+// the function analyzed.
+type origin struct {
+	originKey
+
+	// source is the source, as written, and path the steps from it to
+	// where the value enters the function analyzed, for a source.
+	source string
+	path   []Step
+}
+
+// originKey identifies an origin: the parameter param of the function u,
+// or if param is negative, the source root, whose value enters the
+// function analyzed at entry, the source itself or a call. The value is
+// clean for the kinds of sinks in mask.
+type originKey struct {
+	u           *unit
+	param       int
+	root, entry ast.Node
+	mask        uint64
+}
+
+// set is a set of origins, the taint of a value.
+type set map[*origin]bool
+
+func (s set) copy() set {
+	c := make(set, len(s))
+	for o := range s {
+		c[o] = true
+	}
+	return c
+}
+
+func (s set) add(t set) {
+	for o := range t {
+		s[o] = true
+	}
+}
+
+// vars holds the taint of each tainted variable, by name: the facts of
+// the analysis of a unit.
+type vars map[string]set
+
+func (v vars) copy() vars {
+	c := make(vars, len(v))
+	for name, t := range v {
+		c[name] = t
+	}
+	return c
+}
+
+func newAnalyzer(fs *ast.FileSet, config Config) *analyzer {
+	a := &analyzer{
+		config:       config,
+		kinds:        map[string]uint64{},
+		functions:    map[string][]*unit{},
+		methods:      map[string][]*unit{},
+		classMethods: map[string][]*unit{},
+		origins:      map[originKey]*origin{},
+		findings:     map[string]Finding{},
+	}
+	kind := func(k string) {
+		if _, ok := a.kinds[k]; !ok && len(a.kinds) < 64 {
+			a.kinds[k] = 1 << uint(len(a.kinds))
+			a.all |= a.kinds[k]
+		}
+	}
+	for _, s := range config.Sinks {
+		kind(s.Kind)
+	}
+	for _, s := range config.Sanitizers {
+		for _, k := range s.Kinds {
+			kind(k)
+		}
+	}
+
+	paths := make([]string, 0, len(fs.Files))
+	for path := range fs.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		f := fs.Files[path]
+		a.units = append(a.units, &unit{path: path, file: f, graph: cfg.File(f)})
+		for _, n := range f.Nodes {
+			a.walk(path, f, n)
+		}
+	}
+	return a
+}
+
+// walk adds the units of the functions, methods and closures in n, of the
+// file f.
+func (a *analyzer) walk(path string, f *ast.File, n ast.Node) {
+	if ast.IsNil(n) {
+		return
+	}
+	add := func(name string, args []*ast.FunctionArgument, n ast.Node) *unit {
+		g := cfg.Function(n)
+		if g == nil {
+			return nil
+		}
+		u := &unit{path: path, file: f, name: name, graph: g}
+		for _, arg := range args {
+			u.params = append(u.params, arg.Variable.String())
+		}
+		a.units = append(a.units, u)
+		return u
+	}
+	switch n := n.(type) {
+	case *ast.FunctionStmt:
+		if u := add(n.Name, n.Arguments, n); u != nil {
+			name := strings.ToLower(n.Name)
+			a.functions[name] = append(a.functions[name], u)
+		}
+	case *ast.Class:
+		for _, m := range n.Methods {
+			if u := add(n.Name+"::"+m.Name, m.Arguments, m); u != nil {
+				name := strings.ToLower(m.Name)
+				a.methods[name] = append(a.methods[name], u)
+				name = strings.ToLower(n.Name) + "::" + name
+				a.classMethods[name] = append(a.classMethods[name], u)
+			}
+			if m.Body != nil {
+				a.walk(path, f, m.Body)
+			}
+		}
+		return
+	case *ast.AnonymousFunction:
+		add("{closure}", n.Arguments, n)
+	}
+	for _, child := range n.Children() {
+		a.walk(path, f, child)
+	}
+}
+
+// summarize analyzes the unit u, and reports whether its summary changed.
+func (a *analyzer) summarize(u *unit) bool {
+	r := dataflow.Solve(u.graph, analysis{a, u})
+	returns, hits := set{}, map[hit]*trace{}
+	for _, b := range u.graph.Blocks {
+		facts := r.Facts(b)
+		for i, n := range b.Nodes {
+			e := &evaluator{a: a, u: u, vars: facts[i].(vars).copy(), returns: returns, hits: hits}
+			e.node(n)
+		}
+	}
+
+	changed := len(returns) != len(u.returns) || len(hits) != len(u.hits)
+	for o := range returns {
+		if !u.returns[o] {
+			changed = true
+		}
+	}
+	for h, t := range u.hits {
+		if _, ok := hits[h]; ok {
+			// keep the path found first
+			hits[h] = t
+		} else {
+			changed = true
+		}
+	}
+	u.returns, u.hits = returns, hits
+	return changed
+}
+
+// analysis is the data-flow analysis of the taint of the variables of a
+// unit.
+type analysis struct {
+	a *analyzer
+	u *unit
+}
+
+func (analysis) Direction() dataflow.Direction { return dataflow.Forward }
+
+func (s analysis) Boundary() dataflow.Fact {
+	v := vars{}
+	for i, p := range s.u.params {
+		v[strings.TrimPrefix(p, "$")] = set{s.a.param(s.u, i, 0): true}
+	}
+	return v
+}
+
+func (analysis) Top() dataflow.Fact { return vars{} }
+
+func (analysis) Meet(x, y dataflow.Fact) dataflow.Fact {
+	v := x.(vars).copy()
+	for name, t := range y.(vars) {
+		if v[name] == nil {
+			v[name] = t
+			continue
+		}
+		u := v[name].copy()
+		u.add(t)
+		v[name] = u
+	}
+	return v
+}
+
+func (analysis) Equal(x, y dataflow.Fact) bool {
+	v, w := x.(vars), y.(vars)
+	if len(v) != len(w) {
+		return false
+	}
+	for name, t := range v {
+		u, ok := w[name]
+		if !ok || len(t) != len(u) {
+			return false
+		}
+		for o := range t {
+			if !u[o] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s analysis) Transfer(b *cfg.Block, i int, f dataflow.Fact) dataflow.Fact {
+	e := &evaluator{a: s.a, u: s.u, vars: f.(vars).copy(), quiet: true}
+	e.node(b.Nodes[i])
+	return e.vars
+}
+
+// intern returns the origin identified by key, creating it with the
+// source and path given if there is none.
+func (a *analyzer) intern(key originKey, source string, path []Step) *origin {
+	if o, ok := a.origins[key]; ok {
+		return o
+	}
+	o := &origin{originKey: key, source: source, path: path}
+	a.origins[key] = o
+	return o
+}
+
+// param returns the origin of the parameter i of the unit u, clean for the
+// kinds of sinks in mask.
+func (a *analyzer) param(u *unit, i int, mask uint64) *origin {
+	return a.intern(originKey{u: u, param: i, mask: mask}, "", nil)
+}
+
+// source returns the origin of the source n of the unit u.
+func (a *analyzer) source(u *unit, n ast.Node) *origin {
+	text := cfg.Label(n)
+	return a.intern(originKey{param: -1, root: n, entry: n}, text, []Step{a.step(u, n, "source "+text)})
+}
+
+// enter returns the origin of the value of o entering a function at the
+// node entry, by the step step, clean for the kinds of sinks in mask too,
+// or nil if it is clean for all of them.
+func (a *analyzer) enter(o *origin, entry ast.Node, mask uint64, step Step) *origin {
+	mask |= o.mask
+	if mask&a.all == a.all {
+		return nil
+	}
+	if o.param >= 0 {
+		return a.param(o.u, o.param, mask)
+	}
+	path := append(append([]Step{}, o.path...), step)
+	return a.intern(originKey{param: -1, root: o.root, entry: entry, mask: mask}, o.source, path)
+}
+
+// clean returns the origin of the value of o after a sanitizer cleans it
+// for the kinds of sinks in mask, or nil if it is clean for all of them.
+func (a *analyzer) clean(o *origin, mask uint64) *origin {
+	mask |= o.mask
+	switch {
+	case mask&a.all == a.all:
+		return nil
+	case mask == o.mask:
+		return o
+	case o.param >= 0:
+		return a.param(o.u, o.param, mask)
+	}
+	return a.intern(originKey{param: -1, root: o.root, entry: o.entry, mask: mask}, o.source, o.path)
+}
+
+// step returns the step at the node n of the unit u.
+func (a *analyzer) step(u *unit, n ast.Node, text string) Step {
+	span, _ := u.file.Positions.Span(n)
+	return Step{Path: u.path, Span: span, Text: text}
+}
+
+// find records that the value from the source of o reaches a sink of the
+// kind kind named sink, along the steps that follow the path of o.
+func (a *analyzer) find(o *origin, kind, sink string, steps []Step) {
+	if !a.report {
+		return
+	}
+	steps = append(append([]Step{}, o.path...), steps...)
+	first, last := steps[0], steps[len(steps)-1]
+	key := fmt.Sprintf("%s %s:%d %s:%d", kind, first.Path, first.Span.Begin.Position, last.Path, last.Span.Begin.Position)
+	if _, ok := a.findings[key]; !ok {
+		a.findings[key] = Finding{Kind: kind, Source: o.source, Sink: sink, Steps: steps}
+	}
+}
+
+// spec returns the name, in lower case, of the function, or method if
+// method is set, that the name spec of the configuration matches, or "" if
+// it names neither.
+func spec(spec string, method bool) string {
+	if strings.HasPrefix(spec, "->") {
+		if !method {
+			return ""
+		}
+		return strings.ToLower(spec[2:])
+	}
+	if method {
+		return ""
+	}
+	return strings.ToLower(spec[strings.LastIndex(spec, `\`)+1:])
+}
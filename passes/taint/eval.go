@@ -0,0 +1,590 @@
+package taint
+
+import (
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/passes/dataflow"
+)
+
+// evaluator evaluates the taint of the nodes of a unit, updating that of
+// its variables.
+type evaluator struct {
+	a    *analyzer
+	u    *unit
+	vars vars
+
+	// returns and hits collect the summary of the unit, unless quiet is
+	// set, as it is while the taint of the variables is solved.
+	returns set
+	hits    map[hit]*trace
+	quiet   bool
+}
+
+// node evaluates the node n of a block, which stands only for its header
+// if it is a foreach loop or catch clause.
+func (e *evaluator) node(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.ForeachStmt:
+		// the source was evaluated before the loop
+		quiet := e.quiet
+		e.quiet = true
+		t := e.expr(n.Source)
+		e.quiet = quiet
+		if n.Key != nil {
+			e.assign(n.Key, t)
+		}
+		e.assign(n.Value, t)
+	case *ast.CatchStmt:
+		if n.CatchVar != nil {
+			e.assign(n.CatchVar, nil)
+		}
+	case *ast.EchoStmt:
+		e.statement("echo", n.Expressions, n)
+	case ast.ReturnStmt:
+		e.ret(n.Expr)
+	case *ast.ReturnStmt:
+		e.ret(n.Expr)
+	case *ast.GlobalDeclaration:
+		for _, v := range n.Identifiers {
+			e.assign(v, nil)
+		}
+	case *ast.StaticVariableDeclaration:
+		for _, d := range n.Declarations {
+			if assignment, ok := d.(*ast.AssignmentExpr); ok {
+				e.expr(assignment.Value)
+				d = assignment.Assignee
+			}
+			e.assign(d, nil)
+		}
+	case *ast.FunctionStmt, *ast.Class, *ast.Interface:
+	default:
+		e.expr(n)
+	}
+}
+
+// ret evaluates the returned expression n.
+func (e *evaluator) ret(n ast.Node) {
+	t := e.expr(n)
+	if !e.quiet {
+		e.returns.add(t)
+	}
+}
+
+// expr evaluates the expression n and returns its taint, which must not be
+// modified.
+func (e *evaluator) expr(n ast.Node) set {
+	if ast.IsNil(n) {
+		return nil
+	}
+	switch n := n.(type) {
+	case *ast.Variable:
+		name := ast.Static(n.Name)
+		if name == nil {
+			e.expr(n.Name)
+			return nil
+		}
+		if e.a.superglobal(name.Value) {
+			return set{e.a.source(e.u, n): true}
+		}
+		return e.vars[name.Value]
+	case *ast.ArrayLookupExpr:
+		root := n
+		for {
+			e.expr(root.Index)
+			array, ok := root.Array.(*ast.ArrayLookupExpr)
+			if !ok {
+				break
+			}
+			root = array
+		}
+		if v, ok := root.Array.(*ast.Variable); ok {
+			if name := ast.Static(v.Name); name != nil && e.a.superglobal(name.Value) {
+				return set{e.a.source(e.u, n): true}
+			}
+		}
+		return e.expr(root.Array)
+	case *ast.PropertyCallExpr:
+		e.expr(n.Name)
+		return e.expr(n.Receiver)
+	case ast.AssignmentExpr:
+		return e.assignment(n)
+	case *ast.AssignmentExpr:
+		return e.assignment(*n)
+	case *ast.ListStatement:
+		t := e.expr(n.Value)
+		for _, assignee := range n.Assignees {
+			e.assign(assignee, t)
+		}
+		return t
+	case ast.BinaryExpr:
+		t := union(e.expr(n.Antecedent), e.expr(n.Subsequent))
+		if n.Operator == "." {
+			return t
+		}
+		return nil
+	case ast.TernaryCallExpr:
+		e.expr(n.Condition)
+		return union(e.expr(n.True), e.expr(n.False))
+	case *ast.TernaryCallExpr:
+		return e.expr(*n)
+	case ast.UnaryCallExpr:
+		t := e.expr(n.Operand)
+		switch n.Operator {
+		case "++", "--":
+			e.assign(n.Operand, nil)
+			return nil
+		case "!", "-", "+", "~", "(int)", "(integer)", "(float)", "(double)", "(real)", "(bool)", "(boolean)", "(unset)":
+			return nil
+		}
+		return t
+	case *ast.Literal:
+		return e.interpolated(n)
+	case *ast.ShellCommand:
+		e.sink("`", e.interpolated(n), n)
+		return nil
+	case ast.Include:
+		e.statement("include", n.Expressions, n)
+		return nil
+	case *ast.Include:
+		e.statement("include", n.Expressions, n)
+		return nil
+	case ast.IncludeStmt:
+		e.statement("include", n.Expressions, n)
+		return nil
+	case *ast.IncludeStmt:
+		e.statement("include", n.Expressions, n)
+		return nil
+	case *ast.FunctionCallExpr:
+		name := ast.Static(n.FunctionName)
+		if name == nil {
+			e.expr(n.FunctionName)
+			return e.call(n, "", false, "", n.Arguments, nil)
+		}
+		return e.call(n, name.Value, false, "", n.Arguments, nil)
+	case *ast.MethodCallExpr:
+		recv := e.expr(n.Receiver)
+		name := ast.Static(n.FunctionName)
+		if name == nil {
+			e.expr(n.FunctionName)
+			return e.call(n, "", true, "", n.Arguments, recv)
+		}
+		return e.call(n, name.Value, true, "", n.Arguments, recv)
+	case *ast.ClassExpr:
+		call, ok := n.Expr.(*ast.FunctionCallExpr)
+		if !ok {
+			// a static property or class constant
+			return nil
+		}
+		class := ""
+		if id := ast.Static(n.Receiver); id != nil {
+			class = id.Value
+		} else {
+			e.expr(n.Receiver)
+		}
+		name := ast.Static(call.FunctionName)
+		if name == nil {
+			e.expr(call.FunctionName)
+			return e.call(n, "", true, class, call.Arguments, nil)
+		}
+		return e.call(n, name.Value, true, class, call.Arguments, nil)
+	case *ast.NewCallExpr:
+		class := ""
+		if id := ast.Static(n.Class); id != nil {
+			class = id.Value
+		} else {
+			e.expr(n.Class)
+		}
+		return e.call(n, "__construct", true, class, n.Arguments, nil)
+	case ast.ConstantExpr, *ast.AnonymousFunction, *ast.FunctionStmt, *ast.Class, *ast.Interface:
+		return nil
+	}
+	var t set
+	for _, child := range n.Children() {
+		t = union(t, e.expr(child))
+	}
+	return t
+}
+
+// assignment evaluates the assignment n.
+func (e *evaluator) assignment(n ast.AssignmentExpr) set {
+	if ref, ok := n.Value.(ast.UnaryCallExpr); ok && ref.Operator == "&" {
+		t := e.expr(ref.Operand)
+		e.assign(n.Assignee, t)
+		return t
+	}
+	t := e.expr(n.Value)
+	switch n.Operator {
+	case "=":
+	case ".=":
+		t = union(e.expr(n.Assignee), t)
+	default:
+		t = nil
+	}
+	e.assign(n.Assignee, t)
+	return t
+}
+
+// assign assigns a value with the taint t to n. Assigning an element or
+// property of a variable adds to its taint.
+func (e *evaluator) assign(n ast.Node, t set) {
+	switch n := n.(type) {
+	case *ast.Variable:
+		name := ast.Static(n.Name)
+		if name == nil {
+			e.expr(n.Name)
+			return
+		}
+		if len(t) == 0 {
+			delete(e.vars, name.Value)
+		} else {
+			e.vars[name.Value] = t
+		}
+	case *ast.ArrayLookupExpr:
+		e.expr(n.Index)
+		e.weak(n.Array, t)
+	case ast.ArrayAppendExpr:
+		e.weak(n.Array, t)
+	case *ast.ArrayAppendExpr:
+		e.weak(n.Array, t)
+	case *ast.PropertyCallExpr:
+		e.expr(n.Name)
+		e.weak(n.Receiver, t)
+	case *ast.ListStatement:
+		for _, assignee := range n.Assignees {
+			e.assign(assignee, t)
+		}
+	default:
+		e.expr(n)
+	}
+}
+
+// weak adds the taint t to the variable that n is, or is an element or
+// property of.
+func (e *evaluator) weak(n ast.Node, t set) {
+	switch n := n.(type) {
+	case *ast.Variable:
+		if name := ast.Static(n.Name); name != nil && len(t) > 0 {
+			e.vars[name.Value] = union(e.vars[name.Value], t)
+		}
+	case *ast.ArrayLookupExpr:
+		e.expr(n.Index)
+		e.weak(n.Array, t)
+	case ast.ArrayAppendExpr:
+		e.weak(n.Array, t)
+	case *ast.ArrayAppendExpr:
+		e.weak(n.Array, t)
+	case *ast.PropertyCallExpr:
+		e.weak(n.Receiver, t)
+	}
+}
+
+// interpolated returns the taint of the variables interpolated in n.
+func (e *evaluator) interpolated(n ast.Node) set {
+	var t set
+	for _, name := range dataflow.Interpolated(n) {
+		if e.a.superglobal(name) {
+			t = union(t, set{e.a.source(e.u, n): true})
+		} else {
+			t = union(t, e.vars[name])
+		}
+	}
+	return t
+}
+
+// statement evaluates the expressions exprs of the statement n, whose
+// name is the name of the sink it may be.
+func (e *evaluator) statement(name string, exprs []ast.Expr, n ast.Node) {
+	var t set
+	for _, expr := range exprs {
+		t = union(t, e.expr(expr))
+	}
+	e.sink(name, t, n)
+}
+
+// sink checks a value with the taint t reaching the sinks named name at
+// the node n.
+func (e *evaluator) sink(name string, t set, n ast.Node) {
+	if len(t) == 0 {
+		return
+	}
+	for _, s := range e.a.config.Sinks {
+		if s.Name == name {
+			e.reach(t, s, n)
+		}
+	}
+}
+
+// reach checks a value with the taint t reaching the sink s at the node n.
+func (e *evaluator) reach(t set, s Sink, n ast.Node) {
+	steps := []Step{e.a.step(e.u, n, "sink "+s.Name)}
+	for o := range t {
+		e.hit(o, s.Kind, s.Name, steps)
+	}
+}
+
+// hit records that the value of o reaches a sink of the kind kind named
+// sink, along steps.
+func (e *evaluator) hit(o *origin, kind, sink string, steps []Step) {
+	if e.quiet || o.mask&e.a.kinds[kind] != 0 {
+		return
+	}
+	if o.param < 0 {
+		e.a.find(o, kind, sink, steps)
+		return
+	}
+	h := hit{param: o.param, mask: o.mask, kind: kind}
+	if _, ok := e.hits[h]; !ok {
+		e.hits[h] = &trace{steps: steps, sink: sink}
+	}
+}
+
+// call evaluates the call n to the function or method named name, or
+// dynamic if name is empty, with the arguments args. A method is called
+// statically on the class class, if it is not empty, and otherwise on a
+// receiver with the taint recv.
+func (e *evaluator) call(n ast.Node, name string, method bool, class string, args []ast.Expr, recv set) set {
+	lower := strings.ToLower(name[strings.LastIndex(name, `\`)+1:])
+	if !method {
+		switch lower {
+		case "isset", "empty":
+			for _, arg := range args {
+				e.expr(arg)
+			}
+			return nil
+		case "unset":
+			for _, arg := range args {
+				e.assign(arg, nil)
+			}
+			return nil
+		case "compact":
+			var t set
+			for _, arg := range args {
+				t = union(t, e.compact(arg))
+			}
+			return t
+		}
+	}
+
+	ts := make([]set, len(args))
+	for i, arg := range args {
+		ts[i] = e.expr(arg)
+	}
+	if lower == "" {
+		return e.unknown(args, ts, recv)
+	}
+
+	known := false
+	for _, s := range e.a.config.Sinks {
+		if spec(s.Name, method) != lower {
+			continue
+		}
+		known = true
+		for i, t := range ts {
+			if len(t) > 0 && (len(s.Arguments) == 0 || contains(s.Arguments, i)) {
+				e.reach(t, s, n)
+			}
+		}
+	}
+
+	var result set
+	sanitizer, mask := false, uint64(0)
+	for _, s := range e.a.config.Sanitizers {
+		if spec(s.Name, method) != lower {
+			continue
+		}
+		sanitizer = true
+		if len(s.Kinds) == 0 {
+			mask = e.a.all
+		}
+		for _, k := range s.Kinds {
+			mask |= e.a.kinds[k]
+		}
+	}
+	if sanitizer {
+		known = true
+		for _, t := range ts {
+			for o := range t {
+				if c := e.a.clean(o, mask); c != nil {
+					result = union(result, set{c: true})
+				}
+			}
+		}
+	}
+
+	for _, s := range e.a.config.Sources {
+		if spec(s.Name, method) != lower {
+			continue
+		}
+		known = true
+		if s.Argument != "" && (len(args) == 0 || !isString(args[0], s.Argument)) {
+			continue
+		}
+		result = union(result, set{e.a.source(e.u, n): true})
+	}
+
+	if callees := e.callees(lower, method, class); len(callees) > 0 {
+		for _, c := range callees {
+			result = union(result, e.apply(c, n, args, ts))
+		}
+		return result
+	}
+	if known {
+		return result
+	}
+	return e.unknown(args, ts, recv)
+}
+
+// unknown returns the taint of the result of a call to a function that is
+// neither in the file set nor configured, with the arguments args of the
+// taints ts, on a receiver of the taint recv. It passes that of each
+// argument on to the others that are variables, which it may take by
+// reference.
+func (e *evaluator) unknown(args []ast.Expr, ts []set, recv set) set {
+	result := recv
+	for _, t := range ts {
+		result = union(result, t)
+	}
+	for i, arg := range args {
+		switch arg.(type) {
+		case *ast.Variable, *ast.ArrayLookupExpr:
+		default:
+			continue
+		}
+		var others set
+		for j, t := range ts {
+			if j != i {
+				others = union(others, t)
+			}
+		}
+		e.weak(arg, others)
+	}
+	return result
+}
+
+// callees returns the units that a call to the function or method named
+// lower, in lower case, may call, statically on the class class if it is
+// not empty.
+func (e *evaluator) callees(lower string, method bool, class string) []*unit {
+	if !method {
+		return e.a.functions[lower]
+	}
+	switch strings.ToLower(class) {
+	case "self", "static":
+		if i := strings.Index(e.u.name, "::"); i >= 0 {
+			class = e.u.name[:i]
+		} else {
+			class = ""
+		}
+	case "parent":
+		class = ""
+	}
+	if class != "" {
+		class = class[strings.LastIndex(class, `\`)+1:]
+		return e.a.classMethods[strings.ToLower(class)+"::"+lower]
+	}
+	return e.a.methods[lower]
+}
+
+// apply applies the summary of the unit c to the call n, with the
+// arguments args of the taints ts, and returns the taint of its result.
+func (e *evaluator) apply(c *unit, n ast.Node, args []ast.Expr, ts []set) set {
+	var result set
+	for o := range c.returns {
+		if o.param < 0 {
+			if r := e.a.enter(o, n, 0, e.a.step(e.u, n, "returned from "+c.name)); r != nil {
+				result = union(result, set{r: true})
+			}
+			continue
+		}
+		if o.param >= len(ts) {
+			continue
+		}
+		for p := range ts[o.param] {
+			if r := e.a.clean(p, o.mask); r != nil {
+				result = union(result, set{r: true})
+			}
+		}
+	}
+	if e.quiet {
+		return result
+	}
+	for h, tr := range c.hits {
+		if h.param >= len(ts) {
+			continue
+		}
+		var steps []Step
+		for p := range ts[h.param] {
+			r := e.a.clean(p, h.mask)
+			if r == nil {
+				continue
+			}
+			if steps == nil {
+				step := e.a.step(e.u, args[h.param], "passed to "+c.name+" as "+c.params[h.param])
+				steps = append([]Step{step}, tr.steps...)
+			}
+			e.hit(r, h.kind, tr.sink, steps)
+		}
+	}
+	return result
+}
+
+// compact returns the taint of the variables named by the argument n of
+// compact, which may be a string or an array of them.
+func (e *evaluator) compact(n ast.Node) set {
+	switch n := n.(type) {
+	case *ast.Literal:
+		if n.Type == ast.String && len(n.Value) >= 2 {
+			return e.vars[n.Value[1:len(n.Value)-1]]
+		}
+	case *ast.ArrayExpr:
+		var t set
+		for _, pair := range n.Pairs {
+			t = union(t, e.compact(pair.Value))
+		}
+		return t
+	}
+	return e.expr(n)
+}
+
+// superglobal reports whether the superglobal named name, without its $,
+// is a source.
+func (a *analyzer) superglobal(name string) bool {
+	if !ast.SuperGlobals[name] {
+		return false
+	}
+	for _, s := range a.config.Sources {
+		if s.Name == "$"+name {
+			return true
+		}
+	}
+	return false
+}
+
+// union returns the union of the taints s and t, which it does not modify.
+func union(s, t set) set {
+	switch {
+	case len(t) == 0:
+		return s
+	case len(s) == 0:
+		return t
+	}
+	u := s.copy()
+	u.add(t)
+	return u
+}
+
+// isString reports whether n is a string literal of the value s.
+func isString(n ast.Node, s string) bool {
+	l, ok := n.(*ast.Literal)
+	return ok && l.Type == ast.String && len(l.Value) >= 2 && l.Value[1:len(l.Value)-1] == s
+}
+
+func contains(indexes []int, i int) bool {
+	for _, index := range indexes {
+		if index == i {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+// Package taint tracks the values that users control, from the sources
+// that give them to the sinks where they would allow SQL injection,
+// cross-site scripting or running commands or code, unless a sanitizer
+// cleans them on the way.
+//
+// Values are tracked through the variables of each function, method,
+// closure and file along its control-flow graph, built by package cfg and
+// solved with package dataflow, and across calls to the functions and
+// methods of the file set, each summarized by what taints its result and
+// which of its parameters reach sinks. Elements and properties are not
+// told apart from the arrays and objects holding them, a method is found
+// by its name alone unless it is called statically on a named class, and
+// a function that is not in the file set passes the taint of its arguments
+// on to its result, and to the variables passed to it, which it may take
+// by reference.
+package taint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// Config gives the sources, sanitizers and sinks of the analysis. The name
+// of a function is matched regardless of case and namespace, and a name
+// beginning with -> names the methods with that name of any class, called
+// on an object or statically.
+type Config struct {
+	Sources    []Source    `json:"sources"`
+	Sanitizers []Sanitizer `json:"sanitizers"`
+	Sinks      []Sink      `json:"sinks"`
+}
+
+// Source is a source of tainted values.
+type Source struct {
+	// Name is the name of a superglobal, with its $, such as $_GET, whose
+	// value and elements are tainted, or of a function or method whose
+	// result is.
+	Name string `json:"name"`
+
+	// Argument, if it is not empty, limits a function or method to the
+	// calls whose first argument is this string, such as php://input.
+	Argument string `json:"argument,omitempty"`
+}
+
+// Sanitizer is a function or method whose result is clean.
+type Sanitizer struct {
+	Name string `json:"name"`
+
+	// Kinds holds the kinds of the sinks for which the result is clean, or
+	// is empty if it is clean for all of them.
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+// Sink is a function, method or statement that tainted values must not
+// reach.
+type Sink struct {
+	// Name is the name of a function or method, or echo, which covers
+	// print and <?= too, include, which covers include_once, require and
+	// require_once, or ` for shell commands.
+	Name string `json:"name"`
+
+	// Kind is the kind of the sink, such as sql or xss.
+	Kind string `json:"kind"`
+
+	// Arguments holds the indexes, from 0, of the arguments of a function
+	// or method that must not be tainted, or is empty if none may be.
+	Arguments []int `json:"arguments,omitempty"`
+}
+
+// DefaultConfig returns a configuration for SQL injection, cross-site
+// scripting and running commands or code with the common functions of PHP.
+// Prepared statements are safe as the values bound to them, with
+// bind_param, bindValue or execute, reach no sink, while the queries they
+// prepare do.
+func DefaultConfig() Config {
+	return Config{
+		Sources: []Source{
+			{Name: "$_GET"},
+			{Name: "$_POST"},
+			{Name: "$_COOKIE"},
+			{Name: "$_REQUEST"},
+			{Name: "file_get_contents", Argument: "php://input"},
+		},
+		Sanitizers: []Sanitizer{
+			{Name: "htmlspecialchars", Kinds: []string{"xss"}},
+			{Name: "htmlentities", Kinds: []string{"xss"}},
+			{Name: "strip_tags", Kinds: []string{"xss"}},
+			{Name: "mysql_real_escape_string", Kinds: []string{"sql"}},
+			{Name: "mysqli_real_escape_string", Kinds: []string{"sql"}},
+			{Name: "->real_escape_string", Kinds: []string{"sql"}},
+			{Name: "->quote", Kinds: []string{"sql"}},
+			{Name: "escapeshellarg", Kinds: []string{"command"}},
+			{Name: "escapeshellcmd", Kinds: []string{"command"}},
+			{Name: "intval"},
+			{Name: "floatval"},
+			{Name: "boolval"},
+		},
+		Sinks: []Sink{
+			{Name: "mysql_query", Kind: "sql", Arguments: []int{0}},
+			{Name: "mysqli_query", Kind: "sql", Arguments: []int{1}},
+			{Name: "mysqli_prepare", Kind: "sql", Arguments: []int{1}},
+			{Name: "->query", Kind: "sql", Arguments: []int{0}},
+			{Name: "->prepare", Kind: "sql", Arguments: []int{0}},
+			{Name: "echo", Kind: "xss"},
+			{Name: "eval", Kind: "code"},
+			{Name: "include", Kind: "code"},
+			{Name: "system", Kind: "command", Arguments: []int{0}},
+			{Name: "exec", Kind: "command", Arguments: []int{0}},
+			{Name: "passthru", Kind: "command", Arguments: []int{0}},
+			{Name: "shell_exec", Kind: "command", Arguments: []int{0}},
+			{Name: "`", Kind: "command"},
+		},
+	}
+}
+
+// Step is a step of the path of a tainted value.
+type Step struct {
+	// Path is the path of the file of the step, in the file set, and Span
+	// its span in it.
+	Path string
+	Span ast.Span
+
+	// Text describes the step, such as "source $_GET['id']" or "passed to
+	// find as $id".
+	Text string
+}
+
+func (s Step) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", s.Path, s.Span.Begin.Line, s.Span.Begin.Column, s.Text)
+}
+
+// Finding is a path along which a tainted value reaches a sink.
+type Finding struct {
+	// Kind is the kind of the sink.
+	Kind string
+
+	// Source is the source, as written, and Sink the name of the sink.
+	Source string
+	Sink   string
+
+	// Steps holds the steps of the path: the source first, then the calls
+	// the value passes into and returns from, and the sink last.
+	Steps []Step
+}
+
+func (f Finding) String() string {
+	sink := f.Steps[len(f.Steps)-1]
+	return fmt.Sprintf("%s:%d:%d: %s reaches %s sink %s", sink.Path, sink.Span.Begin.Line, sink.Span.Begin.Column, f.Source, f.Kind, f.Sink)
+}
+
+// Analyze returns the paths along which tainted values reach sinks in fs,
+// with the sources, sanitizers and sinks of config, ordered by the
+// positions of their sinks, then of their sources. Of the paths from a
+// source to a sink, one is given.
+func Analyze(fs *ast.FileSet, config Config) []Finding {
+	a := newAnalyzer(fs, config)
+	for changed := true; changed; {
+		changed = false
+		for _, u := range a.units {
+			if a.summarize(u) {
+				changed = true
+			}
+		}
+	}
+	a.report = true
+	for _, u := range a.units {
+		a.summarize(u)
+	}
+
+	findings := make([]Finding, 0, len(a.findings))
+	for _, f := range a.findings {
+		findings = append(findings, f)
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		x, y := findings[i].Steps, findings[j].Steps
+		if a, b := x[len(x)-1], y[len(y)-1]; a.Path != b.Path || a.Span.Begin.Position != b.Span.Begin.Position {
+			if a.Path != b.Path {
+				return a.Path < b.Path
+			}
+			return a.Span.Begin.Position < b.Span.Begin.Position
+		}
+		if a, b := x[0], y[0]; a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return x[0].Span.Begin.Position < y[0].Span.Begin.Position
+	})
+	return findings
+}
@@ -0,0 +1,130 @@
+package taint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+func analyze(t *testing.T, src string, config Config) []string {
+	p := parser.NewParser()
+	if _, err := p.Parse("test.php", src); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range Analyze(p.FileSet, config) {
+		got = append(got, f.String())
+		for _, s := range f.Steps {
+			got = append(got, "\t"+s.String())
+		}
+	}
+	return got
+}
+
+func check(t *testing.T, got, want []string) {
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("taint:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSinks(t *testing.T) {
+	src := `<?php
+	$id = $_GET['id'];
+	mysql_query("SELECT * FROM users WHERE id = $id");
+	mysql_query("SELECT * FROM users WHERE id = " . intval($id));
+	$name = htmlspecialchars($_POST['name']);
+	echo $name;
+	mysqli_query($db, "SELECT * FROM users WHERE name = '$name'");
+	print $_COOKIE['theme'];
+	eval(file_get_contents('php://input'));
+	eval(file_get_contents('code.php'));
+	system('ls ' . $_REQUEST['dir']);
+	system('ls ' . escapeshellarg($_REQUEST['dir']));
+	include $_GET['page'] . '.php';
+	`
+	check(t, analyze(t, src, DefaultConfig()), []string{
+		"test.php:3:2: $_GET['id'] reaches sql sink mysql_query",
+		"\ttest.php:2:8: source $_GET['id']",
+		"\ttest.php:3:2: sink mysql_query",
+		"test.php:7:2: $_POST['name'] reaches sql sink mysqli_query",
+		"\ttest.php:5:27: source $_POST['name']",
+		"\ttest.php:7:2: sink mysqli_query",
+		"test.php:8:2: $_COOKIE['theme'] reaches xss sink echo",
+		"\ttest.php:8:8: source $_COOKIE['theme']",
+		"\ttest.php:8:2: sink echo",
+		"test.php:9:2: file_get_contents('php://input') reaches code sink eval",
+		"\ttest.php:9:7: source file_get_contents('php://input')",
+		"\ttest.php:9:2: sink eval",
+		"test.php:11:2: $_REQUEST['dir'] reaches command sink system",
+		"\ttest.php:11:17: source $_REQUEST['dir']",
+		"\ttest.php:11:2: sink system",
+		"test.php:13:10: $_GET['page'] reaches code sink include",
+		"\ttest.php:13:10: source $_GET['page']",
+		"\ttest.php:13:10: sink include",
+	})
+}
+
+func TestCalls(t *testing.T) {
+	src := `<?php
+	function input($name) {
+		return $_POST[$name];
+	}
+	function find($db, $id) {
+		return $db->query("SELECT * FROM users WHERE id = " . $id);
+	}
+	function show($s) {
+		echo $s;
+	}
+	function escape($s) {
+		return htmlspecialchars($s);
+	}
+	class Page {
+		function render($title) {
+			show(self::wrap($title));
+		}
+		static function wrap($s) {
+			return "<h1>$s</h1>";
+		}
+	}
+	$id = input('id');
+	find($db, $id);
+	find($db, intval($id));
+	show(escape($id));
+	$page = new Page();
+	$page->render($_GET['title']);
+	$stmt = $db->prepare("SELECT * FROM users WHERE id = ?");
+	$stmt->execute(array($id));
+	`
+	check(t, analyze(t, src, DefaultConfig()), []string{
+		"test.php:6:10: $_POST[$name] reaches sql sink ->query",
+		"\ttest.php:3:10: source $_POST[$name]",
+		"\ttest.php:22:8: returned from input",
+		"\ttest.php:23:12: passed to find as $id",
+		"\ttest.php:6:10: sink ->query",
+		"test.php:9:3: $_GET['title'] reaches xss sink echo",
+		"\ttest.php:27:16: source $_GET['title']",
+		"\ttest.php:27:16: passed to Page::render as $title",
+		"\ttest.php:16:9: passed to show as $s",
+		"\ttest.php:9:3: sink echo",
+	})
+}
+
+func TestConfig(t *testing.T) {
+	src := `<?php
+	$q = $request->param('q');
+	$db->run(clean($q));
+	$db->run($q);
+	echo $_GET['q'];
+	`
+	config := Config{
+		Sources:    []Source{{Name: "->param"}},
+		Sanitizers: []Sanitizer{{Name: "clean", Kinds: []string{"sql"}}},
+		Sinks:      []Sink{{Name: "->run", Kind: "sql"}},
+	}
+	check(t, analyze(t, src, config), []string{
+		"test.php:4:2: $request->param('q') reaches sql sink ->run",
+		"\ttest.php:2:7: source $request->param('q')",
+		"\ttest.php:4:2: sink ->run",
+	})
+}
@@ -45,13 +45,17 @@ func (t *Togo) ToGoStmt(php phpast.Statement) goast.Stmt {
 	case phpast.ConstantExpr:
 	case phpast.ContinueStmt:
 	case phpast.DoWhileStmt:
-	case phpast.EchoStmt:
+	case *phpast.EchoStmt:
 		for _, e := range n.Expressions {
 			return &goast.ExprStmt{X: t.CtxFuncCall("Echo.Write", []goast.Expr{t.ToGoExpr(e)})}
 		}
 	case phpast.EmptyStatement:
+	case phpast.InlineHTML:
+		return &goast.ExprStmt{X: t.CtxFuncCall("Echo.Write", []goast.Expr{
+			&goast.BasicLit{Kind: token.STRING, Value: strconv.Quote(n.Value)},
+		})}
 	case phpast.ExitStmt:
-	case phpast.ExprStmt:
+	case *phpast.ExprStmt:
 		switch expr := n.Expr.(type) {
 		case phpast.AssignmentExpr:
 			return t.ToGoStmt(expr)
@@ -111,7 +115,7 @@ func (t *Togo) ToGoStmt(php phpast.Statement) goast.Stmt {
 	case phpast.Statement:
 	case phpast.StaticVariableDeclaration:
 	case phpast.SwitchStmt:
-	case phpast.ThrowStmt:
+	case *phpast.ThrowStmt:
 	case phpast.TryStmt:
 
 	case phpast.Variable:
@@ -0,0 +1,505 @@
+package typecheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/builtins"
+	"github.com/stephens2424/php/passes/hierarchy"
+)
+
+// signature is the parameters of a function or method, against which the
+// arguments of calls to it are checked.
+type signature struct {
+	// name names the function in diagnostics, like "f()" or "C::m()".
+	name   string
+	params []param
+
+	// internal reports whether the function is built in, which PHP does
+	// not pass more arguments than it accepts and passes null for scalar
+	// parameters, and variadic whether it accepts any number of arguments.
+	internal bool
+	variadic bool
+}
+
+type param struct {
+	name                      string
+	typ                       ast.Type
+	optional, variadic, byRef bool
+}
+
+// param returns the parameter of the ith argument, which is the last if it
+// is variadic, and whether there is one.
+func (s signature) param(i int) (param, bool) {
+	if i < len(s.params) {
+		return s.params[i], true
+	}
+	if n := len(s.params); n > 0 && s.params[n-1].variadic {
+		return s.params[n-1], true
+	}
+	return param{}, false
+}
+
+// signature returns the signature of the function or method fn of the file
+// set, in which only declared types are known.
+func (c *checker) signature(fn *function) signature {
+	s := signature{name: describe(fn), variadic: usesArgs(fn.body)}
+	for _, arg := range fn.def.Arguments {
+		hint := arg.TypeHint
+		t := c.inFunction(fn, func() ast.Type { return c.hintType(hint) })
+		if arg.Default != nil && c.info.TypeOf(fn.file, arg.Default) == ast.Null && t != ast.Unknown {
+			t = ast.Union(t, ast.Null)
+		}
+		s.params = append(s.params, param{name: arg.Variable.String(), typ: t, optional: arg.Default != nil})
+	}
+	return s
+}
+
+// builtinSignature returns the signature of the built-in function or method
+// fn, whose name in diagnostics is name.
+func builtinSignature(fn *builtins.Function, name string) signature {
+	s := signature{name: name, internal: true}
+	for _, p := range fn.Params {
+		s.params = append(s.params, param{name: "$" + p.Name, typ: p.Type, optional: p.Optional, variadic: p.Variadic, byRef: p.ByRef})
+		s.variadic = s.variadic || p.Variadic
+	}
+	return s
+}
+
+// methodSignature returns the signature of the method name of the class,
+// and whether it has one.
+func (c *checker) methodSignature(class, name string) (signature, bool) {
+	if m := c.lookupMethod(class, name); m != nil {
+		return c.signature(m), true
+	}
+	base := c.builtinBase(class)
+	if m := c.builtins.Method(base, name); m != nil {
+		return builtinSignature(m, base+"::"+m.Name+"()"), true
+	}
+	return signature{}, false
+}
+
+// describe names the function or method fn in diagnostics.
+func describe(fn *function) string {
+	if fn.class != nil {
+		return fn.class.name + "::" + fn.def.Name + "()"
+	}
+	return fn.def.Name + "()"
+}
+
+// usesArgs reports whether body reads the arguments of its function with
+// func_get_args or similar, so that it may take more than it declares.
+func usesArgs(body *ast.Block) bool {
+	return contains(body, func(n ast.Node) bool {
+		f, ok := n.(*ast.FunctionCallExpr)
+		if !ok {
+			return false
+		}
+		id := ast.Static(f.FunctionName)
+		if id == nil {
+			return false
+		}
+		switch strings.ToLower(strings.TrimPrefix(id.Value, `\`)) {
+		case "func_get_args", "func_get_arg", "func_num_args":
+			return true
+		}
+		return false
+	})
+}
+
+// arguments checks the arguments args of the call n against s.
+func (c *checker) arguments(n ast.Node, args []ast.Expr, s signature) {
+	required := 0
+	for i, p := range s.params {
+		if !p.optional && !p.variadic {
+			required = i + 1
+		}
+	}
+	if len(args) < required {
+		c.report(n, Error, ArgumentCount, "too few arguments to %s: %d passed, %d required", s.name, len(args), required)
+	}
+	if !s.variadic && len(args) > len(s.params) {
+		severity := Warning
+		if s.internal {
+			severity = Error
+		}
+		c.report(n, severity, ArgumentCount, "too many arguments to %s: %d passed, %d accepted", s.name, len(args), len(s.params))
+	}
+	for i, arg := range args {
+		p, ok := s.param(i)
+		if !ok || p.byRef {
+			continue
+		}
+		c.checkType(arg, p.typ, s.internal, ArgumentType, fmt.Sprintf("argument %d (%s) of %s", i+1, p.name, s.name))
+	}
+}
+
+// checkType checks that the declared type accepts the value n, which is
+// described by what, reporting a problem by the rule.
+func (c *checker) checkType(n ast.Node, declared ast.Type, internal bool, rule, what string) {
+	value := c.typeOf(n)
+	if declared == ast.Unknown || value == ast.Unknown {
+		return
+	}
+	var rejected []ast.Type
+	members := ast.Members(value)
+	for _, m := range members {
+		if !c.accepts(declared, m, internal) {
+			rejected = append(rejected, m)
+		}
+	}
+	switch {
+	case len(rejected) == 0:
+	case len(rejected) == len(members):
+		c.report(n, Error, rule, "%s must be %s, %s given", what, declared, value)
+	default:
+		c.report(n, Warning, rule, "%s must be %s, but may be %s", what, declared, ast.Union(rejected...))
+	}
+}
+
+// accepts reports whether a value of the type declared may be of the type
+// t, which is not a union. Scalars are converted to each other, as PHP
+// does unless strict types are declared, and internal functions accept
+// null for scalars.
+func (c *checker) accepts(declared, t ast.Type, internal bool) bool {
+	if _, ok := t.(ast.TypeParameter); ok {
+		return true
+	}
+	for _, d := range ast.Members(declared) {
+		switch d := d.(type) {
+		case ast.TypeParameter:
+			return true
+		case ast.ArrayOf:
+			if is(t, ast.Array) {
+				return true
+			}
+		case ast.ObjectType:
+			switch t := t.(type) {
+			case ast.ObjectType:
+				if c.subtype(t.Class, d.Class) {
+					return true
+				}
+			case ast.BasicType:
+				if t == ast.Object || t == ast.Function && strings.EqualFold(d.Class, "Closure") {
+					return true
+				}
+			}
+		case ast.BasicType:
+			switch d {
+			case ast.String, ast.Integer, ast.Float, ast.Boolean:
+				if scalar(t) || internal && t == ast.Null {
+					return true
+				}
+				if o, ok := t.(ast.ObjectType); ok && d == ast.String {
+					if has, known := c.hasMethod(o.Class, "__toString", false); has || !known {
+						return true
+					}
+				}
+			case ast.Array:
+				if is(t, ast.Array) {
+					return true
+				}
+			case ast.Object:
+				if isObject(t) || t == ast.Function {
+					return true
+				}
+			case ast.Function:
+				if is(t, ast.String) || is(t, ast.Array) || isObject(t) || t == ast.Function {
+					return true
+				}
+			default:
+				if d.Equals(t) {
+					return true
+				}
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// scalar reports whether t is a scalar type.
+func scalar(t ast.Type) bool {
+	return is(t, ast.String) || is(t, ast.Integer) || is(t, ast.Float) || is(t, ast.Boolean)
+}
+
+// subtype reports whether objects of the class sub may be objects of the
+// class or interface super, which they may if it is not known that they
+// are not.
+func (c *checker) subtype(sub, super string) bool {
+	if c.extends(sub, super) || !c.known(super) || !c.complete(sub) {
+		return true
+	}
+	if _, ok := c.interfaces[strings.ToLower(sub)]; ok {
+		return true
+	}
+	b := c.builtins.Class(sub)
+	return b != nil && b.Interface
+}
+
+// known reports whether the class or interface is declared in the file set
+// or built in.
+func (c *checker) known(class string) bool {
+	key := strings.ToLower(class)
+	return c.classes[key] != nil || c.interfaces[key] != nil || c.builtins.Class(class) != nil
+}
+
+// complete reports whether the class and all the classes and interfaces
+// it extends and implements are known.
+func (c *checker) complete(class string) bool {
+	seen := map[string]bool{}
+	var walk func(class string) bool
+	walk = func(class string) bool {
+		if seen[strings.ToLower(class)] {
+			return true
+		}
+		seen[strings.ToLower(class)] = true
+		if !c.known(class) {
+			return false
+		}
+		for _, s := range c.supertypes(class) {
+			if !walk(s) {
+				return false
+			}
+		}
+		return true
+	}
+	return walk(class)
+}
+
+// supertypes returns the classes and interfaces that class directly
+// extends or implements.
+func (c *checker) supertypes(class string) []string {
+	if supertypes := c.index.Supertypes(class); len(supertypes) > 0 {
+		return supertypes
+	}
+	return c.builtins.Supertypes(class)
+}
+
+// hasMethod reports whether objects of the class have the method name,
+// which they do if it or the classes, interfaces and traits it extends,
+// implements or uses declare it, give it by @method tags or alias it with
+// the as rules of a trait use, or handle calls to it with __call, or also
+// __callStatic if the call is static. known is false if they extend,
+// implement or use something that is not known.
+func (c *checker) hasMethod(class, name string, static bool) (has, known bool) {
+	t := c.hierarchy.Type(class)
+	if t == nil {
+		return false, false
+	}
+	magic := []string{"__call"}
+	if static {
+		magic = append(magic, "__callStatic")
+	}
+	for _, m := range append(magic, name) {
+		if t.Method(m) != nil {
+			return true, true
+		}
+	}
+	known = true
+	for _, u := range append([]*hierarchy.Type{t}, t.Ancestors()...) {
+		if len(u.Missing) > 0 {
+			known = false
+		}
+		if cls := c.nodes[classNode(u)]; cls != nil {
+			for _, m := range cls.doc.Methods {
+				if strings.EqualFold(m.Name, name) {
+					return true, true
+				}
+			}
+		}
+	}
+	return false, known
+}
+
+// classNode returns the declaration of t if it is a class the file set
+// declares, or nil.
+func classNode(t *hierarchy.Type) *ast.Class {
+	if t.Builtin {
+		return nil
+	}
+	n, _ := t.Node.(*ast.Class)
+	return n
+}
+
+// checkCall checks the call of a function f.
+func (c *checker) checkCall(f *ast.FunctionCallExpr) {
+	id := ast.Static(f.FunctionName)
+	if id == nil {
+		return
+	}
+	if fn := c.lookupFunction(id.Value); fn != nil {
+		c.arguments(f, f.Arguments, c.signature(fn))
+	} else if fn := c.lookupBuiltin(id.Value); fn != nil {
+		c.arguments(f, f.Arguments, builtinSignature(fn, fn.Name+"()"))
+	}
+}
+
+// checkMethodCall checks the call of a method n.
+func (c *checker) checkMethodCall(n *ast.MethodCallExpr) {
+	c.dereference(n, n.Receiver, "call method "+describeName(n.FunctionName)+"() on")
+	id := ast.Static(n.FunctionName)
+	if id == nil {
+		return
+	}
+	var classes []string
+	for _, m := range ast.Members(c.typeOf(n.Receiver)) {
+		if o, ok := m.(ast.ObjectType); ok {
+			classes = append(classes, o.Class)
+		}
+	}
+	c.method(n, classes, id.Value, n.Arguments, false)
+}
+
+// checkStaticCall checks the static call of a method, call, of the class
+// expression n.
+func (c *checker) checkStaticCall(n *ast.ClassExpr, call *ast.FunctionCallExpr) {
+	id := ast.Static(call.FunctionName)
+	if id == nil {
+		return
+	}
+	class := ""
+	if ast.Static(n.Receiver) != nil {
+		class = c.className(n.Receiver)
+	} else if o, ok := c.typeOf(n.Receiver).(ast.ObjectType); ok {
+		class = o.Class
+	}
+	if class == "" {
+		return
+	}
+	c.method(n, []string{class}, id.Value, call.Arguments, true)
+}
+
+// method checks the call n of the method name of an object of one of the
+// classes, with the arguments args.
+func (c *checker) method(n ast.Node, classes []string, name string, args []ast.Expr, static bool) {
+	var missing []string
+	for _, class := range classes {
+		if has, known := c.hasMethod(class, name, static); known && !has {
+			missing = append(missing, class)
+		}
+	}
+	switch {
+	case len(missing) == 0:
+	case len(missing) == len(classes):
+		c.report(n, Error, UndefinedMethod, "call to undefined method %s::%s()", missing[0], name)
+	default:
+		c.report(n, Warning, UndefinedMethod, "call to method %s() that %s may not have", name, strings.Join(missing, ", "))
+	}
+	if len(classes) == 1 && len(missing) == 0 {
+		if s, ok := c.methodSignature(classes[0], name); ok {
+			c.arguments(n, args, s)
+		}
+	}
+}
+
+// checkNew checks the arguments of the constructor called by n.
+func (c *checker) checkNew(n *ast.NewCallExpr) {
+	if ast.Static(n.Class) == nil {
+		return
+	}
+	if class := c.className(n.Class); class != "" {
+		if s, ok := c.methodSignature(class, "__construct"); ok {
+			c.arguments(n, n.Arguments, s)
+		}
+	}
+}
+
+// dereference checks that the receiver of n, whose action on it is
+// described by action, is not null.
+func (c *checker) dereference(n ast.Node, receiver ast.Node, action string) {
+	if c.guarded > 0 {
+		return
+	}
+	t := c.typeOf(receiver)
+	if t == ast.Unknown {
+		return
+	}
+	members := ast.Members(t)
+	for _, m := range members {
+		if m != ast.Null {
+			continue
+		}
+		if len(members) == 1 {
+			c.report(n, Error, NullDereference, "cannot %s null", action)
+		} else {
+			c.report(n, Warning, NullDereference, "cannot %s null, which the receiver of type %s may be", action, t)
+		}
+		return
+	}
+}
+
+// arithmetic checks that the operands of the arithmetic operation n, of
+// the operator op, are not arrays. a is nil for a unary operator.
+func (c *checker) arithmetic(n ast.Node, op string, a, b ast.Node) {
+	switch op {
+	case "+", "-", "*", "/", "%", "**":
+	default:
+		return
+	}
+	right := c.typeOf(b)
+	if a == nil {
+		if all, some := arrays(right); all {
+			c.report(n, Error, ArrayArithmetic, "unsupported operand type: %s%s", op, right)
+		} else if some {
+			c.report(n, Warning, ArrayArithmetic, "operand of %s may be an array: %s", op, right)
+		}
+		return
+	}
+	left := c.typeOf(a)
+	leftAll, leftSome := arrays(left)
+	rightAll, rightSome := arrays(right)
+	if op == "+" {
+		// Adding arrays is their union.
+		if leftAll && !rightSome && right != ast.Unknown || rightAll && !leftSome && left != ast.Unknown {
+			c.report(n, Error, ArrayArithmetic, "unsupported operand types: %s + %s", left, right)
+		}
+		return
+	}
+	switch {
+	case leftAll || rightAll:
+		c.report(n, Error, ArrayArithmetic, "unsupported operand types: %s %s %s", left, op, right)
+	case leftSome || rightSome:
+		c.report(n, Warning, ArrayArithmetic, "operand of %s may be an array: %s %s %s", op, left, op, right)
+	}
+}
+
+// arrays reports whether all and whether some of the members of t are
+// arrays, which none are known to be if t is unknown.
+func arrays(t ast.Type) (all, some bool) {
+	if t == ast.Unknown {
+		return false, false
+	}
+	members := ast.Members(t)
+	n := 0
+	for _, m := range members {
+		if is(m, ast.Array) {
+			n++
+		}
+	}
+	return n > 0 && n == len(members), n > 0
+}
+
+// checkReturn checks the value returned by the return statement n of the
+// current function against its declared return type.
+func (c *checker) checkReturn(n ast.Node, value ast.Node) {
+	fn := c.fn
+	if fn.def == nil || fn.def.Type == "" || generator(fn.body) {
+		return
+	}
+	name := describe(fn)
+	if strings.EqualFold(fn.def.Type, "void") {
+		if !ast.IsNil(value) {
+			c.report(n, Error, ReturnType, "void function %s returns a value", name)
+		}
+		return
+	}
+	declared := c.inFunction(fn, func() ast.Type { return c.hintType(fn.def.Type) })
+	if ast.IsNil(value) {
+		c.report(n, Error, ReturnType, "%s must return a value of type %s", name, declared)
+		return
+	}
+	c.checkType(value, declared, false, ReturnType, "the value returned by "+name)
+}
@@ -0,0 +1,231 @@
+package typecheck
+
+import (
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/builtins"
+	"github.com/stephens2424/php/phpdoc"
+)
+
+// resolver returns the resolver of the names of classes in the doc comments
+// of the file path, in the class cls, which may be nil.
+func (in *inferrer) resolver(path string, cls *class) phpdoc.Resolver {
+	return func(name string) ast.Type {
+		if cls != nil {
+			switch strings.ToLower(name) {
+			case "self", "static", "$this":
+				return ast.ObjectType{Class: cls.name}
+			case "parent":
+				if cls.parent != "" {
+					return ast.ObjectType{Class: cls.parent}
+				}
+				return ast.Object
+			}
+			if cls.doc != nil {
+				for _, t := range cls.doc.Templates {
+					if t.Name == name {
+						return ast.TypeParameter{Name: t.Name, Bound: t.Bound}
+					}
+				}
+			}
+		}
+		switch strings.ToLower(name) {
+		case "self", "static", "$this", "parent":
+			return ast.Object
+		}
+		return ast.ObjectType{Class: in.index.ResolveClass(path, name)}
+	}
+}
+
+// doc returns the doc comment preceding the statement n in the current
+// function, or nil if there is none.
+func (in *inferrer) doc(n ast.Node) *phpdoc.Doc {
+	text := in.fn.file.Doc(n)
+	if text == "" {
+		return nil
+	}
+	return phpdoc.Parse(text, in.resolver(in.fn.path, in.fn.class))
+}
+
+// annotated infers the types of the statement n, given the types of the
+// variables before it e, like statement, following the @var tags of the
+// doc comment d preceding it. A @var tag naming a variable gives its type
+// before the statement and where the statement assigns it, and one
+// without a name gives the type of the variable that the statement
+// assigns.
+func (in *inferrer) annotated(n ast.Node, d *phpdoc.Doc, e env) env {
+	for _, v := range d.Vars {
+		if v.Name != "" {
+			e[v.Name] = v.Type
+		}
+	}
+	e = in.statement(n, e)
+	s, ok := n.(*ast.ExprStmt)
+	if !ok || e == nil {
+		return e
+	}
+	var assignee ast.Node
+	switch a := s.Expr.(type) {
+	case ast.AssignmentExpr:
+		assignee = a.Assignee
+	case *ast.AssignmentExpr:
+		assignee = a.Assignee
+	}
+	name, ok := narrowable(assignee)
+	if !ok {
+		return e
+	}
+	if v := d.Var(name); v != nil {
+		e[name] = v.Type
+		in.record(assignee, v.Type)
+	}
+	return e
+}
+
+// lookupProperty returns the type given in a doc comment of the property
+// name of the class, or of the classes it extends, including built-in
+// classes, or ast.Unknown.
+func (in *inferrer) lookupProperty(class, name string) ast.Type {
+	seen := map[string]bool{}
+	for c := in.classes[strings.ToLower(class)]; c != nil && !seen[c.name]; c = in.classes[strings.ToLower(c.parent)] {
+		seen[c.name] = true
+		if t, ok := c.properties[name]; ok {
+			return t
+		}
+	}
+	if t, ok := in.builtins.Property(in.builtinBase(class), name); ok {
+		return t
+	}
+	return ast.Unknown
+}
+
+// property returns the type of the property name of an object of type
+// receiver.
+func (in *inferrer) property(receiver ast.Type, name string) ast.Type {
+	var types []ast.Type
+	for _, member := range ast.Members(receiver) {
+		o, ok := member.(ast.ObjectType)
+		if !ok {
+			return ast.Unknown
+		}
+		types = append(types, in.lookupProperty(o.Class, name))
+	}
+	if len(types) == 0 {
+		return ast.Unknown
+	}
+	return joinTypes(types...)
+}
+
+// methodType returns the type that calling the method name of the class
+// with arguments of the types args returns, following the @method tags of
+// the class and the classes it extends where it does not declare the
+// method, and then the built-in class it extends.
+func (in *inferrer) methodType(class, name string, args []ast.Type) ast.Type {
+	if m := in.lookupMethod(class, name); m != nil {
+		return in.callType(m, args)
+	}
+	seen := map[string]bool{}
+	for c := in.classes[strings.ToLower(class)]; c != nil && !seen[c.name]; c = in.classes[strings.ToLower(c.parent)] {
+		seen[c.name] = true
+		for _, m := range c.doc.Methods {
+			if strings.EqualFold(m.Name, name) {
+				return m.Return
+			}
+		}
+	}
+	if m := in.builtins.Method(in.builtinBase(class), name); m != nil {
+		return builtinCallType(m, args)
+	}
+	return ast.Unknown
+}
+
+// builtinCallType returns the type that calling the built-in function or
+// method fn with arguments of the types args returns, binding the type
+// parameters of its parameters like callType.
+func builtinCallType(fn *builtins.Function, args []ast.Type) ast.Type {
+	if len(fn.Doc.Templates) == 0 {
+		return fn.Return
+	}
+	bindings := map[string]ast.Type{}
+	for i, arg := range args {
+		if p, ok := builtinParam(fn, i); ok {
+			bind(p.Type, arg, bindings)
+		}
+	}
+	return substitute(fn.Return, bindings)
+}
+
+// builtinParam returns the parameter of the built-in function fn for its
+// ith argument, which is the last if it is variadic, and whether there is
+// one.
+func builtinParam(fn *builtins.Function, i int) (builtins.Param, bool) {
+	if i < len(fn.Params) {
+		return fn.Params[i], true
+	}
+	if n := len(fn.Params); n > 0 && fn.Params[n-1].Variadic {
+		return fn.Params[n-1], true
+	}
+	return builtins.Param{}, false
+}
+
+// callType returns the type that calling fn with arguments of the types
+// args returns, binding the type parameters of its @template tags to the
+// types of the arguments whose @param tags they are in.
+func (in *inferrer) callType(fn *function, args []ast.Type) ast.Type {
+	t := in.returnType(fn)
+	if fn == nil || fn.doc == nil || len(fn.doc.Templates) == 0 {
+		return t
+	}
+	bindings := map[string]ast.Type{}
+	for i, arg := range fn.def.Arguments {
+		p := fn.doc.Param(arg.Variable.String())
+		if p == nil || i >= len(args) {
+			continue
+		}
+		bind(p.Type, args[i], bindings)
+	}
+	return substitute(t, bindings)
+}
+
+// bind binds the type parameters in the type of a parameter p to the
+// corresponding parts of the type of the argument a.
+func bind(p, a ast.Type, bindings map[string]ast.Type) {
+	switch p := p.(type) {
+	case ast.TypeParameter:
+		if t, ok := bindings[p.Name]; ok {
+			a = joinTypes(t, a)
+		}
+		bindings[p.Name] = a
+	case ast.ArrayOf:
+		if a, ok := a.(ast.ArrayOf); ok {
+			bind(p.Key, a.Key, bindings)
+			bind(p.Value, a.Value, bindings)
+		}
+	}
+}
+
+// substitute returns t with the type parameters in it replaced by the types
+// they are bound to, or by their bounds if they are not bound.
+func substitute(t ast.Type, bindings map[string]ast.Type) ast.Type {
+	switch t := t.(type) {
+	case ast.TypeParameter:
+		if b, ok := bindings[t.Name]; ok {
+			return b
+		}
+		if t.Bound != nil {
+			return t.Bound
+		}
+		return ast.Unknown
+	case ast.ArrayOf:
+		return ast.ArrayOf{Key: substitute(t.Key, bindings), Value: substitute(t.Value, bindings), List: t.List}
+	}
+	members := ast.Members(t)
+	if len(members) < 2 {
+		return t
+	}
+	for i, member := range members {
+		members[i] = substitute(member, bindings)
+	}
+	return joinTypes(members...)
+}
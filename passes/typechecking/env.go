@@ -0,0 +1,119 @@
+package typecheck
+
+import "github.com/stephens2424/php/ast"
+
+// env holds the types of the variables of a function at a point in it, by
+// their names. The env of code that cannot be reached is nil.
+type env map[string]ast.Type
+
+func (e env) copy() env {
+	if e == nil {
+		return nil
+	}
+	c := make(env, len(e))
+	for name, t := range e {
+		c[name] = t
+	}
+	return c
+}
+
+// replace replaces the types of e with those of o.
+func (e env) replace(o env) {
+	for name := range e {
+		delete(e, name)
+	}
+	for name, t := range o {
+		e[name] = t
+	}
+}
+
+func (e env) equal(o env) bool {
+	if (e == nil) != (o == nil) || len(e) != len(o) {
+		return false
+	}
+	for name, t := range e {
+		if u, ok := o[name]; !ok || !t.Equals(u) {
+			return false
+		}
+	}
+	return true
+}
+
+// merge returns the env where control flow from each of envs joins, in
+// which each variable may have the type it has in any of them.
+func merge(envs ...env) env {
+	var merged env
+	for _, e := range envs {
+		if e == nil {
+			continue
+		}
+		if merged == nil {
+			merged = e.copy()
+			continue
+		}
+		for name, t := range e {
+			if u, ok := merged[name]; ok {
+				merged[name] = joinTypes(u, t)
+			} else {
+				merged[name] = t
+			}
+		}
+	}
+	return merged
+}
+
+// joinTypes returns the union of types, which is unknown if any of them is.
+func joinTypes(types ...ast.Type) ast.Type {
+	for _, t := range types {
+		if t == nil || t == ast.Unknown {
+			return ast.Unknown
+		}
+	}
+	return ast.Union(types...)
+}
+
+// numeric is the type of a number.
+var numeric = ast.Union(ast.Integer, ast.Float)
+
+// is reports whether t is known to be of the basic type b. An array of
+// known types of keys or values is an array.
+func is(t ast.Type, b ast.BasicType) bool {
+	if _, ok := t.(ast.ArrayOf); ok {
+		return b == ast.Array
+	}
+	bt, ok := t.(ast.BasicType)
+	return ok && bt == b
+}
+
+// isObject reports whether t is an object type.
+func isObject(t ast.Type) bool {
+	if _, ok := t.(ast.ObjectType); ok {
+		return true
+	}
+	return is(t, ast.Object)
+}
+
+// filter returns the members of t for which keep returns true, or
+// otherwise if there are none.
+func filter(t ast.Type, keep func(ast.Type) bool, otherwise ast.Type) ast.Type {
+	if t == ast.Unknown {
+		return otherwise
+	}
+	var kept []ast.Type
+	for _, member := range ast.Members(t) {
+		if keep(member) {
+			kept = append(kept, member)
+		}
+	}
+	if len(kept) == 0 {
+		return otherwise
+	}
+	return ast.Union(kept...)
+}
+
+// without returns t without the members for which remove returns true.
+// The types of variables that cannot have any other type are left as they
+// are.
+func without(t ast.Type, remove func(ast.Type) bool) ast.Type {
+	return filter(t, func(member ast.Type) bool { return !remove(member) }, t)
+}
@@ -0,0 +1,454 @@
+package typecheck
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// record records t as the type of n, and returns it.
+func (in *inferrer) record(n ast.Node, t ast.Type) ast.Type {
+	in.info.set(in.fn.file, n, t)
+	return t
+}
+
+// integer matches the integer literals; other number literals are floats.
+var integer = regexp.MustCompile(`^(0[xX][0-9a-fA-F]+|0[bB][01]+|[0-9]+)$`)
+
+// casts holds the types of the cast operators, written without spaces.
+var casts = map[string]ast.Type{
+	"(int)":     ast.Integer,
+	"(integer)": ast.Integer,
+	"(bool)":    ast.Boolean,
+	"(boolean)": ast.Boolean,
+	"(float)":   ast.Float,
+	"(double)":  ast.Float,
+	"(real)":    ast.Float,
+	"(string)":  ast.String,
+	"(binary)":  ast.String,
+	"(array)":   ast.Array,
+	"(object)":  ast.ObjectType{Class: "stdClass"},
+	"(unset)":   ast.Null,
+}
+
+// expr infers the types of n and the expressions it contains, given the
+// types of the variables before it e, which it updates with those after
+// it. It returns the type of n.
+func (in *inferrer) expr(n ast.Node, e env) ast.Type {
+	if ast.IsNil(n) {
+		return ast.Unknown
+	}
+	switch n := n.(type) {
+	case *ast.Literal:
+		return in.record(n, literalType(n))
+	case *ast.Variable:
+		return in.record(n, in.variable(n, e))
+	case ast.ConstantExpr:
+		var t ast.Type = ast.Unknown
+		if id := ast.Static(n.Name); id != nil {
+			switch strings.ToLower(id.Value) {
+			case "true", "false":
+				t = ast.Boolean
+			case "null":
+				t = ast.Null
+			default:
+				if k := in.lookupBuiltinConstant(id.Value); k != nil {
+					t = k.Type
+				}
+			}
+		}
+		return in.record(n, t)
+	case ast.BinaryExpr:
+		return in.record(n, in.binary(n, e))
+	case ast.UnaryCallExpr:
+		return in.record(n, in.unary(n, e))
+	case *ast.TernaryCallExpr:
+		t, f := in.condition(n.Condition, e)
+		a := in.typeOf(n.Condition)
+		if n.True != nil {
+			a = in.expr(n.True, t)
+		}
+		b := in.expr(n.False, f)
+		e.replace(merge(t, f))
+		return in.record(n, joinTypes(a, b))
+	case ast.AssignmentExpr:
+		return in.record(n, in.assignment(n, e))
+	case *ast.AssignmentExpr:
+		return in.record(n, in.assignment(*n, e))
+	case *ast.ListStatement:
+		in.expr(n.Value, e)
+		for _, assignee := range n.Assignees {
+			in.assign(assignee, ast.Unknown, e)
+		}
+		return in.record(n, ast.Array)
+	case ast.ArrayExpr, *ast.ArrayExpr:
+		for _, child := range n.Children() {
+			in.expr(child, e)
+		}
+		return in.record(n, ast.Array)
+	case ast.ArrayPair:
+		in.expr(n.Key, e)
+		in.expr(n.Value, e)
+		return ast.Unknown
+	case *ast.ArrayLookupExpr:
+		var t ast.Type = ast.Unknown
+		switch a := in.expr(n.Array, e).(type) {
+		case ast.ArrayOf:
+			t = a.Value
+		case ast.BasicType:
+			if a == ast.String {
+				t = ast.String
+			}
+		}
+		in.expr(n.Index, e)
+		return in.record(n, t)
+	case ast.ArrayAppendExpr:
+		in.expr(n.Array, e)
+		return in.record(n, ast.Unknown)
+	case *ast.FunctionCallExpr:
+		return in.record(n, in.call(n, e))
+	case *ast.MethodCallExpr:
+		receiver := in.expr(n.Receiver, e)
+		args := in.callArguments(n.FunctionCallExpr, e)
+		t := in.methodCall(receiver, n.FunctionName, args, e)
+		in.record(n.FunctionCallExpr, t)
+		return in.record(n, t)
+	case *ast.PropertyCallExpr:
+		receiver := in.expr(n.Receiver, e)
+		id := ast.Static(n.Name)
+		if id == nil {
+			in.expr(n.Name, e)
+			return in.record(n, ast.Unknown)
+		}
+		return in.record(n, in.property(receiver, id.Value))
+	case *ast.ClassExpr:
+		return in.record(n, in.classExpr(n, e))
+	case *ast.NewCallExpr:
+		for _, arg := range n.Arguments {
+			in.expr(arg, e)
+		}
+		var t ast.Type = ast.Object
+		if ast.Static(n.Class) == nil {
+			in.expr(n.Class, e)
+		} else if name := in.className(n.Class); name != "" {
+			t = ast.ObjectType{Class: name}
+		}
+		return in.record(n, t)
+	case *ast.AnonymousFunction:
+		in.closure(n, e)
+		return in.record(n, ast.Function)
+	case ast.ShellCommand, *ast.ShellCommand:
+		return in.record(n, ast.String)
+	case *ast.Identifier:
+		return ast.Unknown
+	}
+	for _, child := range n.Children() {
+		in.expr(child, e)
+	}
+	return in.record(n, ast.Unknown)
+}
+
+// typeOf returns the type recorded for n in the current file.
+func (in *inferrer) typeOf(n ast.Node) ast.Type {
+	return in.info.TypeOf(in.fn.file, n)
+}
+
+func literalType(l *ast.Literal) ast.Type {
+	switch l.Type {
+	case ast.Float:
+		if integer.MatchString(l.Value) {
+			return ast.Integer
+		}
+		return ast.Float
+	case nil:
+		return ast.Unknown
+	}
+	return l.Type
+}
+
+// variable returns the type of the variable v where it is read.
+func (in *inferrer) variable(v *ast.Variable, e env) ast.Type {
+	id := ast.Static(v.Name)
+	if id == nil {
+		in.expr(v.Name, e)
+		return ast.Unknown
+	}
+	switch {
+	case id.Value == "this" && in.fn.class != nil:
+		return ast.ObjectType{Class: in.fn.class.name}
+	case ast.SuperGlobals[id.Value] || id.Value == "GLOBALS":
+		return ast.Array
+	}
+	if t, ok := e[id.Value]; ok {
+		return t
+	}
+	return ast.Unknown
+}
+
+// assign records that the variable, array element or property n is
+// assigned a value of type t.
+func (in *inferrer) assign(n ast.Node, t ast.Type, e env) {
+	switch n := n.(type) {
+	case *ast.Variable:
+		if id := ast.Static(n.Name); id != nil {
+			e[id.Value] = t
+		} else {
+			in.expr(n.Name, e)
+		}
+		in.record(n, t)
+	case *ast.ArrayLookupExpr:
+		in.expr(n.Index, e)
+		in.assignElement(n.Array, e)
+		in.record(n, t)
+	case ast.ArrayAppendExpr:
+		in.assignElement(n.Array, e)
+		in.record(n, t)
+	case *ast.ListStatement:
+		for _, assignee := range n.Assignees {
+			in.assign(assignee, ast.Unknown, e)
+		}
+	default:
+		in.expr(n, e)
+	}
+}
+
+// assignElement records that an element of the array n is assigned, which
+// makes n an array unless it was something else.
+func (in *inferrer) assignElement(n ast.Node, e env) {
+	v, ok := n.(*ast.Variable)
+	if !ok {
+		in.assign(n, ast.Array, e)
+		return
+	}
+	if id := ast.Static(v.Name); id != nil {
+		if t, ok := e[id.Value]; ok && !is(t, ast.Null) {
+			in.assign(v, t, e)
+			return
+		}
+	}
+	in.assign(v, ast.Array, e)
+}
+
+func (in *inferrer) assignment(a ast.AssignmentExpr, e env) ast.Type {
+	value := in.expr(a.Value, e)
+	op := strings.TrimSuffix(a.Operator, "=")
+	if op != "" {
+		value = operation(op, in.expr(a.Assignee, e), value)
+	}
+	in.assign(a.Assignee, value, e)
+	return value
+}
+
+// binary returns the type of the binary expression b.
+func (in *inferrer) binary(b ast.BinaryExpr, e env) ast.Type {
+	switch strings.ToLower(b.Operator) {
+	case "&&", "||", "and", "or":
+		t, f := in.condition(b, e)
+		e.replace(merge(t, f))
+		return ast.Boolean
+	case "instanceof":
+		in.expr(b.Antecedent, e)
+		if ast.Static(b.Subsequent) == nil {
+			if c, ok := b.Subsequent.(ast.ConstantExpr); !ok || ast.Static(c.Name) == nil {
+				in.expr(b.Subsequent, e)
+			}
+		}
+		return ast.Boolean
+	}
+	return operation(b.Operator, in.expr(b.Antecedent, e), in.expr(b.Subsequent, e))
+}
+
+// operation returns the type of the result of the binary operator op
+// applied to operands of the types a and b.
+func operation(op string, a, b ast.Type) ast.Type {
+	switch strings.ToLower(op) {
+	case "==", "!=", "===", "!==", "<", ">", "<=", ">=", "<>", "xor", "instanceof":
+		return ast.Boolean
+	case "<=>":
+		return ast.Integer
+	case ".":
+		return ast.String
+	case "%", "<<", ">>":
+		return ast.Integer
+	case "&", "|", "^":
+		if is(a, ast.String) && is(b, ast.String) {
+			return ast.String
+		}
+		return ast.Integer
+	case "+":
+		if is(a, ast.Array) && is(b, ast.Array) {
+			return ast.Array
+		}
+		if a == ast.Unknown || b == ast.Unknown {
+			return ast.Unknown
+		}
+		fallthrough
+	case "-", "*", "**":
+		switch {
+		case is(a, ast.Integer) && is(b, ast.Integer):
+			return ast.Integer
+		case is(a, ast.Float) || is(b, ast.Float):
+			return ast.Float
+		}
+		return numeric
+	case "/":
+		if is(a, ast.Float) || is(b, ast.Float) {
+			return ast.Float
+		}
+		return numeric
+	}
+	return ast.Unknown
+}
+
+// unary returns the type of the unary expression u.
+func (in *inferrer) unary(u ast.UnaryCallExpr, e env) ast.Type {
+	operand := in.expr(u.Operand, e)
+	op := strings.ToLower(strings.NewReplacer(" ", "", "\t", "").Replace(u.Operator))
+	if t, ok := casts[op]; ok {
+		return t
+	}
+	switch op {
+	case "!":
+		return ast.Boolean
+	case "~":
+		return ast.Integer
+	case "-", "+":
+		if is(operand, ast.Integer) || is(operand, ast.Float) {
+			return operand
+		}
+		return numeric
+	case "++", "--":
+		t := operand
+		if is(operand, ast.Null) && op == "++" {
+			t = ast.Integer
+		}
+		in.assign(u.Operand, t, e)
+		if u.Preceding {
+			return t
+		}
+		return operand
+	case "clone", "@":
+		return operand
+	case "print":
+		return ast.Integer
+	}
+	return ast.Unknown
+}
+
+// callArguments infers the types of the arguments of the call f, and
+// returns them.
+func (in *inferrer) callArguments(f *ast.FunctionCallExpr, e env) []ast.Type {
+	args := make([]ast.Type, len(f.Arguments))
+	for i, arg := range f.Arguments {
+		args[i] = in.expr(arg, e)
+	}
+	return args
+}
+
+// call returns the type that the function call f returns.
+func (in *inferrer) call(f *ast.FunctionCallExpr, e env) ast.Type {
+	id := ast.Static(f.FunctionName)
+	if id == nil {
+		in.expr(f.FunctionName, e)
+		in.callArguments(f, e)
+		return ast.Unknown
+	}
+	name := strings.ToLower(strings.TrimPrefix(id.Value, `\`))
+	switch name {
+	case "isset", "empty":
+		in.callArguments(f, e)
+		return ast.Boolean
+	case "unset":
+		for _, arg := range f.Arguments {
+			in.assign(arg, ast.Null, e)
+		}
+		return ast.Null
+	}
+	args := in.callArguments(f, e)
+	if _, ok := predicates[name]; ok {
+		return ast.Boolean
+	}
+	if fn := in.lookupFunction(id.Value); fn != nil {
+		return in.callType(fn, args)
+	}
+	fn := in.lookupBuiltin(id.Value)
+	if fn == nil {
+		return ast.Unknown
+	}
+	for i, arg := range f.Arguments {
+		if p, ok := builtinParam(fn, i); ok && p.ByRef && (args[i] == ast.Unknown || args[i] == ast.Null) {
+			in.assign(arg, substitute(p.Type, nil), e)
+		}
+	}
+	return builtinCallType(fn, args)
+}
+
+// methodCall returns the type that calling the method name of an object of
+// type receiver with arguments of the types args returns.
+func (in *inferrer) methodCall(receiver ast.Type, name ast.Dynamic, args []ast.Type, e env) ast.Type {
+	id := ast.Static(name)
+	if id == nil {
+		in.expr(name, e)
+		return ast.Unknown
+	}
+	var types []ast.Type
+	for _, member := range ast.Members(receiver) {
+		o, ok := member.(ast.ObjectType)
+		if !ok {
+			return ast.Unknown
+		}
+		types = append(types, in.methodType(o.Class, id.Value, args))
+	}
+	if len(types) == 0 {
+		return ast.Unknown
+	}
+	return joinTypes(types...)
+}
+
+// classExpr returns the type of the static method call, class constant or
+// static property c.
+func (in *inferrer) classExpr(c *ast.ClassExpr, e env) ast.Type {
+	class := ""
+	if ast.Static(c.Receiver) != nil {
+		class = in.className(c.Receiver)
+	} else if o, ok := in.expr(c.Receiver, e).(ast.ObjectType); ok {
+		class = o.Class
+	}
+
+	switch x := c.Expr.(type) {
+	case *ast.FunctionCallExpr:
+		args := in.callArguments(x, e)
+		var t ast.Type = ast.Unknown
+		if id := ast.Static(x.FunctionName); id != nil && class != "" {
+			t = in.methodType(class, id.Value, args)
+		} else if id == nil {
+			in.expr(x.FunctionName, e)
+		}
+		return in.record(x, t)
+	case ast.ConstantExpr:
+		id := ast.Static(x.Name)
+		if id == nil {
+			return ast.Unknown
+		}
+		if strings.ToLower(id.Value) == "class" {
+			return in.record(x, ast.String)
+		}
+		k, declaring := in.lookupConstant(class, id.Value)
+		if k == nil {
+			if t, ok := in.builtins.ClassConstant(in.builtinBase(class), id.Value); ok {
+				return in.record(x, t)
+			}
+			return in.record(x, ast.Unknown)
+		}
+		fn := &function{file: declaring.file, path: declaring.path, class: declaring}
+		return in.record(x, in.inFunction(fn, func() ast.Type { return in.constant(k) }))
+	case *ast.Variable:
+		if ast.Static(x.Name) == nil {
+			in.expr(x.Name, e)
+		}
+		return in.record(x, ast.Unknown)
+	}
+	in.expr(c.Expr, e)
+	return ast.Unknown
+}
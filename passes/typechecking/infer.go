@@ -0,0 +1,619 @@
+// Package typecheck infers and checks the types of PHP code.
+package typecheck
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/builtins"
+	"github.com/stephens2424/php/passes/hierarchy"
+	"github.com/stephens2424/php/passes/symbols"
+	"github.com/stephens2424/php/phpdoc"
+)
+
+// Info holds the types inferred for the expressions and variables of a file
+// set.
+type Info struct {
+	// types holds the types of nodes represented by pointers, such as
+	// *ast.Variable, and values those of nodes represented by values, such
+	// as ast.BinaryExpr, which are identified by their kind and span.
+	types  map[ast.Node]ast.Type
+	values map[valueKey]ast.Type
+
+	returns map[*ast.FunctionDefinition]ast.Type
+}
+
+type valueKey struct {
+	file       *ast.File
+	kind       reflect.Type
+	begin, end int
+}
+
+// TypeOf returns the type inferred for the expression n of file f, which
+// for a variable is its type where it occurs, or ast.Unknown.
+func (info *Info) TypeOf(f *ast.File, n ast.Node) ast.Type {
+	if ast.IsNil(n) {
+		return ast.Unknown
+	}
+	var t ast.Type
+	if reflect.ValueOf(n).Kind() == reflect.Ptr {
+		t = info.types[n]
+	} else if key, ok := info.key(f, n); ok {
+		t = info.values[key]
+	}
+	if t == nil {
+		return ast.Unknown
+	}
+	return t
+}
+
+// ReturnType returns the type that the function or method def returns, or
+// ast.Unknown.
+func (info *Info) ReturnType(def *ast.FunctionDefinition) ast.Type {
+	if t, ok := info.returns[def]; ok {
+		return t
+	}
+	return ast.Unknown
+}
+
+func (info *Info) key(f *ast.File, n ast.Node) (valueKey, bool) {
+	span, ok := f.Positions.Span(n)
+	if !ok {
+		return valueKey{}, false
+	}
+	return valueKey{file: f, kind: reflect.TypeOf(n), begin: span.Begin.Position, end: span.End.Position}, true
+}
+
+func (info *Info) set(f *ast.File, n ast.Node, t ast.Type) {
+	if reflect.ValueOf(n).Kind() == reflect.Ptr {
+		info.types[n] = t
+	} else if key, ok := info.key(f, n); ok {
+		info.values[key] = t
+	}
+}
+
+// Infer infers the types of the expressions and variables of the files in
+// fs.
+//
+// Inference is flow sensitive: the type of a variable where it occurs is
+// the union of the types of the values that may be assigned to it by then,
+// following branches and loops, and narrowed by conditions that test it,
+// such as instanceof and is_string. Types come from literals, operators,
+// the declared types of arguments and the types returned by functions and
+// methods, which are inferred from their return statements unless they
+// declare them. A type that cannot be known statically is ast.Unknown.
+//
+// The types written in doc comments are used where the code declares none:
+// @param and @return tags give the types of the arguments of a function
+// and of what it returns, @var tags those of properties and of variables
+// assigned by the statement they precede, and the @property and @method
+// tags of a class those of its magic properties and methods. The type
+// parameters of @template tags are bound by the types of the arguments of
+// each call.
+//
+// The functions, classes and constants built into PHP have the types given
+// by package builtins, and a built-in function assigns the types of its
+// parameters passed by reference to the variables passed for them that
+// were not yet assigned, such as the matches of preg_match.
+func Infer(fs *ast.FileSet) *Info {
+	return inferFiles(fs).info
+}
+
+// inferFiles infers the types of fs, and returns the inferrer that did.
+func inferFiles(fs *ast.FileSet) *inferrer {
+	in := &inferrer{
+		info: &Info{
+			types:   map[ast.Node]ast.Type{},
+			values:  map[valueKey]ast.Type{},
+			returns: map[*ast.FunctionDefinition]ast.Type{},
+		},
+		index:      symbols.New(fs),
+		builtins:   builtins.Load(),
+		hierarchy:  hierarchy.New(fs),
+		functions:  map[string]*function{},
+		classes:    map[string]*class{},
+		interfaces: map[string]*ast.Interface{},
+		defs:       map[*ast.FunctionDefinition]*function{},
+		nodes:      map[*ast.Class]*class{},
+		inProgress: map[*ast.FunctionDefinition]bool{},
+		constants:  map[*ast.Constant]bool{},
+	}
+
+	paths := make([]string, 0, len(fs.Files))
+	for path := range fs.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		in.declare(path, fs.Files[path])
+	}
+	for _, path := range paths {
+		f := fs.Files[path]
+		in.fn = &function{file: f, path: path}
+		in.statements(f.Nodes, env{})
+	}
+	return in
+}
+
+// inferrer infers the types of a file set.
+type inferrer struct {
+	info      *Info
+	index     *symbols.Index
+	builtins  *builtins.DB
+	hierarchy *hierarchy.Hierarchy
+
+	// functions, classes and interfaces hold the functions, classes and
+	// interfaces of the file set by their lower case qualified names, defs
+	// holds functions and methods by their definitions, and nodes holds
+	// classes by their declarations.
+	functions  map[string]*function
+	classes    map[string]*class
+	interfaces map[string]*ast.Interface
+	defs       map[*ast.FunctionDefinition]*function
+	nodes      map[*ast.Class]*class
+
+	// inProgress holds the functions whose types are being inferred, whose
+	// return types are unknown to recursive calls, and constants holds the
+	// class constants whose values are being inferred.
+	inProgress map[*ast.FunctionDefinition]bool
+	constants  map[*ast.Constant]bool
+
+	// fn is the function being inferred, loops the loops and switches of
+	// it enclosing the current statement, innermost last, and returns the
+	// types of the values it has returned.
+	fn      *function
+	loops   []*loop
+	returns []ast.Type
+}
+
+// function is a function, method or closure, or the code of a file outside
+// of functions.
+type function struct {
+	file *ast.File
+	path string
+
+	// class is the class of a method, or of the method enclosing a closure.
+	class *class
+
+	// def is the definition of a function or method, which is nil for a
+	// closure and for the code outside of functions.
+	def  *ast.FunctionDefinition
+	body *ast.Block
+
+	// doc is the doc comment of a function or method, which is empty if
+	// it has none.
+	doc *phpdoc.Doc
+}
+
+// class is a class of the file set.
+type class struct {
+	name   string
+	parent string
+	node   *ast.Class
+	file   *ast.File
+	path   string
+
+	// methods holds the methods of the class by their lower case names,
+	// and properties the types of the properties that have them in doc
+	// comments by their names, without the $.
+	methods    map[string]*function
+	properties map[string]ast.Type
+
+	doc *phpdoc.Doc
+}
+
+// declare records the functions, classes and methods declared in f.
+func (in *inferrer) declare(path string, f *ast.File) {
+	bodies := map[*ast.FunctionDefinition]*ast.Block{}
+	docs := map[*ast.FunctionDefinition]string{}
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		if ast.IsNil(n) {
+			return
+		}
+		switch n := n.(type) {
+		case *ast.FunctionStmt:
+			bodies[n.FunctionDefinition] = n.Body
+			docs[n.FunctionDefinition] = f.Doc(n)
+		case *ast.Method:
+			bodies[n.FunctionDefinition] = n.Body
+			docs[n.FunctionDefinition] = f.Doc(n)
+		}
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	for _, n := range f.Nodes {
+		walk(n)
+	}
+
+	for _, o := range in.index.Occurrences(path) {
+		if !o.Definition {
+			continue
+		}
+		switch o.Symbol.Kind {
+		case symbols.Function:
+			def := o.Node.(*ast.FunctionDefinition)
+			fn := &function{file: f, path: path, def: def, body: bodies[def]}
+			fn.doc = phpdoc.Parse(docs[def], in.resolver(path, nil))
+			in.functions[strings.ToLower(o.Symbol.Name)] = fn
+			in.defs[def] = fn
+		case symbols.Class:
+			c, ok := o.Node.(*ast.Class)
+			if !ok {
+				if i, ok := o.Node.(*ast.Interface); ok {
+					in.interfaces[strings.ToLower(o.Symbol.Name)] = i
+				}
+				continue
+			}
+			cls := &class{name: o.Symbol.Name, node: c, file: f, path: path, methods: map[string]*function{}, properties: map[string]ast.Type{}}
+			if supertypes := in.index.Supertypes(o.Symbol.Name); c.Extends != "" && len(supertypes) > 0 {
+				cls.parent = supertypes[0]
+			}
+			cls.doc = phpdoc.Parse(f.Doc(c), in.resolver(path, cls))
+			for _, p := range cls.doc.Properties {
+				cls.properties[p.Name] = p.Type
+			}
+			for _, p := range c.Properties {
+				if v := phpdoc.Parse(f.Doc(p), in.resolver(path, cls)).Var(p.Name); v != nil {
+					cls.properties[strings.TrimPrefix(p.Name, "$")] = v.Type
+				}
+			}
+			for _, m := range c.Methods {
+				fn := &function{file: f, path: path, class: cls, def: m.FunctionDefinition, body: m.Body}
+				fn.doc = phpdoc.Parse(docs[m.FunctionDefinition], in.resolver(path, cls))
+				cls.methods[strings.ToLower(m.Name)] = fn
+				in.defs[m.FunctionDefinition] = fn
+			}
+			in.classes[strings.ToLower(o.Symbol.Name)] = cls
+			in.nodes[c] = cls
+		}
+	}
+}
+
+// lookupFunction returns the function called by name in the current
+// function's namespace, or nil.
+func (in *inferrer) lookupFunction(name string) *function {
+	if strings.HasPrefix(name, `\`) {
+		return in.functions[strings.ToLower(name[1:])]
+	}
+	if ns := in.index.Namespace(in.fn.path); ns != "" {
+		if fn, ok := in.functions[strings.ToLower(ns+`\`+name)]; ok {
+			return fn
+		}
+	}
+	return in.functions[strings.ToLower(name)]
+}
+
+// lookupBuiltin returns the built-in function called by name, or nil. An
+// unqualified name in a namespace calls the built-in function if the
+// namespace does not declare one of that name.
+func (in *inferrer) lookupBuiltin(name string) *builtins.Function {
+	if name, ok := global(name); ok {
+		return in.builtins.Function(name)
+	}
+	return nil
+}
+
+// lookupBuiltinConstant returns the built-in constant name, or nil.
+func (in *inferrer) lookupBuiltinConstant(name string) *builtins.Constant {
+	if name, ok := global(name); ok {
+		return in.builtins.Constant(name)
+	}
+	return nil
+}
+
+// global returns name without a leading \, and whether it may name a
+// built-in, which it does if it is unqualified or fully qualified in the
+// global namespace.
+func global(name string) (string, bool) {
+	name = strings.TrimPrefix(name, `\`)
+	return name, !strings.Contains(name, `\`)
+}
+
+// builtinBase returns the class that class is or extends which the file
+// set does not declare, which is the built-in class it extends, if any.
+func (in *inferrer) builtinBase(class string) string {
+	seen := map[string]bool{}
+	for c := in.classes[strings.ToLower(class)]; c != nil && !seen[c.name]; c = in.classes[strings.ToLower(c.parent)] {
+		seen[c.name] = true
+		class = c.parent
+	}
+	return class
+}
+
+// lookupMethod returns the method name of the class, which it declares
+// or has from a trait or a class it extends, following the insteadof and
+// as rules of its trait uses, or nil.
+func (in *inferrer) lookupMethod(class, name string) *function {
+	t := in.hierarchy.Type(class)
+	if t == nil {
+		return nil
+	}
+	if m, ok := methodNode(t.Method(name)); ok {
+		return in.defs[m.FunctionDefinition]
+	}
+	return nil
+}
+
+// methodNode returns the declaration of the method m, if m is one the
+// file set declares.
+func methodNode(m *hierarchy.Member) (*ast.Method, bool) {
+	if m == nil || m.Type.Builtin {
+		return nil, false
+	}
+	n, ok := m.Node.(*ast.Method)
+	return n, ok
+}
+
+// lookupConstant returns the constant name of the class, or of the classes
+// it extends, and the class declaring it.
+func (in *inferrer) lookupConstant(class, name string) (*ast.Constant, *class) {
+	seen := map[string]bool{}
+	for c := in.classes[strings.ToLower(class)]; c != nil && !seen[c.name]; c = in.classes[strings.ToLower(c.parent)] {
+		seen[c.name] = true
+		for _, k := range c.node.Constants {
+			if k.Name == name {
+				return k, c
+			}
+		}
+	}
+	return nil, nil
+}
+
+// extends reports whether the class sub is, extends or implements super.
+func (in *inferrer) extends(sub, super string) bool {
+	seen := map[string]bool{}
+	var walk func(class string) bool
+	walk = func(class string) bool {
+		if strings.EqualFold(class, super) {
+			return true
+		}
+		if seen[strings.ToLower(class)] {
+			return false
+		}
+		seen[strings.ToLower(class)] = true
+		supertypes := in.index.Supertypes(class)
+		if len(supertypes) == 0 {
+			supertypes = in.builtins.Supertypes(class)
+		}
+		for _, s := range supertypes {
+			if walk(s) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(sub)
+}
+
+// className returns the qualified name of the class named by n, resolving
+// self, static and parent, or "" if it is not known statically.
+func (in *inferrer) className(n ast.Node) string {
+	id, ok := n.(*ast.Identifier)
+	if !ok {
+		return ""
+	}
+	switch strings.ToLower(id.Value) {
+	case "self", "static":
+		if in.fn.class != nil {
+			return in.fn.class.name
+		}
+		return ""
+	case "parent":
+		if in.fn.class != nil {
+			return in.fn.class.parent
+		}
+		return ""
+	}
+	return in.index.ResolveClass(in.fn.path, id.Value)
+}
+
+// hintType returns the type that a type hint or declared return type
+// names.
+func (in *inferrer) hintType(hint string) ast.Type {
+	switch strings.ToLower(hint) {
+	case "", "mixed", "iterable":
+		return ast.Unknown
+	case "array":
+		return ast.Array
+	case "callable":
+		return ast.Function
+	case "int", "integer":
+		return ast.Integer
+	case "float", "double":
+		return ast.Float
+	case "string":
+		return ast.String
+	case "bool", "boolean":
+		return ast.Boolean
+	case "void", "null":
+		return ast.Null
+	case "object":
+		return ast.Object
+	}
+	if name := in.className(&ast.Identifier{Value: hint}); name != "" {
+		return ast.ObjectType{Class: name}
+	}
+	return ast.Object
+}
+
+// returnType returns the type that fn returns, inferring it if it has not
+// been yet.
+func (in *inferrer) returnType(fn *function) ast.Type {
+	if fn == nil {
+		return ast.Unknown
+	}
+	if _, ok := in.info.returns[fn.def]; !ok && !in.inProgress[fn.def] {
+		in.function(fn)
+	}
+	return in.info.ReturnType(fn.def)
+}
+
+// function infers the types of the function or method fn, unless they have
+// been already.
+func (in *inferrer) function(fn *function) {
+	if _, ok := in.info.returns[fn.def]; ok || in.inProgress[fn.def] {
+		return
+	}
+	in.inProgress[fn.def] = true
+	e := in.arguments(fn, fn.def.Arguments, env{})
+	returned := in.body(fn, fn.body, e)
+	delete(in.inProgress, fn.def)
+
+	if declared := in.inFunction(fn, func() ast.Type { return in.hintType(fn.def.Type) }); declared != ast.Unknown {
+		returned = declared
+	} else if fn.doc != nil && fn.doc.Return != nil {
+		returned = fn.doc.Return
+	} else if generator(fn.body) {
+		returned = ast.ObjectType{Class: "Generator"}
+	}
+	in.info.returns[fn.def] = returned
+}
+
+// generator reports whether body, of a function, yields, which makes the
+// function return a Generator.
+func generator(body *ast.Block) bool {
+	return contains(body, func(n ast.Node) bool {
+		_, ok := n.(*ast.YieldExpr)
+		return ok
+	})
+}
+
+// contains reports whether f returns true for n or a node it contains,
+// outside of the closures it contains.
+func contains(n ast.Node, f func(ast.Node) bool) bool {
+	if ast.IsNil(n) {
+		return false
+	}
+	if f(n) {
+		return true
+	}
+	if _, ok := n.(*ast.AnonymousFunction); ok {
+		return false
+	}
+	for _, child := range n.Children() {
+		if contains(child, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// inFunction calls f with fn as the current function.
+func (in *inferrer) inFunction(fn *function, f func() ast.Type) ast.Type {
+	saved, loops, returns := in.fn, in.loops, in.returns
+	in.fn, in.loops, in.returns = fn, nil, nil
+	t := f()
+	in.fn, in.loops, in.returns = saved, loops, returns
+	return t
+}
+
+// arguments returns e with the types of the arguments of fn.
+func (in *inferrer) arguments(fn *function, args []*ast.FunctionArgument, e env) env {
+	in.inFunction(fn, func() ast.Type {
+		for _, arg := range args {
+			t := in.hintType(arg.TypeHint)
+			if t == ast.Unknown && fn.doc != nil {
+				if p := fn.doc.Param(arg.Variable.String()); p != nil {
+					t = p.Type
+				}
+			}
+			if arg.Default != nil {
+				if in.expr(arg.Default, env{}) == ast.Null && t != ast.Unknown {
+					t = ast.Union(t, ast.Null)
+				}
+			}
+			if id := ast.Static(arg.Variable.Name); id != nil {
+				e[id.Value] = t
+			}
+			in.record(arg.Variable, t)
+		}
+		return nil
+	})
+	return e
+}
+
+// body infers the types of the body of fn, given the types of its
+// variables e, and returns the type it returns.
+func (in *inferrer) body(fn *function, body *ast.Block, e env) ast.Type {
+	if body == nil {
+		return ast.Unknown
+	}
+	return in.inFunction(fn, func() ast.Type {
+		if end := in.statements(blockStatements(body), e); end != nil {
+			in.returns = append(in.returns, ast.Null)
+		}
+		if len(in.returns) == 0 {
+			return ast.Null
+		}
+		return joinTypes(in.returns...)
+	})
+}
+
+// closure infers the types of the closure f, whose enclosing function's
+// variables have the types e.
+func (in *inferrer) closure(f *ast.AnonymousFunction, e env) {
+	fn := &function{file: in.fn.file, path: in.fn.path, class: in.fn.class}
+	inner := env{}
+	if in.fn.class != nil {
+		inner["this"] = ast.ObjectType{Class: in.fn.class.name}
+	}
+	for _, v := range f.ClosureVariables {
+		if id := ast.Static(v.Variable.Name); id != nil {
+			t, ok := e[id.Value]
+			if !ok {
+				t = ast.Null
+			}
+			inner[id.Value] = t
+			in.record(v.Variable, t)
+		}
+	}
+	in.body(fn, f.Body, in.arguments(fn, f.Arguments, inner))
+}
+
+// class infers the types of the members of c.
+func (in *inferrer) class(c *ast.Class) {
+	cls, ok := in.nodes[c]
+	if !ok {
+		return
+	}
+	for _, m := range c.Methods {
+		in.function(cls.methods[strings.ToLower(m.Name)])
+	}
+	fn := &function{file: cls.file, path: cls.path, class: cls}
+	in.inFunction(fn, func() ast.Type {
+		for _, k := range c.Constants {
+			in.constant(k)
+		}
+		for _, p := range c.Properties {
+			if p.Initialization != nil {
+				in.expr(p.Initialization, env{})
+			}
+		}
+		return nil
+	})
+}
+
+// constant returns the type of the value of the class constant k of the
+// current class.
+func (in *inferrer) constant(k *ast.Constant) ast.Type {
+	value, ok := k.Value.(ast.Node)
+	if !ok || ast.IsNil(value) || in.constants[k] {
+		return ast.Unknown
+	}
+	in.constants[k] = true
+	defer delete(in.constants, k)
+	return in.expr(value, env{})
+}
+
+func blockStatements(b *ast.Block) []ast.Node {
+	nodes := make([]ast.Node, len(b.Statements))
+	for i, s := range b.Statements {
+		nodes[i] = s
+	}
+	return nodes
+}
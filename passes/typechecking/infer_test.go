@@ -0,0 +1,432 @@
+package typecheck
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/parser"
+)
+
+// infer parses src as the file x.php, and infers its types.
+func infer(t *testing.T, src string) (*Info, *ast.File) {
+	p := parser.NewParser()
+	if _, err := p.Parse("x.php", src); err != nil {
+		t.Fatal(err)
+	}
+	return Infer(p.FileSet), p.FileSet.Files["x.php"]
+}
+
+// variableAt returns the variable beginning at the first occurrence of s
+// in src.
+func variableAt(t *testing.T, f *ast.File, src, s string) *ast.Variable {
+	offset := strings.Index(src, s)
+	for n, span := range f.Positions {
+		if v, ok := n.(*ast.Variable); ok && span.Begin.Position == offset {
+			return v
+		}
+	}
+	t.Fatalf("no variable at %q", s)
+	return nil
+}
+
+type typeTest struct {
+	at   string
+	want string
+}
+
+func checkTypes(t *testing.T, src string, tests []typeTest) {
+	info, f := infer(t, src)
+	for _, test := range tests {
+		if got := info.TypeOf(f, variableAt(t, f, src, test.at)).String(); got != test.want {
+			t.Errorf("type of %q is %s, want %s", test.at, got, test.want)
+		}
+	}
+}
+
+func TestInferExpressions(t *testing.T) {
+	src := `<?php
+$a = 1;
+$b = 1.5;
+$c = $a + $b;
+$d = $a . "x";
+$e = $a < 2;
+$f = (string)$a;
+$g = array(1, 2);
+$h = -$a;
+$i = $a * 2;
+$j = $a / 2;
+$k = !$a;
+$l = null;
+$m = $a ? "yes" : false;
+$n = function() {};
+$o = 0x1F;
+$p = $b;
+$a .= "";
+$q = $a;
+$r = $_GET;
+`
+	checkTypes(t, src, []typeTest{
+		{"$a = 1", "integer"},
+		{"$b = 1.5", "float"},
+		{"$c =", "float"},
+		{"$d =", "string"},
+		{"$e =", "boolean"},
+		{"$f =", "string"},
+		{"$g =", "array"},
+		{"$h =", "integer"},
+		{"$i =", "integer"},
+		{"$j =", "float|integer"},
+		{"$k =", "boolean"},
+		{"$l =", "null"},
+		{"$m =", "boolean|string"},
+		{"$n =", "function"},
+		{"$o =", "integer"},
+		{"$p =", "float"},
+		{"$q =", "string"},
+		{"$r =", "array"},
+	})
+}
+
+func TestInferFlow(t *testing.T) {
+	src := `<?php
+function branches($c) {
+	if ($c) {
+		$x = 1;
+	} else {
+		$x = "one";
+	}
+	echo $x /* branches */;
+}
+function returns($c) {
+	$y = 1;
+	if ($c) {
+		$y = "one";
+		return;
+	}
+	echo $y /* returns */;
+}
+function loops($c) {
+	$i = 0;
+	while ($c) {
+		echo $i /* in loop */;
+		$i = $i . "";
+	}
+	echo $i /* after loop */;
+}
+function breaks($c) {
+	while (true) {
+		$b = 1;
+		if ($c) {
+			$b = 1.5;
+			break;
+		}
+	}
+	echo $b /* breaks */;
+}
+function cases($c) {
+	switch ($c) {
+	case 1:
+		$s = 1;
+		break;
+	default:
+		$s = array();
+	}
+	echo $s /* cases */;
+}
+function catches() {
+	try {
+		$t = 1;
+	} catch (Exception $e) {
+		$t = "one";
+	}
+	echo $t /* catches */, $e /* exception */;
+}
+`
+	checkTypes(t, src, []typeTest{
+		{"$x /* branches */", "integer|string"},
+		{"$y /* returns */", "integer"},
+		{"$i /* in loop */", "integer|string"},
+		{"$i /* after loop */", "integer|string"},
+		{"$b /* breaks */", "float"},
+		{"$s /* cases */", "array|integer"},
+		{"$t /* catches */", "integer|string"},
+		{"$e /* exception */", "Exception"},
+	})
+}
+
+func TestInferCalls(t *testing.T) {
+	src := `<?php
+namespace N;
+
+function one() {
+	return 1;
+}
+
+function maybe($c) {
+	if ($c) {
+		return "s";
+	}
+}
+
+function declared(): string {
+	return undefined();
+}
+
+class A {
+	const NAME = "a";
+
+	function make() {
+		return new B;
+	}
+
+	static function name() {
+		return self::NAME;
+	}
+
+	function me() {
+		return $this;
+	}
+}
+
+class B extends A {
+	function parentName() {
+		return parent::name();
+	}
+}
+
+$one = one();
+$maybe = maybe(true);
+$declared = declared();
+$a = new A;
+$b = $a->make();
+$name = A::name();
+$me = $b->me();
+$parentName = $b->parentName();
+$class = A::class;
+$unknown = undefined();
+`
+	checkTypes(t, src, []typeTest{
+		{"$one =", "integer"},
+		{"$maybe =", "null|string"},
+		{"$declared =", "string"},
+		{"$a =", `N\A`},
+		{"$b =", `N\B`},
+		{"$name =", "string"},
+		{"$me =", `N\A`},
+		{"$parentName =", "string"},
+		{"$class =", "string"},
+		{"$unknown =", "unknown"},
+	})
+}
+
+func TestInferArguments(t *testing.T) {
+	src := `<?php
+class A {}
+
+function f(A $a = null, array $b, $c, $d = 1) {
+	echo $a /* a */, $b /* b */, $c /* c */, $d /* d */;
+	$g = function($e) use ($b) {
+		echo $b /* used */, $e /* e */;
+	};
+}
+`
+	checkTypes(t, src, []typeTest{
+		{"$a /* a */", "A|null"},
+		{"$b /* b */", "array"},
+		{"$c /* c */", "unknown"},
+		{"$d /* d */", "unknown"},
+		{"$b /* used */", "array"},
+		{"$e /* e */", "unknown"},
+	})
+}
+
+func TestInferNarrowing(t *testing.T) {
+	src := `<?php
+class A {}
+class B extends A {}
+
+function f(A $a = null, $c) {
+	if ($a === null) {
+		echo $a /* null */;
+		return;
+	}
+	echo $a /* not null */;
+	if ($a instanceof B) {
+		echo $a /* instanceof */;
+	}
+	$v = $c ? 1 : "one";
+	if (is_int($v)) {
+		echo $v /* is_int */;
+	} else {
+		echo $v /* not is_int */;
+	}
+	if (!is_string($v) || $c) {
+		return;
+	}
+	echo $v /* is_string */;
+	if (is_array($c) && $c) {
+		echo $c /* is_array */;
+	}
+	echo $c /* after is_array */;
+}
+`
+	checkTypes(t, src, []typeTest{
+		{"$a /* null */", "null"},
+		{"$a /* not null */", "A"},
+		{"$a /* instanceof */", "B"},
+		{"$v /* is_int */", "integer"},
+		{"$v /* not is_int */", "string"},
+		{"$v /* is_string */", "string"},
+		{"$c /* is_array */", "array"},
+		{"$c /* after is_array */", "unknown"},
+	})
+}
+
+func TestInferReturnTypes(t *testing.T) {
+	src := `<?php
+function recursive($n) {
+	if ($n > 0) {
+		return recursive($n - 1);
+	}
+	return 0;
+}
+
+function none() {
+}
+`
+	info, f := infer(t, src)
+	want := map[string]string{"recursive": "unknown", "none": "null"}
+	for _, n := range f.Nodes {
+		fn, ok := n.(*ast.FunctionStmt)
+		if !ok {
+			continue
+		}
+		if got := info.ReturnType(fn.FunctionDefinition).String(); got != want[fn.Name] {
+			t.Errorf("%s returns %s, want %s", fn.Name, got, want[fn.Name])
+		}
+	}
+}
+
+func TestInferDocs(t *testing.T) {
+	src := `<?php
+class Item {}
+
+/**
+ * @property-read int $count
+ * @method Item first()
+ * @method static self make()
+ */
+class Box {
+	/** @var Item[] */
+	public $items;
+
+	/**
+	 * @param string $name
+	 * @return list<Item>
+	 */
+	function find($name) {
+		echo $name /* param */;
+		return $this->items;
+	}
+
+	/** @return int */
+	function declared(): string {
+		return "s";
+	}
+}
+
+/**
+ * @template T
+ * @param T $value
+ * @return T[]
+ */
+function wrap($value) {
+	return array($value);
+}
+
+$box = new Box;
+$items = $box->items;
+$found = $box->find("a");
+$count = $box->count;
+$first = $box->first();
+$made = Box::make();
+$declared = $box->declared();
+$wrapped = wrap(1);
+foreach ($found as $key => $item) {
+	echo $key /* key */, $item /* item */;
+}
+$element = $items[0];
+/** @var Item $annotated */
+$annotated = f();
+/** @var int */
+$unnamed = f();
+/** @var Item $before */
+$before /* before */->f();
+`
+	checkTypes(t, src, []typeTest{
+		{"$name /* param */", "string"},
+		{"$items =", "array<Item>"},
+		{"$found =", "list<Item>"},
+		{"$count =", "integer"},
+		{"$first =", "Item"},
+		{"$made =", "Box"},
+		{"$declared =", "string"},
+		{"$wrapped =", "array<integer>"},
+		{"$key /* key */", "integer"},
+		{"$item /* item */", "Item"},
+		{"$element =", "Item"},
+		{"$annotated =", "Item"},
+		{"$unnamed =", "integer"},
+		{"$before /* before */", "Item"},
+	})
+}
+
+func TestInferBuiltins(t *testing.T) {
+	src := `<?php
+namespace App;
+
+class NotFound extends \RuntimeException {}
+
+function strlen($s) { return "shadowed"; }
+
+$length = \strlen("abc");
+$shadowed = strlen("abc");
+$upper = strtoupper("abc");
+$position = strpos("abc", "b");
+/** @var int[] $ints */
+$popped = array_pop($ints);
+if (preg_match('/a/', "abc", $matches)) {
+	echo $matches /* matches */;
+}
+$eol = PHP_EOL;
+$date = new \DateTime();
+$modified = $date->modify("+1 day");
+$formatted = $date->format(\DateTime::ATOM);
+$e = new NotFound("missing");
+$message = $e->getMessage();
+$previous = $e->getPrevious();
+$interval = $date->diff($date);
+$days = $interval->days;
+if ($e instanceof \Exception) {
+	echo $e /* exception */;
+}
+`
+	checkTypes(t, src, []typeTest{
+		{"$length =", "integer"},
+		{"$shadowed =", "string"},
+		{"$upper =", "string"},
+		{"$position =", "boolean|integer"},
+		{"$popped =", "integer|null"},
+		{"$ints);", "array<integer>"},
+		{"$matches /* matches */", "array"},
+		{"$eol =", "string"},
+		{"$modified =", "DateTime|boolean"},
+		{"$formatted =", "string"},
+		{"$message =", "string"},
+		{"$previous =", "Throwable|null"},
+		{"$interval =", "DateInterval"},
+		{"$days =", "boolean|integer"},
+		{"$e /* exception */", `App\NotFound`},
+	})
+}
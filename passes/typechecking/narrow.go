@@ -0,0 +1,231 @@
+package typecheck
+
+import (
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// predicate is a function testing the type of its argument, such as
+// is_string. The type of a variable it accepts is narrowed to accepted
+// when it returns true, and when it returns false, the members of the
+// type for which rejects returns true are removed.
+type predicate struct {
+	accepted ast.Type
+	rejects  func(ast.Type) bool
+}
+
+func basicPredicate(types ...ast.BasicType) predicate {
+	members := make([]ast.Type, len(types))
+	for i, t := range types {
+		members[i] = t
+	}
+	return predicate{
+		accepted: ast.Union(members...),
+		rejects: func(t ast.Type) bool {
+			for _, b := range types {
+				if is(t, b) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+var predicates = map[string]predicate{
+	"is_string":   basicPredicate(ast.String),
+	"is_int":      basicPredicate(ast.Integer),
+	"is_integer":  basicPredicate(ast.Integer),
+	"is_long":     basicPredicate(ast.Integer),
+	"is_float":    basicPredicate(ast.Float),
+	"is_double":   basicPredicate(ast.Float),
+	"is_real":     basicPredicate(ast.Float),
+	"is_bool":     basicPredicate(ast.Boolean),
+	"is_array":    basicPredicate(ast.Array),
+	"is_null":     basicPredicate(ast.Null),
+	"is_resource": basicPredicate(ast.Resource),
+	"is_object":   {accepted: ast.Object, rejects: isObject},
+
+	// a numeric string is numeric, but another string is not
+	"is_numeric": {
+		accepted: ast.Union(ast.Integer, ast.Float, ast.String),
+		rejects:  basicPredicate(ast.Integer, ast.Float).rejects,
+	},
+}
+
+// narrowable returns the name of the variable n, if it is one whose type
+// may be narrowed.
+func narrowable(n ast.Node) (string, bool) {
+	v, ok := n.(*ast.Variable)
+	if !ok {
+		return "", false
+	}
+	id := ast.Static(v.Name)
+	if id == nil || id.Value == "this" || ast.SuperGlobals[id.Value] {
+		return "", false
+	}
+	return id.Value, true
+}
+
+// condition infers the types of the condition n, given the types of the
+// variables before it e, and returns the types after it when it is true
+// and when it is false, either of which is nil if it cannot be.
+func (in *inferrer) condition(n ast.Node, e env) (t, f env) {
+	switch n := n.(type) {
+	case ast.UnaryCallExpr:
+		if n.Operator == "!" {
+			t, f = in.condition(n.Operand, e)
+			in.record(n, ast.Boolean)
+			return f, t
+		}
+	case ast.BinaryExpr:
+		switch strings.ToLower(n.Operator) {
+		case "&&", "and":
+			at, af := in.condition(n.Antecedent, e)
+			in.record(n, ast.Boolean)
+			if at == nil {
+				in.condition(n.Subsequent, env{})
+				return nil, af
+			}
+			bt, bf := in.condition(n.Subsequent, at)
+			return bt, merge(af, bf)
+		case "||", "or":
+			at, af := in.condition(n.Antecedent, e)
+			in.record(n, ast.Boolean)
+			if af == nil {
+				in.condition(n.Subsequent, env{})
+				return at, nil
+			}
+			bt, bf := in.condition(n.Subsequent, af)
+			return merge(at, bt), bf
+		case "instanceof":
+			in.expr(n, e)
+			v, ok := narrowable(n.Antecedent)
+			c, isConstant := n.Subsequent.(ast.ConstantExpr)
+			class := ""
+			if isConstant {
+				class = in.className(c.Name)
+			}
+			if !ok || class == "" {
+				return e, e.copy()
+			}
+			subclass := func(member ast.Type) bool {
+				o, ok := member.(ast.ObjectType)
+				return ok && in.extends(o.Class, class)
+			}
+			return narrow(v, e, filter(e[v], subclass, ast.ObjectType{Class: class}), subclass)
+		case "===", "!==":
+			in.expr(n, e)
+			v, ok := narrowable(n.Antecedent)
+			other := n.Subsequent
+			if !ok {
+				v, ok = narrowable(n.Subsequent)
+				other = n.Antecedent
+			}
+			if !ok || !is(in.typeOf(other), ast.Null) {
+				return e, e.copy()
+			}
+			isNull := func(member ast.Type) bool { return is(member, ast.Null) }
+			t, f = narrow(v, e, ast.Null, isNull)
+			if n.Operator == "!==" {
+				return f, t
+			}
+			return t, f
+		case "==", "!=", "<>":
+			// Loosely, null equals every falsy value, so only the
+			// inequality says anything about the variable.
+			in.expr(n, e)
+			v, ok := narrowable(n.Antecedent)
+			other := n.Subsequent
+			if !ok {
+				v, ok = narrowable(n.Subsequent)
+				other = n.Antecedent
+			}
+			if !ok || !is(in.typeOf(other), ast.Null) {
+				return e, e.copy()
+			}
+			f, t = truthy(v, e)
+			if n.Operator == "==" {
+				return t, f
+			}
+			return f, t
+		}
+	case *ast.Variable:
+		in.expr(n, e)
+		if v, ok := narrowable(n); ok {
+			return truthy(v, e)
+		}
+		return e, e.copy()
+	case *ast.Literal:
+		if n.Type == ast.Boolean {
+			in.expr(n, e)
+			if strings.EqualFold(n.Value, "true") {
+				return e, nil
+			}
+			return nil, e
+		}
+	case ast.ConstantExpr:
+		if id := ast.Static(n.Name); id != nil {
+			in.expr(n, e)
+			switch strings.ToLower(id.Value) {
+			case "true":
+				return e, nil
+			case "false":
+				return nil, e
+			}
+			return e, e.copy()
+		}
+	case *ast.FunctionCallExpr:
+		id := ast.Static(n.FunctionName)
+		if id == nil || len(n.Arguments) != 1 {
+			break
+		}
+		in.expr(n, e)
+		v, ok := narrowable(n.Arguments[0])
+		if !ok {
+			return e, e.copy()
+		}
+		name := strings.ToLower(strings.TrimPrefix(id.Value, `\`))
+		if p, ok := predicates[name]; ok {
+			return narrow(v, e, filter(e[v], p.rejects, p.accepted), p.rejects)
+		}
+		if name == "isset" {
+			isNull := func(member ast.Type) bool { return is(member, ast.Null) }
+			f, t = narrow(v, e, ast.Null, isNull)
+			return t, f
+		}
+		return e, e.copy()
+	}
+	in.expr(n, e)
+	return e, e.copy()
+}
+
+// truthy returns the types of the variables e when the variable v is
+// truthy, which it cannot be if it is null, and when it is falsy.
+func truthy(v string, e env) (t, f env) {
+	current, ok := e[v]
+	if !ok {
+		return e, e.copy()
+	}
+	isNull := func(member ast.Type) bool { return is(member, ast.Null) }
+	if is(current, ast.Null) {
+		return nil, e
+	}
+	t, f = e, e.copy()
+	t[v] = without(current, isNull)
+	return t, f
+}
+
+// narrow returns the types of the variables e when the variable v has the
+// type accepted, and when it has any of the types it may have but
+// accepted.
+func narrow(v string, e env, accepted ast.Type, rejects func(ast.Type) bool) (t, f env) {
+	t, f = e, e.copy()
+	current, ok := e[v]
+	t[v] = accepted
+	if ok {
+		f[v] = without(current, rejects)
+	}
+	return t, f
+}
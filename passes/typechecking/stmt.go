@@ -0,0 +1,326 @@
+package typecheck
+
+import (
+	"strconv"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// maxIterations bounds the number of times the body of a loop is inferred
+// while the types of its variables change.
+const maxIterations = 8
+
+// loop is a loop or switch, from which break and continue statements leave
+// with the types of their envs.
+type loop struct {
+	breaks, continues []env
+}
+
+// statements infers the types of stmts, given the types of the variables
+// before them e, and returns the types after them, or nil if they cannot
+// complete. Statements after one that cannot complete are inferred as if
+// nothing were known of their variables.
+func (in *inferrer) statements(stmts []ast.Node, e env) env {
+	reachable := true
+	for _, s := range stmts {
+		if e == nil {
+			reachable = false
+			e = env{}
+		}
+		if d := in.doc(s); d != nil && len(d.Vars) > 0 {
+			e = in.annotated(s, d, e)
+			continue
+		}
+		e = in.statement(s, e)
+	}
+	if !reachable {
+		return nil
+	}
+	return e
+}
+
+// statement infers the types of n, given the types of the variables before
+// it e, and returns the types after it, or nil if it cannot complete.
+func (in *inferrer) statement(n ast.Node, e env) env {
+	if ast.IsNil(n) {
+		return e
+	}
+	switch n := n.(type) {
+	case *ast.Block:
+		return in.statements(blockStatements(n), e)
+	case ast.Block:
+		return in.statements(blockStatements(&n), e)
+	case *ast.ExprStmt:
+		if isExit(n.Expr) {
+			in.expr(n.Expr, e)
+			return nil
+		}
+		in.expr(n.Expr, e)
+		return e
+	case *ast.IfStmt:
+		return in.ifStmt(n, e)
+	case *ast.WhileStmt:
+		return in.while(n, e)
+	case ast.WhileStmt:
+		return in.while(&n, e)
+	case *ast.DoWhileStmt:
+		return in.doWhile(n, e)
+	case ast.DoWhileStmt:
+		return in.doWhile(&n, e)
+	case *ast.ForStmt:
+		return in.forStmt(n, e)
+	case ast.ForStmt:
+		return in.forStmt(&n, e)
+	case *ast.ForeachStmt:
+		return in.foreach(n, e)
+	case *ast.SwitchStmt:
+		return in.switchStmt(n, e)
+	case ast.SwitchStmt:
+		return in.switchStmt(&n, e)
+	case *ast.TryStmt:
+		return in.try(n, e)
+	case ast.TryStmt:
+		return in.try(&n, e)
+	case *ast.ReturnStmt:
+		return in.returnStmt(n.Expr, e)
+	case ast.ReturnStmt:
+		return in.returnStmt(n.Expr, e)
+	case *ast.ThrowStmt:
+		in.expr(n.Expr, e)
+		return nil
+	case *ast.ExitStmt, ast.ExitStmt:
+		in.expr(n, e)
+		return nil
+	case *ast.BreakStmt:
+		return in.leave(n.Expr, e, true)
+	case ast.BreakStmt:
+		return in.leave(n.Expr, e, true)
+	case *ast.ContinueStmt:
+		return in.leave(n.Expr, e, false)
+	case ast.ContinueStmt:
+		return in.leave(n.Expr, e, false)
+	case *ast.GlobalDeclaration:
+		for _, v := range n.Identifiers {
+			in.assign(v, ast.Unknown, e)
+		}
+		return e
+	case *ast.StaticVariableDeclaration:
+		for _, d := range n.Declarations {
+			if a, ok := d.(*ast.AssignmentExpr); ok {
+				in.expr(a.Value, e)
+				d = a.Assignee
+			}
+			if v, ok := d.(*ast.Variable); ok {
+				in.assign(v, ast.Unknown, e)
+			}
+		}
+		return e
+	case *ast.DeclareBlock:
+		return in.statement(n.Statements, e)
+	case *ast.FunctionStmt:
+		if fn, ok := in.defs[n.FunctionDefinition]; ok {
+			in.function(fn)
+		}
+		return e
+	case *ast.Class:
+		in.class(n)
+		return e
+	case *ast.Interface, ast.Interface, *ast.UseStmt, ast.UseStmt, *ast.InlineHTML, ast.InlineHTML, ast.EmptyStatement, *ast.EmptyStatement:
+		return e
+	}
+	in.expr(n, e)
+	return e
+}
+
+// isExit reports whether n is an exit or die expression.
+func isExit(n ast.Node) bool {
+	switch n.(type) {
+	case *ast.ExitStmt, ast.ExitStmt:
+		return true
+	}
+	return false
+}
+
+func (in *inferrer) ifStmt(n *ast.IfStmt, e env) env {
+	var ends []env
+	for _, branch := range n.Branches {
+		t, f := in.condition(branch.Condition, e)
+		ends = append(ends, in.statement(branch.Block, t))
+		e = f
+	}
+	if n.ElseBlock != nil {
+		e = in.statement(n.ElseBlock, e)
+	}
+	return merge(append(ends, e)...)
+}
+
+// enter pushes a loop or switch, and exit pops it.
+func (in *inferrer) enter() *loop {
+	l := &loop{}
+	in.loops = append(in.loops, l)
+	return l
+}
+
+func (in *inferrer) exit() {
+	in.loops = in.loops[:len(in.loops)-1]
+}
+
+// leave records e as leaving the loop that the break or continue statement
+// with the level n leaves.
+func (in *inferrer) leave(n ast.Node, e env, isBreak bool) env {
+	level := 1
+	if l, ok := n.(*ast.Literal); ok {
+		if i, err := strconv.Atoi(l.Value); err == nil && i > 0 {
+			level = i
+		}
+	} else if n != nil {
+		in.expr(n, e)
+	}
+	if level > len(in.loops) {
+		return nil
+	}
+	l := in.loops[len(in.loops)-level]
+	if isBreak {
+		l.breaks = append(l.breaks, e)
+	} else {
+		l.continues = append(l.continues, e)
+	}
+	return nil
+}
+
+// iterate infers the types of a loop, calling body with the types of the
+// variables at its start until they no longer change. Body returns the
+// types at the end of an iteration and when the loop ends by its
+// condition. Iterate returns the types after the loop.
+func (in *inferrer) iterate(e env, body func(head env) (end, done env)) env {
+	head := e.copy()
+	for i := 0; ; i++ {
+		l := in.enter()
+		end, done := body(head.copy())
+		in.exit()
+		next := merge(append([]env{e, end}, l.continues...)...)
+		if i == maxIterations || next.equal(head) {
+			return merge(append([]env{done}, l.breaks...)...)
+		}
+		head = next
+	}
+}
+
+func (in *inferrer) while(n *ast.WhileStmt, e env) env {
+	return in.iterate(e, func(head env) (env, env) {
+		t, f := in.condition(n.Termination, head)
+		return in.statement(n.LoopBlock, t), f
+	})
+}
+
+func (in *inferrer) doWhile(n *ast.DoWhileStmt, e env) env {
+	return in.iterate(e, func(head env) (env, env) {
+		end := in.statement(n.LoopBlock, head)
+		end = merge(append([]env{end}, in.loops[len(in.loops)-1].continues...)...)
+		if end == nil {
+			return nil, nil
+		}
+		return in.condition(n.Termination, end)
+	})
+}
+
+func (in *inferrer) forStmt(n *ast.ForStmt, e env) env {
+	for _, expr := range n.Initialization {
+		in.expr(expr, e)
+	}
+	return in.iterate(e, func(head env) (env, env) {
+		t, f := head, env(nil)
+		for i, expr := range n.Termination {
+			if i < len(n.Termination)-1 {
+				in.expr(expr, t)
+				continue
+			}
+			t, f = in.condition(expr, t)
+		}
+		end := in.statement(n.LoopBlock, t)
+		end = merge(append([]env{end}, in.loops[len(in.loops)-1].continues...)...)
+		if end != nil {
+			for _, expr := range n.Iteration {
+				in.expr(expr, end)
+			}
+		}
+		return end, f
+	})
+}
+
+func (in *inferrer) foreach(n *ast.ForeachStmt, e env) env {
+	source := in.expr(n.Source, e)
+	return in.iterate(e, func(head env) (env, env) {
+		done := head.copy()
+		var key, value ast.Type = ast.Unknown, ast.Unknown
+		if a, ok := source.(ast.ArrayOf); ok {
+			key, value = a.Key, a.Value
+			if a.List {
+				key = ast.Integer
+			}
+		}
+		if key == ast.Unknown && is(source, ast.Array) {
+			key = ast.Union(ast.Integer, ast.String)
+		}
+		if n.Key != nil {
+			in.assign(n.Key, key, head)
+		}
+		if n.Value != nil {
+			in.assign(n.Value, value, head)
+		}
+		return in.statement(n.LoopBlock, head), done
+	})
+}
+
+func (in *inferrer) switchStmt(n *ast.SwitchStmt, e env) env {
+	in.expr(n.Expr, e)
+	l := in.enter()
+	var previous env
+	for _, c := range n.Cases {
+		start := e.copy()
+		in.expr(c.Expr, start)
+		previous = in.statement(c.Block, merge(start, previous))
+	}
+	if n.DefaultCase != nil {
+		previous = in.statement(n.DefaultCase, merge(e.copy(), previous))
+	} else {
+		previous = merge(e, previous)
+	}
+	in.exit()
+	return merge(append(append([]env{previous}, l.breaks...), l.continues...)...)
+}
+
+func (in *inferrer) try(n *ast.TryStmt, e env) env {
+	before := e.copy()
+	end := in.statement(n.TryBlock, e)
+	ends := []env{end}
+	for _, c := range n.CatchStmts {
+		caught := merge(before, end)
+		if c.CatchVar != nil {
+			var t ast.Type = ast.Object
+			if name := in.className(&ast.Identifier{Value: c.CatchType}); name != "" {
+				t = ast.ObjectType{Class: name}
+			}
+			in.assign(c.CatchVar, t, caught)
+		}
+		ends = append(ends, in.statement(c.CatchBlock, caught))
+	}
+	after := merge(ends...)
+	if n.FinallyBlock == nil {
+		return after
+	}
+	if after == nil {
+		in.statement(n.FinallyBlock, merge(before, end))
+		return nil
+	}
+	return in.statement(n.FinallyBlock, after)
+}
+
+func (in *inferrer) returnStmt(n ast.Node, e env) env {
+	var t ast.Type = ast.Null
+	if !ast.IsNil(n) {
+		t = in.expr(n, e)
+	}
+	in.returns = append(in.returns, t)
+	return nil
+}
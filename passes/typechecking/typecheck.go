@@ -2,27 +2,210 @@ package typecheck
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/stephens2424/php/ast"
 )
 
-// Walker is a walker
-type Walker struct {
-	ast.DefaultWalker
+// Severity is the severity of a diagnostic.
+type Severity int
+
+const (
+	// Error is a problem that makes PHP fail where the code is run.
+	Error Severity = iota
+	// Warning is a problem that makes PHP fail for some of the values that
+	// the code may be run with.
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// The rules that Check checks, which identify the diagnostics it reports.
+const (
+	// ArgumentCount is passing fewer arguments than a function requires,
+	// or more than it accepts.
+	ArgumentCount = "argument-count"
+	// ArgumentType is passing an argument of a type that the declared type
+	// of the parameter does not accept.
+	ArgumentType = "argument-type"
+	// UndefinedMethod is calling a method that the class of the object
+	// does not have.
+	UndefinedMethod = "undefined-method"
+	// ReturnType is returning a value of a type that the declared return
+	// type does not accept, or none when one is declared.
+	ReturnType = "return-type"
+	// ArrayArithmetic is using an array as an operand of an arithmetic
+	// operator.
+	ArrayArithmetic = "array-arithmetic"
+	// NullDereference is calling a method or reading a property of null.
+	NullDereference = "null-dereference"
+)
+
+// Diagnostic is a problem found in the code of a file set.
+type Diagnostic struct {
+	Severity Severity
+	Rule     string
+	Message  string
+
+	// Path is the path of the file with the problem, in the file set, and
+	// Span its span in it.
+	Path string
+	Span ast.Span
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.Path, d.Span.Begin.Line, d.Span.Begin.Column, d.Severity, d.Message, d.Rule)
 }
 
-func (w *Walker) Walk(node ast.Node) {
-	switch n := node.(type) {
-	case ast.Block:
-		for _, stmt := range n.Statements {
-			w.Walk(stmt)
+// Check checks the types of the code of fs, following the types that Infer
+// infers, and returns the problems it finds, ordered by their positions.
+//
+// A type that cannot be known statically is assumed to be right, and
+// values of the scalar types are accepted for each other, as PHP converts
+// them unless strict types are declared. Where only some of the types that
+// a value may have are wrong, the diagnostic is a warning.
+func Check(fs *ast.FileSet) []Diagnostic {
+	c := &checker{inferrer: inferFiles(fs)}
+	paths := make([]string, 0, len(fs.Files))
+	for path := range fs.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		f := fs.Files[path]
+		c.fn = &function{file: f, path: path}
+		for _, n := range f.Nodes {
+			c.walk(n)
 		}
-	case *ast.IfStmt:
-		for _, branch := range n.Branches {
-			fmt.Println("parsed condition:", branch.Condition)
-			if !branch.Condition.EvaluatesTo().Contains(ast.Boolean) {
-				w.Errorf("If condition does not evaluate to boolean")
-			}
+	}
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		a, b := c.diagnostics[i], c.diagnostics[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
 		}
+		return a.Span.Begin.Position < b.Span.Begin.Position
+	})
+	return c.diagnostics
+}
+
+// checker checks the types of a file set, whose types it has inferred.
+type checker struct {
+	*inferrer
+	diagnostics []Diagnostic
+
+	// guarded is greater than zero in the arguments of isset and empty,
+	// which may be null.
+	guarded int
+}
+
+// report reports a problem with n in the current function.
+func (c *checker) report(n ast.Node, severity Severity, rule, format string, args ...interface{}) {
+	span, _ := c.fn.file.Positions.Span(n)
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Severity: severity,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+		Path:     c.fn.path,
+		Span:     span,
+	})
+}
+
+// walk checks n and the nodes it contains.
+func (c *checker) walk(n ast.Node) {
+	if ast.IsNil(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *ast.FunctionStmt:
+		if fn, ok := c.defs[n.FunctionDefinition]; ok {
+			c.within(fn, n.Body)
+		}
+		return
+	case *ast.Method:
+		if fn, ok := c.defs[n.FunctionDefinition]; ok {
+			c.within(fn, n.Body)
+		}
+		return
+	case *ast.AnonymousFunction:
+		c.within(&function{file: c.fn.file, path: c.fn.path, class: c.fn.class}, n.Body)
+		return
+	case *ast.ReturnStmt:
+		c.checkReturn(n, n.Expr)
+	case ast.ReturnStmt:
+		c.checkReturn(n, n.Expr)
+	case *ast.FunctionCallExpr:
+		c.checkCall(n)
+		id := ast.Static(n.FunctionName)
+		if id != nil && (strings.EqualFold(id.Value, "isset") || strings.EqualFold(id.Value, "empty")) {
+			c.guard(n.Arguments...)
+			return
+		}
+	case *ast.MethodCallExpr:
+		c.checkMethodCall(n)
+		c.walk(n.Receiver)
+		c.walkCall(n.FunctionCallExpr)
+		return
+	case *ast.ClassExpr:
+		if call, ok := n.Expr.(*ast.FunctionCallExpr); ok {
+			c.checkStaticCall(n, call)
+			c.walk(n.Receiver)
+			c.walkCall(call)
+			return
+		}
+	case *ast.NewCallExpr:
+		c.checkNew(n)
+	case *ast.PropertyCallExpr:
+		c.dereference(n, n.Receiver, "access property "+describeName(n.Name)+" of")
+	case ast.BinaryExpr:
+		c.arithmetic(n, n.Operator, n.Antecedent, n.Subsequent)
+	case ast.UnaryCallExpr:
+		if n.Operator == "-" || n.Operator == "+" {
+			c.arithmetic(n, n.Operator, nil, n.Operand)
+		}
+	}
+	for _, child := range n.Children() {
+		c.walk(child)
+	}
+}
+
+// within checks body, of the function fn.
+func (c *checker) within(fn *function, body *ast.Block) {
+	saved := c.fn
+	c.fn = fn
+	c.walk(body)
+	c.fn = saved
+}
+
+// walkCall checks the name and arguments of the method call f.
+func (c *checker) walkCall(f *ast.FunctionCallExpr) {
+	if ast.Static(f.FunctionName) == nil {
+		c.walk(f.FunctionName)
+	}
+	for _, arg := range f.Arguments {
+		c.walk(arg)
+	}
+}
+
+// guard checks nodes, in which null is not dereferenced.
+func (c *checker) guard(nodes ...ast.Expr) {
+	c.guarded++
+	for _, n := range nodes {
+		c.walk(n)
+	}
+	c.guarded--
+}
+
+// describeName describes the name of a method or property, which may be
+// dynamic.
+func describeName(n ast.Dynamic) string {
+	if id := ast.Static(n); id != nil {
+		return id.Value
 	}
+	return "(dynamic)"
 }
@@ -0,0 +1,234 @@
+package typecheck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+// checkDiagnostics checks src as the file x.php, and compares the line,
+// severity and rule of each diagnostic to want.
+func checkDiagnostics(t *testing.T, src string, want []string) {
+	p := parser.NewParser()
+	if _, err := p.Parse("x.php", src); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range Check(p.FileSet) {
+		got = append(got, fmt.Sprintf("%d %s %s", d.Span.Begin.Line, d.Severity, d.Rule))
+		t.Log(d)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCheckArguments(t *testing.T) {
+	checkDiagnostics(t, `<?php
+class Item {}
+class Other {}
+
+function take(Item $item, array $list, $count = 0) {}
+function variadic() { return func_get_args(); }
+
+take(new Item, array());
+take(new Item);
+take(new Item, array(), 1, 2);
+variadic(1, 2, 3);
+take(new Other, array());
+take(new Item, "list");
+take(null, array());
+strlen("a", "b");
+strlen();
+strlen(1);
+strlen(array());
+$item = rand() ? new Item : new Other;
+take($item, array());
+$f = new Item(1);
+$d = new DateTime(array());
+$d->setDate(2000, 1);
+`, []string{
+		"9 error argument-count",
+		"10 warning argument-count",
+		"12 error argument-type",
+		"13 error argument-type",
+		"14 error argument-type",
+		"15 error argument-count",
+		"16 error argument-count",
+		"18 error argument-type",
+		"20 warning argument-type",
+		"22 error argument-type",
+		"23 error argument-count",
+	})
+}
+
+func TestCheckMethods(t *testing.T) {
+	checkDiagnostics(t, `<?php
+interface Shape { function area(); }
+class Square implements Shape {
+	function area() { return 1; }
+	static function make() { return new Square; }
+}
+/** @method int perimeter() */
+class Magic extends Square {}
+class Dynamic { function __call($name, $args) {} }
+class Unknown extends Undeclared {}
+
+$s = new Square;
+$s->area();
+$s->volume();
+Square::make();
+Square::unmake();
+$m = new Magic;
+$m->perimeter();
+$d = new Dynamic;
+$d->anything();
+$u = new Unknown;
+$u->anything();
+$e = new Exception("e");
+$e->getMessage();
+$e->getMassage();
+$shape = rand() ? new Square : new Exception;
+$shape->area();
+function f(Shape $shape) { $shape->area(); $shape->volume(); }
+function generate(): Generator { yield; }
+function lines() { $line = yield; }
+$lines = lines();
+$lines->send("line");
+$lines->rewind();
+$lines->close();
+function pairs() { yield "a" => 1; yield from lines(); }
+$pairs = pairs();
+$pairs->key();
+$pairs->keys();
+`, []string{
+		"14 error undefined-method",
+		"16 error undefined-method",
+		"25 error undefined-method",
+		"27 warning undefined-method",
+		"28 error undefined-method",
+		"34 error undefined-method",
+		"38 error undefined-method",
+	})
+}
+
+func TestCheckTraitMethods(t *testing.T) {
+	checkDiagnostics(t, `<?php
+trait T { function hello() {} function greet($name) {} }
+trait U { function hello() {} }
+class C { use T; }
+class D {
+	use T, U { T::hello insteadof U; U::hello as protected helloU; greet as welcome; }
+}
+class E extends C {}
+
+$c = new C;
+$c->hello();
+$c->greet();
+$c->goodbye();
+$d = new D;
+$d->helloU();
+$d->welcome("you");
+$d->welcome();
+$e = new E;
+$e->hello();
+`, []string{
+		"12 error argument-count",
+		"13 error undefined-method",
+		"17 error argument-count",
+	})
+}
+
+func TestCheckReturns(t *testing.T) {
+	checkDiagnostics(t, `<?php
+class Item {}
+
+function item(): Item {
+	return new Item;
+}
+function wrong(): Item {
+	return array();
+}
+function maybe($x): string {
+	if ($x) {
+		return array();
+	}
+	return "s";
+}
+function nothing(): void {
+	return 1;
+}
+function missing(): int {
+	return;
+}
+function converted(): int {
+	return "1";
+}
+function nullable(Item $item = null): Item {
+	return $item;
+}
+`, []string{
+		"8 error return-type",
+		"12 error return-type",
+		"17 error return-type",
+		"20 error return-type",
+		"26 warning return-type",
+	})
+}
+
+func TestCheckArithmetic(t *testing.T) {
+	checkDiagnostics(t, `<?php
+$a = array(1);
+$n = 1;
+$sum = $a + $a;
+$bad = $a + 1;
+$product = $a * 2;
+$negative = -$a;
+$maybe = rand() ? $a : 1;
+$difference = $maybe - 1;
+$fine = $n * 2;
+`, []string{
+		"5 error array-arithmetic",
+		"6 error array-arithmetic",
+		"7 error array-arithmetic",
+		"9 warning array-arithmetic",
+	})
+}
+
+func TestCheckNull(t *testing.T) {
+	checkDiagnostics(t, `<?php
+class Item {
+	public $name;
+	function name() { return $this->name; }
+}
+
+$null = null;
+$null->name();
+$item = rand() ? new Item : null;
+$item->name();
+echo $item->name;
+if ($item !== null) {
+	$item->name();
+}
+if (isset($item->name)) {}
+if ($item) {
+	$item->name();
+}
+$item ? $item->name() : 0;
+$item != null && $item->name();
+$item && $item->name();
+if ($null) {
+	$null->name();
+}
+if (!$item) {
+	return;
+}
+$item->name();
+`, []string{
+		"8 error null-dereference",
+		"10 warning null-dereference",
+		"11 warning null-dereference",
+	})
+}
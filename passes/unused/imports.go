@@ -0,0 +1,98 @@
+package unused
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// docName matches a name written in a doc comment, which may be qualified.
+var docName = regexp.MustCompile(`\\?[A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*(\\[A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*)*`)
+
+// imports reports the use declarations of the current file whose names the
+// file never writes. A name is written if it names a class, in code or in
+// a type in a doc comment, or begins a qualified name of a class,
+// function or constant.
+func (u *finder) imports() {
+	var uses []*ast.UseStmt
+	for _, n := range u.file.Nodes {
+		if use, ok := n.(*ast.UseStmt); ok {
+			uses = append(uses, use)
+		}
+	}
+	if len(uses) == 0 {
+		return
+	}
+
+	written := map[string]bool{}
+	write := func(name string, qualified bool) {
+		if strings.HasPrefix(name, `\`) {
+			return
+		}
+		i := strings.Index(name, `\`)
+		if i >= 0 {
+			name = name[:i]
+		} else if qualified {
+			return
+		}
+		written[strings.ToLower(name)] = true
+	}
+	for _, name := range u.file.ClassNames {
+		write(name.Name, false)
+	}
+	for _, doc := range u.file.Docs {
+		for _, name := range docName.FindAllString(doc, -1) {
+			write(name, false)
+		}
+	}
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		if ast.IsNil(n) {
+			return
+		}
+		switch n := n.(type) {
+		case *ast.NewCallExpr:
+			if id := ast.Static(n.Class); id != nil {
+				write(id.Value, false)
+			}
+		case *ast.ClassExpr:
+			if id := ast.Static(n.Receiver); id != nil {
+				write(id.Value, false)
+			}
+		case ast.BinaryExpr:
+			if c, ok := n.Subsequent.(ast.ConstantExpr); ok && n.Operator == "instanceof" {
+				if id := ast.Static(c.Name); id != nil {
+					write(id.Value, false)
+				}
+			}
+		case ast.ConstantExpr:
+			if id := ast.Static(n.Name); id != nil {
+				write(id.Value, true)
+			}
+		case *ast.FunctionCallExpr:
+			if id := ast.Static(n.FunctionName); id != nil {
+				write(id.Value, true)
+			}
+		case *ast.CatchStmt:
+			write(n.CatchType, false)
+		}
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	for _, n := range u.file.Nodes {
+		walk(n)
+	}
+
+	for _, use := range uses {
+		name := strings.TrimPrefix(use.Name, `\`)
+		alias := use.Alias
+		if alias == "" {
+			alias = name[strings.LastIndex(name, `\`)+1:]
+		}
+		if !written[strings.ToLower(alias)] {
+			u.report(Import, name, use, use)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package unused
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// access is how a variable is used.
+type access int
+
+const (
+	read access = 1 << iota
+	write
+)
+
+// function is a function, method or closure whose variables are checked.
+type function struct {
+	file *ast.File
+
+	// access holds the uses of the variables of the function, found by
+	// walking it, by the variable nodes that its scope references.
+	access map[*ast.Variable]access
+
+	// reads holds the offsets of the uses of variables that the scope does
+	// not reference: variables captured by closures and interpolated in
+	// strings, and names given to compact.
+	reads map[string][]int
+
+	// kept holds the names of the variables declared global or static, or
+	// bound by reference, which are never unused.
+	kept map[string]bool
+
+	// dynamic is set if the function may use variables without naming
+	// them, and allArgs if it reads its arguments with func_get_args.
+	dynamic, allArgs bool
+
+	// catches holds the catch clauses of the function, values the value
+	// variables of its foreach loops, and elements the variables whose
+	// elements it assigns, which for an object implementing ArrayAccess
+	// uses it.
+	catches  []*ast.CatchStmt
+	values   map[*ast.Variable]bool
+	elements map[*ast.Variable]bool
+}
+
+// check reports the unused parameters and variables of the function,
+// method or closure n, with the arguments args, closure variables closure
+// and body, declared in the class c.
+func (u *finder) check(n ast.Node, args, closure []*ast.FunctionArgument, body *ast.Block, c *ast.Class) {
+	if body == nil || body.Scope == nil {
+		return
+	}
+	fn := &function{
+		file:     u.file,
+		access:   map[*ast.Variable]access{},
+		reads:    map[string][]int{},
+		kept:     map[string]bool{"this": true},
+		values:   map[*ast.Variable]bool{},
+		elements: map[*ast.Variable]bool{},
+	}
+	for _, s := range body.Statements {
+		fn.walk(s, 0)
+	}
+	if fn.dynamic || len(body.Scope.DynamicVariables) > 0 {
+		return
+	}
+
+	params := map[string]bool{}
+	for _, arg := range args {
+		if name := ast.Static(arg.Variable.Name); name != nil {
+			params[name.Value] = true
+		}
+	}
+	for _, arg := range closure {
+		if name := ast.Static(arg.Variable.Name); name != nil {
+			params[name.Value] = true
+			if !arg.Reference && !fn.read(body.Scope, name.Value) {
+				u.report(Variable, arg.Variable.String(), arg, arg.Variable)
+			}
+		}
+	}
+	if !fn.allArgs && !u.implementation(n, c) {
+		u.checkParams(fn, n, args, body.Scope)
+	}
+
+	for name, group := range body.Scope.Identifiers {
+		if fn.kept[name] || ast.SuperGlobals[name] {
+			continue
+		}
+		u.checkCatches(fn, name, group)
+		if params[name] || fn.read(body.Scope, name) {
+			continue
+		}
+		if v := fn.firstWrite(group); v != nil {
+			u.report(Variable, v.String(), v, v)
+		}
+	}
+}
+
+// checkParams reports the unused parameters args of the function n. Only
+// the parameters of a closure after the last one it reads are unused.
+func (u *finder) checkParams(fn *function, n ast.Node, args []*ast.FunctionArgument, scope *ast.Scope) {
+	first := 0
+	if _, ok := n.(*ast.AnonymousFunction); ok {
+		for i, arg := range args {
+			if name := ast.Static(arg.Variable.Name); name != nil && fn.read(scope, name.Value) {
+				first = i + 1
+			}
+		}
+	}
+	for _, arg := range args[first:] {
+		name := ast.Static(arg.Variable.Name)
+		if name == nil || arg.Reference || fn.kept[name.Value] || fn.read(scope, name.Value) || fn.element(scope, name.Value) {
+			continue
+		}
+		u.report(Parameter, arg.Variable.String(), arg, arg.Variable)
+	}
+}
+
+// implementation reports whether n is a method whose signature is given
+// by a method it implements or overrides, when such methods are ignored.
+func (u *finder) implementation(n ast.Node, c *ast.Class) bool {
+	stmt, ok := n.(*ast.FunctionStmt)
+	if !ok || c == nil || u.h == nil {
+		return false
+	}
+	t := u.h.Declaration(c)
+	return t != nil && len(t.Overrides(stmt.Name)) > 0
+}
+
+// checkCatches reports the catch clauses whose variable name the clause
+// never reads.
+func (u *finder) checkCatches(fn *function, name string, group ast.VariableGroup) {
+	for _, c := range fn.catches {
+		if v := ast.Static(c.CatchVar.Name); v == nil || v.Value != name {
+			continue
+		}
+		span, ok := u.file.Positions.Span(c.CatchBlock)
+		if !ok {
+			continue
+		}
+		if !fn.readBetween(group, name, span.Begin.Position, span.End.Position) {
+			u.report(CatchVariable, c.CatchVar.String(), c, c.CatchVar)
+		}
+	}
+}
+
+// read reports whether the function reads the variable name.
+func (fn *function) read(scope *ast.Scope, name string) bool {
+	return fn.readBetween(scope.Identifiers[name], name, 0, -1)
+}
+
+// readBetween reports whether the function reads the variable name, of the
+// variable group, between the offsets begin and end, or anywhere after
+// begin if end is negative.
+func (fn *function) readBetween(group ast.VariableGroup, name string, begin, end int) bool {
+	in := func(offset int) bool {
+		return offset >= begin && (end < 0 || offset < end)
+	}
+	for _, v := range group.References {
+		if fn.access[v]&read == 0 {
+			continue
+		}
+		if span, ok := fn.file.Positions[v]; ok && in(span.Begin.Position) {
+			return true
+		}
+	}
+	for _, offset := range fn.reads[name] {
+		if in(offset) {
+			return true
+		}
+	}
+	return false
+}
+
+// element reports whether the function assigns elements of the variable
+// name.
+func (fn *function) element(scope *ast.Scope, name string) bool {
+	for _, v := range scope.Identifiers[name].References {
+		if fn.elements[v] {
+			return true
+		}
+	}
+	return false
+}
+
+// firstWrite returns the first variable of the group that the function
+// assigns, other than by catching an exception or iterating over values in
+// foreach, or nil.
+func (fn *function) firstWrite(group ast.VariableGroup) *ast.Variable {
+	var first *ast.Variable
+	firstOffset := 0
+	for _, v := range group.References {
+		if fn.access[v]&write == 0 || fn.values[v] || fn.caught(v) {
+			continue
+		}
+		span, ok := fn.file.Positions[v]
+		if ok && (first == nil || span.Begin.Position < firstOffset) {
+			first, firstOffset = v, span.Begin.Position
+		}
+	}
+	return first
+}
+
+// caught reports whether v is the variable of a catch clause.
+func (fn *function) caught(v *ast.Variable) bool {
+	for _, c := range fn.catches {
+		if c.CatchVar == v {
+			return true
+		}
+	}
+	return false
+}
+
+// add records the use of the variable v, if it is named statically, and
+// reports whether it is.
+func (fn *function) add(v *ast.Variable, a access) bool {
+	if ast.Static(v.Name) == nil {
+		return false
+	}
+	fn.access[v] |= a
+	return true
+}
+
+// keep records that the variable n is never unused, if it is one.
+func (fn *function) keep(n ast.Node) {
+	if v, ok := n.(*ast.Variable); ok {
+		if name := ast.Static(v.Name); name != nil {
+			fn.kept[name.Value] = true
+		}
+	}
+}
+
+// walk finds the uses of variables in n, whose value is read if a has
+// read set, and written if it has write set.
+func (fn *function) walk(n ast.Node, a access) {
+	if ast.IsNil(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *ast.Variable:
+		if !fn.add(n, a) {
+			fn.walk(n.Name, read)
+		}
+		return
+	case *ast.ExprStmt:
+		fn.walk(n.Expr, 0)
+		return
+	case ast.AssignmentExpr:
+		if ref, ok := n.Value.(ast.UnaryCallExpr); ok && ref.Operator == "&" {
+			fn.keep(n.Assignee)
+			fn.keep(ref.Operand)
+		}
+		fn.walk(n.Value, read)
+		if n.Operator == "=" {
+			fn.walk(n.Assignee, write)
+		} else {
+			fn.walk(n.Assignee, a&read|write)
+		}
+		return
+	case *ast.AssignmentExpr:
+		fn.walk(*n, a)
+		return
+	case *ast.ListStatement:
+		for _, assignee := range n.Assignees {
+			fn.walk(assignee, write)
+		}
+		fn.walk(n.Value, read)
+		return
+	case ast.UnaryCallExpr:
+		if n.Operator == "++" || n.Operator == "--" {
+			fn.walk(n.Operand, a&read|write)
+			return
+		}
+	case ast.ArrayAppendExpr:
+		fn.walkElement(n.Array, a)
+		return
+	case *ast.ArrayLookupExpr:
+		fn.walkElement(n.Array, a)
+		fn.walk(n.Index, read)
+		return
+	case *ast.ForeachStmt:
+		fn.walk(n.Source, read)
+		fn.walk(n.Key, write)
+		if n.Value != nil {
+			fn.values[n.Value] = true
+			fn.walk(n.Value, write)
+		}
+		fn.walk(n.LoopBlock, 0)
+		return
+	case *ast.CatchStmt:
+		if n.CatchVar != nil {
+			fn.catches = append(fn.catches, n)
+			fn.walk(n.CatchVar, write)
+		}
+		fn.walk(n.CatchBlock, 0)
+		return
+	case *ast.GlobalDeclaration:
+		for _, v := range n.Identifiers {
+			fn.keep(v)
+			fn.walk(v, write)
+		}
+		return
+	case *ast.StaticVariableDeclaration:
+		for _, d := range n.Declarations {
+			if assignment, ok := d.(*ast.AssignmentExpr); ok {
+				fn.walk(assignment.Value, read)
+				d = assignment.Assignee
+			}
+			fn.keep(d)
+			fn.walk(d, write)
+		}
+		return
+	case *ast.FunctionCallExpr:
+		if fn.call(n) {
+			return
+		}
+	case ast.Include, *ast.Include, ast.IncludeStmt, *ast.IncludeStmt:
+		fn.dynamic = true
+	case *ast.ClassExpr:
+		// a static property is not a variable
+		if _, ok := n.Expr.(*ast.Variable); ok {
+			fn.walk(n.Receiver, read)
+			return
+		}
+	case ast.ConstantExpr:
+		return
+	case *ast.AnonymousFunction:
+		for _, arg := range n.ClosureVariables {
+			fn.walk(arg.Variable, read)
+		}
+		return
+	case *ast.FunctionStmt, *ast.Class, *ast.Interface:
+		return
+	case *ast.Literal:
+		fn.interpolated(n)
+		return
+	}
+	for _, child := range n.Children() {
+		fn.walk(child, read)
+	}
+}
+
+// walkElement finds the uses of variables in the array n, an element of
+// which is used with access a.
+func (fn *function) walkElement(n ast.Node, a access) {
+	if v, ok := n.(*ast.Variable); ok && a&write != 0 {
+		fn.elements[v] = true
+	}
+	fn.walk(n, a)
+}
+
+// call finds the uses of variables in the call f to a function that uses
+// variables by name, and reports whether it is one.
+func (fn *function) call(f *ast.FunctionCallExpr) bool {
+	name := ast.Static(f.FunctionName)
+	if name == nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimPrefix(name.Value, `\`)) {
+	case "compact":
+		for _, arg := range f.Arguments {
+			fn.compact(arg)
+		}
+		return true
+	case "isset", "empty":
+		for _, arg := range f.Arguments {
+			fn.walk(arg, read)
+		}
+		return true
+	case "unset":
+		for _, arg := range f.Arguments {
+			fn.walk(arg, write)
+		}
+		return true
+	case "extract", "get_defined_vars", "eval":
+		fn.dynamic = true
+	case "parse_str":
+		if len(f.Arguments) < 2 {
+			fn.dynamic = true
+		}
+	case "func_get_args", "func_get_arg":
+		fn.allArgs = true
+	}
+	return false
+}
+
+// compact records the variables named by the argument n of compact, which
+// may be a string or an array of them, as read.
+func (fn *function) compact(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Literal:
+		span, ok := fn.file.Positions[n]
+		if n.Type != ast.String || len(n.Value) < 2 || !ok {
+			fn.dynamic = true
+			return
+		}
+		name := n.Value[1 : len(n.Value)-1]
+		fn.reads[name] = append(fn.reads[name], span.Begin.Position)
+	case *ast.ArrayExpr:
+		for _, pair := range n.Pairs {
+			fn.compact(pair.Value)
+		}
+	default:
+		fn.walk(n, read)
+		fn.dynamic = true
+	}
+}
+
+// interpolation matches a variable interpolated in a string, as $name or
+// ${name}, with its name as the first group.
+var interpolation = regexp.MustCompile(`\$\{?([A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*)`)
+
+// interpolated records the variables interpolated in the string l as read.
+func (fn *function) interpolated(l *ast.Literal) {
+	if l.Type != ast.String || !strings.HasPrefix(l.Value, `"`) && !strings.HasPrefix(l.Value, "<<<") || strings.HasPrefix(l.Value, "<<<'") {
+		return
+	}
+	span, ok := fn.file.Positions[l]
+	if !ok {
+		return
+	}
+	for _, m := range interpolation.FindAllStringSubmatchIndex(l.Value, -1) {
+		if m[0] > 0 && l.Value[m[0]-1] == '\\' {
+			continue
+		}
+		name := l.Value[m[2]:m[3]]
+		fn.reads[name] = append(fn.reads[name], span.Begin.Position)
+	}
+}
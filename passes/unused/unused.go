@@ -0,0 +1,165 @@
+// Package unused finds the declarations of PHP code that are never used:
+// local variables assigned but never read, function parameters, imports
+// and catch variables.
+//
+// Variables are found through the scopes of functions, as recorded by the
+// parser in ast.Scope, and a function whose variables may be used without
+// being named, through variable variables, compact with names that are
+// not known statically, extract, get_defined_vars, eval or include, is
+// left out. The code outside of functions, whose variables are global, is
+// left out too.
+package unused
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/passes/hierarchy"
+)
+
+// Kind is the kind of an unused declaration.
+type Kind int
+
+const (
+	// Variable is a local variable assigned but never read, or a variable
+	// a closure captures but never reads.
+	Variable Kind = iota
+	// Parameter is a parameter of a function, method or closure never
+	// read.
+	Parameter
+	// Import is a use declaration importing a name never written.
+	Import
+	// CatchVariable is the variable of a catch clause that the clause
+	// never reads.
+	CatchVariable
+)
+
+var kindNames = map[Kind]string{
+	Variable:      "variable",
+	Parameter:     "parameter",
+	Import:        "import",
+	CatchVariable: "catch variable",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Options are the options of Find.
+type Options struct {
+	// IgnoreImplementations leaves out the parameters of methods that
+	// implement or override methods of the interfaces and classes that
+	// their classes extend, whose signatures they must keep.
+	IgnoreImplementations bool
+}
+
+// Finding is an unused declaration.
+type Finding struct {
+	Kind Kind
+
+	// Name is the name of the variable, with its $, or the name imported.
+	Name string
+
+	// Path is the path of the file declaring it, in the file set, and Span
+	// its span in it.
+	Path string
+	Span ast.Span
+
+	// Node is the *ast.Variable of the first assignment of a variable, the
+	// *ast.FunctionArgument of a parameter or captured variable, the
+	// *ast.UseStmt of an import or the *ast.CatchStmt of a catch variable.
+	Node ast.Node
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: unused %s %s", f.Path, f.Span.Begin.Line, f.Span.Begin.Column, f.Kind, f.Name)
+}
+
+// Find returns the unused declarations of fs, ordered by their positions.
+//
+// A variable is read where its value is used, so one that is only
+// assigned, incremented or appended to is unused, but one passed to a
+// function, which may take it by reference, is read. Variables declared
+// global or static, and those bound by reference with =&, are never
+// unused, and neither is the value variable of a foreach loop, which the
+// loop needs. A parameter passed by reference is never unused, nor are the
+// parameters of a function calling func_get_args or func_get_arg. As the
+// parameters of a closure are given by its caller, only those after the
+// last one the closure reads are unused.
+func Find(fs *ast.FileSet, options Options) []Finding {
+	u := &finder{fs: fs, options: options}
+	if options.IgnoreImplementations {
+		u.h = hierarchy.New(fs)
+	}
+	paths := make([]string, 0, len(fs.Files))
+	for path := range fs.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		u.path, u.file = path, fs.Files[path]
+		u.imports()
+		for _, n := range u.file.Nodes {
+			u.walk(n, nil)
+		}
+	}
+	sort.SliceStable(u.findings, func(i, j int) bool {
+		a, b := u.findings[i], u.findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Span.Begin.Position < b.Span.Begin.Position
+	})
+	return u.findings
+}
+
+// finder finds the unused declarations of a file set.
+type finder struct {
+	fs      *ast.FileSet
+	options Options
+	h       *hierarchy.Hierarchy
+
+	path string
+	file *ast.File
+
+	findings []Finding
+}
+
+// report records an unused declaration named name, of kind k, whose span
+// is that of the name of at, or of at.
+func (u *finder) report(k Kind, name string, n, at ast.Node) {
+	span, ok := u.file.Names[at]
+	if !ok {
+		span, ok = u.file.Positions.Span(at)
+	}
+	if !ok {
+		return
+	}
+	u.findings = append(u.findings, Finding{Kind: k, Name: name, Path: u.path, Span: span, Node: n})
+}
+
+// walk finds the functions, methods and closures in n, declared in the
+// class c, which may be nil, and checks each.
+func (u *finder) walk(n ast.Node, c *ast.Class) {
+	if ast.IsNil(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *ast.Class:
+		for _, m := range n.Methods {
+			u.check(m.FunctionStmt, m.Arguments, nil, m.Body, n)
+		}
+		for _, child := range n.Children() {
+			u.walk(child, n)
+		}
+		return
+	case *ast.FunctionStmt:
+		u.check(n, n.Arguments, nil, n.Body, c)
+	case *ast.AnonymousFunction:
+		u.check(n, n.Arguments, n.ClosureVariables, n.Body, c)
+	}
+	for _, child := range n.Children() {
+		u.walk(child, c)
+	}
+}
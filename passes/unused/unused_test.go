@@ -0,0 +1,169 @@
+package unused
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+func find(t *testing.T, src string, options Options) []string {
+	p := parser.NewParser()
+	if _, err := p.Parse("test.php", src); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range Find(p.FileSet, options) {
+		got = append(got, f.String())
+	}
+	return got
+}
+
+func check(t *testing.T, got, want []string) {
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unused:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestVariables(t *testing.T) {
+	src := `<?php
+	function f($a, $b, &$out) {
+		$x = 1;
+		$y = 2;
+		echo $y;
+		$count = 0;
+		$count++;
+		$list = array();
+		$list[] = $a;
+		$out = 3;
+		$s = "$z {$w}";
+		$z = 1;
+		$w = 2;
+		foreach (g() as $k => $v) {}
+		global $g;
+		$g = 1;
+		static $n = 0;
+		$n = 1;
+		$r = &$q;
+		$r = 1;
+	}
+
+	function compacted() {
+		$a = 1;
+		$b = 2;
+		$c = 3;
+		return compact('a', array('b'));
+	}
+
+	function extracted($args) {
+		extract($args);
+		$a = 1;
+	}
+
+	function variable($name) {
+		$a = 1;
+		return $$name;
+	}
+
+	function all() {
+		$args = func_get_args();
+		return $args;
+	}
+
+	function catches() {
+		try {
+			g();
+		} catch (Exception $e) {
+			echo "failed";
+		}
+		try {
+			g();
+		} catch (Exception $f) {
+			echo $f->getMessage();
+		}
+	}
+
+	function closures($items) {
+		$unused = 1;
+		$prefix = "-";
+		return array_map(function($key, $item, $extra) use ($prefix, $unused) {
+			return $prefix . $item;
+		}, $items);
+	}
+	`
+	check(t, find(t, src, Options{}), []string{
+		"test.php:2:17: unused parameter $b",
+		"test.php:3:3: unused variable $x",
+		"test.php:6:3: unused variable $count",
+		"test.php:8:3: unused variable $list",
+		"test.php:11:3: unused variable $s",
+		"test.php:14:19: unused variable $k",
+		"test.php:26:3: unused variable $c",
+		"test.php:48:22: unused catch variable $e",
+		"test.php:61:42: unused parameter $extra",
+		"test.php:61:64: unused variable $unused",
+	})
+}
+
+func TestParameters(t *testing.T) {
+	src := `<?php
+	interface Handler {
+		function handle($request, $response);
+	}
+
+	class Base {
+		function render($view) {}
+	}
+
+	class Page extends Base implements Handler {
+		function handle($request, $response) {
+			return $request;
+		}
+		function render($view) {}
+		function own($unused) {}
+		function offset($storage) {
+			$storage['key'] = 1;
+		}
+	}
+	`
+	check(t, find(t, src, Options{}), []string{
+		"test.php:7:19: unused parameter $view",
+		"test.php:11:29: unused parameter $response",
+		"test.php:14:19: unused parameter $view",
+		"test.php:15:16: unused parameter $unused",
+	})
+	check(t, find(t, src, Options{IgnoreImplementations: true}), []string{
+		"test.php:7:19: unused parameter $view",
+		"test.php:15:16: unused parameter $unused",
+	})
+}
+
+func TestImports(t *testing.T) {
+	src := `<?php
+	namespace App;
+	use Lib\Model;
+	use Lib\Unused;
+	use Lib\Thrown as Failure;
+	use Lib\Helpers;
+	use Lib\Documented;
+	use Lib\Checked;
+	use Lib\Typed;
+
+	/**
+	 * @return Documented
+	 */
+	function f(Typed $t) {
+		try {
+			$t->run();
+			$m = new Model;
+			$ok = $m instanceof Checked;
+			return Helpers\format($ok);
+		} catch (Failure $e) {
+			throw $e;
+		}
+	}
+	`
+	check(t, find(t, src, Options{}), []string{
+		"test.php:4:6: unused import Lib\\Unused",
+	})
+}
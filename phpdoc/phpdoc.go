@@ -0,0 +1,442 @@
+// Package phpdoc parses doc comments, and the types written in them.
+package phpdoc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// Doc is a parsed doc comment.
+type Doc struct {
+	// Summary is the text before the first tag.
+	Summary string
+
+	Params     []Param
+	Return     ast.Type // nil if there is no @return tag
+	Vars       []Var
+	Properties []Property
+	Methods    []Method
+	Throws     []ast.Type
+	Templates  []Template
+
+	// Deprecated reports whether there is a @deprecated tag, whose text,
+	// which may begin with the version deprecating it, is Deprecation.
+	// Since and Removed are the versions of the @since and @removed tags.
+	Deprecated  bool
+	Deprecation string
+	Since       string
+	Removed     string
+
+	// Errors holds the errors in tags that could not be parsed, which are
+	// otherwise ignored.
+	Errors []error
+}
+
+// Param is a @param tag.
+type Param struct {
+	Name        string // without the $
+	Type        ast.Type
+	Variadic    bool
+	ByRef       bool
+	Description string
+}
+
+// Var is a @var tag.
+type Var struct {
+	Name        string // without the $, and empty if not given
+	Type        ast.Type
+	Description string
+}
+
+// Property is a @property, @property-read or @property-write tag.
+type Property struct {
+	Name        string // without the $
+	Type        ast.Type
+	Read, Write bool
+	Description string
+}
+
+// Method is a @method tag.
+type Method struct {
+	Name        string
+	Static      bool
+	Return      ast.Type
+	Params      []Param
+	Description string
+}
+
+// Template is a @template tag, declaring a type parameter.
+type Template struct {
+	Name  string
+	Bound ast.Type // nil if the type parameter is not bounded
+}
+
+// Param returns the @param tag of the parameter named name, or nil if there
+// is none.
+func (d *Doc) Param(name string) *Param {
+	name = strings.TrimPrefix(name, "$")
+	for i := range d.Params {
+		if d.Params[i].Name == name {
+			return &d.Params[i]
+		}
+	}
+	return nil
+}
+
+// Var returns the @var tag of the variable or property named name, or nil
+// if there is none. A @var tag without a name is returned for any name.
+func (d *Doc) Var(name string) *Var {
+	name = strings.TrimPrefix(name, "$")
+	for i := range d.Vars {
+		if d.Vars[i].Name == name || d.Vars[i].Name == "" {
+			return &d.Vars[i]
+		}
+	}
+	return nil
+}
+
+// Parse parses the doc comment text, which may include the /** and */
+// that delimit it, using resolve to resolve the names of classes. Type
+// parameters declared by @template tags in text are TypeParameters in the
+// types of its other tags.
+func Parse(text string, resolve Resolver) *Doc {
+	if resolve == nil {
+		resolve = resolveClass
+	}
+	d := &Doc{}
+	tags := split(text)
+	if len(tags) > 0 && !strings.HasPrefix(tags[0], "@") {
+		d.Summary = tags[0]
+		tags = tags[1:]
+	}
+
+	// declare the type parameters first, so that tags may use them
+	// wherever they are in the comment
+	var templates map[string]ast.Type
+	for _, tag := range tags {
+		name, rest := cutTag(tag)
+		switch name {
+		case "template", "template-covariant", "template-contravariant":
+		default:
+			continue
+		}
+		t, err := d.template(rest, resolve)
+		if err != nil {
+			d.Errors = append(d.Errors, err)
+			continue
+		}
+		d.Templates = append(d.Templates, t)
+		if templates == nil {
+			templates = map[string]ast.Type{}
+		}
+		templates[t.Name] = ast.TypeParameter{Name: t.Name, Bound: t.Bound}
+	}
+	if templates != nil {
+		outer := resolve
+		resolve = func(name string) ast.Type {
+			if t, ok := templates[name]; ok {
+				return t
+			}
+			return outer(name)
+		}
+	}
+
+	for _, tag := range tags {
+		if err := d.tag(tag, resolve); err != nil {
+			d.Errors = append(d.Errors, err)
+		}
+	}
+	return d
+}
+
+// split returns the summary and the tags of the comment text, with the
+// comment delimiters and the leading asterisks of its lines removed.
+func split(text string) []string {
+	text = strings.TrimPrefix(strings.TrimSpace(text), "/**")
+	text = strings.TrimSuffix(text, "*/")
+	var parts []string
+	var current []string
+	flush := func() {
+		if s := strings.TrimSpace(strings.Join(current, "\n")); s != "" {
+			parts = append(parts, s)
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		if strings.HasPrefix(line, "@") {
+			flush()
+		}
+		current = append(current, line)
+	}
+	flush()
+	return parts
+}
+
+// cutTag returns the name of tag, without the @ and any psalm- or phpstan-
+// prefix, and the rest of it.
+func cutTag(tag string) (name, rest string) {
+	name, rest = cutWord(tag[1:])
+	name = strings.ToLower(name)
+	name = strings.TrimPrefix(name, "psalm-")
+	name = strings.TrimPrefix(name, "phpstan-")
+	return name, rest
+}
+
+// cutWord returns the text of s before the first white space and the text
+// after it, with the white space removed.
+func cutWord(s string) (word, rest string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t\n"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// cutType returns the type at the beginning of s, which may contain white
+// space inside brackets or around a |, and the text after it.
+func cutType(s string) (typ, rest string) {
+	s = strings.TrimSpace(s)
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '<', '(', '{', '[':
+			depth++
+		case '>', ')', '}', ']':
+			depth--
+		case '\'', '"':
+			if end := strings.IndexByte(s[i+1:], c); end >= 0 {
+				i += end + 1
+			}
+		case ' ', '\t', '\n':
+			if depth > 0 {
+				continue
+			}
+			// a | or & may be surrounded by white space, but an & before
+			// the name of a parameter passes it by reference
+			before := strings.TrimRight(s[:i], " \t\n")
+			after := strings.TrimLeft(s[i:], " \t\n")
+			if strings.HasSuffix(before, "|") || strings.HasSuffix(before, "&") ||
+				strings.HasPrefix(after, "|") || strings.HasPrefix(after, "&") && !isVariable(after) {
+				continue
+			}
+			return s[:i], strings.TrimSpace(s[i:])
+		}
+	}
+	return s, ""
+}
+
+// tag parses the tag, adding it to d.
+func (d *Doc) tag(tag string, resolve Resolver) error {
+	name, rest := cutTag(tag)
+	switch name {
+	case "param":
+		p, err := param(rest, resolve)
+		if err != nil {
+			return err
+		}
+		d.Params = append(d.Params, p)
+	case "return":
+		s, _ := cutType(rest)
+		t, err := ParseType(s, resolve)
+		if err != nil {
+			return err
+		}
+		d.Return = t
+	case "var":
+		v, err := variable(rest, resolve)
+		if err != nil {
+			return err
+		}
+		d.Vars = append(d.Vars, v)
+	case "property", "property-read", "property-write":
+		v, err := variable(rest, resolve)
+		if err != nil {
+			return err
+		}
+		if v.Name == "" {
+			return fmt.Errorf("@%s without a name", name)
+		}
+		d.Properties = append(d.Properties, Property{
+			Name:        v.Name,
+			Type:        v.Type,
+			Read:        name != "property-write",
+			Write:       name != "property-read",
+			Description: v.Description,
+		})
+	case "method":
+		m, err := method(rest, resolve)
+		if err != nil {
+			return err
+		}
+		d.Methods = append(d.Methods, m)
+	case "throws":
+		s, _ := cutType(rest)
+		t, err := ParseType(s, resolve)
+		if err != nil {
+			return err
+		}
+		d.Throws = append(d.Throws, t)
+	case "deprecated":
+		d.Deprecated = true
+		d.Deprecation = rest
+	case "since":
+		d.Since, _ = cutWord(rest)
+	case "removed":
+		d.Removed, _ = cutWord(rest)
+	}
+	return nil
+}
+
+// param parses the text of a @param tag, which is a type, followed by the
+// name of the parameter and a description.
+func param(s string, resolve Resolver) (Param, error) {
+	typ, rest := cutType(s)
+	p := Param{Type: ast.Unknown}
+	if !isVariable(typ) {
+		t, err := ParseType(typ, resolve)
+		if err != nil {
+			return p, err
+		}
+		p.Type = t
+	} else {
+		// the type is optional
+		rest = s
+	}
+	name, rest := cutWord(rest)
+	if strings.HasPrefix(name, "&") {
+		p.ByRef = true
+		name = name[1:]
+	}
+	if strings.HasPrefix(name, "...") {
+		p.Variadic = true
+		name = name[3:]
+	}
+	if !strings.HasPrefix(name, "$") {
+		return p, fmt.Errorf("@param %s without a name", s)
+	}
+	p.Name = name[1:]
+	p.Description = rest
+	return p, nil
+}
+
+// isVariable reports whether s is the name of a variable, possibly passed
+// by reference or variadic.
+func isVariable(s string) bool {
+	s = strings.TrimPrefix(s, "&")
+	s = strings.TrimPrefix(s, "...")
+	return strings.HasPrefix(s, "$") && s != "$this"
+}
+
+// variable parses the text of a @var or @property tag, which is a type,
+// and a name and a description, either of which is optional. The name may
+// come before the type.
+func variable(s string, resolve Resolver) (Var, error) {
+	v := Var{}
+	first, rest := cutType(s)
+	if isVariable(first) {
+		v.Name = first[1:]
+		first, rest = cutType(rest)
+	}
+	t, err := ParseType(first, resolve)
+	if err != nil {
+		return v, err
+	}
+	v.Type = t
+	if v.Name == "" {
+		if name, after := cutWord(rest); isVariable(name) {
+			v.Name, rest = name[1:], after
+		}
+	}
+	v.Description = rest
+	return v, nil
+}
+
+// method parses the text of a @method tag, which is static if the method is
+// static, the return type, which is optional, and the name and parameters
+// of the method, followed by a description.
+func method(s string, resolve Resolver) (Method, error) {
+	m := Method{Return: ast.Unknown}
+	open := strings.IndexByte(s, '(')
+	close := strings.LastIndexByte(s, ')')
+	if open < 0 || close < open {
+		return m, fmt.Errorf("@method %s without parameters", s)
+	}
+	head := strings.Fields(s[:open])
+	if len(head) == 0 {
+		return m, fmt.Errorf("@method %s without a name", s)
+	}
+	m.Name = head[len(head)-1]
+	head = head[:len(head)-1]
+	if len(head) > 0 && strings.EqualFold(head[0], "static") {
+		m.Static = true
+		head = head[1:]
+	}
+	if len(head) > 0 {
+		t, err := ParseType(strings.Join(head, " "), resolve)
+		if err != nil {
+			return m, err
+		}
+		m.Return = t
+	}
+	for _, arg := range splitParams(s[open+1 : close]) {
+		// drop the default value
+		if i := strings.IndexByte(arg, '='); i >= 0 {
+			arg = strings.TrimSpace(arg[:i])
+		}
+		p, err := param(arg, resolve)
+		if err != nil {
+			return m, err
+		}
+		m.Params = append(m.Params, p)
+	}
+	m.Description = strings.TrimSpace(s[close+1:])
+	return m, nil
+}
+
+// splitParams splits the parameters of a @method tag at the commas that
+// are not inside brackets.
+func splitParams(s string) []string {
+	var params []string
+	depth, begin := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<', '(', '{', '[':
+			depth++
+		case '>', ')', '}', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(s[begin:i]))
+				begin = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[begin:]); last != "" {
+		params = append(params, last)
+	}
+	return params
+}
+
+// template parses the text of a @template tag, which is the name of the
+// type parameter, optionally followed by "of" or "as" and its bound.
+func (d *Doc) template(s string, resolve Resolver) (Template, error) {
+	name, rest := cutWord(s)
+	if name == "" {
+		return Template{}, fmt.Errorf("@template without a name")
+	}
+	t := Template{Name: name}
+	keyword, rest := cutWord(rest)
+	if k := strings.ToLower(keyword); k == "of" || k == "as" {
+		s, _ := cutType(rest)
+		bound, err := ParseType(s, resolve)
+		if err != nil {
+			return t, err
+		}
+		t.Bound = bound
+	}
+	return t, nil
+}
@@ -0,0 +1,245 @@
+package phpdoc
+
+import (
+	"testing"
+
+	"github.com/stephens2424/php/ast"
+)
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"int", "integer"},
+		{"Integer", "integer"},
+		{"?string", "null|string"},
+		{"int|string|null", "integer|null|string"},
+		{"int | string", "integer|string"},
+		{`\Foo\Bar`, `Foo\Bar`},
+		{"Foo[]", "array<Foo>"},
+		{"int[][]", "array<array<integer>>"},
+		{"(int|string)[]", "array<integer|string>"},
+		{"array<Foo>", "array<Foo>"},
+		{"array<string, Foo>", "array<string, Foo>"},
+		{"array<array-key, list<int>>", "array<integer|string, list<integer>>"},
+		{"list<Foo>", "list<Foo>"},
+		{"list", "list<unknown>"},
+		{"array{a: int, b?: string}", "array"},
+		{"callable(int): string", "function"},
+		{"Collection<int, Foo>", "Collection"},
+		{"mixed", "unknown"},
+		{"int|mixed", "unknown"},
+		{"void", "null"},
+		{"false", "boolean"},
+		{"'a'|'b'", "string"},
+		{"1|2", "integer"},
+		{"numeric", "float|integer"},
+		{"class-string<Foo>", "string"},
+		{"Countable&Traversable", "Countable"},
+	}
+	for _, test := range tests {
+		got, err := ParseType(test.in, nil)
+		if err != nil {
+			t.Errorf("ParseType(%q): %s", test.in, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("ParseType(%q) = %s, want %s", test.in, got, test.want)
+		}
+	}
+
+	for _, bad := range []string{"", "array<int", "int|", "Foo[", "$x"} {
+		if _, err := ParseType(bad, nil); err == nil {
+			t.Errorf("ParseType(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestParseTypeResolver(t *testing.T) {
+	resolve := func(name string) ast.Type {
+		if name == "self" || name == "$this" {
+			return ast.ObjectType{Class: `N\A`}
+		}
+		return ast.ObjectType{Class: `N\` + name}
+	}
+	got, err := ParseType("self|B[]|$this", resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `N\A|array<N\B>`; got.String() != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	d := Parse(`/**
+ * Finds the users.
+ *
+ * More about finding them.
+ *
+ * @param int|null $limit The number of users
+ *                        to find.
+ * @param string ...$names
+ * @param array &$out
+ * @param $untyped
+ * @psalm-param list<int> $ids
+ * @return User[]
+ * @throws \RuntimeException
+ * @throws NotFound
+ */`, nil)
+
+	if want := "Finds the users.\n\nMore about finding them."; d.Summary != want {
+		t.Errorf("summary is %q, want %q", d.Summary, want)
+	}
+	params := []struct {
+		name, typ string
+		variadic  bool
+		byRef     bool
+	}{
+		{"limit", "integer|null", false, false},
+		{"names", "string", true, false},
+		{"out", "array", false, true},
+		{"untyped", "unknown", false, false},
+		{"ids", "list<integer>", false, false},
+	}
+	if len(d.Params) != len(params) {
+		t.Fatalf("got %d params, want %d", len(d.Params), len(params))
+	}
+	for i, want := range params {
+		p := d.Params[i]
+		if p.Name != want.name || p.Type.String() != want.typ || p.Variadic != want.variadic || p.ByRef != want.byRef {
+			t.Errorf("param %d is %+v, want %+v", i, p, want)
+		}
+	}
+	if p := d.Param("$limit"); p == nil || p.Description != "The number of users\nto find." {
+		t.Errorf("description of $limit is %+v", p)
+	}
+	if d.Return.String() != "array<User>" {
+		t.Errorf("return is %s", d.Return)
+	}
+	if len(d.Throws) != 2 || d.Throws[0].String() != "RuntimeException" || d.Throws[1].String() != "NotFound" {
+		t.Errorf("throws are %v", d.Throws)
+	}
+	if len(d.Errors) != 0 {
+		t.Errorf("errors: %v", d.Errors)
+	}
+}
+
+func TestParseVar(t *testing.T) {
+	tests := []struct {
+		in, name, typ, description string
+	}{
+		{"/** @var int */", "", "integer", ""},
+		{"/** @var int $x */", "x", "integer", ""},
+		{"/** @var $x int */", "x", "integer", ""},
+		{"/** @var array<string, int> $counts the counts */", "counts", "array<string, integer>", "the counts"},
+		{"/** @phpstan-var ?Foo */", "", "Foo|null", ""},
+	}
+	for _, test := range tests {
+		d := Parse(test.in, nil)
+		if len(d.Vars) != 1 {
+			t.Errorf("%s: got %d vars, errors %v", test.in, len(d.Vars), d.Errors)
+			continue
+		}
+		v := d.Vars[0]
+		if v.Name != test.name || v.Type.String() != test.typ || v.Description != test.description {
+			t.Errorf("%s: got %+v", test.in, v)
+		}
+	}
+	d := Parse("/** @var int $x */", nil)
+	if d.Var("x") == nil || d.Var("y") != nil {
+		t.Errorf("Var looks up the wrong names")
+	}
+}
+
+func TestParseClass(t *testing.T) {
+	d := Parse(`/**
+ * @property int $id
+ * @property-read string $name
+ * @property-write Foo|null $foo
+ * @method static self create(array $attributes = [], bool $save = true)
+ * @method Foo[] all()
+ * @method find($id) Finds one.
+ */`, nil)
+
+	properties := []Property{
+		{Name: "id", Type: ast.Integer, Read: true, Write: true},
+		{Name: "name", Type: ast.String, Read: true},
+		{Name: "foo", Type: ast.Union(ast.ObjectType{Class: "Foo"}, ast.Null), Write: true},
+	}
+	if len(d.Properties) != len(properties) {
+		t.Fatalf("got %d properties, want %d", len(d.Properties), len(properties))
+	}
+	for i, want := range properties {
+		p := d.Properties[i]
+		if p.Name != want.Name || !p.Type.Equals(want.Type) || p.Read != want.Read || p.Write != want.Write {
+			t.Errorf("property %d is %+v, want %+v", i, p, want)
+		}
+	}
+
+	if len(d.Methods) != 3 {
+		t.Fatalf("got %d methods, errors %v", len(d.Methods), d.Errors)
+	}
+	create := d.Methods[0]
+	if create.Name != "create" || !create.Static || create.Return.String() != "self" || len(create.Params) != 2 ||
+		create.Params[0].Name != "attributes" || create.Params[1].Type != ast.Boolean {
+		t.Errorf("create is %+v", create)
+	}
+	all := d.Methods[1]
+	if all.Name != "all" || all.Static || all.Return.String() != "array<Foo>" || len(all.Params) != 0 {
+		t.Errorf("all is %+v", all)
+	}
+	find := d.Methods[2]
+	if find.Name != "find" || find.Return != ast.Unknown || len(find.Params) != 1 || find.Description != "Finds one." {
+		t.Errorf("find is %+v", find)
+	}
+}
+
+func TestParseTemplates(t *testing.T) {
+	d := Parse(`/**
+ * @param T $value
+ * @return list<T>
+ * @template T of Countable
+ * @psalm-template U
+ */`, nil)
+	if len(d.Templates) != 2 || d.Templates[0].Name != "T" || d.Templates[0].Bound.String() != "Countable" ||
+		d.Templates[1].Name != "U" || d.Templates[1].Bound != nil {
+		t.Fatalf("templates are %+v", d.Templates)
+	}
+	if _, ok := d.Params[0].Type.(ast.TypeParameter); !ok {
+		t.Errorf("type of $value is %T, want a TypeParameter", d.Params[0].Type)
+	}
+	r, ok := d.Return.(ast.ArrayOf)
+	if !ok || !r.List || !r.Value.Equals(ast.TypeParameter{Name: "T"}) {
+		t.Errorf("return is %s", d.Return)
+	}
+}
+
+func TestParseVersions(t *testing.T) {
+	d := Parse(`/**
+ * @since 5.3.0
+ * @deprecated 7.2 Use something else.
+ * @removed 8.0
+ */`, nil)
+	if d.Since != "5.3.0" || d.Removed != "8.0" || !d.Deprecated || d.Deprecation != "7.2 Use something else." {
+		t.Errorf("got %+v", d)
+	}
+	if d := Parse("/** Nothing. */", nil); d.Deprecated || d.Since != "" {
+		t.Errorf("got %+v", d)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	d := Parse(`/**
+ * @param int
+ * @return array<
+ * @method broken
+ * @param string $ok
+ */`, nil)
+	if len(d.Errors) != 3 {
+		t.Errorf("got errors %v, want 3", d.Errors)
+	}
+	if len(d.Params) != 1 || d.Params[0].Name != "ok" {
+		t.Errorf("params are %+v", d.Params)
+	}
+}
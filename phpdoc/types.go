@@ -0,0 +1,296 @@
+package phpdoc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// Resolver returns the type of the class name as written in a doc comment,
+// which may be self, static, parent or $this.
+type Resolver func(name string) ast.Type
+
+// resolveClass is the resolver used when none is given, which takes class
+// names to be fully qualified.
+func resolveClass(name string) ast.Type {
+	return ast.ObjectType{Class: strings.TrimPrefix(name, `\`)}
+}
+
+// keywords holds the types named by keywords, in lower case.
+var keywords = map[string]ast.Type{
+	"int":              ast.Integer,
+	"integer":          ast.Integer,
+	"positive-int":     ast.Integer,
+	"negative-int":     ast.Integer,
+	"non-negative-int": ast.Integer,
+	"non-positive-int": ast.Integer,
+	"float":            ast.Float,
+	"double":           ast.Float,
+	"string":           ast.String,
+	"non-empty-string": ast.String,
+	"numeric-string":   ast.String,
+	"class-string":     ast.String,
+	"callable-string":  ast.String,
+	"literal-string":   ast.String,
+	"bool":             ast.Boolean,
+	"boolean":          ast.Boolean,
+	"true":             ast.Boolean,
+	"false":            ast.Boolean,
+	"null":             ast.Null,
+	"void":             ast.Null,
+	"resource":         ast.Resource,
+	"array":            ast.Array,
+	"non-empty-array":  ast.Array,
+	"list":             ast.ArrayOf{Key: ast.Integer, Value: ast.Unknown, List: true},
+	"non-empty-list":   ast.ArrayOf{Key: ast.Integer, Value: ast.Unknown, List: true},
+	"callable":         ast.Function,
+	"object":           ast.Object,
+	"array-key":        ast.Union(ast.Integer, ast.String),
+	"numeric":          ast.Union(ast.Integer, ast.Float),
+	"number":           ast.Union(ast.Integer, ast.Float),
+	"scalar":           ast.Union(ast.Integer, ast.Float, ast.String, ast.Boolean),
+	"mixed":            ast.Unknown,
+	"iterable":         ast.Unknown,
+	"never":            ast.Unknown,
+	"never-return":     ast.Unknown,
+	"no-return":        ast.Unknown,
+}
+
+// ParseType parses the type written in a doc comment, such as
+// "int|string", "?Foo", "Foo[]", "array<int, Foo>" or "list<Foo>", using
+// resolve to resolve the names of classes.
+func ParseType(s string, resolve Resolver) (ast.Type, error) {
+	if resolve == nil {
+		resolve = resolveClass
+	}
+	p := &typeParser{s: s, resolve: resolve}
+	t, err := p.union()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return t, nil
+}
+
+// typeParser parses a type.
+type typeParser struct {
+	s       string
+	pos     int
+	resolve Resolver
+}
+
+func (p *typeParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("bad type %q: %s", p.s, fmt.Sprintf(format, args...))
+}
+
+func (p *typeParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// accept consumes c if it is next, and reports whether it was.
+func (p *typeParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// union parses types separated by | or &. An intersection is taken to be
+// its first type.
+func (p *typeParser) union() (ast.Type, error) {
+	t, err := p.array()
+	if err != nil {
+		return nil, err
+	}
+	types := []ast.Type{t}
+	for {
+		switch {
+		case p.accept('|'):
+			t, err := p.array()
+			if err != nil {
+				return nil, err
+			}
+			types = append(types, t)
+		case p.accept('&'):
+			if _, err := p.array(); err != nil {
+				return nil, err
+			}
+		default:
+			return union(types...), nil
+		}
+	}
+}
+
+// union returns the union of types, which is unknown if any of them is.
+func union(types ...ast.Type) ast.Type {
+	for _, t := range types {
+		if t == ast.Unknown {
+			return ast.Unknown
+		}
+	}
+	return ast.Union(types...)
+}
+
+// array parses a type followed by any number of [].
+func (p *typeParser) array() (ast.Type, error) {
+	t, err := p.nullable()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept('[') {
+		if !p.accept(']') {
+			return nil, p.errorf("expected ]")
+		}
+		t = ast.ArrayOf{Key: ast.Unknown, Value: t}
+	}
+	return t, nil
+}
+
+// nullable parses a type, which may be preceded by ?.
+func (p *typeParser) nullable() (ast.Type, error) {
+	if !p.accept('?') {
+		return p.single()
+	}
+	t, err := p.single()
+	if err != nil {
+		return nil, err
+	}
+	return union(t, ast.Null), nil
+}
+
+// single parses a named type, a literal or a type in parentheses.
+func (p *typeParser) single() (ast.Type, error) {
+	p.skipSpace()
+	if p.accept('(') {
+		t, err := p.union()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(')') {
+			return nil, p.errorf("expected )")
+		}
+		return t, nil
+	}
+	if p.pos < len(p.s) && (p.s[p.pos] == '\'' || p.s[p.pos] == '"') {
+		end := strings.IndexByte(p.s[p.pos+1:], p.s[p.pos])
+		if end < 0 {
+			return nil, p.errorf("unterminated string")
+		}
+		p.pos += end + 2
+		return ast.String, nil
+	}
+
+	begin := p.pos
+	for p.pos < len(p.s) && isNameByte(p.s[p.pos]) {
+		p.pos++
+	}
+	name := p.s[begin:p.pos]
+	switch {
+	case name == "":
+		if p.pos == len(p.s) {
+			return nil, p.errorf("expected a type")
+		}
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	case strings.Trim(name, "-0123456789.") == "":
+		if strings.Contains(name, ".") {
+			return ast.Float, nil
+		}
+		return ast.Integer, nil
+	}
+
+	var params []ast.Type
+	if p.accept('<') {
+		for {
+			t, err := p.union()
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, t)
+			if p.accept('>') {
+				break
+			}
+			if !p.accept(',') {
+				return nil, p.errorf("expected , or >")
+			}
+		}
+	}
+	if err := p.skipShape(); err != nil {
+		return nil, err
+	}
+	return p.named(name, params)
+}
+
+// skipShape skips the description of the keys of an array shape, such as
+// {a: int}, or the signature of a callable, such as (int): string.
+func (p *typeParser) skipShape() error {
+	if p.pos >= len(p.s) || p.s[p.pos] != '{' && p.s[p.pos] != '(' {
+		return nil
+	}
+	depth := 0
+	for ; p.pos < len(p.s); p.pos++ {
+		switch p.s[p.pos] {
+		case '{', '(', '<':
+			depth++
+		case '}', ')', '>':
+			depth--
+		}
+		if depth == 0 {
+			p.pos++
+			break
+		}
+	}
+	if depth != 0 {
+		return p.errorf("unbalanced brackets")
+	}
+	// the return type of a callable
+	if p.accept(':') {
+		_, err := p.nullable()
+		return err
+	}
+	return nil
+}
+
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '\\' || c == '-' || c == '.' || c == '$' || c >= 0x80
+}
+
+// named returns the type named name, with the type parameters params.
+func (p *typeParser) named(name string, params []ast.Type) (ast.Type, error) {
+	lower := strings.ToLower(name)
+	switch lower {
+	case "array", "non-empty-array", "iterable":
+		switch len(params) {
+		case 0:
+		case 1:
+			return ast.ArrayOf{Key: ast.Unknown, Value: params[0]}, nil
+		case 2:
+			return ast.ArrayOf{Key: params[0], Value: params[1]}, nil
+		default:
+			return nil, p.errorf("%s has at most two type parameters", name)
+		}
+	case "list", "non-empty-list":
+		switch len(params) {
+		case 0:
+		case 1:
+			return ast.ArrayOf{Key: ast.Integer, Value: params[0], List: true}, nil
+		default:
+			return nil, p.errorf("%s has one type parameter", name)
+		}
+	}
+	if t, ok := keywords[lower]; ok {
+		return t, nil
+	}
+	if strings.HasPrefix(name, "$") && lower != "$this" {
+		return nil, p.errorf("unexpected variable %s", name)
+	}
+	// the type parameters of a generic class are not known to its type
+	return p.resolve(name), nil
+}
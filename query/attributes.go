@@ -0,0 +1,197 @@
+package query
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+)
+
+// AttributeRule matches nodes by the value of one of their attributes.
+type AttributeRule struct {
+	Name string
+	// Operator is "=" to match values equal to Value, "~=" to match values
+	// matching the regular expression Value, or "" to match any node with
+	// the attribute.
+	Operator string
+	Value    string
+
+	re *regexp.Regexp
+}
+
+func (r AttributeRule) Pass(n Node) bool {
+	for _, v := range Attribute(n.Node, r.Name) {
+		switch r.Operator {
+		case "":
+			return true
+		case "=":
+			if v == r.Value {
+				return true
+			}
+		case "~=":
+			if r.re.MatchString(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Attribute returns the values of the named attribute of n. The attributes
+// are:
+//
+// "name", the name of a declared function, method, class, interface,
+// constant or property, of a called function or method, of an accessed
+// property, of a variable, or the value of an identifier. Variable and
+// property names do not include the $.
+//
+// "extends", the class a class extends, or the interfaces an interface
+// extends.
+//
+// "implements", the interfaces a class implements.
+//
+// "class", the class of a new expression, or the class in a static access
+// such as Foo::bar().
+//
+// "operator", the operator of a unary, binary or assignment expression.
+//
+// "value", the source text of a literal.
+//
+// "type", the caught type of a catch clause, or the type hint of a function
+// argument.
+//
+// "visibility", which is public, protected or private, of a method or
+// property.
+//
+// Names that are computed at run time have no value.
+func Attribute(n ast.Node, name string) []string {
+	switch name {
+	case "name":
+		return nameOf(n)
+	case "extends":
+		switch n := n.(type) {
+		case *ast.Class:
+			return nonEmpty(n.Extends)
+		case *ast.Interface:
+			return n.Inherits
+		}
+	case "implements":
+		if c, ok := n.(*ast.Class); ok {
+			return c.Implements
+		}
+	case "class":
+		switch n := n.(type) {
+		case *ast.NewCallExpr:
+			return staticName(n.Class)
+		case *ast.ClassExpr:
+			return staticName(n.Receiver)
+		}
+	case "operator":
+		switch n := n.(type) {
+		case ast.BinaryExpr:
+			return nonEmpty(n.Operator)
+		case *ast.BinaryExpr:
+			return nonEmpty(n.Operator)
+		case ast.UnaryCallExpr:
+			return nonEmpty(n.Operator)
+		case *ast.UnaryCallExpr:
+			return nonEmpty(n.Operator)
+		case ast.AssignmentExpr:
+			return nonEmpty(n.Operator)
+		case *ast.AssignmentExpr:
+			return nonEmpty(n.Operator)
+		}
+	case "value":
+		if l, ok := n.(*ast.Literal); ok {
+			return nonEmpty(l.Value)
+		}
+	case "type":
+		switch n := n.(type) {
+		case *ast.CatchStmt:
+			return nonEmpty(n.CatchType)
+		case *ast.FunctionArgument:
+			return nonEmpty(n.TypeHint)
+		}
+	case "visibility":
+		switch n := n.(type) {
+		case *ast.Method:
+			return []string{visibility(n.Visibility)}
+		case ast.Method:
+			return []string{visibility(n.Visibility)}
+		case *ast.Property:
+			return []string{visibility(n.Visibility)}
+		}
+	}
+	return nil
+}
+
+func nameOf(n ast.Node) []string {
+	switch n := n.(type) {
+	case *ast.Identifier:
+		return nonEmpty(n.Value)
+	case *ast.Variable:
+		return staticName(n.Name)
+	case *ast.FunctionStmt:
+		return nonEmpty(n.Name)
+	case *ast.Method:
+		return nonEmpty(n.Name)
+	case ast.Method:
+		return nonEmpty(n.Name)
+	case *ast.MethodCallExpr:
+		return staticName(n.FunctionName)
+	case *ast.FunctionCallExpr:
+		return staticName(n.FunctionName)
+	case *ast.PropertyCallExpr:
+		return staticName(n.Name)
+	case *ast.Class:
+		return nonEmpty(n.Name)
+	case *ast.Interface:
+		return nonEmpty(n.Name)
+	case *ast.Constant:
+		return nonEmpty(n.Name)
+	case ast.Constant:
+		return nonEmpty(n.Name)
+	case ast.ConstantExpr:
+		return staticName(n.Name)
+	case *ast.ConstantExpr:
+		return staticName(n.Name)
+	case *ast.Property:
+		return nonEmpty(strings.TrimPrefix(n.Name, "$"))
+	case *ast.FunctionArgument:
+		return nameOf(n.Variable)
+	}
+	return nil
+}
+
+// staticName returns the name given by n if it is an identifier or a
+// constant, as in new Foo or Foo::bar().
+func staticName(n ast.Node) []string {
+	switch n := n.(type) {
+	case *ast.Identifier:
+		return nonEmpty(n.Value)
+	case ast.Identifier:
+		return nonEmpty(n.Value)
+	case ast.ConstantExpr:
+		return staticName(n.Name)
+	case *ast.ConstantExpr:
+		return staticName(n.Name)
+	}
+	return nil
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+func visibility(v ast.Visibility) string {
+	switch v {
+	case ast.Private:
+		return "private"
+	case ast.Protected:
+		return "protected"
+	}
+	return "public"
+}
@@ -1,7 +1,12 @@
 // Command query implements a simple CLI for querying a PHP AST.
+//
+// It searches the PHP files in the current directory, and with -r in its
+// subdirectories, for nodes matching the selector given as its arguments,
+// printing the position and source of each.
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -16,68 +21,163 @@ import (
 
 func main() {
 	recursive := flag.Bool("r", false, "Recursive")
+	asJSON := flag.Bool("json", false, "print matches as a JSON array")
+	count := flag.Bool("count", false, "print only the number of matches")
+	context := flag.Int("context", 0, "print `N` lines of source around each match")
 	flag.Parse()
+
 	selector := strings.Join(flag.Args(), " ")
-	dir, err := os.Getwd()
+	sel, err := query.ParseSelector(selector)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	g := newGatherer(*recursive)
-	if err := filepath.Walk(dir, g.walkFile); err != nil {
-		panic(err)
+	g := newGatherer(*recursive, sel)
+	if err := filepath.Walk(".", g.walkFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	selected, _ := query.Select(g.nodes).Select(selector)
-
-	/*
-		for _, sel := range selected {
-			pos := sel.Node.Begin()
-			fmt.Println(pos)
+	switch {
+	case *count:
+		fmt.Println(len(g.matches))
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(g.matches); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
-	*/
-
-	fmt.Println(len(selected), "found")
+	default:
+		for _, m := range g.matches {
+			m.print(*context)
+		}
+	}
+	if g.failed {
+		os.Exit(1)
+	}
 }
 
-func newGatherer(recursive bool) gatherer {
-	return gatherer{recursive, make([]ast.Node, 0)}
+func newGatherer(recursive bool, sel query.SelectorGroup) gatherer {
+	return gatherer{recursive: recursive, sel: sel, matches: make([]match, 0)}
 }
 
 type gatherer struct {
 	recursive bool
-	nodes     []ast.Node
+	sel       query.SelectorGroup
+	matches   []match
+	failed    bool
 }
 
-func (g *gatherer) walkFile(path string, info os.FileInfo, err error) error {
-	// TODO handle error
-	_ = err
+// match is a node matching the selector.
+type match struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+
+	src []string
+}
 
-	if info.IsDir() || !strings.HasSuffix(path, ".php") {
+func (g *gatherer) walkFile(path string, info os.FileInfo, err error) error {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		g.failed = true
 		return nil
 	}
 
-	if info.IsDir() && !g.recursive {
-		return filepath.SkipDir
+	if info.IsDir() {
+		if path != "." && !g.recursive {
+			return filepath.SkipDir
+		}
+		return nil
 	}
-
-	f, err := os.Open(path)
-	if err != nil {
-		return err
+	if !strings.HasSuffix(path, ".php") {
+		return nil
 	}
-	defer func() { _ = f.Close() }()
-	src, err := ioutil.ReadAll(f)
 
+	src, err := ioutil.ReadFile(path)
 	if err != nil {
-		return err
+		fmt.Fprintln(os.Stderr, err)
+		g.failed = true
+		return nil
 	}
 
-	p := parser.NewParser()
-	file, err := p.Parse("test.php", string(src))
+	file, err := parser.NewParser().Parse(path, string(src))
 	if err != nil {
-		return err
+		fmt.Fprintln(os.Stderr, err)
+		g.failed = true
+		return nil
 	}
 
-	g.nodes = append(g.nodes, file.Nodes...)
+	lines := strings.Split(string(src), "\n")
+	for _, n := range query.Select(file.Nodes).Filter(g.sel) {
+		m := match{File: path, Type: typeName(n.Node), src: lines}
+		if span, ok := nodeSpan(file, n); ok {
+			m.Line, m.Column = span.Begin.Line, span.Begin.Column
+			m.EndLine, m.EndColumn = span.End.Line, span.End.Column
+			m.Text = string(src[span.Begin.Position:span.End.Position])
+		}
+		g.matches = append(g.matches, m)
+	}
 	return nil
 }
+
+// nodeSpan returns the span of n in file. A function definition has the
+// span of its name, and a node with no position of its own or of its
+// children that of the nearest node enclosing it that has one.
+func nodeSpan(file *ast.File, n query.Node) (ast.Span, bool) {
+	if def, ok := n.Node.(*ast.FunctionDefinition); ok {
+		if span, ok := file.Names[def]; ok {
+			return span, true
+		}
+	}
+	if span, ok := file.Positions.Span(n.Node); ok {
+		return span, true
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if span, ok := file.Positions.Span(p.Node); ok {
+			return span, true
+		}
+	}
+	return ast.Span{}, false
+}
+
+func typeName(n ast.Node) string {
+	name := fmt.Sprintf("%T", n)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// print prints the position of m followed by the first line of its source,
+// or with context, by the lines of source around it.
+func (m match) print(context int) {
+	if m.Line == 0 {
+		fmt.Printf("%s: %s\n", m.File, m.Type)
+		return
+	}
+	if context <= 0 {
+		first := strings.SplitN(m.Text, "\n", 2)[0]
+		if first != m.Text {
+			first += " ..."
+		}
+		fmt.Printf("%s:%d:%d: %s\n", m.File, m.Line, m.Column, first)
+		return
+	}
+
+	fmt.Printf("%s:%d:%d:\n", m.File, m.Line, m.Column)
+	for i := m.Line - context; i <= m.EndLine+context; i++ {
+		if i < 1 || i > len(m.src) {
+			continue
+		}
+		marker := " "
+		if i >= m.Line && i <= m.EndLine {
+			marker = ">"
+		}
+		fmt.Printf("%s%5d  %s\n", marker, i, m.src[i-1])
+	}
+	fmt.Println()
+}
@@ -0,0 +1,106 @@
+// Command rewrite replaces PHP code matching a pattern, as described by the
+// rewrite package.
+//
+// Usage:
+//
+//	rewrite [-w] [-where selector] pattern replacement [path ...]
+//
+// The paths may be files or directories, which are searched recursively for
+// PHP files, and default to the current directory. Without -w, the changes
+// are printed as a unified diff rather than written.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stephens2424/php/edit"
+	"github.com/stephens2424/php/parser"
+	"github.com/stephens2424/php/query"
+	"github.com/stephens2424/php/query/rewrite"
+)
+
+func main() {
+	write := flag.Bool("w", false, "write the changes to the files instead of printing a diff")
+	where := flag.String("where", "", "only replace matches passing this query `selector`")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] pattern replacement [path ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	rule, err := rewrite.Compile(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *where != "" {
+		if rule.Where, err = query.ParseSelector(*where); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	paths := flag.Args()[2:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	failed := false
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !strings.HasSuffix(path, ".php") {
+				return nil
+			}
+			if err := rewriteFile(rule, path, info, *write); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func rewriteFile(rule *rewrite.Rule, path string, info os.FileInfo, write bool) error {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	file, err := parser.NewParser().Parse(path, string(src))
+	if err != nil {
+		return err
+	}
+
+	edits := rule.Rewrite(file, string(src))
+	if len(edits) == 0 {
+		return nil
+	}
+	out, err := edit.Apply(string(src), edits)
+	if err != nil {
+		return err
+	}
+
+	if write {
+		return ioutil.WriteFile(path, []byte(out), info.Mode())
+	}
+	fmt.Print(edit.Diff(filepath.ToSlash(path), string(src), out))
+	return nil
+}
@@ -0,0 +1,307 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SelectorError describes a syntax error in a selector.
+type SelectorError struct {
+	Selector string
+	// Offset is the byte offset of the error in Selector.
+	Offset int
+	Msg    string
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("query: %s at offset %d in %q", e.Msg, e.Offset, e.Selector)
+}
+
+// ParseSelector parses a comma-separated group of selectors. A node passes
+// the group if it passes any of its selectors.
+func ParseSelector(s string) (SelectorGroup, error) {
+	p := &selectorParser{src: s}
+	g, err := p.parseGroup()
+	if err != nil {
+		return nil, err
+	}
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return g, nil
+}
+
+type selectorParser struct {
+	src string
+	pos int
+}
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return &SelectorError{Selector: p.src, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *selectorParser) atEnd() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *selectorParser) peek() byte {
+	if p.atEnd() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skipSpace advances past whitespace, reporting whether there was any.
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for !p.atEnd() && isSelectorSpace(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) expect(ch byte) error {
+	if p.peek() != ch {
+		if p.atEnd() {
+			return p.errorf("expected %q, found end of selector", ch)
+		}
+		return p.errorf("expected %q, found %q", ch, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+func isSelectorSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+func isNameByte(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_' || ch == '-'
+}
+
+func (p *selectorParser) parseName() string {
+	start := p.pos
+	for !p.atEnd() && isNameByte(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *selectorParser) parseGroup() (SelectorGroup, error) {
+	var g SelectorGroup
+	for {
+		p.skipSpace()
+		sel, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		g = append(g, sel)
+		p.skipSpace()
+		if p.peek() != ',' {
+			return g, nil
+		}
+		p.pos++
+	}
+}
+
+// parseComplex parses compound selectors separated by combinators.
+func (p *selectorParser) parseComplex() (*Selector, error) {
+	sel, err := p.parseCompound()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		start := p.pos
+		space := p.skipSpace()
+		var c Combinator
+		switch ch := p.peek(); {
+		case ch == '>' || ch == '+' || ch == '~':
+			c = Combinator(ch)
+			p.pos++
+			p.skipSpace()
+		case ch == 0 || ch == ',' || ch == ')':
+			p.pos = start
+			return sel, nil
+		case space:
+			c = Descendant
+		default:
+			return nil, p.errorf("unexpected %q", ch)
+		}
+
+		next, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		next.parent = sel
+		next.combinator = c
+		sel = next
+	}
+}
+
+// parseCompound parses an optional node type, or *, followed by any number
+// of attribute selectors and pseudo-classes.
+func (p *selectorParser) parseCompound() (*Selector, error) {
+	sel := &Selector{}
+	start := p.pos
+	if p.peek() == '*' {
+		p.pos++
+	} else if name := p.parseName(); name != "" {
+		sel.localRules = append(sel.localRules, NodeRule{name})
+	}
+
+	for p.peek() == '[' || p.peek() == ':' {
+		var rule Rule
+		var err error
+		if p.peek() == '[' {
+			rule, err = p.parseAttribute()
+		} else {
+			rule, err = p.parsePseudoClass()
+		}
+		if err != nil {
+			return nil, err
+		}
+		sel.localRules = append(sel.localRules, rule)
+	}
+
+	if p.pos == start {
+		if p.atEnd() {
+			return nil, p.errorf("expected a selector, found end of selector")
+		}
+		return nil, p.errorf("expected a selector, found %q", p.peek())
+	}
+	return sel, nil
+}
+
+// parseAttribute parses [name], [name=value] or [name~=regexp]. Values may
+// be quoted with single or double quotes.
+func (p *selectorParser) parseAttribute() (Rule, error) {
+	if err := p.expect('['); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	rule := AttributeRule{}
+	if rule.Name = p.parseName(); rule.Name == "" {
+		return nil, p.errorf("expected an attribute name")
+	}
+	p.skipSpace()
+
+	switch {
+	case p.peek() == ']':
+		p.pos++
+		return rule, nil
+	case p.peek() == '=':
+		rule.Operator = "="
+	case strings.HasPrefix(p.src[p.pos:], "~="):
+		rule.Operator = "~="
+	default:
+		return nil, p.errorf("expected '=', '~=' or ']' in attribute")
+	}
+	p.pos += len(rule.Operator)
+	p.skipSpace()
+
+	valueStart := p.pos
+	value, err := p.parseValue(']')
+	if err != nil {
+		return nil, err
+	}
+	rule.Value = value
+	if rule.Operator == "~=" {
+		if rule.re, err = regexp.Compile(value); err != nil {
+			p.pos = valueStart
+			return nil, p.errorf("invalid regular expression: %s", err)
+		}
+	}
+	p.skipSpace()
+	if err := p.expect(']'); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// parseValue parses a quoted string, or an unquoted string ending before
+// whitespace or end.
+func (p *selectorParser) parseValue(end byte) (string, error) {
+	if quote := p.peek(); quote == '"' || quote == '\'' {
+		length := strings.IndexByte(p.src[p.pos+1:], quote)
+		if length < 0 {
+			return "", p.errorf("unterminated string")
+		}
+		value := p.src[p.pos+1 : p.pos+1+length]
+		p.pos += length + 2
+		return value, nil
+	}
+
+	start := p.pos
+	for !p.atEnd() && p.src[p.pos] != end && !isSelectorSpace(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a value")
+	}
+	return p.src[start:p.pos], nil
+}
+
+// parsePseudoClass parses a pseudo-class, such as :empty or :has(selector).
+func (p *selectorParser) parsePseudoClass() (Rule, error) {
+	start := p.pos
+	if err := p.expect(':'); err != nil {
+		return nil, err
+	}
+	name := p.parseName()
+
+	switch name {
+	case "first-child":
+		return ChildRule{Position: 1}, nil
+	case "last-child":
+		return ChildRule{Position: 1, FromEnd: true}, nil
+	case "empty":
+		return EmptyRule{}, nil
+	case "has", "not", "nth-child", "in-function", "in-class":
+	default:
+		p.pos = start
+		return nil, p.errorf("unknown pseudo-class %q", ":"+name)
+	}
+
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	var rule Rule
+	switch name {
+	case "has", "not":
+		g, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		if name == "has" {
+			rule = HasRule{g}
+		} else {
+			rule = NotRule{g}
+		}
+	case "nth-child":
+		numStart := p.pos
+		n, err := strconv.Atoi(p.parseName())
+		if err != nil || n < 1 {
+			p.pos = numStart
+			return nil, p.errorf("expected a positive integer")
+		}
+		rule = ChildRule{Position: n}
+	case "in-function", "in-class":
+		value, err := p.parseValue(')')
+		if err != nil {
+			return nil, err
+		}
+		if name == "in-function" {
+			rule = InFunctionRule{value}
+		} else {
+			rule = InClassRule{value}
+		}
+	}
+	p.skipSpace()
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
@@ -0,0 +1,93 @@
+package query
+
+import (
+	"github.com/stephens2424/php/ast"
+)
+
+// HasRule matches nodes with a descendant passing Selector, as in
+// :has(selector).
+type HasRule struct {
+	Selector SelectorGroup
+}
+
+func (r HasRule) Pass(n Node) bool {
+	for _, d := range flatten(children(n.Node.Children()), nil, &n) {
+		if r.Selector.Pass(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotRule matches nodes not passing Selector, as in :not(selector).
+type NotRule struct {
+	Selector SelectorGroup
+}
+
+func (r NotRule) Pass(n Node) bool {
+	return !r.Selector.Pass(n)
+}
+
+// ChildRule matches nodes by their position among the children of their
+// parent. Positions count from 1, and from the last child if FromEnd is set.
+// It implements :first-child, :last-child and :nth-child(n).
+type ChildRule struct {
+	Position int
+	FromEnd  bool
+}
+
+func (r ChildRule) Pass(n Node) bool {
+	if r.FromEnd {
+		return len(n.siblings)-n.Index == r.Position
+	}
+	return n.Index+1 == r.Position
+}
+
+// EmptyRule matches nodes without children, as in :empty.
+type EmptyRule struct{}
+
+func (r EmptyRule) Pass(n Node) bool {
+	return len(children(n.Node.Children())) == 0
+}
+
+// InFunctionRule matches nodes inside the body of the function or method
+// named Name, as in :in-function(name).
+type InFunctionRule struct {
+	Name string
+}
+
+func (r InFunctionRule) Pass(n Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		switch f := p.Node.(type) {
+		case *ast.FunctionStmt, *ast.Method, ast.Method:
+			for _, name := range nameOf(f) {
+				if name == r.Name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// InClassRule matches nodes inside the class or interface named Name, as
+// in :in-class(name).
+type InClassRule struct {
+	Name string
+}
+
+func (r InClassRule) Pass(n Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		switch c := p.Node.(type) {
+		case *ast.Class:
+			if c.Name == r.Name {
+				return true
+			}
+		case *ast.Interface:
+			if c.Name == r.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
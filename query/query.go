@@ -1,10 +1,29 @@
 // Package query implements CSS/jQuery-esque selectors over a PHP AST
 //
-// Currently, the only supported selectors are:
+// The supported selectors are:
 //
-// "node", where node is the type name of an AST node (e.g. EchoStmt)
+// "Type", where Type is the type name of an AST node (e.g. EchoStmt), and
+// "*", which matches any node.
 //
-// "parent child", where the descendance may skip levels.
+// "[attr]", "[attr=value]" and "[attr~=regexp]", which match nodes with the
+// given attribute, with the attribute equal to value, or with the attribute
+// matching the regular expression. Values may be quoted. See Attribute for
+// the attributes of each node. Attribute selectors may follow a type, as in
+// FunctionCallExpr[name=mysql_query].
+//
+// "ancestor descendant", where the descendance may skip levels,
+// "parent > child", "previous + next", where next immediately follows
+// previous among the children of the same node, and "previous ~ next",
+// where next follows previous anywhere among them.
+//
+// "a, b", which matches nodes matching either a or b.
+//
+// The pseudo-classes ":has(selector)", matching nodes with a descendant
+// matching selector, ":not(selector)", ":first-child", ":last-child",
+// ":nth-child(n)", counting from 1, ":empty", matching nodes without
+// children, and ":in-function(name)" and ":in-class(name)", matching nodes
+// inside the named function or method, or class or interface. Like
+// attribute selectors, they may follow a type.
 package query
 
 import (
@@ -20,7 +39,7 @@ type Q []Node
 // Select flattens a node list to a queue
 func Select(nodes []ast.Node) Q {
 	flat := make([]Node, 0, len(nodes))
-	flat = flatten(nodes, flat, nil)
+	flat = flatten(children(nodes), flat, nil)
 	return flat
 }
 
@@ -29,62 +48,80 @@ func (q Q) Select(s string) (Q, error) {
 	if err != nil {
 		return nil, err
 	}
+	return q.Filter(sel), nil
+}
+
+// Filter returns the nodes in q that pass sel.
+func (q Q) Filter(sel SelectorGroup) Q {
 	passed := make([]Node, 0)
 	for _, node := range q {
-		if sel.Pass(node, true) {
+		if sel.Pass(node) {
 			passed = append(passed, node)
 		}
 	}
-	return passed, nil
+	return passed
 }
 
-// ParseSelector parses a string to a selector
-func ParseSelector(s string) (*Selector, error) {
-	var previous *Selector
-	parts := strings.Split(s, " ")
-	for _, part := range parts {
-		s := &Selector{parent: previous, localRules: parseRuleSet(part)}
-		previous = s
-	}
-	return previous, nil
-}
+// SelectorGroup is a group of selectors separated by commas.
+type SelectorGroup []*Selector
 
-func parseRuleSet(s string) []Rule {
-	if len(s) == 0 {
-		return nil
+// Pass reports whether n passes any of the selectors in the group.
+func (g SelectorGroup) Pass(n Node) bool {
+	for _, s := range g {
+		if s.Pass(n) {
+			return true
+		}
 	}
-	return []Rule{NodeRule{s}}
+	return false
 }
 
+// Combinator is the relationship between the nodes matched by a selector
+// and by the selector before it.
+type Combinator byte
+
+const (
+	Descendant        Combinator = ' '
+	Child             Combinator = '>'
+	AdjacentSibling   Combinator = '+'
+	SubsequentSibling Combinator = '~'
+)
+
 // Selector is a selector
 type Selector struct {
 	localRules []Rule
 	parent     *Selector
+	combinator Combinator
 }
 
-func (s Selector) Pass(n Node, local bool) bool {
+// Pass reports whether n passes the rules of the selector and is related to
+// a node passing the previous selector, if there is one.
+func (s Selector) Pass(n Node) bool {
 	for _, rule := range s.localRules {
-		passed := rule.Pass(n)
-
-		// Pass and no more rules
-		if passed && s.parent == nil {
-			return true
+		if !rule.Pass(n) {
+			return false
 		}
+	}
+	if s.parent == nil {
+		return true
+	}
 
-		// Pass, but we have parent elements and rules to check
-		if passed && n.Parent != nil {
-			if s.parent.Pass(*n.Parent, false) {
+	switch s.combinator {
+	case Descendant:
+		for p := n.Parent; p != nil; p = p.Parent {
+			if s.parent.Pass(*p) {
 				return true
 			}
 		}
-
-		// Didn't pass, but we have a parent element we could try
-		if !passed && n.Parent != nil && !local {
-			if s.Pass(*n.Parent, false) {
+	case Child:
+		return n.Parent != nil && s.parent.Pass(*n.Parent)
+	case AdjacentSibling:
+		return n.Index > 0 && s.parent.Pass(n.sibling(n.Index-1))
+	case SubsequentSibling:
+		for i := n.Index - 1; i >= 0; i-- {
+			if s.parent.Pass(n.sibling(i)) {
 				return true
 			}
 		}
-
 	}
 	return false
 }
@@ -108,9 +145,9 @@ func (r NodeRule) Pass(n Node) bool {
 
 func flatten(nodes []ast.Node, flat []Node, parent *Node) []Node {
 	for i, node := range nodes {
-		self := &Node{nodes[i], parent}
+		self := &Node{Node: nodes[i], Parent: parent, Index: i, siblings: nodes}
 		flat = append(flat, *self)
-		children := node.Children()
+		children := children(node.Children())
 		if len(children) != 0 {
 			flat = flatten(children, flat, self)
 		}
@@ -118,8 +155,35 @@ func flatten(nodes []ast.Node, flat []Node, parent *Node) []Node {
 	return flat
 }
 
+// children returns nodes without any nil pointers, which some nodes return
+// for parts that are missing, such as an absent default value.
+func children(nodes []ast.Node) []ast.Node {
+	for i, n := range nodes {
+		if ast.IsNil(n) {
+			present := append([]ast.Node{}, nodes[:i]...)
+			for _, n := range nodes[i+1:] {
+				if !ast.IsNil(n) {
+					present = append(present, n)
+				}
+			}
+			return present
+		}
+	}
+	return nodes
+}
+
 // Node is a node
 type Node struct {
 	Node   ast.Node
 	Parent *Node
+	// Index is the position of Node among the children of Parent, or among
+	// the selected nodes if Parent is nil.
+	Index int
+
+	siblings []ast.Node
+}
+
+// sibling returns the child of n's parent at index i.
+func (n Node) sibling(i int) Node {
+	return Node{Node: n.siblings[i], Parent: n.Parent, Index: i, siblings: n.siblings}
 }
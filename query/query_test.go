@@ -0,0 +1,137 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stephens2424/php/parser"
+)
+
+const testSrc = `<?php
+class Users extends Controller implements Countable {
+	public function getName() { return $this->name; }
+	private function setName($name) { $this->name = $name; }
+	public function count() { return mysql_query("SELECT COUNT(*)"); }
+}
+function helper($a) {
+	echo $a;
+	echo 1;
+	return $a + 1;
+}
+mysql_query("SELECT 1");
+`
+
+func selectCount(t *testing.T, selector string) int {
+	file, err := parser.NewParser().Parse("test.php", testSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected, err := Select(file.Nodes).Select(selector)
+	if err != nil {
+		t.Fatalf("%s: %s", selector, err)
+	}
+	return len(selected)
+}
+
+func TestSelectors(t *testing.T) {
+	tests := []struct {
+		selector string
+		count    int
+	}{
+		{"EchoStmt", 2},
+		{"Class Method", 3},
+		{"FunctionStmt ReturnStmt", 1},
+		{"FunctionCallExpr[name=mysql_query]", 2},
+		{"FunctionCallExpr[name='mysql_query']", 2},
+		{"FunctionStmt FunctionCallExpr[name=mysql_query]", 0},
+		{"Class[extends=Controller]", 1},
+		{"Class[extends=Model]", 0},
+		{"Class[implements=Countable]", 1},
+		{"Method[name~='^[gs]et']", 2},
+		{"Method[name~=^get]", 1},
+		{"Method[visibility=private]", 1},
+		{"Variable[name=name]", 2},
+		{"BinaryExpr[operator=\"+\"]", 1},
+		{"[extends]", 1},
+		{"*", selectCount(t, "*")},
+		{"FunctionStmt > Block > EchoStmt", 2},
+		{"FunctionStmt > EchoStmt", 0},
+		{"EchoStmt + EchoStmt", 1},
+		{"EchoStmt + ReturnStmt", 1},
+		{"EchoStmt ~ ReturnStmt", 1},
+		{"ReturnStmt ~ EchoStmt", 0},
+		{"Class + FunctionStmt", 1},
+		{"EchoStmt, ReturnStmt", 5},
+		{"Method[name=count] ReturnStmt, Class ~ ExprStmt", 2},
+	}
+	for _, test := range tests {
+		if count := selectCount(t, test.selector); count != test.count {
+			t.Errorf("%s: selected %d nodes, expected %d", test.selector, count, test.count)
+		}
+	}
+}
+
+func TestPseudoClasses(t *testing.T) {
+	tests := []struct {
+		selector string
+		count    int
+	}{
+		{"Method:has(FunctionCallExpr[name=mysql_query])", 1},
+		{"Class:has(Method:has(ReturnStmt))", 1},
+		{"FunctionStmt:has(Class)", 0},
+		{"Method:not([name=count])", 2},
+		{"FunctionCallExpr[name=mysql_query]:not(:in-class(Users))", 1},
+		{"EchoStmt:first-child", 1},
+		{"ReturnStmt:first-child", 2},
+		{"ReturnStmt:last-child", 3},
+		{"EchoStmt:last-child", 0},
+		{"EchoStmt:nth-child(2)", 1},
+		{"ReturnStmt:nth-child(3)", 1},
+		{"Literal:empty", 4},
+		{"FunctionCallExpr:empty", 0},
+		{"EchoStmt:in-function(helper)", 2},
+		{"Variable:in-function('setName')", 3},
+		{"FunctionCallExpr:in-function(helper)", 0},
+		{"ReturnStmt:in-class(Users)", 2},
+		{"EchoStmt:in-class(Users)", 0},
+	}
+	for _, test := range tests {
+		if count := selectCount(t, test.selector); count != test.count {
+			t.Errorf("%s: selected %d nodes, expected %d", test.selector, count, test.count)
+		}
+	}
+}
+
+func TestSelectorErrors(t *testing.T) {
+	tests := []struct {
+		selector string
+		offset   int
+	}{
+		{"", 0},
+		{"EchoStmt >", 10},
+		{"EchoStmt,", 9},
+		{"[name", 5},
+		{"[name=x", 7},
+		{"[name^=x]", 5},
+		{"[name~=(]", 7},
+		{"[name='x]", 6},
+		{"EchoStmt )", 9},
+		{"Echo$Stmt", 4},
+		{":foo", 0},
+		{"EchoStmt:has", 12},
+		{":has(", 5},
+		{":not(EchoStmt", 13},
+		{":nth-child(0)", 11},
+		{":in-class()", 10},
+	}
+	for _, test := range tests {
+		_, err := ParseSelector(test.selector)
+		serr, ok := err.(*SelectorError)
+		if !ok {
+			t.Errorf("%q: expected a SelectorError, got %v", test.selector, err)
+			continue
+		}
+		if serr.Offset != test.offset {
+			t.Errorf("%q: error at offset %d, expected %d: %s", test.selector, serr.Offset, test.offset, serr)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+// Package rewrite implements structural search and replace of PHP code.
+//
+// A Rule is compiled from a pattern and a replacement, each a PHP
+// expression or statement. Variables in the pattern, other than $this, are
+// metavariables: they match any expression, and every occurrence of the
+// same metavariable must match the same syntax. In the replacement, they
+// are replaced by the source of the expression they matched. For example,
+// the rule
+//
+//	mysql_query($q) => $db->query($q)
+//
+// rewrites mysql_query("SELECT 1") as $db->query("SELECT 1"), leaving $db,
+// which is not in the pattern, as it is.
+//
+// Replacements are printed with the ast/printer package and spliced into
+// the original source, so only the matched code is reformatted.
+package rewrite
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/stephens2424/php/ast"
+	"github.com/stephens2424/php/ast/printer"
+	"github.com/stephens2424/php/edit"
+	"github.com/stephens2424/php/parser"
+	"github.com/stephens2424/php/query"
+)
+
+// Rule replaces code matching a pattern.
+type Rule struct {
+	// Where, if it is set, restricts the rule to nodes passing it.
+	Where query.SelectorGroup
+
+	pattern     ast.Node
+	replacement ast.Node
+	metas       map[string]bool
+
+	// template is the printed replacement, in which each metavariable is
+	// written as the placeholder holding its position in placeholders.
+	template     string
+	placeholders []placeholder
+}
+
+type placeholder struct {
+	meta string
+	// operand is set if the metavariable is an operand of an operator in
+	// the replacement, where an operation must be parenthesized.
+	operand bool
+}
+
+// Compile returns a rule replacing code matching pattern with replacement.
+func Compile(pattern, replacement string) (*Rule, error) {
+	pat, err := parseFragment(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("rewrite: pattern: %s", err)
+	}
+	repl, err := parseFragment(replacement)
+	if err != nil {
+		return nil, fmt.Errorf("rewrite: replacement: %s", err)
+	}
+	_, patExpr := pat.(ast.Expr)
+	_, replExpr := repl.(ast.Expr)
+	if patExpr != replExpr {
+		return nil, fmt.Errorf("rewrite: the pattern and replacement must both be expressions or both be statements")
+	}
+	if !patExpr && reflect.ValueOf(pat).Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("rewrite: cannot match %T statements", pat)
+	}
+
+	r := &Rule{pattern: pat, replacement: repl, metas: map[string]bool{}}
+	for _, n := range query.Select([]ast.Node{pat}) {
+		if name, ok := variableName(n.Node); ok && name != "this" {
+			r.metas[name] = true
+		}
+	}
+	r.compileTemplate()
+	return r, nil
+}
+
+// parseFragment parses a single expression or statement.
+func parseFragment(src string) (ast.Node, error) {
+	src = strings.TrimSpace(src)
+	if !strings.HasSuffix(src, ";") && !strings.HasSuffix(src, "}") {
+		src += ";"
+	}
+	file, err := parser.NewParser().Parse("pattern.php", "<?php "+src)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nodes) != 1 {
+		return nil, fmt.Errorf("expected a single expression or statement, found %d", len(file.Nodes))
+	}
+	switch n := file.Nodes[0].(type) {
+	case ast.ExprStmt:
+		return n.Expr, nil
+	case *ast.ExprStmt:
+		return n.Expr, nil
+	default:
+		return n, nil
+	}
+}
+
+func variableName(n ast.Node) (string, bool) {
+	if v, ok := n.(*ast.Variable); ok {
+		if id, ok := v.Name.(*ast.Identifier); ok {
+			return id.Value, true
+		}
+	}
+	return "", false
+}
+
+func placeholderName(i int) string {
+	return fmt.Sprintf("__rewrite_%d__", i)
+}
+
+// compileTemplate prints the replacement with its metavariables renamed to
+// placeholders.
+func (r *Rule) compileTemplate() {
+	for _, n := range query.Select([]ast.Node{r.replacement}) {
+		v, ok := n.Node.(*ast.Variable)
+		if !ok {
+			continue
+		}
+		if name, ok := variableName(v); ok && r.metas[name] {
+			r.placeholders = append(r.placeholders, placeholder{meta: name, operand: isOperand(n)})
+			v.Name = &ast.Identifier{Value: placeholderName(len(r.placeholders) - 1)}
+		}
+	}
+	buf := &bytes.Buffer{}
+	printer.NewPrinter(buf).PrintNode(r.replacement)
+	r.template = buf.String()
+}
+
+// Match reports whether n matches the pattern of r, returning the nodes
+// matched by each metavariable.
+func (r *Rule) Match(n ast.Node) (map[string]ast.Node, bool) {
+	m := &matcher{metas: r.metas, bindings: map[string]ast.Node{}}
+	if !m.match(reflect.ValueOf(r.pattern), reflect.ValueOf(n)) {
+		return nil, false
+	}
+	return m.bindings, true
+}
+
+// match is a match of the pattern of a rule.
+type match struct {
+	span     ast.Span
+	node     query.Node
+	bindings map[string]ast.Node
+}
+
+// Rewrite returns the edits replacing each match of r in file, which was
+// parsed from src. Matches within the code matched by a metavariable are
+// also replaced, but other matches nested in a match are not.
+func (r *Rule) Rewrite(file *ast.File, src string) []edit.Edit {
+	var matches []match
+	for _, n := range query.Select(file.Nodes) {
+		if r.Where != nil && !r.Where.Pass(n) || isMemberCall(n) {
+			continue
+		}
+		bindings, ok := r.Match(n.Node)
+		if !ok {
+			continue
+		}
+		span, ok := file.Positions.Span(n.Node)
+		if !ok {
+			continue
+		}
+		matches = append(matches, match{span: span, node: n, bindings: bindings})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].span.Begin.Position < matches[j].span.Begin.Position
+	})
+
+	rw := &rewriter{rule: r, file: file, src: src, matches: matches}
+	var edits []edit.Edit
+	end := 0
+	for _, m := range matches {
+		if m.span.Begin.Position < end {
+			// nested in an earlier match
+			continue
+		}
+		end = m.span.End.Position
+		edits = append(edits, edit.Edit{
+			Begin: m.span.Begin.Position,
+			End:   m.span.End.Position,
+			Text:  rw.replacement(m),
+		})
+	}
+	return edits
+}
+
+// isMemberCall reports whether n is the call of a method call or static
+// call, which the AST represents as a function call, so that a pattern
+// calling a function does not match methods of the same name.
+func isMemberCall(n query.Node) bool {
+	if n.Parent == nil {
+		return false
+	}
+	switch parent := n.Parent.Node.(type) {
+	case *ast.MethodCallExpr:
+		return n.Node == ast.Node(parent.FunctionCallExpr)
+	case *ast.ClassExpr:
+		return n.Node == ast.Node(parent.Expr)
+	}
+	return false
+}
+
+type rewriter struct {
+	rule    *Rule
+	file    *ast.File
+	src     string
+	matches []match
+}
+
+// replacement returns the text replacing m.
+func (rw *rewriter) replacement(m match) string {
+	text := rw.rule.template
+	for i := len(rw.rule.placeholders) - 1; i >= 0; i-- {
+		ph := rw.rule.placeholders[i]
+		bound := rw.text(m.bindings[ph.meta])
+		if ph.operand && isOperation(m.bindings[ph.meta]) {
+			bound = "(" + bound + ")"
+		}
+		text = strings.Replace(text, "$"+placeholderName(i), bound, -1)
+	}
+
+	if isOperand(m.node) && isOperation(rw.rule.replacement) {
+		text = "(" + text + ")"
+	}
+	if strings.Contains(text, "\n") {
+		text = strings.Replace(text, "\n", "\n"+indentation(rw.src, m.span.Begin.Position), -1)
+	}
+	return text
+}
+
+// text returns the source of n with the matches within it replaced.
+func (rw *rewriter) text(n ast.Node) string {
+	span, ok := rw.file.Positions.Span(n)
+	if !ok {
+		buf := &bytes.Buffer{}
+		printer.NewPrinter(buf).PrintNode(n)
+		return buf.String()
+	}
+
+	var edits []edit.Edit
+	end := span.Begin.Position
+	for _, m := range rw.matches {
+		if m.span.Begin.Position < end || m.span.End.Position > span.End.Position {
+			continue
+		}
+		end = m.span.End.Position
+		edits = append(edits, edit.Edit{
+			Begin: m.span.Begin.Position - span.Begin.Position,
+			End:   m.span.End.Position - span.Begin.Position,
+			Text:  rw.replacement(m),
+		})
+	}
+	text, err := edit.Apply(rw.src[span.Begin.Position:span.End.Position], edits)
+	if err != nil {
+		// the edits are sorted and disjoint by construction
+		panic(err)
+	}
+	return text
+}
+
+// indentation returns the whitespace beginning the line containing pos.
+func indentation(src string, pos int) string {
+	start := strings.LastIndexByte(src[:pos], '\n') + 1
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return src[start:end]
+}
+
+// isOperation reports whether n must be parenthesized to be used as an
+// operand, as the printer does.
+func isOperation(n ast.Node) bool {
+	switch n.(type) {
+	case ast.BinaryExpr, *ast.BinaryExpr, ast.TernaryCallExpr, *ast.TernaryCallExpr,
+		ast.AssignmentExpr, *ast.AssignmentExpr, *ast.ListStatement:
+		return true
+	}
+	return false
+}
+
+// isOperand reports whether n is used in a place where an operation would
+// need parentheses, such as an operand of another operator.
+func isOperand(n query.Node) bool {
+	if n.Parent == nil {
+		return false
+	}
+	switch n.Parent.Node.(type) {
+	case ast.ExprStmt, *ast.ExprStmt, ast.EchoStmt, *ast.EchoStmt, *ast.ReturnStmt,
+		*ast.ArrayPair, ast.ArrayPair, *ast.IfBranch, ast.IfBranch, *ast.WhileStmt, *ast.DoWhileStmt,
+		*ast.ForStmt, ast.SwitchStmt, *ast.SwitchStmt, *ast.SwitchCase:
+		return false
+	case *ast.FunctionCallExpr:
+		// the function name is an operand, but not the arguments
+		return n.Index == 0
+	case *ast.MethodCallExpr:
+		return n.Index == 0
+	case ast.AssignmentExpr, *ast.AssignmentExpr:
+		return n.Index == 0
+	case *ast.ArrayLookupExpr:
+		// the index is delimited by brackets
+		return n.Index == 0
+	}
+	return true
+}
+
+// matcher compares a pattern to syntax, binding metavariables.
+type matcher struct {
+	metas    map[string]bool
+	bindings map[string]ast.Node
+}
+
+var (
+	nodeType  = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	typeType  = reflect.TypeOf((*ast.Type)(nil)).Elem()
+	scopeType = reflect.TypeOf((*ast.Scope)(nil))
+)
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// match reports whether the syntax y matches the pattern x, comparing them
+// as ast.Equal does.
+func (m *matcher) match(x, y reflect.Value) bool {
+	if x.IsValid() && x.Type().Implements(nodeType) {
+		if name, ok := variableName(asNode(x)); ok && m.metas[name] {
+			return m.bind(name, y)
+		}
+	}
+
+	x, y = indirect(x), indirect(y)
+	if !x.IsValid() || !y.IsValid() {
+		return x.IsValid() == y.IsValid()
+	}
+	if x.Type() != y.Type() {
+		return false
+	}
+
+	switch x.Kind() {
+	case reflect.Struct:
+		for i := 0; i < x.NumField(); i++ {
+			field := x.Type().Field(i)
+			if field.PkgPath != "" || field.Type == typeType || field.Type == scopeType {
+				continue
+			}
+			if !m.match(x.Field(i), y.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if x.Len() != y.Len() {
+			return false
+		}
+		for i := 0; i < x.Len(); i++ {
+			if !m.match(x.Index(i), y.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		return true
+	default:
+		return x.Interface() == y.Interface()
+	}
+}
+
+// asNode returns the node held by v, which may be nil.
+func asNode(v reflect.Value) ast.Node {
+	if v.Kind() == reflect.Interface && v.IsNil() {
+		return nil
+	}
+	return v.Interface().(ast.Node)
+}
+
+// bind binds the metavariable name to the expression y, or reports whether
+// y is equal to the expression it is already bound to.
+func (m *matcher) bind(name string, y reflect.Value) bool {
+	for y.IsValid() && y.Kind() == reflect.Interface {
+		y = y.Elem()
+	}
+	if !y.IsValid() || !y.Type().Implements(nodeType) || y.Kind() == reflect.Ptr && y.IsNil() {
+		return false
+	}
+	n := y.Interface().(ast.Node)
+	if _, ok := n.(ast.Expr); !ok {
+		return false
+	}
+	if bound, ok := m.bindings[name]; ok {
+		return ast.Equal(bound, n)
+	}
+	m.bindings[name] = n
+	return true
+}
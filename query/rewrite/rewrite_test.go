@@ -0,0 +1,106 @@
+package rewrite
+
+import (
+	"testing"
+
+	"github.com/stephens2424/php/edit"
+	"github.com/stephens2424/php/parser"
+	"github.com/stephens2424/php/query"
+)
+
+func rewrite(t *testing.T, r *Rule, src string) string {
+	file, err := parser.NewParser().Parse("test.php", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := edit.Apply(src, r.Rewrite(file, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		pattern, replacement string
+		src, expected        string
+	}{
+		{
+			"mysql_query($q)", "$db->query($q)",
+			"<?php\n$r = mysql_query(\"SELECT \" . $cols);\nmysql_query($q, $conn);\n",
+			"<?php\n$r = $db->query(\"SELECT \" . $cols);\nmysql_query($q, $conn);\n",
+		},
+		{
+			// nested matches in metavariables are rewritten too
+			"strtoupper($s)", "mb_strtoupper($s)",
+			"<?php echo strtoupper(strtoupper($a));",
+			"<?php echo mb_strtoupper(mb_strtoupper($a));",
+		},
+		{
+			// a metavariable must match the same syntax each time
+			"$a == $a", "true",
+			"<?php f($x == $x, $x == $y);",
+			"<?php f(true, $x == $y);",
+		},
+		{
+			// operations are parenthesized where they are operands
+			"square($x)", "$x * $x",
+			"<?php $y = 1 / square($a + 1); $z = square($b);",
+			"<?php $y = 1 / (($a + 1) * ($a + 1)); $z = $b * $b;",
+		},
+		{
+			"$this->log($m)", "$this->logger->info($m)",
+			"<?php $this->log('x'); $other->log('y');",
+			"<?php $this->logger->info('x'); $other->log('y');",
+		},
+		{
+			// functions do not match methods of the same name
+			"log($m)", "error_log($m)",
+			"<?php log('x'); $this->log('y'); Logger::log('z');",
+			"<?php error_log('x'); $this->log('y'); Logger::log('z');",
+		},
+		{
+			"if ($c) { return $v; }", "if ($c) {\n\treturn $v;\n}",
+			"<?php\nfunction f($a) {\n\tif ($a) { return 1; }\n}",
+			"<?php\nfunction f($a) {\n\tif ($a) {\n\t\treturn 1;\n\t}\n}",
+		},
+	}
+	for _, test := range tests {
+		r, err := Compile(test.pattern, test.replacement)
+		if err != nil {
+			t.Errorf("%s: %s", test.pattern, err)
+			continue
+		}
+		if out := rewrite(t, r, test.src); out != test.expected {
+			t.Errorf("%s => %s:\ngot      %q\nexpected %q", test.pattern, test.replacement, out, test.expected)
+		}
+	}
+}
+
+func TestRewriteWhere(t *testing.T) {
+	r, err := Compile("mysql_query($q)", "$db->query($q)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Where, err = query.ParseSelector("*:in-function(legacy)"); err != nil {
+		t.Fatal(err)
+	}
+	src := "<?php function legacy() { mysql_query('a'); } mysql_query('b');"
+	expected := "<?php function legacy() { $db->query('a'); } mysql_query('b');"
+	if out := rewrite(t, r, src); out != expected {
+		t.Errorf("got %q, expected %q", out, expected)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, test := range [][2]string{
+		{"mysql_query(", "x"},
+		{"f($a)", "g($a"},
+		{"f($a)", "if ($a) {}"},
+		{"f(); g();", "h()"},
+	} {
+		if _, err := Compile(test[0], test[1]); err == nil {
+			t.Errorf("%s => %s: expected an error", test[0], test[1])
+		}
+	}
+}
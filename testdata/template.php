@@ -0,0 +1,33 @@
+<!DOCTYPE html>
+<html>
+<body>
+<?php if ($user): ?>
+  <p>Hello, <?= $user->name ?>!</p>
+<?php elseif ($guest): ?>
+  <p>Hello, guest</p>
+<?php else: ?>
+  <p>Please log in</p>
+<?php endif; ?>
+<ul>
+<?php foreach ($items as $key => $item): ?>
+  <li class="<?= $key ?>"><?= $item, "x" ?></li>
+<?php endforeach ?>
+</ul>
+<?php for ($i = 0; $i < 3; $i++): ?>
+<b><?= $i ?></b>
+<?php endfor; ?>
+<?php while ($row = next($rows)): ?>
+<i>row</i>
+<?php endwhile; ?>
+<?php switch ($a): ?>
+<?php case 1: ?>
+one
+<?php break; ?>
+<?php default: ?>
+other
+<?php endswitch; ?>
+<?php function f() { ?>
+<em>in function</em>
+<?php } ?>
+</body>
+</html>
@@ -38,6 +38,7 @@ const (
 	IgnoreErrorOperator
 
 	Return
+	Yield
 	Comma
 	StatementEnd
 	Echo
@@ -75,6 +76,7 @@ const (
 	Public
 	Protected
 	Interface
+	Trait
 	Implements
 	Extends
 	NewOperator
@@ -157,6 +159,7 @@ var tokens = []string{
 
 	Global:       "global",
 	Return:       "Return",
+	Yield:        "yield",
 	Comma:        "Function Argument Separator",
 	StatementEnd: ";",
 	Echo:         "echo",
@@ -206,6 +209,7 @@ var tokens = []string{
 	Protected:   "Protected",
 	Public:      "Public",
 	Interface:   "Interface",
+	Trait:       "trait",
 	Implements:  "implements",
 	Extends:     "extends",
 	NewOperator: "new",
@@ -280,6 +284,7 @@ var TokenMap = map[string]Token{
 	"const":        Const,
 	"abstract":     Abstract,
 	"interface":    Interface,
+	"trait":        Trait,
 	"implements":   Implements,
 	"extends":      Extends,
 	"new":          NewOperator,
@@ -294,6 +299,7 @@ var TokenMap = map[string]Token{
 	"endif;":       EndIf,
 	"endif":        EndIf,
 	"endfor;":      EndFor,
+	"endfor":       EndFor,
 	"endforeach;":  EndForeach,
 	"endforeach":   EndForeach,
 	"endwhile;":    EndWhile,
@@ -310,6 +316,7 @@ var TokenMap = map[string]Token{
 	"self":         Self,
 	"parent":       Parent,
 	"return":       Return,
+	"yield":        Yield,
 	"{":            BlockBegin,
 	"}":            BlockEnd,
 	";":            StatementEnd,
@@ -52,6 +52,7 @@ var tokenTypes = map[Token]Type{
 	Final:     KeywordType,
 	Global:    KeywordType,
 	Return:    KeywordType,
+	Yield:     KeywordType,
 	Namespace: KeywordType,
 	Use:       KeywordType,
 	Echo:      KeywordType,
@@ -110,6 +111,7 @@ var tokenTypes = map[Token]Type{
 	Protected:   KeywordType,
 	Public:      KeywordType,
 	Interface:   KeywordType,
+	Trait:       KeywordType,
 	Implements:  KeywordType,
 	Extends:     KeywordType,
 	NewOperator: KeywordType,